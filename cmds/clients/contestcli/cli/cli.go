@@ -10,7 +10,10 @@ import (
 	"io"
 	"time"
 
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/transport"
+	"github.com/linuxboot/contest/pkg/transport/grpc"
 	"github.com/linuxboot/contest/pkg/transport/http"
 
 	flag "github.com/spf13/pflag"
@@ -27,24 +30,69 @@ var JobDescriptorVersion string = job.CurrentDescriptorVersion()
 var (
 	flagSet       *flag.FlagSet
 	flagAddr      *string
+	flagTransport *string
 	flagRequestor *string
 	flagWait      *bool
 	flagYAML      *bool
 	flagStates    *[]string
+	flagState     *[]string
 	flagTags      *[]string
+
+	flagFailedOnly *bool
+
+	flagReleaseTargets *bool
+
+	flagReporter *string
+	flagRun      *uint
+
+	flagCron    *string
+	flagOverlap *string
+
+	flagFQDN         *string
+	flagIPv4         *string
+	flagIPv6         *string
+	flagLabels       *string
+	flagRemoveLabels *[]string
+	flagHealth       *string
+	flagSelector     *string
 )
 
 func initFlags(cmd string) {
 	flagSet = flag.NewFlagSet(cmd, flag.ContinueOnError)
 	flagAddr = flagSet.StringP("addr", "a", "http://localhost:8080", "ConTest server [scheme://]host:port[/basepath] to connect to")
+	flagTransport = flagSet.StringP("transport", "t", "http", "Transport used to talk to the ConTest server, either http (httplistener) or grpc (grpclistener)")
 	flagRequestor = flagSet.StringP("requestor", "r", defaultRequestor, "Identifier of the requestor of the API call")
 	flagWait = flagSet.BoolP("wait", "w", false, "After starting a job, wait for it to finish, and exit 0 only if it is successful")
 	flagYAML = flagSet.BoolP("yaml", "Y", false, "Parse job descriptor as YAML instead of JSON")
 
 	// Flags for the "list" command.
-	flagStates = flagSet.StringSlice("states", []string{}, "List of job states for the list command. A job must be in any of the specified states to match.")
+	flagStates = flagSet.StringSlice("states", []string{}, "List of job states for the list command, e.g. JobStateStarted or started. A job must be in any of the specified states to match.")
+	flagState = flagSet.StringSlice("state", []string{}, "Same as --states")
 	flagTags = flagSet.StringSlice("tags", []string{}, "List of tags for the list command. A job must have all the tags to match.")
 
+	// Flags for the "retry" command.
+	flagFailedOnly = flagSet.Bool("failed-only", false, "Only retry the targets which failed in the last run of the job")
+
+	// Flags for the "pause" command.
+	flagReleaseTargets = flagSet.Bool("release-targets", false, "Release the targets of the job paused by the pause command, rather than keeping them locked")
+
+	// Flags for the "report" command.
+	flagReporter = flagSet.String("reporter", "JUnit", "Name of the reporter whose report is downloaded by the report command")
+	flagRun = flagSet.Uint("run", 0, "Run whose report is downloaded by the report command, 0 for the final report")
+
+	// Flags for the "schedule add" command.
+	flagCron = flagSet.String("cron", "", "Cron expression of the schedule created by the schedule add command, e.g. \"0 2 * * *\"")
+	flagOverlap = flagSet.String("overlap", string(job.OverlapSkip), "What the schedule add command's schedule does when its previous job is still running: skip, queue or cancel-previous")
+
+	// Flags for the "inventory" commands.
+	flagFQDN = flagSet.String("fqdn", "", "FQDN of the target added by the inventory add command")
+	flagIPv4 = flagSet.String("ipv4", "", "Primary IPv4 of the target added by the inventory add command")
+	flagIPv6 = flagSet.String("ipv6", "", "Primary IPv6 of the target added by the inventory add command")
+	flagLabels = flagSet.String("labels", "", "Labels set by the inventory add and label commands, e.g. board=X,bmc=openbmc")
+	flagRemoveLabels = flagSet.StringSlice("remove-labels", []string{}, "Keys of the labels removed by the inventory label command")
+	flagHealth = flagSet.String("health", string(inventory.HealthUnknown), "Health of the target added by the inventory add command: unknown, healthy or unhealthy")
+	flagSelector = flagSet.String("selector", "", "Label selector of the inventory list command, e.g. board=X,!broken")
+
 	flagSet.Usage = func() {
 		fmt.Fprintf(flagSet.Output(),
 			`Usage:
@@ -61,10 +109,40 @@ Commands:
         stop a job by job ID
   status int
         get the status of a job by job ID
-  retry int
-        retry a job by job ID
+  retry [--failed-only] int
+        retry a job by job ID, creating a new job from its descriptor.
+        with --failed-only, only the targets that failed are retried
+  pause [--release-targets] int
+        pause a running job by job ID, until it is resumed. Its targets
+        stay locked, unless --release-targets is set, in which case its
+        current test attempt starts over when it is resumed
+  resume int
+        resume a job paused by the pause command by job ID
   list [--states=JobStateStarted,...] [--tags=foo,...]
-        list jobs by state and/or tags
+        list jobs by state and/or tags, e.g. list --state queued lists the
+        jobs waiting for busy targets
+  report [--reporter=JUnit] [--run=N] int
+        print the report of a reporter for a job by job ID, e.g. the JUnit
+        XML. Reports which are not text are printed as JSON.
+        without --run, the final report is printed
+  schedule add --cron=expr [--overlap=skip] [file]
+        register a job description, from the specified file or passed via
+        stdin, to be started periodically according to a cron expression
+  schedule list
+        list the schedules of the server
+  schedule pause|resume|delete int
+        pause, resume or delete a schedule by schedule ID
+  inventory add [--fqdn=host] [--ipv4=ip] [--ipv6=ip] [--labels=k=v,...] [--health=unknown] id
+        add a target to the inventory of the server
+  inventory list [--selector=k=v,...]
+        list the targets of the inventory matching a label selector
+  inventory remove|drain|undrain id
+        remove a target from the inventory, or stop or resume acquiring it
+  inventory label [--labels=k=v,...] [--remove-labels=k,...] id
+        set or remove labels of a target of the inventory
+  inventory health id unknown|healthy|unhealthy
+        set the health of a target of the inventory, unhealthy targets are
+        not acquired
   version
         request the API version to the server
 
@@ -82,5 +160,14 @@ func CLIMain(cmd string, args []string, stdout io.Writer) error {
 		}
 		return err
 	}
-	return run(*flagRequestor, &http.HTTP{Addr: *flagAddr}, stdout)
+	var t transport.Transport
+	switch *flagTransport {
+	case "http":
+		t = &http.HTTP{Addr: *flagAddr}
+	case "grpc":
+		t = &grpc.GRPC{Addr: *flagAddr}
+	default:
+		return fmt.Errorf("invalid transport: '%s'", *flagTransport)
+	}
+	return run(*flagRequestor, t, stdout)
 }
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -20,7 +21,7 @@ import (
 
 	"github.com/linuxboot/contest/pkg/api"
 	"github.com/linuxboot/contest/pkg/config"
-	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/transport"
 	"github.com/linuxboot/contest/pkg/types"
@@ -37,35 +38,9 @@ func run(requestor string, transport transport.Transport, stdout io.Writer) erro
 	var err error
 	switch verb {
 	case "start":
-		var jobDesc []byte
-		if flagSet.Arg(1) == "" {
-			fmt.Fprintf(os.Stderr, "Reading from stdin...\n")
-			jd, err := ioutil.ReadAll(os.Stdin)
-			if err != nil {
-				return fmt.Errorf("failed to read job descriptor: %w", err)
-			}
-			jobDesc = jd
-		} else {
-			jd, err := ioutil.ReadFile(flagSet.Arg(1))
-			if err != nil {
-				return fmt.Errorf("failed to read job descriptor: %w", err)
-			}
-			jobDesc = jd
-		}
-
-		jobDescFormat := config.JobDescFormatJSON
-		if *flagYAML {
-			jobDescFormat = config.JobDescFormatYAML
-		}
-		jobDescJSON, err := config.ParseJobDescriptor(jobDesc, jobDescFormat)
+		jobDescJSON, err := readJobDescriptor(flagSet.Arg(1))
 		if err != nil {
-			return fmt.Errorf("failed to parse job descriptor: %w", err)
-		}
-
-		// Add the version field if it does not exist
-		jobDescJSON, err = addVersion(jobDescJSON)
-		if err != nil {
-			return fmt.Errorf("failed to add version to descriptor: %w", err)
+			return err
 		}
 
 		startResp, err := transport.Start(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, string(jobDescJSON))
@@ -117,14 +92,32 @@ func run(requestor string, transport transport.Transport, stdout io.Writer) erro
 		if err != nil {
 			return err
 		}
-		resp, err = transport.Retry(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, jobID)
+		resp, err = transport.Retry(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, jobID, *flagFailedOnly)
+		if err != nil {
+			return err
+		}
+	case "pause":
+		jobID, err := parseJob(flagSet.Arg(1))
+		if err != nil {
+			return err
+		}
+		resp, err = transport.Pause(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, jobID, *flagReleaseTargets)
+		if err != nil {
+			return err
+		}
+	case "resume":
+		jobID, err := parseJob(flagSet.Arg(1))
+		if err != nil {
+			return err
+		}
+		resp, err = transport.Resume(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, jobID)
 		if err != nil {
 			return err
 		}
 	case "list":
 		var states []job.State
-		for _, sts := range *flagStates {
-			st, err := job.EventNameToJobState(event.Name(sts))
+		for _, sts := range append(*flagStates, *flagState...) {
+			st, err := job.ParseState(sts)
 			if err != nil {
 				return err
 			}
@@ -134,6 +127,37 @@ func run(requestor string, transport transport.Transport, stdout io.Writer) erro
 		if err != nil {
 			return err
 		}
+	case "report":
+		jobID, err := parseJob(flagSet.Arg(1))
+		if err != nil {
+			return err
+		}
+		statusResp, err := transport.Status(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, jobID)
+		if err != nil {
+			return err
+		}
+		if statusResp.Err != nil {
+			return fmt.Errorf("server responded with an error: %s", statusResp.Err)
+		}
+		report, err := findReport(statusResp.Data.Status, *flagReporter, types.RunID(*flagRun))
+		if err != nil {
+			return err
+		}
+		if text, ok := report.Data.(string); ok {
+			_, err := io.WriteString(stdout, text)
+			return err
+		}
+		resp = report.Data
+	case "schedule":
+		resp, err = schedule(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, transport)
+		if err != nil {
+			return err
+		}
+	case "inventory":
+		resp, err = inventoryCommand(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor, transport)
+		if err != nil {
+			return err
+		}
 	case "version":
 		resp, err = transport.Version(xcontext.NewContext(ctx, "", nil, nil, nil, nil, nil), requestor)
 		if err != nil {
@@ -154,6 +178,136 @@ func run(requestor string, transport transport.Transport, stdout io.Writer) erro
 	return nil
 }
 
+// schedule runs the subcommands of the schedule verb.
+func schedule(ctx xcontext.Context, requestor string, transport transport.Transport) (interface{}, error) {
+	subcommand := strings.ToLower(flagSet.Arg(1))
+	switch subcommand {
+	case "add":
+		if *flagCron == "" {
+			return nil, errors.New("missing --cron expression")
+		}
+		jobDescJSON, err := readJobDescriptor(flagSet.Arg(2))
+		if err != nil {
+			return nil, err
+		}
+		return transport.AddSchedule(ctx, requestor, *flagCron, job.OverlapPolicy(*flagOverlap), string(jobDescJSON))
+	case "list":
+		return transport.ListSchedules(ctx, requestor)
+	case "pause", "resume":
+		scheduleID, err := parseSchedule(flagSet.Arg(2))
+		if err != nil {
+			return nil, err
+		}
+		return transport.PauseSchedule(ctx, requestor, scheduleID, subcommand == "pause")
+	case "delete":
+		scheduleID, err := parseSchedule(flagSet.Arg(2))
+		if err != nil {
+			return nil, err
+		}
+		return transport.DeleteSchedule(ctx, requestor, scheduleID)
+	case "":
+		return nil, errors.New("missing schedule command, see --help")
+	default:
+		return nil, fmt.Errorf("invalid schedule command: '%s'", subcommand)
+	}
+}
+
+// inventoryCommand runs the subcommands of the inventory verb.
+func inventoryCommand(ctx xcontext.Context, requestor string, transport transport.Transport) (interface{}, error) {
+	subcommand := strings.ToLower(flagSet.Arg(1))
+	if subcommand != "list" && subcommand != "" && flagSet.Arg(2) == "" {
+		return nil, errors.New("missing target ID")
+	}
+	targetID := flagSet.Arg(2)
+	switch subcommand {
+	case "add":
+		t := inventory.Target{
+			ID:     targetID,
+			FQDN:   *flagFQDN,
+			Health: inventory.Health(*flagHealth),
+		}
+		var err error
+		if t.PrimaryIPv4, err = parseIP(*flagIPv4); err != nil {
+			return nil, err
+		}
+		if t.PrimaryIPv6, err = parseIP(*flagIPv6); err != nil {
+			return nil, err
+		}
+		if t.Labels, err = inventory.ParseLabels(*flagLabels); err != nil {
+			return nil, err
+		}
+		return transport.AddInventoryTarget(ctx, requestor, t)
+	case "list":
+		return transport.ListInventoryTargets(ctx, requestor, *flagSelector)
+	case "remove":
+		return transport.RemoveInventoryTarget(ctx, requestor, targetID)
+	case "drain", "undrain":
+		return transport.DrainInventoryTarget(ctx, requestor, targetID, subcommand == "drain")
+	case "label":
+		labels, err := inventory.ParseLabels(*flagLabels)
+		if err != nil {
+			return nil, err
+		}
+		return transport.LabelInventoryTarget(ctx, requestor, targetID, labels, *flagRemoveLabels)
+	case "health":
+		if flagSet.Arg(3) == "" {
+			return nil, errors.New("missing health")
+		}
+		return transport.SetInventoryTargetHealth(ctx, requestor, targetID, inventory.Health(strings.ToLower(flagSet.Arg(3))))
+	case "":
+		return nil, errors.New("missing inventory command, see --help")
+	default:
+		return nil, fmt.Errorf("invalid inventory command: '%s'", subcommand)
+	}
+}
+
+func parseIP(s string) (net.IP, error) {
+	if s == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", s)
+	}
+	return ip, nil
+}
+
+// readJobDescriptor reads a job descriptor from a file, or from stdin if path
+// is empty, and returns it as JSON.
+func readJobDescriptor(path string) ([]byte, error) {
+	var jobDesc []byte
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Reading from stdin...\n")
+		jd, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job descriptor: %w", err)
+		}
+		jobDesc = jd
+	} else {
+		jd, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read job descriptor: %w", err)
+		}
+		jobDesc = jd
+	}
+
+	jobDescFormat := config.JobDescFormatJSON
+	if *flagYAML {
+		jobDescFormat = config.JobDescFormatYAML
+	}
+	jobDescJSON, err := config.ParseJobDescriptor(jobDesc, jobDescFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse job descriptor: %w", err)
+	}
+
+	// Add the version field if it does not exist
+	jobDescJSON, err = addVersion(jobDescJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add version to descriptor: %w", err)
+	}
+	return jobDescJSON, nil
+}
+
 func wait(ctx context.Context, jobID types.JobID, jobWaitPoll time.Duration, requestor string, transport transport.Transport) (*api.StatusResponse, error) {
 	// keep polling for status till job is completed, used when -wait is set
 	for {
@@ -177,6 +331,32 @@ func wait(ctx context.Context, jobID types.JobID, jobWaitPoll time.Duration, req
 	}
 }
 
+// findReport returns the report of a reporter for a run of a job, or the final
+// report if runID is 0.
+func findReport(status *job.Status, reporterName string, runID types.RunID) (*job.Report, error) {
+	if status == nil || status.JobReport == nil {
+		return nil, errors.New("the job has no reports")
+	}
+	var reports []*job.Report
+	if runID == 0 {
+		reports = status.JobReport.FinalReports
+	} else {
+		if int(runID) > len(status.JobReport.RunReports) {
+			return nil, fmt.Errorf("the job has no reports for run %d", runID)
+		}
+		reports = status.JobReport.RunReports[runID-1]
+	}
+	for _, report := range reports {
+		if report != nil && strings.EqualFold(report.ReporterName, reporterName) {
+			return report, nil
+		}
+	}
+	if runID == 0 {
+		return nil, fmt.Errorf("no final report from reporter %s", reporterName)
+	}
+	return nil, fmt.Errorf("no report from reporter %s for run %d", reporterName, runID)
+}
+
 func parseJob(jobIDStr string) (types.JobID, error) {
 	if jobIDStr == "" {
 		return 0, errors.New("missing job ID")
@@ -193,6 +373,20 @@ func parseJob(jobIDStr string) (types.JobID, error) {
 	return jobID, nil
 }
 
+func parseSchedule(scheduleIDStr string) (types.ScheduleID, error) {
+	if scheduleIDStr == "" {
+		return 0, errors.New("missing schedule ID")
+	}
+	scheduleID, err := strconv.ParseUint(scheduleIDStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid schedule ID: %s: %v", scheduleIDStr, err)
+	}
+	if scheduleID == 0 {
+		return 0, fmt.Errorf("Invalid schedule ID: %s: it must be positive", scheduleIDStr)
+	}
+	return types.ScheduleID(scheduleID), nil
+}
+
 // addVersion adds the version field to the job descriptor if it does not exist
 func addVersion(jobDescJSON []byte) ([]byte, error) {
 	jobDesc := make(map[string]interface{})
@@ -13,7 +13,8 @@ import (
 )
 
 // Unauthenticated, unencrypted sample HTTP client for ConTest.
-// Requires the `httplistener` plugin for the API listener.
+// Requires the `httplistener` plugin for the API listener, or the
+// `grpclistener` plugin when used with `-transport grpc`.
 //
 // Usage examples:
 // Start a job with the provided job description from a JSON file
@@ -30,6 +31,9 @@ import (
 //
 // List all the failed jobs with tags "foo" and "bar":
 //   ./contestcli list -state JobStateFailed -tags foo,bar
+//
+// Stop the job whose ID is 10 through the gRPC listener:
+//   ./contestcli -transport grpc stop 10
 
 func main() {
 	if err := cli.CLIMain(os.Args[0], os.Args[1:], os.Stdout); err != nil {
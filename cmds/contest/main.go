@@ -26,7 +26,7 @@ import (
 func main() {
 	sigs := make(chan os.Signal, 1)
 	defer close(sigs)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1, syscall.SIGHUP)
 	go func() {
 		_ = http.ListenAndServe("localhost:8081", nil)
 	}()
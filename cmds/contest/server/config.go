@@ -0,0 +1,235 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/config"
+	"github.com/linuxboot/contest/pkg/pluginregistry"
+	"github.com/linuxboot/contest/pkg/resource"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	logrusadapter "github.com/linuxboot/contest/pkg/xcontext/logger/logadapter/logrus"
+	"github.com/linuxboot/contest/plugins/listeners/compositelistener"
+	"github.com/linuxboot/contest/plugins/listeners/grpclistener"
+	"github.com/linuxboot/contest/plugins/listeners/httplistener"
+)
+
+// cmdlineFlags are the names of the flags passed on the command line, which
+// take precedence over the configuration file.
+var cmdlineFlags map[string]bool
+
+// listenerFlags are the flags configuring the API listener.
+var listenerFlags = []string{"listener", "listenAddr", "tlsCert", "tlsKey", "tlsClientCA"}
+
+// applyServerConfig sets the flags which were not passed on the command line
+// to the values of the configuration file.
+func applyServerConfig(cfg *config.ServerConfig) error {
+	values := map[string]string{
+		"dbURI":         cfg.Storage.DBURI,
+		"replicaDBURI":  cfg.Storage.ReplicaDBURI,
+		"targetLocker":  cfg.TargetLocker,
+		"logLevel":      cfg.LogLevel,
+		"metricsAddr":   cfg.MetricsAddr,
+		"traceExporter": cfg.Tracing.Exporter,
+		"traceEndpoint": cfg.Tracing.Endpoint,
+	}
+	for name, value := range values {
+		if value == "" || cmdlineFlags[name] {
+			continue
+		}
+		if err := flagSet.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, name, err)
+		}
+	}
+	return nil
+}
+
+// listenerConfigs returns the configuration of the API listeners to serve:
+// the ones of the configuration file, unless the listener is configured on
+// the command line.
+func listenerConfigs(cfg *config.ServerConfig) []config.ListenerConfig {
+	fromCmdline := cfg == nil || len(cfg.Listeners) == 0
+	for _, name := range listenerFlags {
+		fromCmdline = fromCmdline || cmdlineFlags[name]
+	}
+	if !fromCmdline {
+		return cfg.Listeners
+	}
+	return []config.ListenerConfig{{
+		Type:        *flagListener,
+		ListenAddr:  *flagListenAddr,
+		TLSCert:     *flagTLSCert,
+		TLSKey:      *flagTLSKey,
+		TLSClientCA: *flagTLSClientCA,
+	}}
+}
+
+// newListener creates an API listener from its configuration.
+func newListener(cfg config.ListenerConfig) (api.Listener, error) {
+	switch cfg.Type {
+	case "", "grpc":
+		var opts []grpclistener.Option
+		if cfg.TLSCert != "" || cfg.TLSKey != "" {
+			opts = append(opts, grpclistener.OptionTLS{CertFile: cfg.TLSCert, KeyFile: cfg.TLSKey})
+		}
+		if cfg.TLSClientCA != "" {
+			opts = append(opts,
+				grpclistener.OptionClientCA(cfg.TLSClientCA),
+				grpclistener.OptionAuthenticator{Authenticator: grpclistener.CommonNameAuthenticator},
+			)
+		}
+		return grpclistener.New(cfg.ListenAddr, opts...), nil
+	case "http":
+		if cfg.TLSCert != "" || cfg.TLSKey != "" || cfg.TLSClientCA != "" {
+			return nil, fmt.Errorf("TLS is not supported by the http listener")
+		}
+		return httplistener.New(cfg.ListenAddr), nil
+	default:
+		return nil, fmt.Errorf("invalid listener %q", cfg.Type)
+	}
+}
+
+// newListeners creates the API listeners to serve, combined in a composite
+// listener if there are several of them.
+func newListeners(cfgs []config.ListenerConfig) (api.Listener, error) {
+	listeners := make([]api.Listener, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		l, err := newListener(cfg)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+	return compositelistener.New(listeners...), nil
+}
+
+// selectPlugins returns the plugins of pc which are enabled by the
+// configuration. Unknown plugin names are reported as errors.
+func selectPlugins(pc *PluginConfig, cfg config.PluginsConfig) (*PluginConfig, error) {
+	if len(cfg.Enabled) == 0 && len(cfg.Disabled) == 0 {
+		return pc, nil
+	}
+	names := cfg.Enabled
+	if len(names) == 0 {
+		names = cfg.Disabled
+	}
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[strings.ToLower(name)] = false
+	}
+	keep := func(name string) bool {
+		name = strings.ToLower(name)
+		_, ok := listed[name]
+		if ok {
+			listed[name] = true
+		}
+		return ok == (len(cfg.Enabled) > 0)
+	}
+
+	var res PluginConfig
+	for _, loader := range pc.TargetManagerLoaders {
+		if name, _ := loader(); keep(name) {
+			res.TargetManagerLoaders = append(res.TargetManagerLoaders, loader)
+		}
+	}
+	for _, loader := range pc.TestFetcherLoaders {
+		if name, _ := loader(); keep(name) {
+			res.TestFetcherLoaders = append(res.TestFetcherLoaders, loader)
+		}
+	}
+	for _, loader := range pc.TestStepLoaders {
+		if name, _, _ := loader(); keep(name) {
+			res.TestStepLoaders = append(res.TestStepLoaders, loader)
+		}
+	}
+	for _, loader := range pc.ReporterLoaders {
+		if name, _ := loader(); keep(name) {
+			res.ReporterLoaders = append(res.ReporterLoaders, loader)
+		}
+	}
+	for _, name := range names {
+		if !listed[strings.ToLower(name)] {
+			return nil, fmt.Errorf("unknown plugin %q", name)
+		}
+	}
+	return &res, nil
+}
+
+// testStepDefaults converts the test step defaults of the configuration to
+// test step parameters.
+func testStepDefaults(cfg config.PluginsConfig) (map[string]test.TestStepParameters, error) {
+	res := make(map[string]test.TestStepParameters, len(cfg.Defaults))
+	for stepName, params := range cfg.Defaults {
+		stepParams := make(test.TestStepParameters, len(params))
+		for paramName, values := range params {
+			for _, value := range values {
+				data, err := json.Marshal(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid default for parameter %q of %q: %w", paramName, stepName, err)
+				}
+				stepParams[paramName] = append(stepParams[paramName], test.Param{RawMessage: data})
+			}
+		}
+		res[stepName] = stepParams
+	}
+	return res, nil
+}
+
+// applyReloadableConfig applies the settings of the configuration which can be
+// changed while the server is running.
+func applyReloadableConfig(ctx xcontext.Context, cfg *config.ServerConfig, pluginRegistry *pluginregistry.PluginRegistry) error {
+	defaults, err := testStepDefaults(cfg.Plugins)
+	if err != nil {
+		return err
+	}
+	if cfg.LogLevel != "" && !cmdlineFlags["logLevel"] {
+		logLevel, err := logger.ParseLogLevel(cfg.LogLevel)
+		if err != nil {
+			return err
+		}
+		if entry, ok := ctx.Logger().OriginalLogger().(*logrus.Entry); ok {
+			entry.Logger.SetLevel(logrusadapter.Adapter.Level(logLevel))
+		}
+	}
+	pluginRegistry.SetTestStepDefaults(defaults)
+	capacities := make(map[string]uint, len(cfg.Resources))
+	for _, r := range cfg.Resources {
+		capacities[r.Resource] = r.Capacity
+	}
+	resource.SetCapacities(capacities)
+	return nil
+}
+
+// reloadServerConfig reloads the configuration file on SIGHUP. Settings which
+// differ from the ones the server was started with and need a restart are
+// not applied.
+func reloadServerConfig(ctx xcontext.Context, path string, startup *config.ServerConfig, pluginRegistry *pluginregistry.PluginRegistry) {
+	log := ctx.Logger()
+	cfg, err := config.LoadServerConfig(path)
+	if err != nil {
+		log.Errorf("Could not reload server configuration: %v", err)
+		return
+	}
+	if !cfg.StructureEqual(startup) {
+		log.Warnf("Changes of listeners, storage, target locker, metrics, tracing and plugin selection require a restart, ignoring them")
+	}
+	if err := applyReloadableConfig(ctx, cfg, pluginRegistry); err != nil {
+		log.Errorf("Could not apply server configuration: %v", err)
+		return
+	}
+	log.Infof("Reloaded server configuration from %s", path)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+	prometheusadapter "github.com/linuxboot/contest/pkg/xcontext/metrics/prometheus"
+)
+
+// metricsShutdownTimeout is how long the metrics endpoint waits for pending
+// scrapes when the server exits.
+const metricsShutdownTimeout = 5 * time.Second
+
+// newMetricsRegistry creates the Prometheus registry of the server, with the
+// Go runtime and process collectors registered.
+func newMetricsRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// newMetrics returns the metrics handler of the root context, which reports
+// to the registry of the server.
+func newMetrics(reg *prometheus.Registry) xcontext.Metrics {
+	return prometheusadapter.New(reg, reg)
+}
+
+// serveMetrics serves the metrics of the registry on /metrics at addr, until
+// ctx is done.
+func serveMetrics(ctx xcontext.Context, addr string, reg *prometheus.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	ctx.Infof("Serving metrics on %s/metrics", addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		ctx.Errorf("Metrics endpoint failed: %v", err)
+	}
+}
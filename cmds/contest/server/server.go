@@ -6,6 +6,7 @@
 package server
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -17,6 +18,10 @@ import (
 
 	"github.com/linuxboot/contest/pkg/api"
 	"github.com/linuxboot/contest/pkg/config"
+	// Register the DUT backends which no test step imports.
+	_ "github.com/linuxboot/contest/pkg/dut/dutctl"
+	_ "github.com/linuxboot/contest/pkg/dut/hwaas"
+	_ "github.com/linuxboot/contest/pkg/dut/pikvm"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/jobmanager"
 	"github.com/linuxboot/contest/pkg/logging"
@@ -24,21 +29,23 @@ import (
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/userfunctions/donothing"
 	"github.com/linuxboot/contest/pkg/userfunctions/ocp"
 	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles"
 	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
 	"github.com/linuxboot/contest/pkg/xcontext/logger"
 	"github.com/linuxboot/contest/plugins/storage/memory"
 	"github.com/linuxboot/contest/plugins/storage/rdbms"
+	"github.com/linuxboot/contest/plugins/storage/sqlite"
 	"github.com/linuxboot/contest/plugins/targetlocker/dblocker"
 	"github.com/linuxboot/contest/plugins/targetlocker/inmemory"
-
-	// the listener plugin
-	"github.com/linuxboot/contest/plugins/listeners/grpclistener"
+	"github.com/linuxboot/contest/plugins/targetlocker/sqlitelocker"
 
 	// the targetmanager plugins
 	csvtargetmanager "github.com/linuxboot/contest/plugins/targetmanagers/csvtargetmanager"
+	inventorytargetmanager "github.com/linuxboot/contest/plugins/targetmanagers/inventorytargetmanager"
 	targetlist "github.com/linuxboot/contest/plugins/targetmanagers/targetlist"
 
 	// the testfetcher plugins
@@ -52,19 +59,24 @@ import (
 	bios_setting_set "github.com/linuxboot/contest/plugins/teststeps/bios_settings_set"
 	chipsec "github.com/linuxboot/contest/plugins/teststeps/chipsec"
 	cmd "github.com/linuxboot/contest/plugins/teststeps/cmd"
+	console "github.com/linuxboot/contest/plugins/teststeps/console"
 	copy "github.com/linuxboot/contest/plugins/teststeps/copy"
 	cpuload "github.com/linuxboot/contest/plugins/teststeps/cpuload"
 	cpuset "github.com/linuxboot/contest/plugins/teststeps/cpuset"
 	cpustats "github.com/linuxboot/contest/plugins/teststeps/cpustats"
 	dutctl "github.com/linuxboot/contest/plugins/teststeps/dutctl"
+	flash "github.com/linuxboot/contest/plugins/teststeps/flash"
 	firmware_version "github.com/linuxboot/contest/plugins/teststeps/fw_version"
 	fwhunt "github.com/linuxboot/contest/plugins/teststeps/fwhunt"
 	fwts "github.com/linuxboot/contest/plugins/teststeps/fwts"
 	hsi "github.com/linuxboot/contest/plugins/teststeps/hsi"
 	hwaas "github.com/linuxboot/contest/plugins/teststeps/hwaas"
+	interact "github.com/linuxboot/contest/plugins/teststeps/interact"
 	pikvm "github.com/linuxboot/contest/plugins/teststeps/pikvm"
 	ping "github.com/linuxboot/contest/plugins/teststeps/ping"
+	power "github.com/linuxboot/contest/plugins/teststeps/power"
 	qemu "github.com/linuxboot/contest/plugins/teststeps/qemu"
+	redfish "github.com/linuxboot/contest/plugins/teststeps/redfish"
 	robot "github.com/linuxboot/contest/plugins/teststeps/robot"
 	s0ix_selftest "github.com/linuxboot/contest/plugins/teststeps/s0ix-selftest"
 	secureboot "github.com/linuxboot/contest/plugins/teststeps/secureboot"
@@ -72,13 +84,20 @@ import (
 	sysbench "github.com/linuxboot/contest/plugins/teststeps/sysbench"
 
 	// the reporter plugins
+	junit "github.com/linuxboot/contest/plugins/reporters/junit"
+	metricthreshold "github.com/linuxboot/contest/plugins/reporters/metricthreshold"
 	noop "github.com/linuxboot/contest/plugins/reporters/noop"
+	tap "github.com/linuxboot/contest/plugins/reporters/tap"
 	targetsuccess "github.com/linuxboot/contest/plugins/reporters/targetsuccess"
+	webhook "github.com/linuxboot/contest/plugins/reporters/webhook"
 )
 
 var (
 	flagSet                *flag.FlagSet
+	flagConfig             *string
 	flagDBURI              *string
+	flagReplicaDBURI       *string
+	flagListener           *string
 	flagListenAddr         *string
 	flagServerID           *string
 	flagProcessTimeout     *time.Duration
@@ -88,11 +107,21 @@ var (
 	flagPauseTimeout       *time.Duration
 	flagResumeJobs         *bool
 	flagTargetLockDuration *time.Duration
+	flagTLSCert            *string
+	flagTLSKey             *string
+	flagTLSClientCA        *string
+	flagSchedulerInterval  *time.Duration
+	flagMetricsAddr        *string
+	flagTraceExporter      *string
+	flagTraceEndpoint      *string
 )
 
 func initFlags(cmd string) {
 	flagSet = flag.NewFlagSet(cmd, flag.ContinueOnError)
-	flagDBURI = flagSet.String("dbURI", config.DefaultDBURI, "Database URI")
+	flagConfig = flagSet.String("config", "", "Path to a YAML or JSON server configuration file, reloaded on SIGHUP. Flags passed on the command line take precedence")
+	flagDBURI = flagSet.String("dbURI", config.DefaultDBURI, "Database URI, use sqlite://<path> for a SQLite database file and postgres://... for PostgreSQL")
+	flagReplicaDBURI = flagSet.String("replicaDBURI", "", "Database URI of the replica storage, defaults to dbURI")
+	flagListener = flagSet.String("listener", "grpc", "API listener implementation, possible values: grpc, http")
 	flagListenAddr = flagSet.String("listenAddr", ":8080", "Listen address and port")
 	flagServerID = flagSet.String("serverID", "", "Set a static server ID, e.g. the host name or another unique identifier. If unset, will use the listener's default")
 	flagProcessTimeout = flagSet.Duration("processTimeout", api.DefaultEventTimeout, "API request processing timeout")
@@ -104,6 +133,14 @@ func initFlags(cmd string) {
 	flagTargetLockDuration = flagSet.Duration("targetLockDuration", config.DefaultTargetLockDuration,
 		"The amount of time target lock is extended by while the job is running. "+
 			"This is the maximum amount of time a job can stay paused safely.")
+	flagTLSCert = flagSet.String("tlsCert", "", "Path to the PEM certificate of the API listener. If set, the listener only accepts TLS connections")
+	flagTLSKey = flagSet.String("tlsKey", "", "Path to the PEM private key of the API listener certificate")
+	flagTLSClientCA = flagSet.String("tlsClientCA", "", "Path to the PEM CA certificates used to verify clients (mutual TLS). "+
+		"The common name of the client certificate is used as the API requestor")
+	flagSchedulerInterval = flagSet.Duration("schedulerInterval", config.DefaultSchedulerInterval, "How often the schedules are checked for jobs to start")
+	flagMetricsAddr = flagSet.String("metricsAddr", "", "Listen address and port of the Prometheus /metrics endpoint, e.g. :8090. Disabled if empty")
+	flagTraceExporter = flagSet.String("traceExporter", tracing.ExporterNone, "OpenTelemetry trace exporter, possible values: none, otlp, file")
+	flagTraceEndpoint = flagSet.String("traceEndpoint", "", "URL of the OTLP/HTTP collector (e.g. http://localhost:4318) for -traceExporter=otlp, path of the trace file for -traceExporter=file")
 }
 
 var userFunctions = []map[string]interface{}{
@@ -126,6 +163,7 @@ func GetPluginConfig() *PluginConfig {
 	var pc PluginConfig
 	pc.TargetManagerLoaders = append(pc.TargetManagerLoaders, csvtargetmanager.Load)
 	pc.TargetManagerLoaders = append(pc.TargetManagerLoaders, targetlist.Load)
+	pc.TargetManagerLoaders = append(pc.TargetManagerLoaders, inventorytargetmanager.Load)
 
 	pc.TestFetcherLoaders = append(pc.TestFetcherLoaders, literal.Load)
 	pc.TestFetcherLoaders = append(pc.TestFetcherLoaders, uri.Load)
@@ -136,19 +174,24 @@ func GetPluginConfig() *PluginConfig {
 	pc.TestStepLoaders = append(pc.TestStepLoaders, bios_setting_set.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, chipsec.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, cmd.Load)
+	pc.TestStepLoaders = append(pc.TestStepLoaders, console.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, copy.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, cpuload.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, cpuset.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, cpustats.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, dutctl.Load)
+	pc.TestStepLoaders = append(pc.TestStepLoaders, flash.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, fwhunt.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, fwts.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, firmware_version.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, hsi.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, hwaas.Load)
+	pc.TestStepLoaders = append(pc.TestStepLoaders, interact.Load)
 	pc.ReporterLoaders = append(pc.ReporterLoaders, noop.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, ping.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, pikvm.Load)
+	pc.TestStepLoaders = append(pc.TestStepLoaders, power.Load)
+	pc.TestStepLoaders = append(pc.TestStepLoaders, redfish.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, robot.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, s0ix_selftest.Load)
 	pc.TestStepLoaders = append(pc.TestStepLoaders, secureboot.Load)
@@ -157,6 +200,10 @@ func GetPluginConfig() *PluginConfig {
 	pc.TestStepLoaders = append(pc.TestStepLoaders, qemu.Load)
 
 	pc.ReporterLoaders = append(pc.ReporterLoaders, targetsuccess.Load)
+	pc.ReporterLoaders = append(pc.ReporterLoaders, metricthreshold.Load)
+	pc.ReporterLoaders = append(pc.ReporterLoaders, junit.Load)
+	pc.ReporterLoaders = append(pc.ReporterLoaders, tap.Load)
+	pc.ReporterLoaders = append(pc.ReporterLoaders, webhook.Load)
 
 	return &pc
 }
@@ -211,12 +258,32 @@ func RegisterPlugins(pluginRegistry *pluginregistry.PluginRegistry, pluginConfig
 	return nil
 }
 
+// tracingShutdownTimeout is how long the server waits for the pending spans to
+// be exported when it exits.
+const tracingShutdownTimeout = 10 * time.Second
+
 // Main is the main function that executes the ConTest server.
 func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 	initFlags(cmd)
 	if err := flagSet.Parse(args); err != nil {
 		return err
 	}
+	cmdlineFlags = make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		cmdlineFlags[f.Name] = true
+	})
+
+	var serverConfig *config.ServerConfig
+	if *flagConfig != "" {
+		cfg, err := config.LoadServerConfig(*flagConfig)
+		if err != nil {
+			return err
+		}
+		if err := applyServerConfig(cfg); err != nil {
+			return fmt.Errorf("could not apply server configuration: %w", err)
+		}
+		serverConfig = cfg
+	}
 
 	logLevel, err := logger.ParseLogLevel(*flagLogLevel)
 	if err != nil {
@@ -225,17 +292,50 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 
 	clk := clock.New()
 
-	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logLevel, logging.DefaultOptions()...))
+	tracer, shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter: *flagTraceExporter,
+		Endpoint: *flagTraceEndpoint,
+	})
+	if err != nil {
+		return fmt.Errorf("could not set up tracing: %w", err)
+	}
+
+	metricsRegistry := newMetricsRegistry()
+	ctxOpts := append(logging.DefaultOptions(), bundles.OptionMetrics{Metrics: newMetrics(metricsRegistry)})
+	if tracer != nil {
+		ctxOpts = append(ctxOpts, bundles.OptionTracer{Tracer: tracer})
+	}
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logLevel, ctxOpts...))
 	ctx, pause := xcontext.WithNotify(ctx, xcontext.ErrPaused)
 	log := ctx.Logger()
 	defer cancel()
 
+	if *flagMetricsAddr != "" {
+		go serveMetrics(ctx, *flagMetricsAddr, metricsRegistry)
+	}
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
+
 	// Let's store storage engine in context
 	storageEngineVault := storage.NewSimpleEngineVault()
 
 	pluginConfig := GetPluginConfig()
 
 	pluginRegistry := pluginregistry.NewPluginRegistry(ctx)
+	if serverConfig != nil {
+		if pluginConfig, err = selectPlugins(pluginConfig, serverConfig.Plugins); err != nil {
+			return fmt.Errorf("failed to select plugins: %w", err)
+		}
+		if err := applyReloadableConfig(ctx, serverConfig, pluginRegistry); err != nil {
+			return fmt.Errorf("could not apply server configuration: %w", err)
+		}
+	}
 	if err := RegisterPlugins(pluginRegistry, pluginConfig); err != nil {
 		return fmt.Errorf("failed to register plugins: %w", err)
 	}
@@ -251,9 +351,17 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 
 	// primary storage initialization
 	if *flagDBURI != "" {
+		newDBStorage := rdbms.New
+		if sqlite.IsURI(*flagDBURI) {
+			newDBStorage = sqlite.New
+		}
+		dbOpts := []rdbms.Opt{rdbms.Metrics(ctx.Metrics())}
+		if rdbms.IsPostgresURI(*flagDBURI) {
+			dbOpts = append(dbOpts, rdbms.SQLDialect(rdbms.DialectPostgres))
+		}
 		primaryDBURI := *flagDBURI
 		log.Infof("Using database URI for primary storage: %s", primaryDBURI)
-		s, err := rdbms.New(primaryDBURI)
+		s, err := newDBStorage(primaryDBURI, dbOpts...)
 		if err != nil {
 			log.Fatalf("Could not initialize database: %v", err)
 		}
@@ -270,10 +378,13 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 		}
 
 		// replica storage initialization
-		// pointing to main database for now but can be used to point to replica
+		// pointing to main database unless a replica is configured
 		replicaDBURI := *flagDBURI
+		if *flagReplicaDBURI != "" {
+			replicaDBURI = *flagReplicaDBURI
+		}
 		log.Infof("Using database URI for replica storage: %s", replicaDBURI)
-		r, err := rdbms.New(replicaDBURI)
+		r, err := newDBStorage(replicaDBURI, dbOpts...)
 		if err != nil {
 			log.Fatalf("Could not initialize replica database: %v", err)
 		}
@@ -309,7 +420,9 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 
 	// set Locker engine
 	if *flagTargetLocker == "auto" {
-		if *flagDBURI != "" {
+		if sqlite.IsURI(*flagDBURI) {
+			*flagTargetLocker = sqlitelocker.Name
+		} else if *flagDBURI != "" {
 			*flagTargetLocker = dblocker.Name
 		} else {
 			*flagTargetLocker = inmemory.Name
@@ -320,7 +433,17 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 	case inmemory.Name:
 		target.SetLocker(inmemory.New(clk))
 	case dblocker.Name:
-		if l, err := dblocker.New(*flagDBURI, dblocker.WithClock(clk)); err == nil {
+		lockerOpts := []dblocker.Opt{dblocker.WithClock(clk)}
+		if rdbms.IsPostgresURI(*flagDBURI) {
+			lockerOpts = append(lockerOpts, dblocker.WithDialect(dblocker.DialectPostgres))
+		}
+		if l, err := dblocker.New(*flagDBURI, lockerOpts...); err == nil {
+			target.SetLocker(l)
+		} else {
+			log.Fatalf("Failed to create locker %q: %v", *flagTargetLocker, err)
+		}
+	case sqlitelocker.Name:
+		if l, err := sqlitelocker.New(*flagDBURI, dblocker.WithClock(clk)); err == nil {
 			target.SetLocker(l)
 		} else {
 			log.Fatalf("Failed to create locker %q: %v", *flagTargetLocker, err)
@@ -329,8 +452,14 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 		log.Fatalf("Invalid target locker name %q", *flagTargetLocker)
 	}
 
+	// the inventory target manager acquires targets from the storage
+	inventorytargetmanager.SetStorage(storage.NewInventoryStorageManager(storageEngineVault))
+
 	// spawn JobManager
-	listener := grpclistener.New(*flagListenAddr)
+	listener, err := newListeners(listenerConfigs(serverConfig))
+	if err != nil {
+		log.Fatalf("Could not create API listener: %v", err)
+	}
 
 	opts := []jobmanager.Option{
 		jobmanager.APIOption(api.OptionEventTimeout(*flagProcessTimeout)),
@@ -344,6 +473,9 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 	if *flagTargetLockDuration != 0 {
 		opts = append(opts, jobmanager.OptionTargetLockDuration(*flagTargetLockDuration))
 	}
+	if *flagSchedulerInterval != 0 {
+		opts = append(opts, jobmanager.OptionSchedulerInterval(*flagSchedulerInterval))
+	}
 
 	jm, err := jobmanager.New(listener, pluginRegistry, storageEngineVault, opts...)
 	if err != nil {
@@ -364,6 +496,12 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 				return
 			}
 			switch sig {
+			case syscall.SIGHUP:
+				if *flagConfig == "" {
+					log.Infof("Signal %q, no configuration file to reload", sig)
+					continue
+				}
+				reloadServerConfig(ctx, *flagConfig, serverConfig, pluginRegistry)
 			case syscall.SIGUSR1:
 				// Gentle shutdown: stop accepting requests, drain without asserting pause signal.
 				jm.StopAPI()
@@ -397,6 +535,7 @@ func Main(cmd string, args []string, sigs <-chan os.Signal) error {
 	err = jm.Run(ctx, *flagResumeJobs)
 
 	target.SetLocker(nil)
+	inventorytargetmanager.SetStorage(nil)
 
 	log.Infof("Exiting, %v", err)
 
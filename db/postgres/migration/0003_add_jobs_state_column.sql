@@ -0,0 +1,48 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+ALTER TABLE jobs ADD COLUMN state SMALLINT DEFAULT 0;
+CREATE INDEX job_state ON jobs (state, job_id);
+
+-- Populate the column from the last job state event of each job.
+UPDATE
+  jobs
+SET
+  state =
+    -- Translate string to numeric representation.
+    CASE tt.state
+      WHEN 'JobStateStarted' THEN 1
+      WHEN 'JobStateCompleted' THEN 2
+      WHEN 'JobStateFailed' THEN 3
+      WHEN 'JobStatePaused' THEN 4
+      WHEN 'JobStatePauseFailed' THEN 5
+      WHEN 'JobStateCancelling' THEN 6
+      WHEN 'JobStateCancelled' THEN 7
+      WHEN 'JobStateCancellationFailed' THEN 8
+      ELSE 0
+    END
+FROM
+  ( -- This query finds the last job state event for each job.
+    SELECT DISTINCT ON (job_id)
+      job_id,
+      event_name AS state
+    FROM
+      framework_events
+    WHERE
+      event_name IN ('JobStateStarted', 'JobStateCompleted', 'JobStateFailed',
+                     'JobStatePaused', 'JobStatePauseFailed', 'JobStateCancelling',
+                     'JobStateCancelled', 'JobStateCancellationFailed')
+    ORDER BY
+      job_id, event_id DESC
+  ) tt
+WHERE
+  jobs.job_id = tt.job_id;
+
+-- +goose Down
+
+DROP INDEX job_state;
+ALTER TABLE jobs DROP COLUMN state;
@@ -0,0 +1,27 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+CREATE TABLE job_tags (
+  job_id BIGINT NOT NULL,
+  tag VARCHAR(32),
+  PRIMARY KEY (job_id, tag)
+);
+CREATE INDEX job_tags_tag ON job_tags (tag);
+
+-- Extract tags from the descriptor field.
+INSERT INTO
+  job_tags
+SELECT
+  jobs.job_id,
+  job_tags.tag
+FROM
+  jobs,
+  jsonb_array_elements_text(jobs.descriptor::jsonb -> 'Tags') AS job_tags (tag);
+
+-- +goose Down
+
+DROP TABLE job_tags;
@@ -0,0 +1,10 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+-- TEXT columns have no size limit in PostgreSQL, there is nothing to do.
+
+-- +goose Down
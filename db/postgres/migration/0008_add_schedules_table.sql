@@ -0,0 +1,25 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+CREATE TABLE schedules (
+	schedule_id BIGSERIAL NOT NULL,
+	requestor VARCHAR(32) NOT NULL,
+	server_id VARCHAR(64) NOT NULL,
+	create_time TIMESTAMPTZ NOT NULL,
+	cron_expr VARCHAR(128) NOT NULL,
+	overlap_policy VARCHAR(32) NOT NULL,
+	paused BOOLEAN NOT NULL DEFAULT FALSE,
+	descriptor TEXT NOT NULL,
+	last_fire_time TIMESTAMPTZ NULL,
+	last_job_id BIGINT NOT NULL DEFAULT 0,
+	PRIMARY KEY (schedule_id)
+);
+CREATE INDEX schedules_server_id ON schedules (server_id);
+
+-- +goose Down
+
+DROP TABLE schedules;
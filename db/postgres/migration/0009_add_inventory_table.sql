@@ -0,0 +1,22 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+CREATE TABLE inventory (
+	target_id VARCHAR(128) NOT NULL,
+	fqdn VARCHAR(255) NOT NULL DEFAULT '',
+	ipv4 VARCHAR(16) NOT NULL DEFAULT '',
+	ipv6 VARCHAR(40) NOT NULL DEFAULT '',
+	labels TEXT NOT NULL,
+	health VARCHAR(32) NOT NULL,
+	drained BOOLEAN NOT NULL DEFAULT FALSE,
+	update_time TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (target_id)
+);
+
+-- +goose Down
+
+DROP TABLE inventory;
@@ -0,0 +1,25 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+CREATE TABLE schedules (
+	schedule_id BIGINT(20) UNSIGNED NOT NULL AUTO_INCREMENT,
+	requestor VARCHAR(32) NOT NULL,
+	server_id VARCHAR(64) NOT NULL,
+	create_time TIMESTAMP NOT NULL,
+	cron_expr VARCHAR(128) NOT NULL,
+	overlap_policy VARCHAR(32) NOT NULL,
+	paused BOOL NOT NULL DEFAULT FALSE,
+	descriptor TEXT NOT NULL,
+	last_fire_time TIMESTAMP NULL,
+	last_job_id BIGINT(20) UNSIGNED NOT NULL DEFAULT 0,
+	PRIMARY KEY (schedule_id),
+	KEY (server_id)
+);
+
+-- +goose Down
+
+DROP TABLE schedules;
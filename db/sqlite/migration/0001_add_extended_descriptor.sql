@@ -0,0 +1,10 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+ALTER TABLE jobs ADD COLUMN extended_descriptor TEXT;
+
+-- +goose Down
+ALTER TABLE jobs DROP COLUMN extended_descriptor;
@@ -0,0 +1,42 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+ALTER TABLE jobs ADD COLUMN state TINYINT DEFAULT 0;
+CREATE INDEX job_state ON jobs (state, job_id);
+
+-- Populate the column from the last job state event of each job.
+UPDATE
+  jobs
+SET
+  state = COALESCE((
+    SELECT
+      CASE fe.event_name
+        WHEN 'JobStateStarted' THEN 1
+        WHEN 'JobStateCompleted' THEN 2
+        WHEN 'JobStateFailed' THEN 3
+        WHEN 'JobStatePaused' THEN 4
+        WHEN 'JobStatePauseFailed' THEN 5
+        WHEN 'JobStateCancelling' THEN 6
+        WHEN 'JobStateCancelled' THEN 7
+        WHEN 'JobStateCancellationFailed' THEN 8
+      END
+    FROM
+      framework_events fe
+    WHERE
+      fe.job_id = jobs.job_id AND
+      fe.event_name IN ('JobStateStarted', 'JobStateCompleted', 'JobStateFailed',
+                        'JobStatePaused', 'JobStatePauseFailed', 'JobStateCancelling',
+                        'JobStateCancelled', 'JobStateCancellationFailed')
+    ORDER BY
+      fe.event_id DESC
+    LIMIT 1
+  ), 0);
+
+-- +goose Down
+
+DROP INDEX job_state;
+ALTER TABLE jobs DROP COLUMN state;
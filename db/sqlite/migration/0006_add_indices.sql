@@ -0,0 +1,18 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- +goose Up
+
+CREATE INDEX test_events_fetch_index_0 ON test_events (job_id, run_id, test_name);
+CREATE INDEX framework_events_fetch_index_0 ON framework_events (job_id, event_name);
+CREATE INDEX run_reports_job_id_idx ON run_reports (job_id);
+CREATE INDEX final_reports_job_id_idx ON final_reports (job_id);
+
+-- +goose Down
+
+DROP INDEX test_events_fetch_index_0;
+DROP INDEX framework_events_fetch_index_0;
+DROP INDEX run_reports_job_id_idx;
+DROP INDEX final_reports_job_id_idx;
@@ -0,0 +1,65 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+-- SQLite version of db/rdbms/schema/v0/create_contest_db.sql
+
+CREATE TABLE test_events (
+	event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id BIGINT NOT NULL,
+	run_id BIGINT NOT NULL,
+	test_name VARCHAR(32) NULL,
+	test_step_label VARCHAR(32) NULL,
+	event_name VARCHAR(32) NULL,
+	target_name VARCHAR(64) NULL,
+	target_id VARCHAR(64) NULL,
+	payload TEXT NULL,
+	emit_time TIMESTAMP NOT NULL
+);
+
+CREATE TABLE framework_events (
+	event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id BIGINT NOT NULL,
+	event_name VARCHAR(32) NULL,
+	payload TEXT NULL,
+	emit_time TIMESTAMP NOT NULL
+);
+
+CREATE TABLE run_reports (
+	report_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id BIGINT NOT NULL,
+	run_id BIGINT NOT NULL,
+	reporter_name VARCHAR(32) NOT NULL,
+	success TINYINT(1) NULL,
+	report_time TIMESTAMP NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE final_reports (
+	report_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	job_id BIGINT NOT NULL,
+	success TINYINT(1) NULL,
+	reporter_name VARCHAR(32) NOT NULL,
+	report_time TIMESTAMP NOT NULL,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE jobs (
+	job_id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name VARCHAR(64) NOT NULL,
+	requestor VARCHAR(32) NOT NULL,
+	server_id VARCHAR(64) NOT NULL,
+	request_time TIMESTAMP NOT NULL,
+	descriptor TEXT NOT NULL,
+	teststeps TEXT
+);
+
+CREATE TABLE locks (
+	target_id VARCHAR(64) NOT NULL,
+	job_id BIGINT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	valid BOOL NOT NULL DEFAULT TRUE,
+	PRIMARY KEY (target_id)
+);
@@ -0,0 +1,22 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package sqlite embeds the SQLite versions of the ConTest schema and of the
+// migrations in db/rdbms, so that the SQLite storage can create and upgrade
+// its database without external files.
+package sqlite
+
+import "embed"
+
+// Schema contains the initial schema, schema/v0/create_contest_db.sql.
+//
+//go:embed schema/v0/*.sql
+var Schema embed.FS
+
+// Migrations contains the goose SQL migrations, one per version. Versions
+// match the ones of the migrations in db/rdbms/migration.
+//
+//go:embed migration/*.sql
+var Migrations embed.FS
@@ -0,0 +1,14 @@
+-- Copyright (c) Facebook, Inc. and its affiliates.
+--
+-- This source code is licensed under the MIT license found in the
+-- LICENSE file in the root directory of this source tree.
+
+CREATE USER contest WITH PASSWORD 'contest';
+CREATE DATABASE contest OWNER contest;
+CREATE DATABASE contest_integ OWNER contest;
+
+\connect contest contest
+\i /create_contest_db.sql
+
+\connect contest_integ contest
+\i /create_contest_db.sql
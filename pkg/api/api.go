@@ -11,6 +11,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/storage/limits"
 	"github.com/linuxboot/contest/pkg/types"
@@ -236,15 +238,20 @@ func (a *API) Status(ctx xcontext.Context, requestor EventRequestor, jobID types
 
 // Retry will retry a job identified by its ID, using the same job
 // description. If the job is still running, an error is returned.
-func (a *API) Retry(ctx xcontext.Context, requestor EventRequestor, jobID types.JobID) (Response, error) {
+// If failedTargetsOnly is set, the new job only runs on the targets that
+// failed the last run of the original job.
+func (a *API) Retry(ctx xcontext.Context, requestor EventRequestor, jobID types.JobID, failedTargetsOnly bool) (Response, error) {
 	resp := a.newResponse(ResponseTypeRetry)
 	ev := &Event{
-		Context:  ctx.WithTag("api_method", "retry"),
+		// As for Start, the new job must not inherit cancel and pause signals
+		// from the request context.
+		Context:  xcontext.WithResetSignalers(ctx).WithTag("api_method", "retry"),
 		Type:     EventTypeRetry,
 		ServerID: resp.ServerID,
 		Msg: EventRetryMsg{
-			requestor: requestor,
-			JobID:     jobID,
+			requestor:         requestor,
+			JobID:             jobID,
+			FailedTargetsOnly: failedTargetsOnly,
 		},
 		RespCh: make(chan *EventResponse, 1),
 	}
@@ -254,9 +261,63 @@ func (a *API) Retry(ctx xcontext.Context, requestor EventRequestor, jobID types.
 	}
 	resp.Data = ResponseDataRetry{
 		// this is the job ID of the job to retry, not the new job ID
+		JobID:    jobID,
+		NewJobID: respEv.JobID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// Pause pauses a running job by the given job ID. Its pause state is stored,
+// and it stays paused until it is resumed through the API, even across server
+// restarts. If releaseTargets is set, the targets of the job are released and
+// its current test attempt starts over when it is resumed, otherwise they stay
+// locked while the server runs.
+func (a *API) Pause(ctx xcontext.Context, requestor EventRequestor, jobID types.JobID, releaseTargets bool) (Response, error) {
+	resp := a.newResponse(ResponseTypePause)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "pause"),
+		Type:     EventTypePause,
+		ServerID: resp.ServerID,
+		Msg: EventPauseMsg{
+			requestor:      requestor,
+			JobID:          jobID,
+			ReleaseTargets: releaseTargets,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataPause{
+		JobID: jobID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// Resume resumes a paused job by the given job ID.
+func (a *API) Resume(ctx xcontext.Context, requestor EventRequestor, jobID types.JobID) (Response, error) {
+	resp := a.newResponse(ResponseTypeResume)
+	ev := &Event{
+		// As for Start, the resumed job must not inherit cancel and pause
+		// signals from the request context.
+		Context:  xcontext.WithResetSignalers(ctx).WithTag("api_method", "resume"),
+		Type:     EventTypeResume,
+		ServerID: resp.ServerID,
+		Msg: EventResumeMsg{
+			requestor: requestor,
+			JobID:     jobID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataResume{
 		JobID: jobID,
-		// TODO this should set the new Job ID
-		// NewJobID: ...
 	}
 	resp.Err = respEv.Err
 	return resp, nil
@@ -285,3 +346,293 @@ func (a *API) List(ctx xcontext.Context, requestor EventRequestor, query *storag
 	resp.Err = respEv.Err
 	return resp, nil
 }
+
+// JobEvents returns the test and framework events of a job whose sequence IDs
+// are greater or equal than testEventsFrom and frameworkEventsFrom
+// respectively, together with the current state of the job. It is meant to
+// be called repeatedly to follow a job while it is running.
+func (a *API) JobEvents(ctx xcontext.Context, requestor EventRequestor, jobID types.JobID, testEventsFrom, frameworkEventsFrom uint64) (Response, error) {
+	resp := a.newResponse(ResponseTypeEvents)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "events"),
+		Type:     EventTypeEvents,
+		ServerID: resp.ServerID,
+		Msg: EventEventsMsg{
+			requestor:           requestor,
+			JobID:               jobID,
+			TestEventsFrom:      testEventsFrom,
+			FrameworkEventsFrom: frameworkEventsFrom,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataEvents{
+		JobState:        respEv.JobState,
+		TestEvents:      respEv.TestEvents,
+		FrameworkEvents: respEv.FrameworkEvents,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// AddSchedule registers a job to be started periodically according to the
+// given cron expression. Every firing starts a new job from the same job
+// descriptor. The overlap policy determines what happens when the schedule
+// fires while the job started by the previous firing is still running.
+func (a *API) AddSchedule(ctx xcontext.Context, requestor EventRequestor, cronExpr string, overlapPolicy job.OverlapPolicy, jobDescriptor string) (Response, error) {
+	resp := a.newResponse(ResponseTypeAddSchedule)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "add_schedule"),
+		Type:     EventTypeAddSchedule,
+		ServerID: resp.ServerID,
+		Msg: EventAddScheduleMsg{
+			requestor:     requestor,
+			CronExpr:      cronExpr,
+			OverlapPolicy: overlapPolicy,
+			JobDescriptor: jobDescriptor,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataAddSchedule{
+		ScheduleID: respEv.ScheduleID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ListSchedules returns the schedules owned by this server.
+func (a *API) ListSchedules(ctx xcontext.Context, requestor EventRequestor) (Response, error) {
+	resp := a.newResponse(ResponseTypeListSchedules)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "list_schedules"),
+		Type:     EventTypeListSchedules,
+		ServerID: resp.ServerID,
+		Msg: EventListSchedulesMsg{
+			requestor: requestor,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataListSchedules{
+		Schedules: respEv.Schedules,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// PauseSchedule pauses a schedule, or resumes it if paused is false. Jobs
+// already started by the schedule are not affected.
+func (a *API) PauseSchedule(ctx xcontext.Context, requestor EventRequestor, scheduleID types.ScheduleID, paused bool) (Response, error) {
+	resp := a.newResponse(ResponseTypePauseSchedule)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "pause_schedule"),
+		Type:     EventTypePauseSchedule,
+		ServerID: resp.ServerID,
+		Msg: EventPauseScheduleMsg{
+			requestor:  requestor,
+			ScheduleID: scheduleID,
+			Paused:     paused,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataPauseSchedule{
+		ScheduleID: scheduleID,
+		Paused:     paused,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// DeleteSchedule deletes a schedule. Jobs already started by the schedule
+// are not affected.
+func (a *API) DeleteSchedule(ctx xcontext.Context, requestor EventRequestor, scheduleID types.ScheduleID) (Response, error) {
+	resp := a.newResponse(ResponseTypeDeleteSchedule)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "delete_schedule"),
+		Type:     EventTypeDeleteSchedule,
+		ServerID: resp.ServerID,
+		Msg: EventDeleteScheduleMsg{
+			requestor:  requestor,
+			ScheduleID: scheduleID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataDeleteSchedule{
+		ScheduleID: scheduleID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// AddInventoryTarget adds a target to the inventory. The ID of the target must
+// not be in the inventory already.
+func (a *API) AddInventoryTarget(ctx xcontext.Context, requestor EventRequestor, t inventory.Target) (Response, error) {
+	resp := a.newResponse(ResponseTypeAddInventoryTarget)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "add_inventory_target"),
+		Type:     EventTypeAddInventoryTarget,
+		ServerID: resp.ServerID,
+		Msg: EventAddInventoryTargetMsg{
+			requestor: requestor,
+			Target:    t,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataAddInventoryTarget{
+		Target: respEv.InventoryTarget,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// ListInventoryTargets returns the targets of the inventory matching a label
+// selector, see inventory.ParseSelector. The empty selector matches all the
+// targets.
+func (a *API) ListInventoryTargets(ctx xcontext.Context, requestor EventRequestor, selector string) (Response, error) {
+	resp := a.newResponse(ResponseTypeListInventoryTargets)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "list_inventory_targets"),
+		Type:     EventTypeListInventoryTargets,
+		ServerID: resp.ServerID,
+		Msg: EventListInventoryTargetsMsg{
+			requestor: requestor,
+			Selector:  selector,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataListInventoryTargets{
+		Targets: respEv.InventoryTargets,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// RemoveInventoryTarget removes a target from the inventory. Jobs which already
+// acquired the target are not affected.
+func (a *API) RemoveInventoryTarget(ctx xcontext.Context, requestor EventRequestor, targetID string) (Response, error) {
+	resp := a.newResponse(ResponseTypeRemoveInventoryTarget)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "remove_inventory_target"),
+		Type:     EventTypeRemoveInventoryTarget,
+		ServerID: resp.ServerID,
+		Msg: EventRemoveInventoryTargetMsg{
+			requestor: requestor,
+			TargetID:  targetID,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataRemoveInventoryTarget{
+		TargetID: targetID,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// DrainInventoryTarget drains a target of the inventory so that it is not
+// acquired anymore, or puts it back in service if drained is false.
+func (a *API) DrainInventoryTarget(ctx xcontext.Context, requestor EventRequestor, targetID string, drained bool) (Response, error) {
+	resp := a.newResponse(ResponseTypeDrainInventoryTarget)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "drain_inventory_target"),
+		Type:     EventTypeDrainInventoryTarget,
+		ServerID: resp.ServerID,
+		Msg: EventDrainInventoryTargetMsg{
+			requestor: requestor,
+			TargetID:  targetID,
+			Drained:   drained,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataDrainInventoryTarget{
+		Target: respEv.InventoryTarget,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// LabelInventoryTarget adds or overwrites the labels of set on a target of the
+// inventory, and removes the labels whose keys are in remove.
+func (a *API) LabelInventoryTarget(ctx xcontext.Context, requestor EventRequestor, targetID string, set map[string]string, remove []string) (Response, error) {
+	resp := a.newResponse(ResponseTypeLabelInventoryTarget)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "label_inventory_target"),
+		Type:     EventTypeLabelInventoryTarget,
+		ServerID: resp.ServerID,
+		Msg: EventLabelInventoryTargetMsg{
+			requestor: requestor,
+			TargetID:  targetID,
+			Set:       set,
+			Remove:    remove,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataLabelInventoryTarget{
+		Target: respEv.InventoryTarget,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
+
+// SetInventoryTargetHealth sets the health state of a target of the
+// inventory. Unhealthy targets are not acquired.
+func (a *API) SetInventoryTargetHealth(ctx xcontext.Context, requestor EventRequestor, targetID string, health inventory.Health) (Response, error) {
+	resp := a.newResponse(ResponseTypeSetInventoryTargetHealth)
+	ev := &Event{
+		Context:  ctx.WithTag("api_method", "set_inventory_target_health"),
+		Type:     EventTypeSetInventoryTargetHealth,
+		ServerID: resp.ServerID,
+		Msg: EventSetInventoryTargetHealthMsg{
+			requestor: requestor,
+			TargetID:  targetID,
+			Health:    health,
+		},
+		RespCh: make(chan *EventResponse, 1),
+	}
+	respEv, err := a.SendReceiveEvent(ev, nil)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = ResponseDataSetInventoryTargetHealth{
+		Target: respEv.InventoryTarget,
+	}
+	resp.Err = respEv.Err
+	return resp, nil
+}
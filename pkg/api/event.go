@@ -6,6 +6,9 @@
 package api
 
 import (
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
@@ -35,6 +38,22 @@ var eventTypeNames = map[EventType]string{
 	EventTypeRetry:  "event_type_retry",
 	EventTypeError:  "event_type_error",
 	EventTypeList:   "event_type_list",
+	EventTypeEvents: "event_type_events",
+
+	EventTypeAddSchedule:    "event_type_add_schedule",
+	EventTypeListSchedules:  "event_type_list_schedules",
+	EventTypePauseSchedule:  "event_type_pause_schedule",
+	EventTypeDeleteSchedule: "event_type_delete_schedule",
+
+	EventTypeAddInventoryTarget:       "event_type_add_inventory_target",
+	EventTypeListInventoryTargets:     "event_type_list_inventory_targets",
+	EventTypeRemoveInventoryTarget:    "event_type_remove_inventory_target",
+	EventTypeDrainInventoryTarget:     "event_type_drain_inventory_target",
+	EventTypeLabelInventoryTarget:     "event_type_label_inventory_target",
+	EventTypeSetInventoryTargetHealth: "event_type_set_inventory_target_health",
+
+	EventTypePause:  "event_type_pause",
+	EventTypeResume: "event_type_resume",
 }
 
 // list of existing API event types.
@@ -45,6 +64,19 @@ const (
 	EventTypeRetry
 	EventTypeError
 	EventTypeList
+	EventTypeEvents
+	EventTypeAddSchedule
+	EventTypeListSchedules
+	EventTypePauseSchedule
+	EventTypeDeleteSchedule
+	EventTypeAddInventoryTarget
+	EventTypeListInventoryTargets
+	EventTypeRemoveInventoryTarget
+	EventTypeDrainInventoryTarget
+	EventTypeLabelInventoryTarget
+	EventTypeSetInventoryTargetHealth
+	EventTypePause
+	EventTypeResume
 )
 
 // Event represents an event that the API can generate. This is used by the API
@@ -101,11 +133,36 @@ func (e EventStopMsg) Requestor() EventRequestor { return e.requestor }
 type EventRetryMsg struct {
 	requestor EventRequestor
 	JobID     types.JobID
+	// FailedTargetsOnly restricts the new job to the targets which failed
+	// in the last run of the original job.
+	FailedTargetsOnly bool
 }
 
 // Requestor returns the requestor of the API call as reported by the client.
 func (e EventRetryMsg) Requestor() EventRequestor { return e.requestor }
 
+// EventPauseMsg contains the arguments for an event of type Pause.
+type EventPauseMsg struct {
+	requestor EventRequestor
+	JobID     types.JobID
+	// ReleaseTargets releases the targets of the job while it is paused, its
+	// current test attempt starts over when it is resumed. Otherwise the
+	// targets stay locked until the job is resumed.
+	ReleaseTargets bool
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventPauseMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventResumeMsg contains the arguments for an event of type Resume.
+type EventResumeMsg struct {
+	requestor EventRequestor
+	JobID     types.JobID
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventResumeMsg) Requestor() EventRequestor { return e.requestor }
+
 // EventResponse is a response to an EventMsg.
 type EventResponse struct {
 	Requestor EventRequestor
@@ -113,6 +170,19 @@ type EventResponse struct {
 	Err       error
 	Status    *job.Status
 	JobIDs    []types.JobID
+	// JobState, TestEvents and FrameworkEvents are set in response to an
+	// EventEventsMsg.
+	JobState        string
+	TestEvents      []testevent.Event
+	FrameworkEvents []frameworkevent.Event
+	// ScheduleID and Schedules are set in response to the schedule
+	// management messages.
+	ScheduleID types.ScheduleID
+	Schedules  []*job.Schedule
+	// InventoryTarget and InventoryTargets are set in response to the
+	// inventory management messages.
+	InventoryTarget  *inventory.Target
+	InventoryTargets []*inventory.Target
 }
 
 // EventListMsg contains the arguments for an event of type List.
@@ -130,3 +200,122 @@ type EventListResponse struct {
 	Jobs      []types.JobID
 	Err       error
 }
+
+// EventEventsMsg contains the arguments for an event of type Events.
+type EventEventsMsg struct {
+	requestor EventRequestor
+	JobID     types.JobID
+	// TestEventsFrom and FrameworkEventsFrom are the lowest sequence IDs of
+	// the test and framework events to return.
+	TestEventsFrom      uint64
+	FrameworkEventsFrom uint64
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventEventsMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventAddScheduleMsg contains the arguments for an event of type AddSchedule.
+type EventAddScheduleMsg struct {
+	requestor     EventRequestor
+	CronExpr      string
+	OverlapPolicy job.OverlapPolicy
+	JobDescriptor string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventAddScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventListSchedulesMsg contains the arguments for an event of type
+// ListSchedules.
+type EventListSchedulesMsg struct {
+	requestor EventRequestor
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventListSchedulesMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventPauseScheduleMsg contains the arguments for an event of type
+// PauseSchedule. Paused set to false resumes the schedule.
+type EventPauseScheduleMsg struct {
+	requestor  EventRequestor
+	ScheduleID types.ScheduleID
+	Paused     bool
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventPauseScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventDeleteScheduleMsg contains the arguments for an event of type
+// DeleteSchedule.
+type EventDeleteScheduleMsg struct {
+	requestor  EventRequestor
+	ScheduleID types.ScheduleID
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventDeleteScheduleMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventAddInventoryTargetMsg contains the arguments for an event of type
+// AddInventoryTarget.
+type EventAddInventoryTargetMsg struct {
+	requestor EventRequestor
+	Target    inventory.Target
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventAddInventoryTargetMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventListInventoryTargetsMsg contains the arguments for an event of type
+// ListInventoryTargets.
+type EventListInventoryTargetsMsg struct {
+	requestor EventRequestor
+	Selector  string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventListInventoryTargetsMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventRemoveInventoryTargetMsg contains the arguments for an event of type
+// RemoveInventoryTarget.
+type EventRemoveInventoryTargetMsg struct {
+	requestor EventRequestor
+	TargetID  string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventRemoveInventoryTargetMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventDrainInventoryTargetMsg contains the arguments for an event of type
+// DrainInventoryTarget. Drained set to false puts the target back in service.
+type EventDrainInventoryTargetMsg struct {
+	requestor EventRequestor
+	TargetID  string
+	Drained   bool
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventDrainInventoryTargetMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventLabelInventoryTargetMsg contains the arguments for an event of type
+// LabelInventoryTarget. The labels of Set are added or overwritten, then the
+// ones of Remove are removed.
+type EventLabelInventoryTargetMsg struct {
+	requestor EventRequestor
+	TargetID  string
+	Set       map[string]string
+	Remove    []string
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventLabelInventoryTargetMsg) Requestor() EventRequestor { return e.requestor }
+
+// EventSetInventoryTargetHealthMsg contains the arguments for an event of type
+// SetInventoryTargetHealth.
+type EventSetInventoryTargetHealthMsg struct {
+	requestor EventRequestor
+	TargetID  string
+	Health    inventory.Health
+}
+
+// Requestor returns the requestor of the API call as reported by the client.
+func (e EventSetInventoryTargetHealthMsg) Requestor() EventRequestor { return e.requestor }
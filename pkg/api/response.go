@@ -6,6 +6,9 @@
 package api
 
 import (
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/types"
 
@@ -23,6 +26,19 @@ const (
 	ResponseTypeRetry
 	ResponseTypeVersion
 	ResponseTypeList
+	ResponseTypeEvents
+	ResponseTypeAddSchedule
+	ResponseTypeListSchedules
+	ResponseTypePauseSchedule
+	ResponseTypeDeleteSchedule
+	ResponseTypeAddInventoryTarget
+	ResponseTypeListInventoryTargets
+	ResponseTypeRemoveInventoryTarget
+	ResponseTypeDrainInventoryTarget
+	ResponseTypeLabelInventoryTarget
+	ResponseTypeSetInventoryTargetHealth
+	ResponseTypePause
+	ResponseTypeResume
 )
 
 // ResponseTypeToName maps response types to their names.
@@ -33,6 +49,22 @@ var ResponseTypeToName = map[ResponseType]string{
 	ResponseTypeRetry:   "ResponseTypeRetry",
 	ResponseTypeVersion: "ResponseTypeVersion",
 	ResponseTypeList:    "ResponseTypeList",
+	ResponseTypeEvents:  "ResponseTypeEvents",
+
+	ResponseTypeAddSchedule:    "ResponseTypeAddSchedule",
+	ResponseTypeListSchedules:  "ResponseTypeListSchedules",
+	ResponseTypePauseSchedule:  "ResponseTypePauseSchedule",
+	ResponseTypeDeleteSchedule: "ResponseTypeDeleteSchedule",
+
+	ResponseTypeAddInventoryTarget:       "ResponseTypeAddInventoryTarget",
+	ResponseTypeListInventoryTargets:     "ResponseTypeListInventoryTargets",
+	ResponseTypeRemoveInventoryTarget:    "ResponseTypeRemoveInventoryTarget",
+	ResponseTypeDrainInventoryTarget:     "ResponseTypeDrainInventoryTarget",
+	ResponseTypeLabelInventoryTarget:     "ResponseTypeLabelInventoryTarget",
+	ResponseTypeSetInventoryTargetHealth: "ResponseTypeSetInventoryTargetHealth",
+
+	ResponseTypePause:  "ResponseTypePause",
+	ResponseTypeResume: "ResponseTypeResume",
 }
 
 // Response is the type returned to any API request.
@@ -88,6 +120,26 @@ func (r ResponseDataRetry) Type() ResponseType {
 	return ResponseTypeRetry
 }
 
+// ResponseDataPause is the response type for a Pause request.
+type ResponseDataPause struct {
+	JobID types.JobID
+}
+
+// Type returns the response type.
+func (r ResponseDataPause) Type() ResponseType {
+	return ResponseTypePause
+}
+
+// ResponseDataResume is the response type for a Resume request.
+type ResponseDataResume struct {
+	JobID types.JobID
+}
+
+// Type returns the response type.
+func (r ResponseDataResume) Type() ResponseType {
+	return ResponseTypeResume
+}
+
 // ResponseDataList is the response type for a List request.
 type ResponseDataList struct {
 	JobIDs []types.JobID
@@ -98,6 +150,121 @@ func (r ResponseDataList) Type() ResponseType {
 	return ResponseTypeList
 }
 
+// ResponseDataEvents is the response type for an Events request.
+type ResponseDataEvents struct {
+	// JobState is the name of the last state event of the job, empty if the
+	// job did not start yet.
+	JobState        string
+	TestEvents      []testevent.Event
+	FrameworkEvents []frameworkevent.Event
+}
+
+// Type returns the response type.
+func (r ResponseDataEvents) Type() ResponseType {
+	return ResponseTypeEvents
+}
+
+// ResponseDataAddSchedule is the response type for an AddSchedule request.
+type ResponseDataAddSchedule struct {
+	ScheduleID types.ScheduleID
+}
+
+// Type returns the response type.
+func (r ResponseDataAddSchedule) Type() ResponseType {
+	return ResponseTypeAddSchedule
+}
+
+// ResponseDataListSchedules is the response type for a ListSchedules request.
+type ResponseDataListSchedules struct {
+	Schedules []*job.Schedule
+}
+
+// Type returns the response type.
+func (r ResponseDataListSchedules) Type() ResponseType {
+	return ResponseTypeListSchedules
+}
+
+// ResponseDataPauseSchedule is the response type for a PauseSchedule request.
+type ResponseDataPauseSchedule struct {
+	ScheduleID types.ScheduleID
+	Paused     bool
+}
+
+// Type returns the response type.
+func (r ResponseDataPauseSchedule) Type() ResponseType {
+	return ResponseTypePauseSchedule
+}
+
+// ResponseDataDeleteSchedule is the response type for a DeleteSchedule request.
+type ResponseDataDeleteSchedule struct {
+	ScheduleID types.ScheduleID
+}
+
+// Type returns the response type.
+func (r ResponseDataDeleteSchedule) Type() ResponseType {
+	return ResponseTypeDeleteSchedule
+}
+
+// ResponseDataAddInventoryTarget is the response type for an AddInventoryTarget request.
+type ResponseDataAddInventoryTarget struct {
+	Target *inventory.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataAddInventoryTarget) Type() ResponseType {
+	return ResponseTypeAddInventoryTarget
+}
+
+// ResponseDataListInventoryTargets is the response type for a ListInventoryTargets request.
+type ResponseDataListInventoryTargets struct {
+	Targets []*inventory.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataListInventoryTargets) Type() ResponseType {
+	return ResponseTypeListInventoryTargets
+}
+
+// ResponseDataRemoveInventoryTarget is the response type for a RemoveInventoryTarget request.
+type ResponseDataRemoveInventoryTarget struct {
+	TargetID string
+}
+
+// Type returns the response type.
+func (r ResponseDataRemoveInventoryTarget) Type() ResponseType {
+	return ResponseTypeRemoveInventoryTarget
+}
+
+// ResponseDataDrainInventoryTarget is the response type for a DrainInventoryTarget request.
+type ResponseDataDrainInventoryTarget struct {
+	Target *inventory.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataDrainInventoryTarget) Type() ResponseType {
+	return ResponseTypeDrainInventoryTarget
+}
+
+// ResponseDataLabelInventoryTarget is the response type for a LabelInventoryTarget request.
+type ResponseDataLabelInventoryTarget struct {
+	Target *inventory.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataLabelInventoryTarget) Type() ResponseType {
+	return ResponseTypeLabelInventoryTarget
+}
+
+// ResponseDataSetInventoryTargetHealth is the response type for a SetInventoryTargetHealth request.
+type ResponseDataSetInventoryTargetHealth struct {
+	Target *inventory.Target
+}
+
+// Type returns the response type.
+func (r ResponseDataSetInventoryTargetHealth) Type() ResponseType {
+	return ResponseTypeSetInventoryTargetHealth
+}
+
 // ResponseDataVersion is the response type for a Version request.
 type ResponseDataVersion struct {
 	Version uint32
@@ -139,6 +306,20 @@ type RetryResponse struct {
 	Err      *xjson.Error
 }
 
+// PauseResponse is a typesafe version of Response with a Pause payload
+type PauseResponse struct {
+	ServerID string
+	Data     ResponseDataPause
+	Err      *xjson.Error
+}
+
+// ResumeResponse is a typesafe version of Response with a Resume payload
+type ResumeResponse struct {
+	ServerID string
+	Data     ResponseDataResume
+	Err      *xjson.Error
+}
+
 // ListResponse is a typesafe version of Response with a List payload
 type ListResponse struct {
 	ServerID string
@@ -152,3 +333,73 @@ type VersionResponse struct {
 	Data     ResponseDataVersion
 	Err      *xjson.Error
 }
+
+// AddScheduleResponse is a typesafe version of Response with an AddSchedule payload
+type AddScheduleResponse struct {
+	ServerID string
+	Data     ResponseDataAddSchedule
+	Err      *xjson.Error
+}
+
+// ListSchedulesResponse is a typesafe version of Response with a ListSchedules payload
+type ListSchedulesResponse struct {
+	ServerID string
+	Data     ResponseDataListSchedules
+	Err      *xjson.Error
+}
+
+// PauseScheduleResponse is a typesafe version of Response with a PauseSchedule payload
+type PauseScheduleResponse struct {
+	ServerID string
+	Data     ResponseDataPauseSchedule
+	Err      *xjson.Error
+}
+
+// DeleteScheduleResponse is a typesafe version of Response with a DeleteSchedule payload
+type DeleteScheduleResponse struct {
+	ServerID string
+	Data     ResponseDataDeleteSchedule
+	Err      *xjson.Error
+}
+
+// AddInventoryTargetResponse is a typesafe version of Response with an AddInventoryTarget payload
+type AddInventoryTargetResponse struct {
+	ServerID string
+	Data     ResponseDataAddInventoryTarget
+	Err      *xjson.Error
+}
+
+// ListInventoryTargetsResponse is a typesafe version of Response with a ListInventoryTargets payload
+type ListInventoryTargetsResponse struct {
+	ServerID string
+	Data     ResponseDataListInventoryTargets
+	Err      *xjson.Error
+}
+
+// RemoveInventoryTargetResponse is a typesafe version of Response with a RemoveInventoryTarget payload
+type RemoveInventoryTargetResponse struct {
+	ServerID string
+	Data     ResponseDataRemoveInventoryTarget
+	Err      *xjson.Error
+}
+
+// DrainInventoryTargetResponse is a typesafe version of Response with a DrainInventoryTarget payload
+type DrainInventoryTargetResponse struct {
+	ServerID string
+	Data     ResponseDataDrainInventoryTarget
+	Err      *xjson.Error
+}
+
+// LabelInventoryTargetResponse is a typesafe version of Response with a LabelInventoryTarget payload
+type LabelInventoryTargetResponse struct {
+	ServerID string
+	Data     ResponseDataLabelInventoryTarget
+	Err      *xjson.Error
+}
+
+// SetInventoryTargetHealthResponse is a typesafe version of Response with a SetInventoryTargetHealth payload
+type SetInventoryTargetHealthResponse struct {
+	ServerID string
+	Data     ResponseDataSetInventoryTargetHealth
+	Err      *xjson.Error
+}
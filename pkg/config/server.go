@@ -0,0 +1,196 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServerConfig is the content of the configuration file of the ConTest server,
+// passed with -config. The file can be written in YAML or JSON. Settings which
+// are not set keep the value of the corresponding command line flag.
+//
+// On SIGHUP the server reloads the file and applies the settings which don't
+// change the structure of the server, i.e. the log level, the plugin defaults
+// and the capacity of the resources. The other settings only take effect at
+// the next restart.
+type ServerConfig struct {
+	// Listeners are the API listeners of the server, which are all served at
+	// the same time. They replace the listener configured with the flags.
+	Listeners []ListenerConfig `yaml:"listeners" json:"listeners"`
+	// Storage is the configuration of the primary and replica storage.
+	Storage StorageConfig `yaml:"storage" json:"storage"`
+	// TargetLocker is the target locker implementation, see -targetLocker.
+	TargetLocker string `yaml:"targetLocker" json:"targetLocker"`
+	// LogLevel is the log level of the server, see -logLevel.
+	LogLevel string `yaml:"logLevel" json:"logLevel"`
+	// MetricsAddr is the address of the Prometheus /metrics endpoint, see
+	// -metricsAddr.
+	MetricsAddr string `yaml:"metricsAddr" json:"metricsAddr"`
+	// Tracing is the configuration of the OpenTelemetry traces of the jobs.
+	Tracing TracingConfig `yaml:"tracing" json:"tracing"`
+	// Plugins selects the plugins of the server and their defaults.
+	Plugins PluginsConfig `yaml:"plugins" json:"plugins"`
+	// Resources are the named resources shared by the jobs, which test steps
+	// take while targets run them.
+	Resources []ResourceConfig `yaml:"resources" json:"resources"`
+}
+
+// ListenerConfig is the configuration of an API listener.
+type ListenerConfig struct {
+	// Type is the listener implementation, "grpc" (the default) or "http".
+	Type string `yaml:"type" json:"type"`
+	// ListenAddr is the address and port the listener listens on.
+	ListenAddr string `yaml:"listenAddr" json:"listenAddr"`
+	// TLSCert, TLSKey and TLSClientCA are the same as -tlsCert, -tlsKey and
+	// -tlsClientCA, and are only supported by the grpc listener.
+	TLSCert     string `yaml:"tlsCert" json:"tlsCert"`
+	TLSKey      string `yaml:"tlsKey" json:"tlsKey"`
+	TLSClientCA string `yaml:"tlsClientCA" json:"tlsClientCA"`
+}
+
+// StorageConfig is the configuration of the storage of the server.
+type StorageConfig struct {
+	// DBURI is the URI of the primary database, see -dbURI.
+	DBURI string `yaml:"dbURI" json:"dbURI"`
+	// ReplicaDBURI is the URI of the replica database, see -replicaDBURI.
+	ReplicaDBURI string `yaml:"replicaDBURI" json:"replicaDBURI"`
+}
+
+// TracingConfig is the configuration of the exporter of the traces.
+type TracingConfig struct {
+	// Exporter is "none", "otlp" or "file", see -traceExporter.
+	Exporter string `yaml:"exporter" json:"exporter"`
+	// Endpoint is the URL of the OTLP collector or the path of the trace
+	// file, see -traceEndpoint.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+}
+
+// PluginsConfig selects which plugins are registered by the server, and the
+// default parameters of the test steps.
+type PluginsConfig struct {
+	// Enabled, if not empty, is the list of the only plugins to register.
+	Enabled []string `yaml:"enabled" json:"enabled"`
+	// Disabled is a list of plugins not to register.
+	Disabled []string `yaml:"disabled" json:"disabled"`
+	// Defaults maps test step names to default values of their parameters,
+	// in the same format as the parameters of the job descriptors. The "*"
+	// key sets defaults for all the test steps.
+	Defaults map[string]map[string][]interface{} `yaml:"defaults" json:"defaults"`
+}
+
+// ResourceConfig is the configuration of a named resource, e.g. a HwaaS host,
+// which at most Capacity targets can use at the same time.
+type ResourceConfig struct {
+	Resource string `yaml:"resource" json:"resource"`
+	Capacity uint   `yaml:"capacity" json:"capacity"`
+}
+
+// ServerConfigFormat returns the format of a server configuration file from
+// its extension. Files which are not .json are parsed as YAML.
+func ServerConfigFormat(path string) JobDescFormat {
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return JobDescFormatJSON
+	}
+	return JobDescFormatYAML
+}
+
+// LoadServerConfig reads and parses a server configuration file.
+func LoadServerConfig(path string) (*ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read server configuration: %w", err)
+	}
+	return ParseServerConfig(data, ServerConfigFormat(path))
+}
+
+// ParseServerConfig parses and validates a server configuration. Unknown keys
+// are rejected, so that typos are not silently ignored.
+func ParseServerConfig(data []byte, format JobDescFormat) (*ServerConfig, error) {
+	var cfg ServerConfig
+	switch format {
+	case JobDescFormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse JSON server configuration: %w", err)
+		}
+	case JobDescFormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse YAML server configuration: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown server configuration format")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the consistency of the configuration.
+func (c *ServerConfig) Validate() error {
+	for i, l := range c.Listeners {
+		switch l.Type {
+		case "", "grpc":
+		case "http":
+			if l.TLSCert != "" || l.TLSKey != "" || l.TLSClientCA != "" {
+				return fmt.Errorf("listener %d: TLS is not supported by the http listener", i)
+			}
+		default:
+			return fmt.Errorf("listener %d: unknown listener type %q", i, l.Type)
+		}
+	}
+	switch c.Tracing.Exporter {
+	case "", "none":
+	case "otlp", "file":
+		if c.Tracing.Endpoint == "" {
+			return fmt.Errorf("the %s trace exporter needs an endpoint", c.Tracing.Exporter)
+		}
+	default:
+		return fmt.Errorf("unknown trace exporter %q", c.Tracing.Exporter)
+	}
+	if len(c.Plugins.Enabled) > 0 && len(c.Plugins.Disabled) > 0 {
+		return fmt.Errorf("plugins can either be enabled or disabled, not both")
+	}
+	resources := make(map[string]bool, len(c.Resources))
+	for i, r := range c.Resources {
+		if r.Resource == "" {
+			return fmt.Errorf("resource %d has no name", i)
+		}
+		if resources[r.Resource] {
+			return fmt.Errorf("duplicate resource %q", r.Resource)
+		}
+		resources[r.Resource] = true
+		if r.Capacity == 0 {
+			return fmt.Errorf("resource %q must have a capacity of at least 1", r.Resource)
+		}
+	}
+	return nil
+}
+
+// StructureEqual returns whether two configurations only differ by the
+// settings which can be reloaded without restarting the server.
+func (c *ServerConfig) StructureEqual(other *ServerConfig) bool {
+	return reflect.DeepEqual(c.Listeners, other.Listeners) &&
+		c.Storage == other.Storage &&
+		c.TargetLocker == other.TargetLocker &&
+		c.MetricsAddr == other.MetricsAddr &&
+		c.Tracing == other.Tracing &&
+		reflect.DeepEqual(c.Plugins.Enabled, other.Plugins.Enabled) &&
+		reflect.DeepEqual(c.Plugins.Disabled, other.Plugins.Disabled)
+}
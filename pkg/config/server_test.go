@@ -0,0 +1,92 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseServerConfigYAML(t *testing.T) {
+	cfg, err := ParseServerConfig([]byte(`
+listeners:
+  - type: http
+    listenAddr: ":8081"
+storage:
+  dbURI: sqlite:///var/lib/contest/contest.db
+targetLocker: auto
+logLevel: info
+tracing:
+  exporter: otlp
+  endpoint: http://localhost:4318
+plugins:
+  disabled: [qemu]
+  defaults:
+    "*":
+      transport:
+        - options:
+            identity_file: /etc/contest/id_ed25519
+resources:
+  - resource: hwaas-host-3
+    capacity: 2
+`), JobDescFormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, []ListenerConfig{{Type: "http", ListenAddr: ":8081"}}, cfg.Listeners)
+	require.Equal(t, "sqlite:///var/lib/contest/contest.db", cfg.Storage.DBURI)
+	require.Equal(t, "info", cfg.LogLevel)
+	require.Equal(t, TracingConfig{Exporter: "otlp", Endpoint: "http://localhost:4318"}, cfg.Tracing)
+	require.Equal(t, []string{"qemu"}, cfg.Plugins.Disabled)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"options": map[string]interface{}{"identity_file": "/etc/contest/id_ed25519"}},
+	}, cfg.Plugins.Defaults["*"]["transport"])
+	require.Equal(t, []ResourceConfig{{Resource: "hwaas-host-3", Capacity: 2}}, cfg.Resources)
+}
+
+func TestParseServerConfigJSON(t *testing.T) {
+	cfg, err := ParseServerConfig([]byte(`{"storage": {"dbURI": "x", "replicaDBURI": "y"}, "plugins": {"enabled": ["cmd"]}}`), JobDescFormatJSON)
+	require.NoError(t, err)
+	require.Equal(t, StorageConfig{DBURI: "x", ReplicaDBURI: "y"}, cfg.Storage)
+	require.Equal(t, []string{"cmd"}, cfg.Plugins.Enabled)
+
+	cfg, err = ParseServerConfig(nil, JobDescFormatYAML)
+	require.NoError(t, err)
+	require.Equal(t, &ServerConfig{}, cfg)
+}
+
+func TestParseServerConfigInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown key":        `logLevle: info`,
+		"unknown listener":   `listeners: [{type: smtp}]`,
+		"http with TLS":      `listeners: [{type: http, tlsCert: cert.pem}]`,
+		"enable and disable": `plugins: {enabled: [cmd], disabled: [qemu]}`,
+		"unknown exporter":   `tracing: {exporter: jaeger}`,
+		"no trace endpoint":  `tracing: {exporter: file}`,
+		"unnamed resource":   `resources: [{capacity: 2}]`,
+		"duplicate resource": `resources: [{resource: a, capacity: 1}, {resource: a, capacity: 2}]`,
+		"zero capacity":      `resources: [{resource: a}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseServerConfig([]byte(data), JobDescFormatYAML)
+			require.Error(t, err)
+		})
+	}
+	_, err := ParseServerConfig([]byte(`{"logLevle": "info"}`), JobDescFormatJSON)
+	require.Error(t, err)
+}
+
+func TestServerConfigStructureEqual(t *testing.T) {
+	a := &ServerConfig{LogLevel: "info", Storage: StorageConfig{DBURI: "x"}}
+	b := &ServerConfig{LogLevel: "debug", Storage: StorageConfig{DBURI: "x"}}
+	require.True(t, a.StructureEqual(b))
+	b.TargetLocker = "InMemory"
+	require.False(t, a.StructureEqual(b))
+}
+
+func TestServerConfigFormat(t *testing.T) {
+	require.Equal(t, JobDescFormatJSON, ServerConfigFormat("/etc/contest/server.JSON"))
+	require.Equal(t, JobDescFormatYAML, ServerConfigFormat("/etc/contest/server.yml"))
+}
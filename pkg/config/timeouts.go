@@ -44,3 +44,7 @@ var TestRunnerStepShutdownTimeout = 5 * time.Second
 // DefaultTargetLockDuration is the default value for -targetLockDuration.
 // It is the amount of time target lock is extended by while the job is running.
 const DefaultTargetLockDuration = 10 * time.Minute
+
+// DefaultSchedulerInterval is the default value for -schedulerInterval.
+// It is how often the JobManager checks whether a job schedule has to fire.
+const DefaultSchedulerInterval = 10 * time.Second
@@ -0,0 +1,460 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package console records the consoles of the targets of a test from the
+// beginning to the end of the test, so that no output is lost between the
+// test steps, e.g. the boot log following a flash step. The console log of
+// each target is emitted in chunks as ConsoleOutput events or written to a
+// file, and test steps can match regular expressions against it through the
+// Recorder of the target, see TargetRecorder.
+package console
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// EventConsoleOutput carries a chunk of the console log of a target, or the
+// path of the file the log was written to.
+var EventConsoleOutput = event.Name("ConsoleOutput")
+
+// ConsoleOutputPayload represents the payload carried by a ConsoleOutput
+// event. Offset is the position of Data in the console log of the target.
+// Events naming the Artifact the log was written to are emitted at the end
+// of the test, or when it is paused, instead, with the size of the log as
+// Offset.
+type ConsoleOutputPayload struct {
+	Offset   int64
+	Data     string `json:",omitempty"`
+	Artifact string `json:",omitempty"`
+}
+
+const (
+	// DefaultChunkSize is the maximum size of the data of a ConsoleOutput
+	// event if the configuration does not set it.
+	DefaultChunkSize = 4096
+	// DefaultFlushInterval is the longest time console output waits before
+	// being emitted if the configuration does not set it.
+	DefaultFlushInterval = time.Second
+	// maxLogSize bounds the console log kept in memory for matching, the
+	// oldest output is dropped beyond it.
+	maxLogSize = 16 << 20
+	// matchOverlap is how much of the console log already searched by Match
+	// is searched again with new output, for matches spanning both.
+	matchOverlap = 4 << 10
+	// reopenInterval is the time between attempts to open a console that is
+	// not available, e.g. before the VM exposing it is started.
+	reopenInterval = time.Second
+)
+
+// Recorders are the recorders of the consoles of the targets of a test.
+type Recorders struct {
+	cancel    xcontext.CancelFunc
+	wg        sync.WaitGroup
+	recorders map[string]*Recorder
+}
+
+// Start starts recording the console of every target until Stop is called.
+// Events are emitted with ev. When a paused test is resumed, offsets are the
+// sizes of the console logs recorded before the pause, see Offsets, and the
+// recording continues from there.
+func Start(ctx xcontext.Context, cfg *test.ConsoleRecorderConfig, testName string, targets []*target.Target, ev testevent.Emitter, offsets map[string]int64) *Recorders {
+	runCtx, cancel := xcontext.WithCancel(ctx)
+	rs := &Recorders{
+		cancel:    cancel,
+		recorders: make(map[string]*Recorder, len(targets)),
+	}
+	for _, tgt := range targets {
+		r := newRecorder(tgt, cfg, ev, offsets[tgt.ID])
+		if cfg.ArtifactDir != "" {
+			r.artifact = artifactPath(ctx, cfg.ArtifactDir, testName, tgt.ID)
+		}
+		rs.recorders[tgt.ID] = r
+		rs.wg.Add(1)
+		go func() {
+			defer rs.wg.Done()
+			r.run(runCtx, ctx, cfg)
+		}()
+	}
+	return rs
+}
+
+// Stop stops the recorders, once the remaining console output is emitted.
+func (rs *Recorders) Stop() {
+	rs.cancel()
+	rs.wg.Wait()
+}
+
+// Offsets returns the sizes of the console logs of the targets recorded so
+// far, to resume the recording after a pause.
+func (rs *Recorders) Offsets() map[string]int64 {
+	offsets := make(map[string]int64, len(rs.recorders))
+	for id, r := range rs.recorders {
+		offsets[id] = r.Size()
+	}
+	return offsets
+}
+
+// recordersKey is the key of the Recorders in the values of a context.
+type recordersKey struct{}
+
+// WithRecorders returns a copy of ctx carrying rs, to be passed to the test
+// steps.
+func WithRecorders(ctx xcontext.Context, rs *Recorders) xcontext.Context {
+	return xcontext.WithValue(ctx, recordersKey{}, rs)
+}
+
+// TargetRecorder returns the recorder of the console of a target from the
+// context of a test step, or nil if the test does not record the consoles.
+func TargetRecorder(ctx xcontext.Context, targetID string) *Recorder {
+	rs, ok := ctx.Value(recordersKey{}).(*Recorders)
+	if !ok {
+		return nil
+	}
+	return rs.recorders[targetID]
+}
+
+// artifactPath returns the path of the file the console log of a target is
+// written to: <dir>/<job ID>/<run ID>/<test name>/<target ID>.log.
+func artifactPath(ctx xcontext.Context, dir, testName, targetID string) string {
+	jobID, _ := types.JobIDFromContext(ctx)
+	runID, _ := types.RunIDFromContext(ctx)
+	return filepath.Join(dir, jobID.String(), runID.String(), pathElement(testName), pathElement(targetID)+".log")
+}
+
+// pathElement replaces the characters of s which are unsafe in a file name.
+func pathElement(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// Recorder records the console of a target.
+type Recorder struct {
+	target        *target.Target
+	ev            testevent.Emitter
+	chunkSize     int
+	flushInterval time.Duration
+	artifact      string
+
+	// emitMu serializes the emission of the events, so that the chunks are
+	// emitted in order.
+	emitMu sync.Mutex
+
+	mu      sync.Mutex
+	log     []byte        // Tail of the console log.
+	start   int64         // Offset of log in the console log.
+	pending []byte        // Output not emitted yet.
+	emitted int64         // Offset of pending in the console log.
+	updated chan struct{} // Closed when output is recorded.
+	err     error         // Last error of the source, if any.
+	file    *os.File      // Artifact the log is written to, if any.
+}
+
+func newRecorder(tgt *target.Target, cfg *test.ConsoleRecorderConfig, ev testevent.Emitter, offset int64) *Recorder {
+	r := &Recorder{
+		target:        tgt,
+		ev:            ev,
+		chunkSize:     int(cfg.ChunkSize),
+		flushInterval: time.Duration(cfg.FlushInterval),
+		start:         offset,
+		emitted:       offset,
+		updated:       make(chan struct{}),
+	}
+	if r.chunkSize == 0 {
+		r.chunkSize = DefaultChunkSize
+	}
+	if r.flushInterval == 0 {
+		r.flushInterval = DefaultFlushInterval
+	}
+	return r
+}
+
+// run records the console until ctx is done, reopening it whenever it is
+// closed or fails to open. Events are emitted with emitCtx, which outlives
+// ctx so that the end of the log is emitted.
+func (r *Recorder) run(ctx, emitCtx xcontext.Context, cfg *test.ConsoleRecorderConfig) {
+	ctx = ctx.WithField("target", r.target.ID)
+	defer r.finish(emitCtx)
+
+	if r.artifact != "" {
+		if err := r.openArtifact(); err != nil {
+			ctx.Errorf("Failed to create the console log file, emitting it as events: %v", err)
+			r.artifact = ""
+		}
+	}
+
+	cfg, err := expandConfig(cfg, r.target)
+	if err != nil {
+		r.setErr(fmt.Errorf("failed to expand the console configuration: %w", err))
+		ctx.Errorf("%v", r.Err())
+		return
+	}
+
+	flushDone := make(chan struct{})
+	defer func() { <-flushDone }()
+	go func() {
+		defer close(flushDone)
+		ticker := time.NewTicker(r.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.flush(emitCtx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, r.chunkSize)
+	for {
+		src, err := openSource(ctx, cfg, r.target)
+		if err != nil {
+			if prev := r.Err(); prev == nil || prev.Error() != err.Error() {
+				ctx.Warnf("Failed to open %s, retrying: %v", sourceString(cfg), err)
+			}
+			r.setErr(err)
+		} else {
+			ctx.Debugf("Recording %s", sourceString(cfg))
+			r.setErr(nil)
+			var closeOnce sync.Once
+			closeSrc := func() { closeOnce.Do(func() { src.Close() }) }
+			stop := make(chan struct{})
+			go func() {
+				// Unblock the read below when the recording stops.
+				select {
+				case <-ctx.Done():
+					closeSrc()
+				case <-stop:
+				}
+			}()
+			for {
+				n, err := src.Read(buf)
+				if n > 0 {
+					r.write(buf[:n])
+				}
+				if err != nil {
+					break
+				}
+			}
+			close(stop)
+			closeSrc()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reopenInterval):
+		}
+	}
+}
+
+func (r *Recorder) openArtifact() error {
+	if err := os.MkdirAll(filepath.Dir(r.artifact), 0o755); err != nil {
+		return err
+	}
+	// A resumed recording appends to the log written before the pause.
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if r.start == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(r.artifact, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	r.file = file
+	return nil
+}
+
+// write records console output.
+func (r *Recorder) write(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, data...)
+	if drop := len(r.log) - maxLogSize; drop > 0 {
+		r.log = append([]byte(nil), r.log[drop:]...)
+		r.start += int64(drop)
+	}
+	if r.file != nil {
+		// Errors are reported when closing the file.
+		_, _ = r.file.Write(data)
+		r.emitted += int64(len(data))
+	} else {
+		r.pending = append(r.pending, data...)
+	}
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+// flush emits the pending output in chunks.
+func (r *Recorder) flush(ctx xcontext.Context) {
+	r.emitMu.Lock()
+	defer r.emitMu.Unlock()
+
+	r.mu.Lock()
+	pending, offset := r.pending, r.emitted
+	r.pending = nil
+	r.emitted += int64(len(pending))
+	r.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := r.chunkSize
+		if n > len(pending) {
+			n = len(pending)
+		}
+		r.emit(ctx, ConsoleOutputPayload{Offset: offset, Data: string(pending[:n])})
+		pending = pending[n:]
+		offset += int64(n)
+	}
+}
+
+// finish emits the remaining output, or closes the artifact and emits its
+// path.
+func (r *Recorder) finish(ctx xcontext.Context) {
+	r.flush(ctx)
+
+	r.mu.Lock()
+	file, size := r.file, r.emitted
+	r.file = nil
+	r.mu.Unlock()
+	if file == nil {
+		return
+	}
+	if err := file.Close(); err != nil {
+		ctx.Errorf("Failed to write the console log of target %s to %s: %v", r.target.ID, r.artifact, err)
+	}
+	r.emit(ctx, ConsoleOutputPayload{Offset: size, Artifact: r.artifact})
+}
+
+func (r *Recorder) emit(ctx xcontext.Context, payload ConsoleOutputPayload) {
+	if err := emitEvent(ctx, r.ev, r.target, payload); err != nil {
+		ctx.Errorf("Failed to emit the console output of target %s: %v", r.target.ID, err)
+	}
+}
+
+func (r *Recorder) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Err returns the last error opening or reading the console, or nil if it
+// is being recorded.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Size returns the size of the console log recorded so far.
+func (r *Recorder) Size() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.start + int64(len(r.log))
+}
+
+// Log returns the console log recorded from the given offset. Only the last
+// 16 MiB of the log are kept, and not the log recorded before the test was
+// paused.
+func (r *Recorder) Log(from int64) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]byte(nil), r.tailLocked(from)...)
+}
+
+// tailLocked returns the log from the given offset. The recorded bytes are
+// never modified, so the returned slice can be read after unlocking.
+func (r *Recorder) tailLocked(from int64) []byte {
+	if from < r.start {
+		from = r.start
+	}
+	if from-r.start > int64(len(r.log)) {
+		return nil
+	}
+	return r.log[from-r.start : len(r.log) : len(r.log)]
+}
+
+// Match waits until the console log from the given offset matches all the
+// regexes, and returns that part of the log. It returns the log and an error
+// if ctx is done first.
+//
+// The regexes are only matched on the output recorded since the previous
+// attempt, from the start of a line at most matchOverlap bytes before it, so
+// a match has to fit in the output of an attempt plus matchOverlap bytes.
+func (r *Recorder) Match(ctx xcontext.Context, from int64, regexes []*regexp.Regexp) ([]byte, error) {
+	matched := make([]bool, len(regexes))
+	remaining := len(regexes)
+	scanned := from
+	for {
+		r.mu.Lock()
+		log, start, updated := r.tailLocked(from), r.start, r.updated
+		r.mu.Unlock()
+		if start < from {
+			start = from
+		}
+
+		// The recorded output is never changed, only appended to or copied
+		// when the oldest is dropped, so the snapshot is searched unlocked.
+		window := scanned - matchOverlap
+		if window > start {
+			if idx := bytes.IndexByte(log[window-start:scanned-start], '\n'); idx >= 0 {
+				window += int64(idx) + 1
+			}
+		} else {
+			window = start
+		}
+		for idx, re := range regexes {
+			if !matched[idx] && re.Match(log[window-start:]) {
+				matched[idx] = true
+				remaining--
+			}
+		}
+		scanned = start + int64(len(log))
+		if remaining == 0 {
+			return append([]byte(nil), log...), nil
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			log = append([]byte(nil), log...)
+			if srcErr := r.Err(); srcErr != nil {
+				return log, fmt.Errorf("console log of target %s does not match, the console is not available: %w", r.target.ID, srcErr)
+			}
+			return log, fmt.Errorf("console log of target %s does not match: %w", r.target.ID, ctx.Err())
+		}
+	}
+}
+
+func emitEvent(ctx xcontext.Context, ev testevent.Emitter, tgt *target.Target, payload ConsoleOutputPayload) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	msg := json.RawMessage(payloadJSON)
+	return ev.Emit(ctx, testevent.Data{
+		EventName: EventConsoleOutput,
+		Target:    tgt,
+		Payload:   &msg,
+	})
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/xjson"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+type emitter struct {
+	mu       sync.Mutex
+	payloads map[string][]ConsoleOutputPayload
+}
+
+func (e *emitter) Emit(ctx xcontext.Context, data testevent.Data) error {
+	var payload ConsoleOutputPayload
+	if err := json.Unmarshal(*data.Payload, &payload); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.payloads == nil {
+		e.payloads = make(map[string][]ConsoleOutputPayload)
+	}
+	e.payloads[data.Target.ID] = append(e.payloads[data.Target.ID], payload)
+	return nil
+}
+
+// output returns the console output emitted for a target, checking that the
+// chunks are contiguous.
+func (e *emitter) output(t *testing.T, targetID string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out string
+	for _, payload := range e.payloads[targetID] {
+		require.Equal(t, int64(len(out)), payload.Offset)
+		out += payload.Data
+	}
+	return out
+}
+
+func newContext(t *testing.T) xcontext.Context {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func match(t *testing.T, ctx xcontext.Context, r *Recorder, from int64, regex string) ([]byte, error) {
+	ctx, cancel := xcontext.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return r.Match(ctx, from, []*regexp.Regexp{regexp.MustCompile(regex)})
+}
+
+func TestUnixSource(t *testing.T) {
+	ctx := newContext(t)
+	sock := filepath.Join(t.TempDir(), "T1.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer l.Close()
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conns <- conn
+		}
+	}()
+
+	var ev emitter
+	rs := Start(ctx, &test.ConsoleRecorderConfig{
+		Source:        test.ConsoleSourceUnix,
+		Address:       filepath.Join(filepath.Dir(sock), "{{ .ID }}.sock"),
+		ChunkSize:     4,
+		FlushInterval: xjson.Duration(10 * time.Millisecond),
+	}, "Test", []*target.Target{{ID: "T1"}}, &ev, nil)
+	r := TargetRecorder(WithRecorders(ctx, rs), "T1")
+	require.NotNil(t, r)
+	require.Nil(t, TargetRecorder(WithRecorders(ctx, rs), "T2"))
+	require.Nil(t, TargetRecorder(ctx, "T1"))
+
+	conn := <-conns
+	_, err = conn.Write([]byte("Booting\n"))
+	require.NoError(t, err)
+	log, err := match(t, ctx, r, 0, "Booting")
+	require.NoError(t, err)
+	require.Equal(t, "Booting\n", string(log))
+
+	from := r.Size()
+	_, err = conn.Write([]byte("login: "))
+	require.NoError(t, err)
+	log, err = match(t, ctx, r, from, "login:")
+	require.NoError(t, err)
+	require.Equal(t, "login: ", string(log))
+	require.Equal(t, "Booting\nlogin: ", string(r.Log(0)))
+	require.NoError(t, r.Err())
+
+	conn.Close()
+	rs.Stop()
+	require.Equal(t, "Booting\nlogin: ", ev.output(t, "T1"))
+	for _, payload := range ev.payloads["T1"] {
+		require.LessOrEqual(t, len(payload.Data), 4)
+	}
+}
+
+func TestMatchUnavailable(t *testing.T) {
+	ctx := newContext(t)
+	var ev emitter
+	rs := Start(ctx, &test.ConsoleRecorderConfig{
+		Source:  test.ConsoleSourceUnix,
+		Address: filepath.Join(t.TempDir(), "missing.sock"),
+	}, "Test", []*target.Target{{ID: "T1"}}, &ev, nil)
+	defer rs.Stop()
+	r := rs.recorders["T1"]
+
+	matchCtx, cancel := xcontext.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err := r.Match(matchCtx, 0, []*regexp.Regexp{regexp.MustCompile("login:")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "the console is not available")
+	require.Error(t, r.Err())
+}
+
+func TestMatchIncremental(t *testing.T) {
+	ctx := newContext(t)
+	r := newRecorder(&target.Target{ID: "T1"}, &test.ConsoleRecorderConfig{}, &emitter{}, 0)
+	r.write([]byte("old output\n"))
+
+	type result struct {
+		log []byte
+		err error
+	}
+	results := make(chan result, 1)
+	from := r.Size()
+	go func() {
+		matchCtx, cancel := xcontext.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		log, err := r.Match(matchCtx, from, []*regexp.Regexp{
+			regexp.MustCompile("Booting"),
+			regexp.MustCompile("login: $"),
+		})
+		results <- result{log, err}
+	}()
+
+	// The regexes match different writes, and "login: " is split across
+	// writes.
+	var want string
+	for _, data := range []string{"Boot", "ing\n", strings.Repeat("filler\n", 1000), "lo", "gin", ": "} {
+		r.write([]byte(data))
+		want += data
+		time.Sleep(time.Millisecond)
+	}
+	res := <-results
+	require.NoError(t, res.err)
+	require.Equal(t, want, string(res.log))
+
+	// The log is returned as a copy.
+	res.log[0] = 'X'
+	require.Equal(t, "old output\n"+want, string(r.Log(0)))
+}
+
+func TestFileSource(t *testing.T) {
+	ctx := newContext(t)
+	path := filepath.Join(t.TempDir(), "serial.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ev emitter
+	rs := Start(ctx, &test.ConsoleRecorderConfig{
+		Source:        test.ConsoleSourceFile,
+		Address:       path,
+		FlushInterval: xjson.Duration(10 * time.Millisecond),
+	}, "Test", []*target.Target{{ID: "T1"}}, &ev, nil)
+	r := rs.recorders["T1"]
+
+	// The file is followed as it grows.
+	_, err = f.WriteString("first\n")
+	require.NoError(t, err)
+	_, err = match(t, ctx, r, 0, "first")
+	require.NoError(t, err)
+	_, err = f.WriteString("second\n")
+	require.NoError(t, err)
+	_, err = match(t, ctx, r, 0, "second")
+	require.NoError(t, err)
+
+	rs.Stop()
+	require.Equal(t, "first\nsecond\n", ev.output(t, "T1"))
+}
+
+func TestCommandSourceArtifact(t *testing.T) {
+	ctx := newContext(t)
+	dir := t.TempDir()
+	var ev emitter
+	rs := Start(ctx, &test.ConsoleRecorderConfig{
+		Source:      test.ConsoleSourceCommand,
+		Command:     []string{"sh", "-c", "echo console of {{ .ID }}; sleep 10"},
+		ArtifactDir: dir,
+	}, "Test/1", []*target.Target{{ID: "T1"}}, &ev, nil)
+	r := rs.recorders["T1"]
+
+	_, err := match(t, ctx, r, 0, "console of T1")
+	require.NoError(t, err)
+	rs.Stop()
+
+	path := filepath.Join(dir, "0", "0", "Test_1", "T1.log")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "console of T1\n", string(data))
+	require.Equal(t, []ConsoleOutputPayload{{Offset: int64(len(data)), Artifact: path}}, ev.payloads["T1"])
+}
+
+// A test which is paused and resumed continues the console logs where the
+// recording stopped.
+func TestPauseResume(t *testing.T) {
+	for _, artifacts := range []bool{false, true} {
+		ctx := newContext(t)
+		dir := t.TempDir()
+		var ev emitter
+		var offsets map[string]int64
+		for part := 1; part <= 2; part++ {
+			cfg := &test.ConsoleRecorderConfig{
+				Source:        test.ConsoleSourceCommand,
+				Command:       []string{"sh", "-c", fmt.Sprintf("echo part %d of {{ .ID }}; sleep 10", part)},
+				FlushInterval: xjson.Duration(10 * time.Millisecond),
+			}
+			if artifacts {
+				cfg.ArtifactDir = dir
+			}
+			rs := Start(ctx, cfg, "Test", []*target.Target{{ID: "T1"}}, &ev, offsets)
+			r := rs.recorders["T1"]
+			log, err := match(t, ctx, r, offsets["T1"], fmt.Sprintf("part %d", part))
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("part %d of T1\n", part), string(log))
+			rs.Stop()
+			offsets = rs.Offsets()
+			require.Equal(t, map[string]int64{"T1": int64(13 * part)}, offsets)
+		}
+
+		if !artifacts {
+			require.Equal(t, "part 1 of T1\npart 2 of T1\n", ev.output(t, "T1"))
+			continue
+		}
+		path := filepath.Join(dir, "0", "0", "Test", "T1.log")
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "part 1 of T1\npart 2 of T1\n", string(data))
+		require.Equal(t, []ConsoleOutputPayload{{Offset: 13, Artifact: path}, {Offset: 26, Artifact: path}}, ev.payloads["T1"])
+	}
+}
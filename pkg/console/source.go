@@ -0,0 +1,149 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// expandConfig returns a copy of the configuration with its templates
+// expanded for the target.
+func expandConfig(cfg *test.ConsoleRecorderConfig, tgt *target.Target) (*test.ConsoleRecorderConfig, error) {
+	var expanded test.ConsoleRecorderConfig
+	if err := test.NewParamExpander(tgt).ExpandObject(*cfg, &expanded); err != nil {
+		return nil, err
+	}
+	return &expanded, nil
+}
+
+// openSource opens the console of a target. Closing the returned reader
+// releases the console.
+func openSource(ctx xcontext.Context, cfg *test.ConsoleRecorderConfig, tgt *target.Target) (io.ReadCloser, error) {
+	switch cfg.Source {
+	case test.ConsoleSourceDUT:
+		drv, err := dut.OpenTarget(tgt, nil)
+		if err != nil {
+			return nil, err
+		}
+		console, ok := drv.(dut.Console)
+		if !ok {
+			drv.Close()
+			return nil, dut.Unsupported(drv, "console access")
+		}
+		conn, err := console.OpenConsole(ctx, cfg.Port)
+		if err != nil {
+			drv.Close()
+			return nil, fmt.Errorf("failed to open console %d: %w", cfg.Port, err)
+		}
+		return &closers{Reader: conn, closers: []io.Closer{conn, drv}}, nil
+
+	case test.ConsoleSourceFile:
+		f, err := os.Open(cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			// Regular files, e.g. written by QEMU with -serial file:PATH,
+			// are followed as they grow instead of ending the recording.
+			return &follower{ctx: ctx, File: f}, nil
+		}
+		return f, nil
+
+	case test.ConsoleSourceTCP, test.ConsoleSourceUnix:
+		var d net.Dialer
+		return d.DialContext(ctx, cfg.Source, cfg.Address)
+
+	case test.ConsoleSourceCommand:
+		cmd := exec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Start(); err != nil {
+			r.Close()
+			w.Close()
+			return nil, fmt.Errorf("failed to start %s: %w", cmd, err)
+		}
+		// The read end gets EOF once the command exits.
+		w.Close()
+		return &closers{Reader: r, closers: []io.Closer{r, &process{cmd}}}, nil
+	}
+	return nil, fmt.Errorf("unknown console source %q", cfg.Source)
+}
+
+// closers reads from a reader and closes several resources.
+type closers struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *closers) Close() error {
+	var firstErr error
+	for _, closer := range c.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// follower reads a file as it grows until ctx is done.
+type follower struct {
+	ctx xcontext.Context
+	*os.File
+}
+
+// followInterval is the time between reads at the end of a followed file.
+const followInterval = 200 * time.Millisecond
+
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.File.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// process kills and reaps a command when closed.
+type process struct {
+	cmd *exec.Cmd
+}
+
+func (p *process) Close() error {
+	_ = p.cmd.Process.Kill()
+	_ = p.cmd.Wait()
+	return nil
+}
+
+// sourceString describes the source of a console in the logs.
+func sourceString(cfg *test.ConsoleRecorderConfig) string {
+	switch cfg.Source {
+	case test.ConsoleSourceDUT:
+		return "DUT console " + strconv.Itoa(cfg.Port)
+	case test.ConsoleSourceCommand:
+		return fmt.Sprintf("command %q", cfg.Command)
+	}
+	return cfg.Source + " " + cfg.Address
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package dut
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/target"
+)
+
+// Config selects the backend controlling a DUT and sets its parameters, e.g.
+// the address of the HwaaS host.
+type Config struct {
+	Backend string
+	Params  map[string]string `json:",omitempty"`
+}
+
+// targetState is the part of the TargetManagerState of a target read by this
+// package.
+type targetState struct {
+	DUT *Config `json:",omitempty"`
+}
+
+// LabelPrefix prefixes the labels configuring the DUT of a target:
+// "dut.backend" selects the backend and "dut.<param>" sets its parameters.
+const LabelPrefix = "dut."
+
+// TargetConfig returns the DUT configuration of a target. It is read from the
+// DUT object of the TargetManagerState of the target, e.g. given in the
+// targetlist target manager:
+//
+//	{"ID": "board1", "TMS": {"DUT": {"Backend": "hwaas", "Params": {"machine_id": "ws1"}}}}
+//
+// or from the labels of targets acquired from the inventory, e.g.
+// dut.backend=hwaas,dut.machine_id=ws1. Parameters of the DUT object take
+// precedence over the labels.
+func TargetConfig(t *target.Target) (*Config, error) {
+	cfg := Config{Params: map[string]string{}}
+	for k, v := range inventory.TargetLabels(t) {
+		if !strings.HasPrefix(k, LabelPrefix) {
+			continue
+		}
+		if k == LabelPrefix+"backend" {
+			cfg.Backend = v
+		} else {
+			cfg.Params[strings.TrimPrefix(k, LabelPrefix)] = v
+		}
+	}
+	var state targetState
+	if len(t.TargetManagerState) > 0 {
+		if err := json.Unmarshal(t.TargetManagerState, &state); err != nil {
+			return nil, fmt.Errorf("invalid target manager state of target %s: %w", t.ID, err)
+		}
+	}
+	if state.DUT != nil {
+		if state.DUT.Backend != "" {
+			cfg.Backend = state.DUT.Backend
+		}
+		for k, v := range state.DUT.Params {
+			cfg.Params[k] = v
+		}
+	}
+	if cfg.Backend == "" {
+		return nil, fmt.Errorf("no DUT backend configured for target %s", t.ID)
+	}
+	return &cfg, nil
+}
+
+// OpenTarget creates the driver of the backend of a target.
+func OpenTarget(t *target.Target, output io.Writer) (Driver, error) {
+	cfg, err := TargetConfig(t)
+	if err != nil {
+		return nil, err
+	}
+	return Open(cfg, output)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package dut abstracts the control of devices under test: powering them,
+// flashing their firmware, talking to their console and mounting media. Each
+// backend (dutctl, HwaaS, PiKVM, ...) provides a Driver implementing the
+// interfaces of the capabilities it supports, and the backend of a target is
+// selected by the target itself, see TargetConfig.
+package dut
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// ErrUnsupported is returned when the backend of a target does not support a
+// capability.
+var ErrUnsupported = errors.New("not supported by the DUT backend")
+
+// Driver controls a DUT through a backend. Drivers implement the interfaces
+// of the capabilities supported by the backend among Power, Flash, Console
+// and Media.
+type Driver interface {
+	// Backend returns the name of the backend of the driver.
+	Backend() string
+	// Close releases the connections of the driver.
+	Close() error
+}
+
+// Power controls the power of a DUT.
+type Power interface {
+	PowerOn(ctx xcontext.Context) error
+	PowerOff(ctx xcontext.Context) error
+	// PowerCycle powers the DUT off and on again, or resets it.
+	PowerCycle(ctx xcontext.Context) error
+}
+
+// Flash reads and writes the firmware of a DUT.
+type Flash interface {
+	// FlashWrite writes the image at the given path to the flash.
+	FlashWrite(ctx xcontext.Context, imagePath string) error
+	// FlashRead reads the flash into a file at the given path.
+	FlashRead(ctx xcontext.Context, imagePath string) error
+}
+
+// Console gives access to the console of a DUT, e.g. a UART.
+type Console interface {
+	// OpenConsole opens the console with the given index. Closing it does not
+	// affect the DUT.
+	OpenConsole(ctx xcontext.Context, port int) (io.ReadWriteCloser, error)
+}
+
+// Media attaches media to a DUT, e.g. a virtual USB drive.
+type Media interface {
+	// MountImage makes the image at the given path available to the DUT.
+	MountImage(ctx xcontext.Context, imagePath string) error
+}
+
+// Factory creates a driver from the parameters of the backend. Progress
+// messages of the driver are written to output.
+type Factory func(params map[string]string, output io.Writer) (Driver, error)
+
+var (
+	backendsMu sync.Mutex
+	backends   = map[string]Factory{}
+)
+
+// Register makes a backend available under the given name, which is case
+// insensitive. It is meant to be called from the init function of the
+// package of the driver.
+func Register(backend string, factory Factory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[strings.ToLower(backend)] = factory
+}
+
+// Backends returns the sorted names of the registered backends.
+func Backends() []string {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open creates the driver of a configuration. A nil output discards the
+// progress messages of the driver.
+func Open(cfg *Config, output io.Writer) (Driver, error) {
+	backendsMu.Lock()
+	factory, ok := backends[strings.ToLower(cfg.Backend)]
+	backendsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown DUT backend %q, known backends are %v", cfg.Backend, Backends())
+	}
+	if output == nil {
+		output = io.Discard
+	}
+	drv, err := factory(cfg.Params, output)
+	if err != nil {
+		return nil, fmt.Errorf("could not create %s driver: %w", cfg.Backend, err)
+	}
+	return drv, nil
+}
+
+// Unsupported returns the error reporting that the backend of a driver does
+// not support a capability.
+func Unsupported(drv Driver, capability string) error {
+	return fmt.Errorf("%s is %w %s", capability, ErrUnsupported, drv.Backend())
+}
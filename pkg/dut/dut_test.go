@@ -0,0 +1,84 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package dut
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/target"
+)
+
+type fakeDriver struct {
+	params map[string]string
+}
+
+func (d *fakeDriver) Backend() string { return "fake" }
+func (d *fakeDriver) Close() error    { return nil }
+
+func init() {
+	Register("Fake", func(params map[string]string, output io.Writer) (Driver, error) {
+		if params["fail"] != "" {
+			return nil, errors.New(params["fail"])
+		}
+		return &fakeDriver{params: params}, nil
+	})
+}
+
+func TestTargetConfig(t *testing.T) {
+	// Labels of a target acquired from the inventory, and a DUT object of the
+	// targetlist target manager overriding some of them.
+	tgt := &target.Target{
+		ID:                 "board1",
+		TargetManagerState: []byte(`{"Labels": {"board": "X", "dut.backend": "hwaas", "dut.machine_id": "ws1", "dut.device_id": "flasher"}}`),
+	}
+	cfg, err := TargetConfig(tgt)
+	require.NoError(t, err)
+	require.Equal(t, &Config{Backend: "hwaas", Params: map[string]string{"machine_id": "ws1", "device_id": "flasher"}}, cfg)
+
+	tgt.TargetManagerState = []byte(`{"Labels": {"dut.backend": "hwaas", "dut.machine_id": "ws1"}, "DUT": {"Backend": "fake", "Params": {"machine_id": "ws2"}}}`)
+	cfg, err = TargetConfig(tgt)
+	require.NoError(t, err)
+	require.Equal(t, &Config{Backend: "fake", Params: map[string]string{"machine_id": "ws2"}}, cfg)
+
+	tgt.TargetManagerState = []byte(`{"Labels": {"board": "X"}}`)
+	_, err = TargetConfig(tgt)
+	require.Error(t, err)
+
+	tgt.TargetManagerState = nil
+	_, err = TargetConfig(tgt)
+	require.Error(t, err)
+
+	tgt.TargetManagerState = []byte(`{"DUT": "hwaas"}`)
+	_, err = TargetConfig(tgt)
+	require.Error(t, err)
+}
+
+func TestOpen(t *testing.T) {
+	require.Contains(t, Backends(), "fake")
+
+	drv, err := OpenTarget(&target.Target{
+		ID:                 "board1",
+		TargetManagerState: []byte(`{"DUT": {"Backend": "FAKE", "Params": {"host": "a"}}}`),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "a"}, drv.(*fakeDriver).params)
+
+	_, ok := drv.(Power)
+	require.False(t, ok)
+	err = Unsupported(drv, "power control")
+	require.True(t, errors.Is(err, ErrUnsupported))
+	require.Equal(t, "power control is not supported by the DUT backend fake", err.Error())
+
+	_, err = Open(&Config{Backend: "fake", Params: map[string]string{"fail": "boom"}}, nil)
+	require.EqualError(t, err, "could not create fake driver: boom")
+
+	_, err = Open(&Config{Backend: "unknown"}, nil)
+	require.Error(t, err)
+}
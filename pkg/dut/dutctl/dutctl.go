@@ -0,0 +1,349 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package dutctl implements the DUT driver of the dutctl backend, which
+// controls the power, the flash and the UARTs of the DUTs through a dutctl
+// server.
+package dutctl
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	fti "github.com/9elements/fti/pkg/dut"
+	"github.com/9elements/fti/pkg/dutctl"
+	"github.com/9elements/fti/pkg/remote_lab/client"
+	"github.com/9elements/fti/pkg/tools"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Backend is the name of the backend in the DUT configurations.
+const Backend = "dutctl"
+
+// caCertFile enables TLS connections to the dutctl servers if it exists.
+const caCertFile = "/etc/fti/keys/ca-cert.pem"
+
+// Default ports of the dutctl servers, without and with TLS.
+const (
+	insecurePort = "10000"
+	securePort   = "10001"
+)
+
+// The power operations are tried powerTries times, waiting tryTimeout and
+// reconnecting to the server between the tries.
+const (
+	powerTries = 2
+	tryTimeout = 15 * time.Second
+)
+
+// Driver controls a DUT through a dutctl server. It implements dut.Power,
+// dut.Flash and dut.Console.
+type Driver struct {
+	host   string
+	output io.Writer
+
+	mu          sync.Mutex
+	conn        dutctl.DutCtl
+	powerInit   bool
+	flashInit   bool
+	serialsInit bool
+}
+
+var (
+	_ dut.Power   = (*Driver)(nil)
+	_ dut.Flash   = (*Driver)(nil)
+	_ dut.Console = (*Driver)(nil)
+)
+
+// New creates a driver for the dutctl server at host, whose port defaults to
+// 10001 if the CA certificate of the lab is installed and 10000 otherwise.
+func New(host string, output io.Writer) *Driver {
+	if !strings.Contains(host, ":") {
+		if _, err := os.Stat(caCertFile); err == nil {
+			host += ":" + securePort
+		} else {
+			host += ":" + insecurePort
+		}
+	}
+	return &Driver{host: host, output: output}
+}
+
+// newFromConfig is the dut.Factory of the backend, the only parameter is the
+// host of the dutctl server.
+func newFromConfig(params map[string]string, output io.Writer) (dut.Driver, error) {
+	if params["host"] == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	return New(params["host"], output), nil
+}
+
+func init() {
+	dut.Register(Backend, newFromConfig)
+}
+
+// Backend implements dut.Driver.
+func (d *Driver) Backend() string {
+	return Backend
+}
+
+// Close implements dut.Driver, closing the connection to the server.
+func (d *Driver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		d.conn.Close()
+		d.conn = nil
+	}
+	d.powerInit, d.flashInit, d.serialsInit = false, false, false
+	return nil
+}
+
+// connect returns the connection to the server, connecting first if needed.
+// Servers which do not accept TLS connections are retried without TLS.
+func (d *Driver) connect() (dutctl.DutCtl, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conn != nil {
+		return d.conn, nil
+	}
+	conn, err := client.NewDutCtl("", false, d.host, false, "", 0, 2)
+	if err != nil && strings.HasSuffix(d.host, ":"+securePort) {
+		d.host = strings.TrimSuffix(d.host, securePort) + insecurePort
+		conn, err = client.NewDutCtl("", false, d.host, false, "", 0, 2)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to dutctl server %s: %w", d.host, err)
+	}
+	d.conn = conn
+	return conn, nil
+}
+
+// power returns the connection with the power plugins initialized.
+func (d *Driver) power() (dutctl.DutCtl, error) {
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.powerInit {
+		var stdout tools.LogginFunc
+		if err := conn.InitPowerPlugins(stdout); err != nil {
+			return nil, fmt.Errorf("failed to init power plugins: %w", err)
+		}
+		d.powerInit = true
+	}
+	return conn, nil
+}
+
+// retryPower runs op on the connection with the power plugins initialized.
+// Failures are written to the output and retried on a new connection after
+// tryTimeout, until powerTries attempts failed or ctx is done.
+func (d *Driver) retryPower(ctx xcontext.Context, op func(conn dutctl.DutCtl) error) error {
+	var err error
+	for try := 0; try < powerTries; try++ {
+		if try > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			case <-time.After(tryTimeout):
+			}
+		}
+		var conn dutctl.DutCtl
+		if conn, err = d.power(); err == nil {
+			if err = op(conn); err == nil {
+				return nil
+			}
+		}
+		fmt.Fprintf(d.output, "Try %d of %d failed: %v\n", try+1, powerTries, err)
+		d.Close()
+	}
+	return fmt.Errorf("maximum number of retries for power command reached: %w", err)
+}
+
+// PowerOn implements dut.Power.
+func (d *Driver) PowerOn(ctx xcontext.Context) error {
+	err := d.retryPower(ctx, func(conn dutctl.DutCtl) error {
+		if err := conn.PowerOn(); err != nil {
+			return fmt.Errorf("failed to power on: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	io.WriteString(d.output, "Successfully powered on DUT.\n")
+	return nil
+}
+
+// PowerOff implements dut.Power.
+func (d *Driver) PowerOff(ctx xcontext.Context) error {
+	err := d.retryPower(ctx, func(conn dutctl.DutCtl) error {
+		if err := conn.PowerOff(); err != nil {
+			return fmt.Errorf("failed to power off: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	io.WriteString(d.output, "Successfully powered off DUT.\n")
+	return nil
+}
+
+// HardReset resets the DUT, it fails with dut.ErrUnsupported if the DUT
+// does not support hard resets.
+func (d *Driver) HardReset(ctx xcontext.Context) error {
+	conn, err := d.power()
+	if err != nil {
+		return err
+	}
+	if !conn.HasHardReset() {
+		return dut.Unsupported(d, "hard reset")
+	}
+	if err := conn.HardReset(); err != nil {
+		return fmt.Errorf("failed to hardreset: %w", err)
+	}
+	io.WriteString(d.output, "Successfully reset(hard) the DUT.\n")
+	return nil
+}
+
+// PowerCycle implements dut.Power with a hard reset if the DUT supports it,
+// and by powering the DUT off and on otherwise.
+func (d *Driver) PowerCycle(ctx xcontext.Context) error {
+	err := d.HardReset(ctx)
+	if !errors.Is(err, dut.ErrUnsupported) {
+		return err
+	}
+	if err := d.PowerOff(ctx); err != nil {
+		return err
+	}
+	return d.PowerOn(ctx)
+}
+
+// flash returns the connection with the power and programmer plugins
+// initialized.
+func (d *Driver) flash() (dutctl.DutCtl, error) {
+	conn, err := d.power()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.flashInit {
+		var stdout tools.LogginFunc
+		if err := conn.InitFlashPlugins(stdout); err != nil {
+			return nil, fmt.Errorf("failed to init programmer plugins: %w", err)
+		}
+		d.flashInit = true
+	}
+	return conn, nil
+}
+
+// FlashWrite implements dut.Flash.
+func (d *Driver) FlashWrite(ctx xcontext.Context, imagePath string) error {
+	conn, err := d.flash()
+	if err != nil {
+		return err
+	}
+	rom, err := os.ReadFile(imagePath)
+	if err != nil {
+		return fmt.Errorf("file '%s' could not be read successfully: %w", imagePath, err)
+	}
+	var flashOptions fti.FlashOptions
+	if err := conn.FlashWrite(rom, &flashOptions); err != nil {
+		return fmt.Errorf("failed to write rom: %w", err)
+	}
+	fmt.Fprintf(d.output, "Successfully written flash from '%s'.\n", imagePath)
+	return nil
+}
+
+// FlashRead implements dut.Flash.
+func (d *Driver) FlashRead(ctx xcontext.Context, imagePath string) error {
+	conn, err := d.flash()
+	if err != nil {
+		return err
+	}
+	supported, err := conn.FlashSupportsRead()
+	if err != nil {
+		return fmt.Errorf("error calling FlashSupportsRead: %w", err)
+	}
+	if !supported {
+		return dut.Unsupported(d, "flash read")
+	}
+	rom, err := conn.FlashRead()
+	if err != nil {
+		return fmt.Errorf("fail to read: %w", err)
+	}
+	if err := os.WriteFile(imagePath, rom, 0o660); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	fmt.Fprintf(d.output, "Successfully read flash into '%s'.\n", imagePath)
+	return nil
+}
+
+// FlashVerify compares the flash of the DUT with the image at imagePath, it
+// fails with dut.ErrUnsupported if the programmer cannot verify.
+func (d *Driver) FlashVerify(ctx xcontext.Context, imagePath string) error {
+	conn, err := d.flash()
+	if err != nil {
+		return err
+	}
+	supported, err := conn.FlashSupportsVerify()
+	if err != nil {
+		return fmt.Errorf("error calling FlashSupportsVerify: %w", err)
+	}
+	if !supported {
+		return dut.Unsupported(d, "flash verify")
+	}
+	rom, err := os.ReadFile(imagePath)
+	if err != nil {
+		return fmt.Errorf("file '%s' could not be read successfully: %w", imagePath, err)
+	}
+	if err := conn.FlashVerify(rom); err != nil {
+		return fmt.Errorf("failed to verify: %w", err)
+	}
+	fmt.Fprintf(d.output, "Successfully verified flash against '%s'.\n", imagePath)
+	return nil
+}
+
+// OpenConsole implements dut.Console, port is the index of the UART.
+func (d *Driver) OpenConsole(ctx xcontext.Context, port int) (io.ReadWriteCloser, error) {
+	conn, err := d.connect()
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.serialsInit {
+		if err := conn.InitSerialPlugins(); err != nil {
+			return nil, fmt.Errorf("failed to init serial plugins: %w", err)
+		}
+		d.serialsInit = true
+	}
+	serial, err := conn.GetSerial(port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serial: %w", err)
+	}
+	return &console{ReadWriter: serial, close: func() { serial.Close() }}, nil
+}
+
+// console adapts a UART of the dutctl client to io.ReadWriteCloser.
+type console struct {
+	io.ReadWriter
+	close func()
+}
+
+func (c *console) Close() error {
+	c.close()
+	return nil
+}
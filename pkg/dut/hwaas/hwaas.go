@@ -0,0 +1,151 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package hwaas implements the DUT driver of the HwaaS backend, which
+// controls the power, the flash and the virtual USB drive of the DUTs through
+// the HTTP API of a HwaaS host.
+package hwaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Backend is the name of the backend in the DUT configurations.
+const Backend = "hwaas"
+
+// Default values of the parameters.
+const (
+	DefaultHost      = "http://9e-hwaas-aux1.lab.9e.network"
+	DefaultContextID = "0fb4acd8-e429-11ed-b5ea-0242ac120002"
+	DefaultMachineID = "ws"
+	DefaultDeviceID  = "flasher"
+)
+
+// flashTimeout limits how long a flash read or write may take.
+const flashTimeout = 5 * time.Minute
+
+// Params locate a DUT on a HwaaS host.
+type Params struct {
+	Host      string `json:"host,omitempty"`
+	Version   string `json:"version,omitempty"`
+	ContextID string `json:"context_id,omitempty"`
+	MachineID string `json:"machine_id,omitempty"`
+	DeviceID  string `json:"device_id,omitempty"`
+	// Image, if set, is mounted as a USB drive before powering on the DUT.
+	Image string `json:"image,omitempty"`
+}
+
+// SetDefaults sets the parameters which are not set to their default value.
+func (p *Params) SetDefaults() {
+	if p.Host == "" {
+		p.Host = DefaultHost
+	}
+	if p.ContextID == "" {
+		p.ContextID = DefaultContextID
+	}
+	if p.MachineID == "" {
+		p.MachineID = DefaultMachineID
+	}
+	if p.DeviceID == "" {
+		p.DeviceID = DefaultDeviceID
+	}
+}
+
+// Driver controls a DUT through a HwaaS host. It implements dut.Power,
+// dut.Flash and dut.Media.
+type Driver struct {
+	Params
+	output io.Writer
+}
+
+var (
+	_ dut.Power = (*Driver)(nil)
+	_ dut.Flash = (*Driver)(nil)
+	_ dut.Media = (*Driver)(nil)
+)
+
+// New creates a driver, progress messages are written to output.
+func New(params Params, output io.Writer) *Driver {
+	params.SetDefaults()
+	return &Driver{Params: params, output: output}
+}
+
+// newFromConfig is the dut.Factory of the backend, the parameters have the
+// names of the JSON fields of Params.
+func newFromConfig(params map[string]string, output io.Writer) (dut.Driver, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var p Params
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	return New(p, output), nil
+}
+
+func init() {
+	dut.Register(Backend, newFromConfig)
+}
+
+// Backend implements dut.Driver.
+func (d *Driver) Backend() string {
+	return Backend
+}
+
+// Close implements dut.Driver, the driver holds no connection.
+func (d *Driver) Close() error {
+	return nil
+}
+
+// ClearCRC calls out /clearcrc endpoint and clears the 'bad crc' error.
+func (d *Driver) ClearCRC(ctx xcontext.Context) error {
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/auxiliaries/%s/api/clearcrc",
+		d.Host, d.Version, d.ContextID, d.MachineID, d.DeviceID)
+
+	resp, err := httpRequest(ctx, http.MethodPost, endpoint, bytes.NewBuffer(nil))
+	if err != nil {
+		return fmt.Errorf("failed to do HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to clear bad crc error. Statuscode: %d", resp.StatusCode)
+	}
+
+	io.WriteString(d.output, "Successfully cleared 'Bad CRC' Bios error.\n")
+
+	return nil
+}
+
+// httpRequest triggerers a http request and returns the response. The parameter that can be set are:
+// method: can be every http method
+// endpoint: api endpoint that shall be requested
+// body: the body of the request
+func httpRequest(ctx xcontext.Context, method string, endpoint string, body io.Reader) (*http.Response, error) {
+	client := &http.Client{}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -10,55 +10,56 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
-func (ts *TestStep) mountImage(ctx xcontext.Context, outputBuf *strings.Builder) error {
-	hashSum, err := calcSHA256(ts.Image)
+// MountImage plugs a virtual USB drive with the image at the given path into
+// the DUT. The image is only uploaded if the HwaaS host does not have it yet.
+func (d *Driver) MountImage(ctx xcontext.Context, imagePath string) error {
+	hashSum, err := calcSHA256(imagePath)
 	if err != nil {
 		return err
 	}
 
-	plugged, err := ts.checkUSBPlug(ctx)
+	plugged, err := d.checkUSBPlug(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to check usb plug state: %v", err)
 	}
 
 	if plugged {
-		if err := ts.plugUSB(ctx, unplug); err != nil {
+		if err := d.plugUSB(ctx, unplug); err != nil {
 			return fmt.Errorf("failed to unplug the usb device: %v", err)
 		}
 	}
 
-	if err := ts.checkMountImage(ctx, hashSum); err != nil {
-		if err := ts.postMountImage(ctx); err != nil {
+	if err := d.checkMountImage(ctx, hashSum); err != nil {
+		if err := d.postMountImage(ctx, imagePath); err != nil {
 			return fmt.Errorf("failed to post image to api: %v", err)
 		}
 	}
 
-	if err := ts.createDrive(ctx, fmt.Sprintf("%x", hashSum)); err != nil {
+	if err := d.createDrive(ctx, imagePath, fmt.Sprintf("%x", hashSum)); err != nil {
 		return fmt.Errorf("failed to create drive with provided image: %v", err)
 	}
 
-	if err := ts.configureUSB(ctx); err != nil {
+	if err := d.configureUSB(ctx, imagePath); err != nil {
 		return fmt.Errorf("failed to configure usb device: %v", err)
 	}
 
-	if err := ts.plugUSB(ctx, plug); err != nil {
+	if err := d.plugUSB(ctx, plug); err != nil {
 		return fmt.Errorf("failed to plug the usb device: %v", err)
 	}
 
-	outputBuf.WriteString("Image was mounted successfully.\n")
+	io.WriteString(d.output, "Image was mounted successfully.\n")
 
 	return nil
 }
 
-func (ts *TestStep) checkMountImage(ctx xcontext.Context, hashSum []byte) error {
-	endpoint := fmt.Sprintf("%s%s/images/%x", ts.Host, ts.Version, hashSum)
+func (d *Driver) checkMountImage(ctx xcontext.Context, hashSum []byte) error {
+	endpoint := fmt.Sprintf("%s%s/images/%x", d.Host, d.Version, hashSum)
 
-	resp, err := HTTPRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
+	resp, err := httpRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
 	if err != nil {
 		return fmt.Errorf("failed to do HTTP request: %v", err)
 	}
@@ -76,10 +77,10 @@ func (ts *TestStep) checkMountImage(ctx xcontext.Context, hashSum []byte) error
 	return nil
 }
 
-func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
-	endpoint := fmt.Sprintf("%s%s/images", ts.Host, ts.Version)
+func (d *Driver) postMountImage(ctx xcontext.Context, imagePath string) error {
+	endpoint := fmt.Sprintf("%s%s/images", d.Host, d.Version)
 
-	file, err := os.Open(ts.Image)
+	file, err := os.Open(imagePath)
 	if err != nil {
 		return fmt.Errorf("failed to open the image at the provided path: %v", err)
 	}
@@ -87,7 +88,7 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
-	form, err := writer.CreateFormFile("file", filepath.Base(ts.Image))
+	form, err := writer.CreateFormFile("file", filepath.Base(imagePath))
 	if err != nil {
 		return fmt.Errorf("failed to create the form-data header: %v", err)
 	}
@@ -126,11 +127,11 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 	return nil
 }
 
-func (ts *TestStep) createDrive(ctx xcontext.Context, hash string) error {
+func (d *Driver) createDrive(ctx xcontext.Context, imagePath, hash string) error {
 	endpoint := fmt.Sprintf("%s%s/contexts/%s/drives/%s?image_hash=%s",
-		ts.Host, ts.Version, ts.ContextID, filepath.Base(ts.Image), hash)
+		d.Host, d.Version, d.ContextID, filepath.Base(imagePath), hash)
 
-	resp, err := HTTPRequest(ctx, http.MethodPut, endpoint, nil)
+	resp, err := httpRequest(ctx, http.MethodPut, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to do HTTP request: %v", err)
 	}
@@ -141,13 +142,13 @@ func (ts *TestStep) createDrive(ctx xcontext.Context, hash string) error {
 	}
 
 	if resp.StatusCode == http.StatusConflict {
-		fmt.Print("Drive with same name already exists. Removing it...\n")
+		io.WriteString(d.output, "Drive with same name already exists. Removing it...\n")
 
-		if err := ts.deleteDrive(ctx); err != nil {
+		if err := d.deleteDrive(ctx, imagePath); err != nil {
 			return err
 		}
 
-		return ts.createDrive(ctx, hash)
+		return d.createDrive(ctx, imagePath, hash)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -158,11 +159,11 @@ func (ts *TestStep) createDrive(ctx xcontext.Context, hash string) error {
 	return fmt.Errorf("failed to create drive. Endpoint: %s, Status: %d, Body: %s", endpoint, resp.StatusCode, body)
 }
 
-func (ts *TestStep) deleteDrive(ctx xcontext.Context) error {
+func (d *Driver) deleteDrive(ctx xcontext.Context, imagePath string) error {
 	endpoint := fmt.Sprintf("%s%s/contexts/%s/drives/%s",
-		ts.Host, ts.Version, ts.ContextID, filepath.Base(ts.Image))
+		d.Host, d.Version, d.ContextID, filepath.Base(imagePath))
 
-	resp, err := HTTPRequest(ctx, http.MethodDelete, endpoint, nil)
+	resp, err := httpRequest(ctx, http.MethodDelete, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed to do HTTP request: %v", err)
 	}
@@ -172,9 +173,9 @@ func (ts *TestStep) deleteDrive(ctx xcontext.Context) error {
 	case http.StatusOK:
 		break
 	case http.StatusNotFound:
-		fmt.Print("drive does not exist.\n")
+		io.WriteString(d.output, "drive does not exist.\n")
 	case http.StatusGone:
-		fmt.Print("drive does not exist anymore.\n")
+		io.WriteString(d.output, "drive does not exist anymore.\n")
 	default:
 		return fmt.Errorf("drive could not be deleted. Endpoint: %s, Statuscode: %d", endpoint, resp.StatusCode)
 	}
@@ -182,11 +183,11 @@ func (ts *TestStep) deleteDrive(ctx xcontext.Context) error {
 	return nil
 }
 
-func (ts *TestStep) checkUSBPlug(ctx xcontext.Context) (bool, error) {
+func (d *Driver) checkUSBPlug(ctx xcontext.Context) (bool, error) {
 	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/usb/plug",
-		ts.Host, ts.Version, ts.ContextID, ts.MachineID)
+		d.Host, d.Version, d.ContextID, d.MachineID)
 
-	resp, err := HTTPRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
+	resp, err := httpRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
 	if err != nil {
 		return false, fmt.Errorf("failed to do HTTP request: %v", err)
 	}
@@ -216,9 +217,9 @@ const (
 	unplug = false
 )
 
-func (ts *TestStep) plugUSB(ctx xcontext.Context, plug bool) error {
+func (d *Driver) plugUSB(ctx xcontext.Context, plug bool) error {
 	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/usb/plug",
-		ts.Host, ts.Version, ts.ContextID, ts.MachineID)
+		d.Host, d.Version, d.ContextID, d.MachineID)
 
 	var httpMethod string
 
@@ -228,7 +229,7 @@ func (ts *TestStep) plugUSB(ctx xcontext.Context, plug bool) error {
 		httpMethod = http.MethodDelete
 	}
 
-	resp, err := HTTPRequest(ctx, httpMethod, endpoint, bytes.NewBuffer(nil))
+	resp, err := httpRequest(ctx, httpMethod, endpoint, bytes.NewBuffer(nil))
 	if err != nil {
 		return fmt.Errorf("failed to do HTTP request: %v", err)
 	}
@@ -250,12 +251,12 @@ type drives struct {
 	Drives []string `json:"drives"`
 }
 
-func (ts *TestStep) configureUSB(ctx xcontext.Context) error {
+func (d *Driver) configureUSB(ctx xcontext.Context, imagePath string) error {
 	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/usb/functions",
-		ts.Host, ts.Version, ts.ContextID, ts.MachineID)
+		d.Host, d.Version, d.ContextID, d.MachineID)
 
 	drives := drives{
-		Drives: []string{filepath.Base(ts.Image)},
+		Drives: []string{filepath.Base(imagePath)},
 	}
 
 	drivesBody, err := json.Marshal(drives)
@@ -263,7 +264,7 @@ func (ts *TestStep) configureUSB(ctx xcontext.Context) error {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	resp, err := HTTPRequest(ctx, http.MethodPut, endpoint, bytes.NewBuffer(drivesBody))
+	resp, err := httpRequest(ctx, http.MethodPut, endpoint, bytes.NewBuffer(drivesBody))
 	if err != nil {
 		return fmt.Errorf("failed to do HTTP request: %v", err)
 	}
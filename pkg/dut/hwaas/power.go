@@ -0,0 +1,358 @@
+package hwaas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+const (
+	power  = "power"
+	on     = "on"
+	off    = "off"
+	reset  = "reset"
+	vcc    = "vcc"
+	trials = 5
+)
+
+// PowerOff powers the DUT off, and cuts its power if it does not shut down.
+func (d *Driver) PowerOff(ctx xcontext.Context) error {
+	if err := d.powerOffSoft(ctx); err != nil {
+		fmt.Fprintf(d.output, "Failed to power off the device: %s. Trying to power off the device hard now.\n", err)
+		return d.PowerOffHard(ctx)
+	}
+	return nil
+}
+
+// PowerCycle powers the DUT off and on again.
+func (d *Driver) PowerCycle(ctx xcontext.Context) error {
+	if err := d.PowerOff(ctx); err != nil {
+		return err
+	}
+	return d.PowerOn(ctx)
+}
+
+// PowerOn turns on the device. To power the device on we have to fulfill this requirements -> reset is off -> pdu is on.
+// The boot image of the driver, if any, is mounted before powering on.
+func (d *Driver) PowerOn(ctx xcontext.Context) error {
+	if err := d.unresetDUT(ctx); err != nil {
+		return fmt.Errorf("failed to power on DUT: %v", err)
+	}
+
+	var (
+		state string
+		err   error
+	)
+
+	// Check the if the device is powered on
+	state, err = d.getState(ctx, power)
+	if err != nil {
+		return err
+	}
+
+	if state == off {
+		if d.Image != "" {
+			if err := d.MountImage(ctx, d.Image); err != nil {
+				return fmt.Errorf("failed to mount image: %w", err)
+			}
+		}
+
+		time.Sleep(time.Second)
+
+		if err := d.postPower(ctx, on); err != nil {
+			return fmt.Errorf("failed to power on DUT: %v", err)
+		}
+	} else if state == on {
+		io.WriteString(d.output, "DUT was already powered on.\n")
+
+		return nil
+	}
+
+	// Check if the device is on
+	state, err = d.getState(ctx, power)
+	if err != nil {
+		return err
+	}
+
+	if state != on {
+		return fmt.Errorf("failed to power on DUT: State is '%s'", state)
+	}
+
+	io.WriteString(d.output, "DUT was powered on successfully.\n")
+
+	return nil
+}
+
+// powerOffSoft turns off the device.
+func (d *Driver) powerOffSoft(ctx xcontext.Context) error {
+	var (
+		state string
+		err   error
+	)
+
+	// First check if device needs to be powered down
+	state, err = d.getState(ctx, power)
+	if err != nil {
+		return err
+	}
+
+	if state == on {
+		if err := d.postPower(ctx, off); err != nil {
+			return fmt.Errorf("failed to power off DUT: %v", err)
+		}
+	}
+
+	state, err = d.getState(ctx, power)
+	if err != nil {
+		return err
+	}
+
+	if state == off {
+		io.WriteString(d.output, "DUT was powered off successfully.\n")
+	} else {
+		return fmt.Errorf("failed to power off DUT: DUT is still on")
+	}
+
+	return nil
+}
+
+// PowerOffHard ensures that -> pdu is off & reset is on.
+func (d *Driver) PowerOffHard(ctx xcontext.Context) error {
+	if err := d.resetDUT(ctx); err != nil {
+		return fmt.Errorf("failed to reset DUT: %v", err)
+	}
+
+	io.WriteString(d.output, "DUT was resetted successfully.\n")
+
+	return nil
+}
+
+type postPower struct {
+	State string `json:"state"` // possible values: on/off
+}
+
+// postPower sets the power state into the desired 'state'.
+func (d *Driver) postPower(ctx xcontext.Context, state string) error {
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/auxiliaries/%s/api/power",
+		d.Host, d.Version, d.ContextID, d.MachineID, d.DeviceID)
+
+	postPower := postPower{
+		State: state,
+	}
+
+	powerBody, err := json.Marshal(postPower)
+	if err != nil {
+		return fmt.Errorf("failed to marshal body: %w", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		resp, err := httpRequest(ctx, http.MethodPost, endpoint, bytes.NewBuffer(powerBody))
+		if err != nil {
+			return fmt.Errorf("failed to do HTTP request: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return fmt.Errorf("could not extract response body: %v", err)
+			}
+
+			if resp.StatusCode == http.StatusConflict && strings.Contains(string(body), "power button is busy") {
+				time.Sleep(time.Second)
+
+				continue
+			}
+
+			return fmt.Errorf("failed to Post to Power. Statuscode: %d, Response Body: %v", resp.StatusCode, string(body))
+		}
+
+		break
+	}
+
+	return nil
+}
+
+// pressPDU toggles the PDU as you define the method input parameter.
+// http.MethodDelete does power off the pdu.
+// http.MethodPut does power on the pdu.
+func (d *Driver) pressPDU(ctx xcontext.Context, method string) error {
+	if method != http.MethodDelete && method != http.MethodPut {
+		return fmt.Errorf("invalid method '%s'. Only supported methods for toggeling the PDU are: '%s' and '%s'", method, http.MethodDelete, http.MethodPut)
+	}
+
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/power",
+		d.Host, d.Version, d.ContextID, d.MachineID)
+
+	resp, err := httpRequest(ctx, method, endpoint, bytes.NewBuffer(nil))
+	if err != nil {
+		return fmt.Errorf("failed to do HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not extract response body: %v", err)
+		}
+
+		return fmt.Errorf("PDU could not be set to the correct state. Statuscode: %d, Response Body: %v", resp.StatusCode, string(body))
+	} else {
+		time.Sleep(time.Second)
+
+		powerState, err := d.getPDUState(ctx)
+		if err != nil {
+			return err
+		}
+
+		if method == http.MethodPut && !powerState || method == http.MethodDelete && powerState {
+			return fmt.Errorf("failed to toggle PDU. Method: '%s', State: '%t'", method, powerState)
+		}
+	}
+
+	return nil
+}
+
+type postReset struct {
+	State string `json:"state"` // possible values: "on" or "off"
+}
+
+// postReset toggles the Reset button regarding the state that is passed in.
+// A valid state is either 'on' or 'off'.
+func (d *Driver) postReset(ctx xcontext.Context, wantState string) error {
+	if wantState != on && wantState != off {
+		return fmt.Errorf("invalid state '%s'. Only supported states for reset are: '%s' and '%s'", wantState, on, off)
+	}
+
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/auxiliaries/%s/api/reset",
+		d.Host, d.Version, d.ContextID, d.MachineID, d.DeviceID)
+
+	postReset := postReset{
+		State: wantState,
+	}
+
+	resetBody, err := json.Marshal(postReset)
+	if err != nil {
+		return fmt.Errorf("failed to marshal body: %w", err)
+	}
+
+	resp, err := httpRequest(ctx, http.MethodPost, endpoint, bytes.NewBuffer(resetBody))
+	if err != nil {
+		return fmt.Errorf("failed to do HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	state, err := d.getState(ctx, reset)
+	if err != nil {
+		return err
+	}
+
+	if state != wantState {
+		return fmt.Errorf("reset could not be set to state '%s'. State is '%s'", wantState, state)
+	}
+
+	return nil
+}
+
+// this struct can be used for GET /vcc /power /reset
+type getState struct {
+	State string `json:"state"` // possible values: "on" or "off"
+}
+
+// getState returns the state of either: 'power', 'reset' or 'vcc'.
+// The input parameter command should have one of this values.
+func (d *Driver) getState(ctx xcontext.Context, command string) (string, error) {
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/auxiliaries/%s/api/%s",
+		d.Host, d.Version, d.ContextID, d.MachineID, d.DeviceID, command)
+
+	resp, err := httpRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
+	if err != nil {
+		return "", fmt.Errorf("failed to do HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("could not extract response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(" %s state could not be retrieved. Statuscode: %d, Response Body: %s", command, resp.StatusCode, string(body))
+	}
+
+	data := getState{}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+
+	return data.State, nil
+}
+
+// getPDUState returns the state of the PDU.
+func (d *Driver) getPDUState(ctx xcontext.Context) (bool, error) {
+	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/power",
+		d.Host, d.Version, d.ContextID, d.MachineID)
+
+	resp, err := httpRequest(ctx, http.MethodGet, endpoint, bytes.NewBuffer(nil))
+	if err != nil {
+		return false, fmt.Errorf("failed to do HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("could not extract response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf(" pdu state could not be retrieved. Statuscode: %d, Response Body: %s", resp.StatusCode, string(body))
+	}
+
+	var state bool
+
+	if err := json.Unmarshal(body, &state); err != nil {
+		return false, fmt.Errorf("could not unmarshal response body: %v", err)
+	}
+
+	return state, nil
+}
+
+// resetDUT sets the dut into a state, were it cannot be booted. In this state it is safe to
+// do all flash operations.
+func (d *Driver) resetDUT(ctx xcontext.Context) error {
+	if err := d.postReset(ctx, on); err != nil {
+		return err
+	}
+
+	if err := d.pressPDU(ctx, http.MethodDelete); err != nil {
+		return err
+	}
+
+	time.Sleep(time.Second)
+
+	return nil
+}
+
+// unresetDUT sets the dut into a state, were it can be booted again. PDU has to be turned on
+// and reset has to pull on off.
+func (d *Driver) unresetDUT(ctx xcontext.Context) error {
+	if err := d.postReset(ctx, off); err != nil {
+		return err
+	}
+
+	if err := d.pressPDU(ctx, http.MethodPut); err != nil {
+		return err
+	}
+
+	time.Sleep(time.Second)
+
+	return nil
+}
@@ -80,13 +80,13 @@ var (
 	}
 )
 
-func (ts *TestStep) getUsbPlugStatus(ctx xcontext.Context) (Status, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.Host, nil)
+func (d *Driver) getUsbPlugStatus(ctx xcontext.Context) (Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.msdURL, nil)
 	if err != nil {
 		return Status{}, err
 	}
 
-	req.SetBasicAuth(ts.Username, ts.Password)
+	req.SetBasicAuth(d.Username, d.Password)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -124,8 +124,8 @@ func (ts *TestStep) getUsbPlugStatus(ctx xcontext.Context) (Status, error) {
 	return response.Result, nil
 }
 
-func (ts *TestStep) plugUSB(ctx xcontext.Context, plug bool) error {
-	status, err := ts.getUsbPlugStatus(ctx)
+func (d *Driver) plugUSB(ctx xcontext.Context, plug bool) error {
+	status, err := d.getUsbPlugStatus(ctx)
 	if err != nil {
 		return err
 	}
@@ -134,12 +134,12 @@ func (ts *TestStep) plugUSB(ctx xcontext.Context, plug bool) error {
 		return fmt.Errorf("virtual usb plug is already in the desired state")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/set_connected?connected=%d", ts.Host, boolToInt(plug)), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/set_connected?connected=%d", d.msdURL, boolToInt(plug)), nil)
 	if err != nil {
 		return err
 	}
 
-	req.SetBasicAuth(ts.Username, ts.Password)
+	req.SetBasicAuth(d.Username, d.Password)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -172,8 +172,8 @@ func (ts *TestStep) plugUSB(ctx xcontext.Context, plug bool) error {
 
 var ErrMissingImage = errors.New("image not found in storage")
 
-func (ts *TestStep) checkMountedImages(ctx xcontext.Context, hashSum string) error {
-	status, err := ts.getUsbPlugStatus(ctx)
+func (d *Driver) checkMountedImages(ctx xcontext.Context, hashSum string) error {
+	status, err := d.getUsbPlugStatus(ctx)
 	if err != nil {
 		return err
 	}
@@ -194,8 +194,8 @@ func (ts *TestStep) checkMountedImages(ctx xcontext.Context, hashSum string) err
 	return ErrMissingImage
 }
 
-func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
-	file, err := os.Open(ts.Image)
+func (d *Driver) postMountImage(ctx xcontext.Context, imagePath string) error {
+	file, err := os.Open(imagePath)
 	if err != nil {
 		return fmt.Errorf("failed to open the image at the provided path: %v", err)
 	}
@@ -205,7 +205,7 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 		return err
 	}
 
-	dataHash, err := calcSHA256(ts.Image)
+	dataHash, err := calcSHA256(imagePath)
 	if err != nil {
 		return err
 	}
@@ -226,7 +226,7 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 		}
 	}()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/write?image=%s", ts.Host, dataHash), r)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/write?image=%s", d.msdURL, dataHash), r)
 	if err != nil {
 		return err
 	}
@@ -234,7 +234,7 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 	req.Header.Set("Accept", "*/*")
 
 	req.ContentLength = fileStat.Size()
-	req.SetBasicAuth(ts.Username, ts.Password)
+	req.SetBasicAuth(d.Username, d.Password)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -264,8 +264,8 @@ func (ts *TestStep) postMountImage(ctx xcontext.Context) error {
 	}
 }
 
-func (ts *TestStep) configureUSB(ctx xcontext.Context, imageName string) error {
-	status, err := ts.getUsbPlugStatus(ctx)
+func (d *Driver) configureUSB(ctx xcontext.Context, imageName string) error {
+	status, err := d.getUsbPlugStatus(ctx)
 	if err != nil {
 		return err
 	}
@@ -274,12 +274,12 @@ func (ts *TestStep) configureUSB(ctx xcontext.Context, imageName string) error {
 		return fmt.Errorf("virtual usb plug is currently plugged in")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/set_params?image=%s&cdrom=0&rw=1", ts.Host, imageName), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/set_params?image=%s&cdrom=0&rw=1", d.msdURL, imageName), nil)
 	if err != nil {
 		return err
 	}
 
-	req.SetBasicAuth(ts.Username, ts.Password)
+	req.SetBasicAuth(d.Username, d.Password)
 
 	resp, err := client.Do(req)
 	if err != nil {
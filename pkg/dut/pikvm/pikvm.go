@@ -0,0 +1,127 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package pikvm implements the DUT driver of the PiKVM backend, which mounts
+// images on the virtual USB drive of a PiKVM attached to the DUT.
+package pikvm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Backend is the name of the backend in the DUT configurations.
+const Backend = "pikvm"
+
+// Params locate and authenticate the PiKVM of a DUT.
+type Params struct {
+	Host     string `json:"host"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Driver controls a DUT through its PiKVM. It implements dut.Media.
+type Driver struct {
+	Params
+	msdURL string // URL of the mass storage API.
+	output io.Writer
+}
+
+var _ dut.Media = (*Driver)(nil)
+
+// New creates a driver, progress messages are written to output.
+func New(params Params, output io.Writer) *Driver {
+	return &Driver{
+		Params: params,
+		msdURL: fmt.Sprintf("%s/api/msd", params.Host),
+		output: output,
+	}
+}
+
+// newFromConfig is the dut.Factory of the backend, the parameters have the
+// names of the JSON fields of Params.
+func newFromConfig(params map[string]string, output io.Writer) (dut.Driver, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var p Params
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if p.Host == "" {
+		return nil, errors.New("missing host")
+	}
+	return New(p, output), nil
+}
+
+func init() {
+	dut.Register(Backend, newFromConfig)
+}
+
+// Backend implements dut.Driver.
+func (d *Driver) Backend() string {
+	return Backend
+}
+
+// Close implements dut.Driver, the driver holds no connection.
+func (d *Driver) Close() error {
+	return nil
+}
+
+// MountImage plugs the virtual USB drive with the image at the given path
+// into the DUT. The image is only uploaded if the PiKVM does not have it yet.
+func (d *Driver) MountImage(ctx xcontext.Context, imagePath string) error {
+	hashSum, err := calcSHA256(imagePath)
+	if err != nil {
+		return err
+	}
+
+	status, err := d.getUsbPlugStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check usb plug state: %v", err)
+	}
+
+	if status.Drive.Connected {
+		io.WriteString(d.output, "Unplug USB port.\n")
+
+		if err := d.plugUSB(ctx, unplug); err != nil {
+			return fmt.Errorf("failed to unplug the usb device: %v", err)
+		}
+	}
+
+	if err := d.checkMountedImages(ctx, hashSum); errors.Is(err, ErrMissingImage) {
+		io.WriteString(d.output, "Post image to pikvm.\n")
+
+		if err := d.postMountImage(ctx, imagePath); err != nil {
+			return fmt.Errorf("failed to post image to api: %v", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to check mounted images: %v", err)
+	} else {
+		io.WriteString(d.output, "Image already exists.\n")
+	}
+
+	io.WriteString(d.output, "Configure image.\n")
+
+	if err := d.configureUSB(ctx, hashSum); err != nil {
+		return fmt.Errorf("failed to configure usb device: %v", err)
+	}
+
+	io.WriteString(d.output, "Plug USB port.\n")
+
+	if err := d.plugUSB(ctx, plug); err != nil {
+		return fmt.Errorf("failed to plug the usb device: %v", err)
+	}
+
+	io.WriteString(d.output, "Successfully mounted image.\n")
+
+	return nil
+}
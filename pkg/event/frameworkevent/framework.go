@@ -66,6 +66,7 @@ type queryFieldJobID types.JobID
 type queryFieldEventNames []event.Name
 type queryFieldEmittedStartTime time.Time
 type queryFieldEmittedEndTime time.Time
+type queryFieldSequenceIDStart uint64
 
 // QueryJobID sets the JobID field of the Query object
 func QueryJobID(jobID types.JobID) QueryField                            { return queryFieldJobID(jobID) }
@@ -96,6 +97,14 @@ func (value queryFieldEmittedEndTime) queryFieldPointer(query *Query) interface{
 	return &query.EmittedEndTime
 }
 
+// QuerySequenceIDStart sets the SequenceIDStart field of the Query object
+func QuerySequenceIDStart(sequenceID uint64) QueryField {
+	return queryFieldSequenceIDStart(sequenceID)
+}
+func (value queryFieldSequenceIDStart) queryFieldPointer(query *Query) interface{} {
+	return &query.SequenceIDStart
+}
+
 // Emitter defines the interface that emitter objects for framework vents must implement
 type Emitter interface {
 	Emit(ctx xcontext.Context, event Event) error
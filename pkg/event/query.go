@@ -17,6 +17,8 @@ type Query struct {
 	EventNames       []Name
 	EmittedStartTime time.Time
 	EmittedEndTime   time.Time
+	// SequenceIDStart restricts the query to events with a greater or equal SequenceID
+	SequenceIDStart uint64
 }
 
 type QueryField interface{}
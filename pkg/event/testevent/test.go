@@ -91,6 +91,7 @@ type queryFieldEmittedEndTime time.Time
 type queryFieldTestName string
 type queryFieldTestStepLabel string
 type queryFieldRunID types.RunID
+type queryFieldSequenceIDStart uint64
 
 // QueryJobID sets the JobID field of the Query object
 func QueryJobID(jobID types.JobID) QueryField                            { return queryFieldJobID(jobID) }
@@ -121,6 +122,14 @@ func (value queryFieldEmittedEndTime) queryFieldPointer(query *Query) interface{
 	return &query.EmittedEndTime
 }
 
+// QuerySequenceIDStart sets the SequenceIDStart field of the Query object
+func QuerySequenceIDStart(sequenceID uint64) QueryField {
+	return queryFieldSequenceIDStart(sequenceID)
+}
+func (value queryFieldSequenceIDStart) queryFieldPointer(query *Query) interface{} {
+	return &query.SequenceIDStart
+}
+
 // QueryTestName sets the TestName field of the Query object
 func QueryTestName(testName string) QueryField {
 	return queryFieldTestName(testName)
@@ -15,6 +15,7 @@ const (
 	EventStdout = event.Name("Stdout")
 	EventStderr = event.Name("Stderr")
 	EventOutput = event.Name("Output")
+	EventMetric = event.Name("Metric")
 )
 
 // Events defines the events that a TestStep is allow to emit. Emitting an event
@@ -23,6 +24,7 @@ var Events = []event.Name{
 	EventStdout,
 	EventStderr,
 	EventOutput,
+	EventMetric,
 }
 
 type Component struct {
@@ -30,6 +32,26 @@ type Component struct {
 	Data []byte `json:"data"`
 }
 
+// Metric is a named measurement taken by a test step on a target, e.g. the
+// boot time of a firmware or the score of a benchmark. It is the payload of
+// EventMetric events. Tags qualify the measurement, e.g. the benchmark mode.
+type Metric struct {
+	Name  string            `json:"name"`
+	Value float64           `json:"value"`
+	Unit  string            `json:"unit,omitempty"`
+	Tags  map[string]string `json:"tags,omitempty"`
+}
+
+// HasTags returns true if the metric has all the given tags with the same values.
+func (m Metric) HasTags(tags map[string]string) bool {
+	for k, v := range tags {
+		if m.Tags[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 type payload struct {
 	Msg string
 }
@@ -84,3 +106,28 @@ func EmitOutput(ctx xcontext.Context, name string, data []byte, tgt *target.Targ
 
 	return nil
 }
+
+// EmitMetric emits a Metric event
+func EmitMetric(ctx xcontext.Context, metric Metric, tgt *target.Target, ev testevent.Emitter) error {
+	if metric.Name == "" {
+		return fmt.Errorf("metric name cannot be empty")
+	}
+	if err := emitEvent(ctx, EventMetric, metric, tgt, ev); err != nil {
+		return fmt.Errorf("cannot emit event: %v", err)
+	}
+
+	return nil
+}
+
+// EmitMetrics emits a Metric event for each of the metrics. Test steps which
+// check expectations on their measurements call it before the checks, so that
+// the measurements can be tracked across runs also when the step fails.
+func EmitMetrics(ctx xcontext.Context, metrics []Metric, tgt *target.Target, ev testevent.Emitter) error {
+	for _, metric := range metrics {
+		if err := EmitMetric(ctx, metric, tgt, ev); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
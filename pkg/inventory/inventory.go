@@ -0,0 +1,196 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package inventory defines the targets known to the server, with their
+// key/value labels and their health state. The targets of the inventory are
+// acquired by label selector, see Selector.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/target"
+)
+
+// Health is the health state of a target of the inventory.
+type Health string
+
+// List of health states.
+const (
+	// HealthUnknown is the state of the targets whose health was never
+	// reported. They can be acquired.
+	HealthUnknown Health = "unknown"
+	// HealthHealthy targets can be acquired.
+	HealthHealthy Health = "healthy"
+	// HealthUnhealthy targets are not acquired until they are healthy again.
+	HealthUnhealthy Health = "unhealthy"
+)
+
+// Validate returns an error if the health state is not supported.
+func (h Health) Validate() error {
+	switch h {
+	case HealthUnknown, HealthHealthy, HealthUnhealthy:
+		return nil
+	default:
+		return fmt.Errorf("invalid health %q, must be one of %q, %q, %q", h, HealthUnknown, HealthHealthy, HealthUnhealthy)
+	}
+}
+
+// Target is a target of the inventory.
+type Target struct {
+	ID          string
+	FQDN        string `json:",omitempty"`
+	PrimaryIPv4 net.IP `json:",omitempty"`
+	PrimaryIPv6 net.IP `json:",omitempty"`
+
+	Labels map[string]string `json:",omitempty"`
+	Health Health
+	// Drained targets are kept in the inventory but are not acquired, e.g.
+	// while they are under maintenance. Jobs which already acquired them are
+	// not affected.
+	Drained bool
+
+	UpdateTime time.Time
+}
+
+// TargetState is the TargetManagerState of the targets acquired from the
+// inventory. It carries their labels to the test steps.
+type TargetState struct {
+	Labels map[string]string `json:",omitempty"`
+}
+
+// Target returns the target to run tests on, with its labels in its
+// TargetManagerState.
+func (t *Target) Target() *target.Target {
+	tgt := &target.Target{
+		ID:          t.ID,
+		FQDN:        t.FQDN,
+		PrimaryIPv4: t.PrimaryIPv4,
+		PrimaryIPv6: t.PrimaryIPv6,
+	}
+	if len(t.Labels) > 0 {
+		// Marshaling a map of strings cannot fail.
+		tgt.TargetManagerState, _ = json.Marshal(TargetState{Labels: t.Labels})
+	}
+	return tgt
+}
+
+// TargetLabels returns the labels of a target acquired from the inventory,
+// or nil if the target has none.
+func TargetLabels(t *target.Target) map[string]string {
+	var state TargetState
+	if len(t.TargetManagerState) == 0 || json.Unmarshal(t.TargetManagerState, &state) != nil {
+		return nil
+	}
+	return state.Labels
+}
+
+// Available returns whether the target can be acquired, that is it is not
+// drained nor unhealthy.
+func (t *Target) Available() bool {
+	return !t.Drained && t.Health != HealthUnhealthy
+}
+
+// Validate returns an error if the target has no ID, or invalid labels or
+// health state.
+func (t *Target) Validate() error {
+	if strings.TrimSpace(t.ID) == "" {
+		return fmt.Errorf("invalid target with empty ID")
+	}
+	if err := t.Health.Validate(); err != nil {
+		return err
+	}
+	return CheckLabels(t.Labels)
+}
+
+// LabelsString returns the labels sorted by key, see FormatLabels.
+func (t *Target) LabelsString() string {
+	return FormatLabels(t.Labels)
+}
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^[A-Za-z0-9._/:-]*$`)
+)
+
+// maxLabelLength is the maximum length of the keys and values of labels.
+const maxLabelLength = 63
+
+// CheckLabelKey returns an error if key is not a valid label key. Keys are
+// made of letters, digits, '.', '_', '/' and '-', and start and end with a
+// letter or a digit.
+func CheckLabelKey(key string) error {
+	if len(key) > maxLabelLength || !labelKeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+// CheckLabelValue returns an error if value is not a valid label value.
+// Values are made of letters, digits, '.', '_', '/', ':' and '-', and can be
+// empty.
+func CheckLabelValue(value string) error {
+	if len(value) > maxLabelLength || !labelValueRegexp.MatchString(value) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// CheckLabels returns an error if any of the labels is invalid.
+func CheckLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if err := CheckLabelKey(k); err != nil {
+			return err
+		}
+		if err := CheckLabelValue(v); err != nil {
+			return fmt.Errorf("label %q: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// FormatLabels returns labels sorted by key, in the key=value,... format of
+// the selectors. This is the format read by ParseLabels.
+func FormatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseLabels parses labels in the key=value,... format of the selectors.
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid label %q, must be key=value", pair)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if _, ok := labels[key]; ok {
+			return nil, fmt.Errorf("duplicate label %q", key)
+		}
+		labels[key] = value
+	}
+	if err := CheckLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
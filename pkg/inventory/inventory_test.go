@@ -0,0 +1,88 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package inventory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	sel, err := ParseSelector("board=X, bmc=openbmc,rev!=1,soc,!broken")
+	require.NoError(t, err)
+	require.Equal(t, Selector{
+		{Key: "board", Operator: OperatorEquals, Value: "X"},
+		{Key: "bmc", Operator: OperatorEquals, Value: "openbmc"},
+		{Key: "rev", Operator: OperatorNotEquals, Value: "1"},
+		{Key: "soc", Operator: OperatorExists},
+		{Key: "broken", Operator: OperatorNotExists},
+	}, sel)
+	require.Equal(t, "board=X,bmc=openbmc,rev!=1,soc,!broken", sel.String())
+
+	sel, err = ParseSelector("")
+	require.NoError(t, err)
+	require.Empty(t, sel)
+
+	for _, s := range []string{"=X", "board=X,", "board=a b", "!", "-board"} {
+		_, err := ParseSelector(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"board": "X", "bmc": "openbmc", "soc": "a1"}
+	for s, want := range map[string]bool{
+		"":                       true,
+		"board=X":                true,
+		"board=X,bmc=openbmc":    true,
+		"board=X,bmc=other":      false,
+		"board!=Y":               true,
+		"board!=X":               false,
+		"rev!=1":                 true,
+		"soc":                    true,
+		"rev":                    false,
+		"!rev":                   true,
+		"!soc":                   false,
+		"board=X,soc,!rev,bmc!=": true,
+	} {
+		sel, err := ParseSelector(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, sel.Matches(labels), s)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels("board=X,bmc=openbmc,empty=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"board": "X", "bmc": "openbmc", "empty": ""}, labels)
+
+	tgt := Target{ID: "T1", Health: HealthUnknown, Labels: labels}
+	require.NoError(t, tgt.Validate())
+	require.Equal(t, "bmc=openbmc,board=X,empty=", tgt.LabelsString())
+
+	for _, s := range []string{"board", "board=X,board=Y", "b d=X", "board=X,Y"} {
+		_, err := ParseLabels(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestTargetValidate(t *testing.T) {
+	require.Error(t, (&Target{Health: HealthHealthy}).Validate())
+	require.Error(t, (&Target{ID: "T1", Health: "sick"}).Validate())
+	require.Error(t, (&Target{ID: "T1", Health: HealthHealthy, Labels: map[string]string{"a b": ""}}).Validate())
+
+	tgt := Target{ID: "T1", Health: HealthHealthy}
+	require.True(t, tgt.Available())
+	tgt.Drained = true
+	require.False(t, tgt.Available())
+	tgt = Target{ID: "T1", Health: HealthUnhealthy}
+	require.False(t, tgt.Available())
+	require.Equal(t, "T1", tgt.Target().ID)
+	require.Nil(t, TargetLabels(tgt.Target()))
+	tgt.Labels = map[string]string{"board": "X", "dut.backend": "hwaas"}
+	require.Equal(t, tgt.Labels, TargetLabels(tgt.Target()))
+}
@@ -0,0 +1,118 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package inventory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Operator is the operator of a selector requirement.
+type Operator string
+
+// List of operators.
+const (
+	OperatorEquals    Operator = "="
+	OperatorNotEquals Operator = "!="
+	OperatorExists    Operator = ""
+	OperatorNotExists Operator = "!"
+)
+
+// Requirement is a condition on a label of the targets.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Value    string
+}
+
+// Matches returns whether the labels satisfy the requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case OperatorEquals:
+		return ok && value == r.Value
+	case OperatorNotEquals:
+		return !ok || value != r.Value
+	case OperatorExists:
+		return ok
+	case OperatorNotExists:
+		return !ok
+	default:
+		return false
+	}
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case OperatorNotExists:
+		return "!" + r.Key
+	case OperatorExists:
+		return r.Key
+	default:
+		return r.Key + string(r.Operator) + r.Value
+	}
+}
+
+// Selector selects targets by their labels. A target matches if it satisfies
+// all the requirements, so the empty selector matches all the targets.
+type Selector []Requirement
+
+// ParseSelector parses a comma-separated list of requirements:
+//
+//	key=value   the label is set to value
+//	key!=value  the label is not set, or set to another value
+//	key         the label is set
+//	!key        the label is not set
+//
+// e.g. "board=X,bmc=openbmc" matches the targets with both labels.
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	if strings.TrimSpace(s) == "" {
+		return sel, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		var r Requirement
+		switch {
+		case strings.Contains(part, "!="):
+			kv := strings.SplitN(part, "!=", 2)
+			r = Requirement{Key: strings.TrimSpace(kv[0]), Operator: OperatorNotEquals, Value: strings.TrimSpace(kv[1])}
+		case strings.Contains(part, "="):
+			kv := strings.SplitN(part, "=", 2)
+			r = Requirement{Key: strings.TrimSpace(kv[0]), Operator: OperatorEquals, Value: strings.TrimSpace(kv[1])}
+		case strings.HasPrefix(part, "!"):
+			r = Requirement{Key: strings.TrimSpace(part[1:]), Operator: OperatorNotExists}
+		default:
+			r = Requirement{Key: part, Operator: OperatorExists}
+		}
+		if err := CheckLabelKey(r.Key); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		if err := CheckLabelValue(r.Value); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+// Matches returns whether the labels satisfy all the requirements.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, ",")
+}
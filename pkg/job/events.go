@@ -40,6 +40,19 @@ var EventJobCancelled = event.Name("JobStateCancelled")
 // EventJobCancellationFailed indicates that the cancellation was not completed correctly
 var EventJobCancellationFailed = event.Name("JobStateCancellationFailed")
 
+// EventJobQueued indicates that a Job is waiting in the target acquisition
+// queue for targets locked by other jobs. The Job is started again once it
+// acquires its targets.
+var EventJobQueued = event.Name("JobStateQueued")
+
+// EventJobRetry indicates that a Job was created by retrying another Job. It
+// is emitted for the new Job and carries a RetryEventPayload.
+var EventJobRetry = event.Name("JobRetry")
+
+// EventJobScheduled indicates that a Job was started by a Schedule. It is
+// emitted for the new Job and carries a ScheduledEventPayload.
+var EventJobScheduled = event.Name("JobScheduled")
+
 // JobCompletionEvents gathers all event names that mark the end of a job
 var JobCompletionEvents = []event.Name{
 	EventJobCompleted,
@@ -58,6 +71,7 @@ var JobStateEvents = []event.Name{
 	EventJobCancelling,
 	EventJobCancelled,
 	EventJobCancellationFailed,
+	EventJobQueued,
 }
 
 // States corresponding to events.
@@ -70,6 +84,7 @@ var jobStates = []State{
 	JobStateCancelling,
 	JobStateCancelled,
 	JobStateCancellationFailed,
+	JobStateQueued,
 }
 
 func EventNameToJobState(ev event.Name) (State, error) {
@@ -95,6 +110,12 @@ type PauseEventPayload struct {
 	// Otherwise, if test execution is in progress targets and runner state will be populated.
 	Targets         []*target.Target `json:"TT,omitempty"`
 	TestRunnerState json.RawMessage  `json:"TRS,omitempty"`
+	// Preempted is set if the job was paused to release its targets to a job of
+	// higher priority. The current test attempt starts over when it resumes.
+	Preempted bool `json:"P,omitempty"`
+	// ResumeOnRequest is set if the job was paused through the API. It is only
+	// resumed through the API, not when the server starts.
+	ResumeOnRequest bool `json:"RR,omitempty"`
 }
 
 func (pp *PauseEventPayload) String() string {
@@ -106,11 +127,25 @@ func (pp *PauseEventPayload) String() string {
 	if pp.NextTestAttempt != nil {
 		nta = pp.NextTestAttempt.Unix()
 	}
-	return fmt.Sprintf("[V:%d J:%d R:%d T:%d TR:%d NTA: %d ST:%d TT:%v TRS:%s]",
-		pp.Version, pp.JobID, pp.RunID, pp.TestID, pp.TestAttempt, nta, sts, pp.Targets, pp.TestRunnerState,
+	return fmt.Sprintf("[V:%d J:%d R:%d T:%d TR:%d NTA: %d ST:%d TT:%v TRS:%s P:%t RR:%t]",
+		pp.Version, pp.JobID, pp.RunID, pp.TestID, pp.TestAttempt, nta, sts, pp.Targets, pp.TestRunnerState, pp.Preempted, pp.ResumeOnRequest,
 	)
 }
 
+// RetryEventPayload is the payload of the JobRetry event. It links a Job to
+// the Job it retries.
+type RetryEventPayload struct {
+	RetryOf           types.JobID
+	FailedTargetsOnly bool
+}
+
+// ScheduledEventPayload is the payload of the JobScheduled event. It links a
+// Job to the Schedule which started it.
+type ScheduledEventPayload struct {
+	ScheduleID types.ScheduleID
+	FireTime   time.Time
+}
+
 // Currently supported version of the pause state.
 // Attempting to resume paused jobs with version other than this will fail.
 var CurrentPauseEventPayloadVersion = 1
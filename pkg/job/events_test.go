@@ -20,7 +20,7 @@ func TestEventToJobStateMapping(t *testing.T) {
 		require.NoError(t, err)
 		m[st] = e
 	}
-	require.Equal(t, 8, len(m))
+	require.Equal(t, 9, len(m))
 	st, err := EventNameToJobState(event.Name("foo"))
 	require.Error(t, err)
 	require.Equal(t, JobStateUnknown, st)
@@ -29,3 +29,18 @@ func TestEventToJobStateMapping(t *testing.T) {
 	}
 	require.Equal(t, JobStateUnknown.String(), "JobStateUnknown")
 }
+
+func TestParseState(t *testing.T) {
+	for s, want := range map[string]State{
+		"JobStateQueued":     JobStateQueued,
+		"queued":             JobStateQueued,
+		"Started":            JobStateStarted,
+		"cancellationfailed": JobStateCancellationFailed,
+	} {
+		st, err := ParseState(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, st, s)
+	}
+	_, err := ParseState("foo")
+	require.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/test"
 	"github.com/linuxboot/contest/pkg/types"
 
@@ -36,6 +37,8 @@ type Descriptor struct {
 	Reporting                   Reporting
 	TargetManagerAcquireTimeout *xjson.Duration // optional
 	TargetManagerReleaseTimeout *xjson.Duration // optional
+	// Priority orders the jobs waiting for busy targets, higher first.
+	Priority int `json:",omitempty"`
 }
 
 // Validate performs sanity checks on the job descriptor
@@ -138,6 +141,14 @@ type Job struct {
 	// TargetManagerReleaseTimeout represents the maximum time that JobManager should wait for the execution of the Release function from the chosen TargetManager.
 	TargetManagerReleaseTimeout time.Duration
 
+	// Priority orders the jobs waiting in the target acquisition queue,
+	// higher first.
+	Priority int
+
+	// Requestor is who requested the job. The target acquisition queue is
+	// fair among requestors.
+	Requestor string
+
 	// ExtendedDescriptor represents the descriptor submitted by the client that
 	// resulted in the creation of this ConTest job.
 	ExtendedDescriptor *ExtendedDescriptor
@@ -164,10 +175,11 @@ const (
 	JobStateCancelling               // 6
 	JobStateCancelled                // 7
 	JobStateCancellationFailed       // 8
+	JobStateQueued                   // 9
 )
 
 func (js State) String() string {
-	if js > 8 {
+	if js > 9 {
 		return fmt.Sprintf("JobState%d", js)
 	}
 	return []string{
@@ -180,9 +192,24 @@ func (js State) String() string {
 		string(EventJobCancelling),
 		string(EventJobCancelled),
 		string(EventJobCancellationFailed),
+		string(EventJobQueued),
 	}[js]
 }
 
+// ParseState parses a job state, either its full name, e.g. JobStateQueued,
+// or its short name, e.g. queued. Short names are case-insensitive.
+func ParseState(s string) (State, error) {
+	if st, err := EventNameToJobState(event.Name(s)); err == nil {
+		return st, nil
+	}
+	for i, e := range JobStateEvents {
+		if strings.EqualFold("JobState"+s, string(e)) {
+			return jobStates[i], nil
+		}
+	}
+	return JobStateUnknown, fmt.Errorf("invalid job state %q", s)
+}
+
 // InfoFetcher defines how to fetch job information
 type InfoFetcher interface {
 	FetchJob(types.JobID) (*Job, error)
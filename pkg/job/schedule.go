@@ -0,0 +1,63 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/types"
+)
+
+// OverlapPolicy defines what happens when a schedule fires while the job
+// started by its previous firing is still running.
+type OverlapPolicy string
+
+// List of supported overlap policies.
+const (
+	// OverlapSkip drops the firing, the running job is left alone.
+	OverlapSkip OverlapPolicy = "skip"
+	// OverlapQueue starts the new job as soon as the running one is over.
+	// Multiple firings happening while a job runs are coalesced into one.
+	OverlapQueue OverlapPolicy = "queue"
+	// OverlapCancelPrevious cancels the running job, and starts the new one
+	// once the cancellation is over.
+	OverlapCancelPrevious OverlapPolicy = "cancel-previous"
+)
+
+// Validate returns an error if the overlap policy is not supported.
+func (p OverlapPolicy) Validate() error {
+	switch p {
+	case OverlapSkip, OverlapQueue, OverlapCancelPrevious:
+		return nil
+	default:
+		return fmt.Errorf("invalid overlap policy %q, must be one of %q, %q, %q", p, OverlapSkip, OverlapQueue, OverlapCancelPrevious)
+	}
+}
+
+// Schedule represents a job which is started periodically by the server,
+// according to a cron expression. Every firing starts a new job from the
+// same descriptor.
+type Schedule struct {
+	ID        types.ScheduleID
+	Requestor string
+	// ServerID is the ID of the server which owns the schedule. Only the
+	// owning server fires it.
+	ServerID   string
+	CreateTime time.Time
+
+	CronExpr      string
+	OverlapPolicy OverlapPolicy
+	Paused        bool
+
+	// JobDescriptor is the descriptor of the jobs started by the schedule.
+	JobDescriptor string
+
+	// LastFireTime and LastJobID describe the last time the schedule started
+	// a job. They are zero if the schedule never started one.
+	LastFireTime time.Time
+	LastJobID    types.JobID
+}
@@ -67,6 +67,22 @@ type TestStatus struct {
 	TargetStatuses   []TargetStatus
 }
 
+// FailedIn returns whether the target of a status of one of the steps of the
+// test failed the test in that step. TargetStatuses holds the final status of
+// every target, so the error of a step the target recovered from, e.g. through
+// the OnFailure route of the step, does not count.
+func (ts *TestStatus) FailedIn(stepStatus *TargetStatus) bool {
+	if stepStatus.Error == "" || stepStatus.Target == nil {
+		return false
+	}
+	for _, final := range ts.TargetStatuses {
+		if final.Target != nil && final.Target.ID == stepStatus.Target.ID {
+			return final.Error != "" && final.TestStepLabel == stepStatus.TestStepLabel && final.InTime.Equal(stepStatus.InTime)
+		}
+	}
+	return false
+}
+
 // RunStatus bundles together all TestStatus for a specific run within the job
 type RunStatus struct {
 	RunCoordinates
@@ -101,4 +117,15 @@ type Status struct {
 
 	// Job report information
 	JobReport *JobReport
+
+	// RetryOf is the ID of the job this job is a retry of, zero otherwise
+	RetryOf types.JobID
+
+	// ScheduledBy is the ID of the schedule which started this job, zero
+	// otherwise
+	ScheduledBy types.ScheduleID
+
+	// QueuePosition is the 1-based position of the job in the target
+	// acquisition queue while it waits for busy targets, zero otherwise
+	QueuePosition int
 }
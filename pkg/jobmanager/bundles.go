@@ -85,7 +85,6 @@ func newBundlesFromSteps(ctx xcontext.Context, descriptors []*test.TestStepDescr
 
 // newStepBundles creates bundles for the test
 func newStepBundles(ctx xcontext.Context, descriptors test.TestStepsDescriptors, registry *pluginregistry.PluginRegistry) ([]test.TestStepBundle, error) {
-
 	testStepBundles, err := newBundlesFromSteps(ctx, descriptors.TestSteps, registry)
 	if err != nil {
 		return nil, fmt.Errorf("could not create test steps bundle: %w", err)
@@ -99,5 +98,8 @@ func newStepBundles(ctx xcontext.Context, descriptors test.TestStepsDescriptors,
 		}
 		labels[bundle.TestStepLabel] = true
 	}
+	if err := descriptors.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid steps for test %q: %w", descriptors.TestName, err)
+	}
 	return testStepBundles, nil
 }
@@ -0,0 +1,68 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+)
+
+func (jm *JobManager) events(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+	msg, ok := ev.Msg.(api.EventEventsMsg)
+	if !ok {
+		evResp.Err = fmt.Errorf("invalid argument type %T", ev.Msg)
+		return evResp
+	}
+	evResp.JobID = msg.JobID
+
+	// The state is fetched first: if the job is already completed, the events
+	// fetched afterwards are guaranteed to be the last ones. A job which did
+	// not start yet has no state, its events are watched until it completes.
+	state, err := jm.lastJobState(ctx, msg.JobID)
+	if errors.Is(err, errNoJobState) {
+		if _, reqErr := jm.jsm.GetJobRequest(ctx, msg.JobID); reqErr != nil {
+			evResp.Err = fmt.Errorf("failed to fetch request for job ID %d: %w", msg.JobID, reqErr)
+			return evResp
+		}
+		state, err = "", nil
+	}
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	// The queries reject zero values, sequence IDs of 0 fetch all the events.
+	testQuery := []testevent.QueryField{testevent.QueryJobID(msg.JobID)}
+	if msg.TestEventsFrom > 0 {
+		testQuery = append(testQuery, testevent.QuerySequenceIDStart(msg.TestEventsFrom))
+	}
+	testEvents, err := jm.testEvManager.Fetch(ctx, testQuery...)
+	if err != nil {
+		evResp.Err = fmt.Errorf("could not fetch test events for job %d: %w", msg.JobID, err)
+		return evResp
+	}
+	frameworkQuery := []frameworkevent.QueryField{frameworkevent.QueryJobID(msg.JobID)}
+	if msg.FrameworkEventsFrom > 0 {
+		frameworkQuery = append(frameworkQuery, frameworkevent.QuerySequenceIDStart(msg.FrameworkEventsFrom))
+	}
+	frameworkEvents, err := jm.frameworkEvManager.Fetch(ctx, frameworkQuery...)
+	if err != nil {
+		evResp.Err = fmt.Errorf("could not fetch framework events for job %d: %w", msg.JobID, err)
+		return evResp
+	}
+	evResp.JobState = state
+	evResp.TestEvents = testEvents
+	evResp.FrameworkEvents = frameworkEvents
+	return evResp
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/inventory"
+)
+
+func (jm *JobManager) addInventoryTarget(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventAddInventoryTargetMsg)
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+
+	t := msg.Target
+	if t.Health == "" {
+		t.Health = inventory.HealthUnknown
+	}
+	if err := t.Validate(); err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	t.UpdateTime = time.Now()
+	if err := jm.ism.StoreInventoryTarget(ctx, &t); err != nil {
+		evResp.Err = fmt.Errorf("could not add target %q to the inventory: %w", t.ID, err)
+		return evResp
+	}
+	ctx.Infof("Added target %q to the inventory (labels: %s, health: %s)", t.ID, t.LabelsString(), t.Health)
+	evResp.InventoryTarget = &t
+	return evResp
+}
+
+func (jm *JobManager) listInventoryTargets(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventListInventoryTargetsMsg)
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+
+	sel, err := inventory.ParseSelector(msg.Selector)
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	targets, err := jm.ism.ListInventoryTargets(ev.Context)
+	if err != nil {
+		evResp.Err = fmt.Errorf("failed to list inventory targets: %w", err)
+		return evResp
+	}
+	evResp.InventoryTargets = []*inventory.Target{}
+	for _, t := range targets {
+		if sel.Matches(t.Labels) {
+			evResp.InventoryTargets = append(evResp.InventoryTargets, t)
+		}
+	}
+	return evResp
+}
+
+func (jm *JobManager) removeInventoryTarget(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventRemoveInventoryTargetMsg)
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+
+	jm.inventoryMu.Lock()
+	defer jm.inventoryMu.Unlock()
+	if err := jm.ism.DeleteInventoryTarget(ctx, msg.TargetID); err != nil {
+		evResp.Err = fmt.Errorf("could not remove target %q from the inventory: %w", msg.TargetID, err)
+		return evResp
+	}
+	ctx.Infof("Removed target %q from the inventory", msg.TargetID)
+	return evResp
+}
+
+func (jm *JobManager) drainInventoryTarget(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventDrainInventoryTargetMsg)
+	return jm.updateInventoryTarget(ev, msg.TargetID, func(t *inventory.Target) error {
+		t.Drained = msg.Drained
+		return nil
+	})
+}
+
+func (jm *JobManager) labelInventoryTarget(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventLabelInventoryTargetMsg)
+	return jm.updateInventoryTarget(ev, msg.TargetID, func(t *inventory.Target) error {
+		if err := inventory.CheckLabels(msg.Set); err != nil {
+			return err
+		}
+		if t.Labels == nil {
+			t.Labels = make(map[string]string)
+		}
+		for k, v := range msg.Set {
+			t.Labels[k] = v
+		}
+		for _, k := range msg.Remove {
+			delete(t.Labels, k)
+		}
+		return nil
+	})
+}
+
+func (jm *JobManager) setInventoryTargetHealth(ev *api.Event) *api.EventResponse {
+	msg := ev.Msg.(api.EventSetInventoryTargetHealthMsg)
+	return jm.updateInventoryTarget(ev, msg.TargetID, func(t *inventory.Target) error {
+		if err := msg.Health.Validate(); err != nil {
+			return err
+		}
+		t.Health = msg.Health
+		return nil
+	})
+}
+
+// updateInventoryTarget applies update to a target of the inventory, and
+// stores the result.
+func (jm *JobManager) updateInventoryTarget(ev *api.Event, targetID string, update func(t *inventory.Target) error) *api.EventResponse {
+	ctx := ev.Context
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+
+	jm.inventoryMu.Lock()
+	defer jm.inventoryMu.Unlock()
+	t, err := jm.ism.GetInventoryTarget(ctx, targetID)
+	if err != nil {
+		evResp.Err = fmt.Errorf("failed to fetch inventory target %q: %w", targetID, err)
+		return evResp
+	}
+	if err := update(t); err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	t.UpdateTime = time.Now()
+	if err := jm.ism.UpdateInventoryTarget(ctx, t); err != nil {
+		evResp.Err = fmt.Errorf("could not update inventory target %q: %w", targetID, err)
+		return evResp
+	}
+	ctx.Infof("Updated inventory target %q (labels: %s, health: %s, drained: %t)", t.ID, t.LabelsString(), t.Health, t.Drained)
+	evResp.InventoryTarget = t
+	return evResp
+}
@@ -85,6 +85,8 @@ func newJob(ctx xcontext.Context, registry *pluginregistry.PluginRegistry, jobDe
 			TestFetcherBundle:   bundleTestFetcher,
 			TestStepsBundles:    bundleTest,
 			RetryParameters:     td.RetryParameters,
+			ConsoleRecorder:     td.ConsoleRecorder,
+			TargetIDs:           td.TargetIDs,
 		}
 		tests = append(tests, &test)
 	}
@@ -114,6 +116,7 @@ func newJob(ctx xcontext.Context, registry *pluginregistry.PluginRegistry, jobDe
 		RunInterval:                 time.Duration(jobDescriptor.RunInterval),
 		TargetManagerAcquireTimeout: targetManagerAcquireTimeout,
 		TargetManagerReleaseTimeout: targetManagerReleaseTimeout,
+		Priority:                    jobDescriptor.Priority,
 		Tests:                       tests,
 		RunReporterBundles:          runReportersBundle,
 		FinalReporterBundles:        finalReportersBundle,
@@ -5,6 +5,7 @@ import (
 
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/pluginregistry"
+	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
 	"github.com/linuxboot/contest/pkg/xcontext"
 	"github.com/linuxboot/contest/plugins/reporters/noop"
@@ -154,3 +155,20 @@ func TestNewJobNoTestSteps(t *testing.T) {
 	_, err := NewJobFromDescriptor(xcontext.Background(), pr, &jd)
 	require.Error(t, err)
 }
+
+func TestFailedTargetsByTest(t *testing.T) {
+	status := func(testName string, errs map[string]string) job.TestStatus {
+		ts := job.TestStatus{TestCoordinates: job.TestCoordinates{TestName: testName}}
+		for id, err := range errs {
+			ts.TargetStatuses = append(ts.TargetStatuses, job.TargetStatus{Target: &target.Target{ID: id}, Error: err})
+		}
+		return ts
+	}
+	// Tests with the same name do not share their failed targets.
+	runStatus := job.RunStatus{TestStatuses: []job.TestStatus{
+		status("Test", map[string]string{"T1": "failed"}),
+		status("Test", map[string]string{"T2": "failed", "T3": ""}),
+		status("Other", map[string]string{"T1": ""}),
+	}}
+	require.Equal(t, [][]string{{"T1"}, {"T2"}, nil}, failedTargetsByTest(runStatus))
+}
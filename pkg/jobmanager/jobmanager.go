@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/metrics/perf"
 )
 
 // ErrorEventPayload represents the payload carried by a failure event (e.g. JobStateFailed, JobStateCancelled, etc.)
@@ -40,6 +42,8 @@ type ErrorEventPayload struct {
 // * fetching test definitions, via test fetchers
 // * enqueuing new job requests, and handling their status
 // * starting, stopping, and retrying jobs
+// * starting the jobs of recurring schedules
+// * managing the inventory of targets
 type JobManager struct {
 	config
 
@@ -47,8 +51,22 @@ type JobManager struct {
 	jobRunner *runner.JobRunner
 
 	jobsMu sync.Mutex
+	// pausing is set once all the jobs are paused, the jobs started after that
+	// are paused right away.
+	pausing bool
+	// resumeMu serializes the resumptions of jobs through the API.
+	resumeMu sync.Mutex
 
 	jsm storage.JobStorageManager
+	ssm storage.ScheduleStorageManager
+
+	// schedulesMu serializes the updates of the schedules.
+	schedulesMu sync.Mutex
+
+	ism storage.InventoryStorageManager
+
+	// inventoryMu serializes the updates of the inventory targets.
+	inventoryMu sync.Mutex
 
 	frameworkEvManager frameworkevent.EmitterFetcher
 	testEvManager      testevent.Fetcher
@@ -57,6 +75,11 @@ type JobManager struct {
 	pluginRegistry *pluginregistry.PluginRegistry
 
 	apiCancel xcontext.CancelFunc
+	// jobsCtx is the context of the jobs the job manager resumes by itself,
+	// e.g. after a preemption. It is set by Run and, like the context of the
+	// jobs started through the API, does not carry the signals of the server:
+	// jobs are stopped via CancelAll and PauseAll.
+	jobsCtx xcontext.Context
 
 	msgCounter int
 }
@@ -64,6 +87,8 @@ type JobManager struct {
 type jobInfo struct {
 	job           *job.Job
 	pause, cancel func()
+	// pauseRequested is set if the job is paused through the API.
+	pauseRequested bool
 }
 
 // New initializes and returns a new JobManager with the given API listener.
@@ -92,6 +117,8 @@ func New(l api.Listener, pr *pluginregistry.PluginRegistry, storageEngineVault s
 		pluginRegistry:     pr,
 		jobs:               make(map[types.JobID]*jobInfo),
 		jsm:                jsm,
+		ssm:                storage.NewScheduleStorageManager(storageEngineVault),
+		ism:                storage.NewInventoryStorageManager(storageEngineVault),
 		frameworkEvManager: frameworkEvManager,
 		testEvManager:      testEvManager,
 	}
@@ -102,6 +129,7 @@ func New(l api.Listener, pr *pluginregistry.PluginRegistry, storageEngineVault s
 func (jm *JobManager) handleEvent(ev *api.Event) {
 	var resp *api.EventResponse
 
+	start := time.Now()
 	switch ev.Type {
 	case api.EventTypeStart:
 		resp = jm.start(ev)
@@ -111,8 +139,34 @@ func (jm *JobManager) handleEvent(ev *api.Event) {
 		resp = jm.stop(ev)
 	case api.EventTypeRetry:
 		resp = jm.retry(ev)
+	case api.EventTypePause:
+		resp = jm.pause(ev)
+	case api.EventTypeResume:
+		resp = jm.resume(ev)
 	case api.EventTypeList:
 		resp = jm.list(ev)
+	case api.EventTypeEvents:
+		resp = jm.events(ev)
+	case api.EventTypeAddSchedule:
+		resp = jm.addSchedule(ev)
+	case api.EventTypeListSchedules:
+		resp = jm.listSchedules(ev)
+	case api.EventTypePauseSchedule:
+		resp = jm.pauseSchedule(ev)
+	case api.EventTypeDeleteSchedule:
+		resp = jm.deleteSchedule(ev)
+	case api.EventTypeAddInventoryTarget:
+		resp = jm.addInventoryTarget(ev)
+	case api.EventTypeListInventoryTargets:
+		resp = jm.listInventoryTargets(ev)
+	case api.EventTypeRemoveInventoryTarget:
+		resp = jm.removeInventoryTarget(ev)
+	case api.EventTypeDrainInventoryTarget:
+		resp = jm.drainInventoryTarget(ev)
+	case api.EventTypeLabelInventoryTarget:
+		resp = jm.labelInventoryTarget(ev)
+	case api.EventTypeSetInventoryTargetHealth:
+		resp = jm.setInventoryTargetHealth(ev)
 	default:
 		resp = &api.EventResponse{
 			Requestor: ev.Msg.Requestor(),
@@ -120,6 +174,11 @@ func (jm *JobManager) handleEvent(ev *api.Event) {
 		}
 	}
 
+	if metrics := ev.Context.Metrics(); metrics != nil {
+		verb := strings.TrimPrefix(ev.Type.String(), "event_type_")
+		perf.ObserveDuration(metrics.WithTags(nil).WithTag("verb", verb), perf.API_REQUEST_DURATION, time.Since(start))
+	}
+
 	ev.Context.Debugf("Sending response %+v", resp)
 	// time to wait before printing an error if the response is not received.
 	sendEventTimeout := 3 * time.Second
@@ -144,6 +203,8 @@ func (jm *JobManager) Run(ctx xcontext.Context, resumeJobs bool) error {
 		return fmt.Errorf("Cannot start API: %w", err)
 	}
 
+	jm.jobsCtx = xcontext.WithResetSignalers(ctx)
+
 	// Deal with zombieed jobs (fail them).
 	if err := jm.failZombieJobs(ctx, a.ServerID()); err != nil {
 		ctx.Errorf("failed to fail jobs: %v", err)
@@ -167,6 +228,14 @@ func (jm *JobManager) Run(ctx xcontext.Context, resumeJobs bool) error {
 		close(errCh)
 	}()
 
+	// The scheduler stops together with the API, no new jobs are started
+	// after that.
+	schedulerDone := make(chan struct{})
+	go func() {
+		jm.runScheduler(apiCtx, a.ServerID())
+		close(schedulerDone)
+	}()
+
 	var handlerWg sync.WaitGroup
 loop:
 	for {
@@ -196,6 +265,7 @@ loop:
 	// Stop the API (if not already)
 	jm.StopAPI()
 	<-errCh
+	<-schedulerDone
 	// Wait for event handler completion
 	handlerWg.Wait()
 	// Wait for jobs to complete or for cancellation signal.
@@ -221,7 +291,7 @@ loop:
 }
 
 func (jm *JobManager) failZombieJobs(ctx xcontext.Context, serverID string) error {
-	zombieJobs, err := jm.listMyJobs(ctx, serverID, job.JobStateStarted)
+	zombieJobs, err := jm.listMyJobs(ctx, serverID, job.JobStateStarted, job.JobStateQueued)
 	if err != nil {
 		return fmt.Errorf("failed to list zombie jobs: %w", err)
 	}
@@ -237,10 +307,10 @@ func (jm *JobManager) failZombieJobs(ctx xcontext.Context, serverID string) erro
 	return nil
 }
 
-func (jm *JobManager) listMyJobs(ctx xcontext.Context, serverID string, jobState job.State) ([]types.JobID, error) {
+func (jm *JobManager) listMyJobs(ctx xcontext.Context, serverID string, jobStates ...job.State) ([]types.JobID, error) {
 	queryFields := []storage.JobQueryField{
 		storage.QueryJobServerID(serverID),
-		storage.QueryJobStates(jobState),
+		storage.QueryJobStates(jobStates...),
 	}
 	if jm.config.instanceTag != "" {
 		queryFields = append(queryFields, storage.QueryJobTags(jm.config.instanceTag))
@@ -302,6 +372,7 @@ func (jm *JobManager) CancelAll(ctx xcontext.Context) {
 func (jm *JobManager) PauseAll(ctx xcontext.Context) {
 	jm.jobsMu.Lock()
 	defer jm.jobsMu.Unlock()
+	jm.pausing = true
 	for jobID, ji := range jm.jobs {
 		ctx.Debugf("JobManager: pausing job %d", jobID)
 		ji.pause()
@@ -24,6 +24,7 @@ type config struct {
 	apiOptions         []api.Option
 	instanceTag        string
 	targetLockDuration time.Duration
+	schedulerInterval  time.Duration
 	clock              clock.Clock
 }
 
@@ -57,6 +58,14 @@ func (opt OptionTargetLockDuration) apply(config *config) {
 	config.targetLockDuration = time.Duration(opt)
 }
 
+// OptionSchedulerInterval wraps time.Duration to be used as the interval at
+// which job schedules are checked.
+type OptionSchedulerInterval time.Duration
+
+func (opt OptionSchedulerInterval) apply(config *config) {
+	config.schedulerInterval = time.Duration(opt)
+}
+
 type optionClock struct {
 	clock clock.Clock
 }
@@ -74,6 +83,7 @@ func OptionClock(clk clock.Clock) Option {
 func getConfig(opts ...Option) config {
 	result := config{
 		targetLockDuration: configPkg.DefaultTargetLockDuration,
+		schedulerInterval:  configPkg.DefaultSchedulerInterval,
 		clock:              clock.New(),
 	}
 	for _, opt := range opts {
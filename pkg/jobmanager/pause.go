@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/job"
+)
+
+func (jm *JobManager) pause(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventPauseMsg)
+	evResp := &api.EventResponse{
+		JobID:     msg.JobID,
+		Requestor: ev.Msg.Requestor(),
+	}
+
+	// Like cancellation, pausing is asynchronous: the job is paused when the
+	// JobRunner returns, and its pause state is stored by runJob.
+	jm.jobsMu.Lock()
+	defer jm.jobsMu.Unlock()
+	ji, ok := jm.jobs[msg.JobID]
+	if !ok {
+		evResp.Err = fmt.Errorf("job %d is not running", msg.JobID)
+		return evResp
+	}
+	if err := jm.jobRunner.Pause(msg.JobID, msg.ReleaseTargets); err != nil {
+		evResp.Err = fmt.Errorf("could not pause job: %w", err)
+		return evResp
+	}
+	ji.pauseRequested = true
+	ctx.Infof("Pausing job %d (release targets: %t) as requested by %s", msg.JobID, msg.ReleaseTargets, msg.Requestor())
+	return evResp
+}
+
+func (jm *JobManager) resume(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventResumeMsg)
+	evResp := &api.EventResponse{
+		JobID:     msg.JobID,
+		Requestor: ev.Msg.Requestor(),
+	}
+
+	// Serialize the resumptions, so that a job is not resumed twice.
+	jm.resumeMu.Lock()
+	defer jm.resumeMu.Unlock()
+	jm.jobsMu.Lock()
+	_, running := jm.jobs[msg.JobID]
+	jm.jobsMu.Unlock()
+	if running {
+		evResp.Err = fmt.Errorf("job %d is running", msg.JobID)
+		return evResp
+	}
+	state, err := jm.lastJobState(ctx, msg.JobID)
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	if state != string(job.EventJobPaused) {
+		evResp.Err = fmt.Errorf("job %d is not paused (state %s)", msg.JobID, state)
+		return evResp
+	}
+	if err := jm.resumeJob(ctx, msg.JobID); err != nil {
+		evResp.Err = fmt.Errorf("could not resume job: %w", err)
+		return evResp
+	}
+	ctx.Infof("Resumed job %d as requested by %s", msg.JobID, msg.Requestor())
+	return evResp
+}
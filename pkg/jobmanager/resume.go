@@ -22,7 +22,15 @@ func (jm *JobManager) resumeJobs(ctx xcontext.Context, serverID string) error {
 	}
 	ctx.Infof("Found %d paused jobs for %s/%s", len(pausedJobs), jm.config.instanceTag, serverID)
 	for _, jobID := range pausedJobs {
-		if err := jm.resumeJob(ctx, jobID); err != nil {
+		resumeState, err := jm.pauseState(ctx, jobID)
+		if err == nil && resumeState.ResumeOnRequest {
+			ctx.Infof("Job %d was paused through the API, not resuming it", jobID)
+			continue
+		}
+		if err == nil {
+			err = jm.resumeJob(ctx, jobID)
+		}
+		if err != nil {
 			ctx.Errorf("failed to resume job %d: %v, failing it", jobID, err)
 			if err = jm.emitErrEvent(ctx, jobID, job.EventJobFailed, fmt.Errorf("failed to resume job %d: %w", jobID, err)); err != nil {
 				ctx.Warnf("Failed to emit event for %d: %v", jobID, err)
@@ -32,18 +40,18 @@ func (jm *JobManager) resumeJobs(ctx xcontext.Context, serverID string) error {
 	return nil
 }
 
-func (jm *JobManager) resumeJob(ctx xcontext.Context, jobID types.JobID) error {
-	ctx.Debugf("attempting to resume job %d", jobID)
+// pauseState returns the payload of the last pause event of a job.
+func (jm *JobManager) pauseState(ctx xcontext.Context, jobID types.JobID) (*job.PauseEventPayload, error) {
 	results, err := jm.frameworkEvManager.Fetch(
 		ctx,
 		frameworkevent.QueryJobID(jobID),
 		frameworkevent.QueryEventName(job.EventJobPaused),
 	)
 	if err != nil {
-		return fmt.Errorf("failed to query resume state for job %d: %w", jobID, err)
+		return nil, fmt.Errorf("failed to query resume state for job %d: %w", jobID, err)
 	}
 	if len(results) == 0 {
-		return fmt.Errorf("no resume state found for job %d", jobID)
+		return nil, fmt.Errorf("no resume state found for job %d", jobID)
 	}
 
 	// get the latest event by id
@@ -55,15 +63,26 @@ func (jm *JobManager) resumeJob(ctx xcontext.Context, jobID types.JobID) error {
 	}
 	var resumeState job.PauseEventPayload
 	if results[lastEventIdx].Payload == nil {
-		return fmt.Errorf("invald resume state for job %d: %+v", jobID, results[0])
+		return nil, fmt.Errorf("invald resume state for job %d: %+v", jobID, results[0])
 	}
 	if err := json.Unmarshal(*results[lastEventIdx].Payload, &resumeState); err != nil {
-		return fmt.Errorf("invald resume state for job %d: %w", jobID, err)
+		return nil, fmt.Errorf("invald resume state for job %d: %w", jobID, err)
 	}
 	if resumeState.Version != job.CurrentPauseEventPayloadVersion {
-		return fmt.Errorf("incompatible resume state version (want %d, got %d)",
+		return nil, fmt.Errorf("incompatible resume state version (want %d, got %d)",
 			job.CurrentPauseEventPayloadVersion, resumeState.Version)
 	}
+	return &resumeState, nil
+}
+
+func (jm *JobManager) resumeJob(ctx xcontext.Context, jobID types.JobID) error {
+	ctx.Debugf("attempting to resume job %d", jobID)
+	resumeState, err := jm.pauseState(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	// the job may be paused again, through the API or not
+	resumeState.ResumeOnRequest = false
 	req, err := jm.jsm.GetJobRequest(ctx, jobID)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve job descriptor for %d: %w", jobID, err)
@@ -73,7 +92,8 @@ func (jm *JobManager) resumeJob(ctx xcontext.Context, jobID types.JobID) error {
 		return fmt.Errorf("failed to create job %d: %w", jobID, err)
 	}
 	j.ID = jobID
+	j.Requestor = req.Requestor
 	ctx.Debugf("running resumed job %d", j.ID)
-	jm.startJob(ctx, j, &resumeState)
+	jm.startJob(ctx, j, resumeState)
 	return nil
 }
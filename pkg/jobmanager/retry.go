@@ -6,13 +6,218 @@
 package jobmanager
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
 func (jm *JobManager) retry(ev *api.Event) *api.EventResponse {
-	return &api.EventResponse{
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventRetryMsg)
+	evResp := &api.EventResponse{
 		Requestor: ev.Msg.Requestor(),
-		Err:       fmt.Errorf("Not implemented"),
+		Err:       nil,
+	}
+
+	req, err := jm.jsm.GetJobRequest(ctx, msg.JobID)
+	if err != nil {
+		evResp.Err = fmt.Errorf("failed to fetch request for job ID %d: %w", msg.JobID, err)
+		return evResp
+	}
+	if req.ExtendedDescriptor == nil {
+		evResp.Err = fmt.Errorf("job %d has no extended descriptor, cannot retry", msg.JobID)
+		return evResp
+	}
+
+	// Is it for our instance?
+	if jm.config.instanceTag != "" {
+		found := false
+		for _, tag := range req.ExtendedDescriptor.Tags {
+			if tag == jm.config.instanceTag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			evResp.Err = fmt.Errorf("job %d belongs to a different instance, this is %q",
+				msg.JobID, jm.config.instanceTag)
+			return evResp
+		}
+	}
+
+	state, err := jm.lastJobState(ctx, msg.JobID)
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	if !isCompletionEvent(state) {
+		evResp.Err = fmt.Errorf("job %d is not completed (state %s), cannot retry", msg.JobID, state)
+		return evResp
+	}
+
+	// Work on a copy of the extended descriptor, the original one is left untouched.
+	extendedDescriptor := *req.ExtendedDescriptor
+	extendedDescriptor.TestDescriptors = append([]*test.TestDescriptor(nil), req.ExtendedDescriptor.TestDescriptors...)
+	extendedDescriptor.TestStepsDescriptors = append([]test.TestStepsDescriptors(nil), req.ExtendedDescriptor.TestStepsDescriptors...)
+
+	if msg.FailedTargetsOnly {
+		if err := jm.restrictToFailedTargets(ctx, msg.JobID, &extendedDescriptor); err != nil {
+			evResp.Err = err
+			return evResp
+		}
+	}
+
+	j, err := NewJobFromExtendedDescriptor(ctx, jm.pluginRegistry, &extendedDescriptor)
+	if err != nil {
+		evResp.Err = fmt.Errorf("failed to build job object for retry of job %d: %w", msg.JobID, err)
+		return evResp
+	}
+	jdJSON, err := json.MarshalIndent(&extendedDescriptor.Descriptor, "", "    ")
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+
+	request := job.Request{
+		JobName:            j.Name,
+		JobDescriptor:      string(jdJSON),
+		ExtendedDescriptor: j.ExtendedDescriptor,
+		Requestor:          string(ev.Msg.Requestor()),
+		ServerID:           ev.ServerID,
+		RequestTime:        time.Now(),
+	}
+	jobID, err := jm.jsm.StoreJobRequest(ctx, &request)
+	if err != nil {
+		evResp.Err = fmt.Errorf("could not create job request: %v", err)
+		return evResp
+	}
+	j.ID = jobID
+	j.Requestor = request.Requestor
+
+	// Record the link to the original job before the new job starts running.
+	payload := job.RetryEventPayload{RetryOf: msg.JobID, FailedTargetsOnly: msg.FailedTargetsOnly}
+	if err := jm.emitEventPayload(ctx, j.ID, job.EventJobRetry, &payload); err != nil {
+		ctx.Errorf("failed to emit event: %v", err)
+	}
+
+	ctx.Infof("Retrying job %d as job %d (failed targets only: %t)", msg.JobID, j.ID, msg.FailedTargetsOnly)
+	jm.startJob(ctx, j, nil)
+
+	evResp.JobID = j.ID
+	evResp.Status = &job.Status{
+		Name:      j.Name,
+		State:     string(job.EventJobStarted),
+		StartTime: time.Now(),
+		RetryOf:   msg.JobID,
+	}
+	return evResp
+}
+
+// errNoJobState is returned by lastJobState for jobs without state events,
+// e.g. jobs which were just submitted and did not start yet.
+var errNoJobState = errors.New("no state events found")
+
+// lastJobState returns the name of the last state event emitted for a job.
+func (jm *JobManager) lastJobState(ctx xcontext.Context, jobID types.JobID) (string, error) {
+	jobEvents, err := jm.frameworkEvManager.Fetch(ctx,
+		frameworkevent.QueryJobID(jobID),
+		frameworkevent.QueryEventNames(job.JobStateEvents),
+	)
+	if err != nil {
+		return "", fmt.Errorf("could not fetch events associated to job state: %v", err)
+	}
+	if len(jobEvents) == 0 {
+		return "", fmt.Errorf("%w for job %d", errNoJobState, jobID)
+	}
+	var lastJobEventIdx int
+	for idx, ev := range jobEvents {
+		if ev.SequenceID > jobEvents[lastJobEventIdx].SequenceID {
+			lastJobEventIdx = idx
+		}
+	}
+	return string(jobEvents[lastJobEventIdx].EventName), nil
+}
+
+func isCompletionEvent(state string) bool {
+	for _, eventName := range job.JobCompletionEvents {
+		if state == string(eventName) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictToFailedTargets rewrites the test descriptors of an extended
+// descriptor so that every test only runs on the targets that failed it in
+// the last run of the original job. The targets are still acquired and
+// released by the target manager of the test. Tests without failed targets
+// are dropped.
+func (jm *JobManager) restrictToFailedTargets(ctx xcontext.Context, jobID types.JobID, extendedDescriptor *job.ExtendedDescriptor) error {
+	origJob, err := NewJobFromExtendedDescriptor(ctx, jm.pluginRegistry, extendedDescriptor)
+	if err != nil {
+		return fmt.Errorf("failed to build job object from job request: %w", err)
+	}
+	origJob.ID = jobID
+
+	runStatuses, err := jm.jobRunner.BuildRunStatuses(ctx, origJob)
+	if err != nil {
+		return fmt.Errorf("could not rebuild the statuses of job %d: %w", jobID, err)
+	}
+	if len(runStatuses) == 0 {
+		return fmt.Errorf("job %d has no runs, cannot determine failed targets", jobID)
+	}
+	failedTargets := failedTargetsByTest(runStatuses[len(runStatuses)-1])
+
+	var (
+		testDescriptors      []*test.TestDescriptor
+		testStepsDescriptors []test.TestStepsDescriptors
+		// index of the test among the enabled ones, as in the run status
+		testIdx int
+	)
+	for idx, td := range extendedDescriptor.TestDescriptors {
+		if td.Disabled {
+			continue
+		}
+		var targetIDs []string
+		if testIdx < len(failedTargets) {
+			targetIDs = failedTargets[testIdx]
+		}
+		testIdx++
+		if len(targetIDs) == 0 {
+			continue
+		}
+		newTD := *td
+		newTD.TargetIDs = targetIDs
+		testDescriptors = append(testDescriptors, &newTD)
+		testStepsDescriptors = append(testStepsDescriptors, extendedDescriptor.TestStepsDescriptors[idx])
+	}
+	if len(testDescriptors) == 0 {
+		return fmt.Errorf("job %d has no failed targets to retry", jobID)
+	}
+	extendedDescriptor.TestDescriptors = testDescriptors
+	extendedDescriptor.TestStepsDescriptors = testStepsDescriptors
+	return nil
+}
+
+// failedTargetsByTest returns, for every test of a run by index, the IDs of
+// the targets which reported an error within that test.
+func failedTargetsByTest(runStatus job.RunStatus) [][]string {
+	failed := make([][]string, len(runStatus.TestStatuses))
+	for idx, testStatus := range runStatus.TestStatuses {
+		for _, targetStatus := range testStatus.TargetStatuses {
+			if targetStatus.Target == nil || targetStatus.Error == "" {
+				continue
+			}
+			failed[idx] = append(failed[idx], targetStatus.Target.ID)
+		}
 	}
+	return failed
 }
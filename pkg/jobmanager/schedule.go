@@ -0,0 +1,136 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/lib/cron"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+func (jm *JobManager) addSchedule(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventAddScheduleMsg)
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+
+	if _, err := cron.Parse(msg.CronExpr); err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	overlapPolicy := msg.OverlapPolicy
+	if overlapPolicy == "" {
+		overlapPolicy = job.OverlapSkip
+	}
+	if err := overlapPolicy.Validate(); err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	// Validate the descriptor now rather than at every firing.
+	if _, _, err := jm.newJob(ctx, msg.JobDescriptor); err != nil {
+		evResp.Err = fmt.Errorf("invalid job descriptor: %w", err)
+		return evResp
+	}
+
+	schedule := job.Schedule{
+		Requestor:     string(ev.Msg.Requestor()),
+		ServerID:      ev.ServerID,
+		CreateTime:    time.Now(),
+		CronExpr:      msg.CronExpr,
+		OverlapPolicy: overlapPolicy,
+		JobDescriptor: msg.JobDescriptor,
+	}
+	scheduleID, err := jm.ssm.StoreSchedule(ctx, &schedule)
+	if err != nil {
+		evResp.Err = fmt.Errorf("could not store schedule: %w", err)
+		return evResp
+	}
+	ctx.Infof("Added schedule %d (%q, overlap policy %s)", scheduleID, msg.CronExpr, overlapPolicy)
+	evResp.ScheduleID = scheduleID
+	return evResp
+}
+
+func (jm *JobManager) listSchedules(ev *api.Event) *api.EventResponse {
+	evResp := &api.EventResponse{
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+	}
+	schedules, err := jm.ssm.ListSchedules(ev.Context, ev.ServerID)
+	if err != nil {
+		evResp.Err = fmt.Errorf("failed to list schedules: %w", err)
+		return evResp
+	}
+	evResp.Schedules = schedules
+	return evResp
+}
+
+func (jm *JobManager) pauseSchedule(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventPauseScheduleMsg)
+	evResp := &api.EventResponse{
+		Requestor:  ev.Msg.Requestor(),
+		ScheduleID: msg.ScheduleID,
+		Err:        nil,
+	}
+
+	// The scheduler updates schedules too, don't race with it.
+	jm.schedulesMu.Lock()
+	defer jm.schedulesMu.Unlock()
+	schedule, err := jm.getMySchedule(ctx, ev.ServerID, msg.ScheduleID)
+	if err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	schedule.Paused = msg.Paused
+	if err := jm.ssm.UpdateSchedule(ctx, schedule); err != nil {
+		evResp.Err = fmt.Errorf("could not update schedule %d: %w", msg.ScheduleID, err)
+		return evResp
+	}
+	ctx.Infof("Schedule %d paused: %t", msg.ScheduleID, msg.Paused)
+	return evResp
+}
+
+func (jm *JobManager) deleteSchedule(ev *api.Event) *api.EventResponse {
+	ctx := ev.Context
+	msg := ev.Msg.(api.EventDeleteScheduleMsg)
+	evResp := &api.EventResponse{
+		Requestor:  ev.Msg.Requestor(),
+		ScheduleID: msg.ScheduleID,
+		Err:        nil,
+	}
+
+	jm.schedulesMu.Lock()
+	defer jm.schedulesMu.Unlock()
+	if _, err := jm.getMySchedule(ctx, ev.ServerID, msg.ScheduleID); err != nil {
+		evResp.Err = err
+		return evResp
+	}
+	if err := jm.ssm.DeleteSchedule(ctx, msg.ScheduleID); err != nil {
+		evResp.Err = fmt.Errorf("could not delete schedule %d: %w", msg.ScheduleID, err)
+		return evResp
+	}
+	ctx.Infof("Deleted schedule %d", msg.ScheduleID)
+	return evResp
+}
+
+// getMySchedule fetches a schedule, and checks that it is owned by this server.
+func (jm *JobManager) getMySchedule(ctx xcontext.Context, serverID string, scheduleID types.ScheduleID) (*job.Schedule, error) {
+	schedule, err := jm.ssm.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schedule %d: %w", scheduleID, err)
+	}
+	if schedule.ServerID != serverID {
+		return nil, fmt.Errorf("schedule %d belongs to a different server, this is %q", scheduleID, serverID)
+	}
+	return schedule, nil
+}
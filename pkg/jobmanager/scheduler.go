@@ -0,0 +1,183 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/lib/cron"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// schedulerState is the in-memory state of the scheduler. Firing times are
+// computed when the scheduler first sees a schedule, so firings missed while
+// the server was down or the schedule was paused are not recovered.
+type schedulerState struct {
+	// next is the next firing time of each active schedule.
+	next map[types.ScheduleID]time.Time
+	// deferred holds the firings which wait for the job started by the
+	// previous firing to terminate, see job.OverlapQueue and
+	// job.OverlapCancelPrevious.
+	deferred map[types.ScheduleID]time.Time
+	// fire starts the job of a schedule, it is JobManager.fireSchedule.
+	fire func(ctx xcontext.Context, schedule *job.Schedule, fireTime time.Time) error
+}
+
+// runScheduler periodically starts the jobs of the schedules owned by this
+// server, until ctx is done.
+func (jm *JobManager) runScheduler(ctx xcontext.Context, serverID string) {
+	state := schedulerState{
+		next:     make(map[types.ScheduleID]time.Time),
+		deferred: make(map[types.ScheduleID]time.Time),
+		fire:     jm.fireSchedule,
+	}
+	ticker := jm.config.clock.Ticker(jm.config.schedulerInterval)
+	defer ticker.Stop()
+	for {
+		jm.fireSchedules(ctx, serverID, &state)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scheduledFiring is a firing of a schedule which is due.
+type scheduledFiring struct {
+	schedule *job.Schedule
+	fireTime time.Time
+}
+
+// fireSchedules starts the jobs of the due firings. Creating a job goes
+// through the storage, so schedulesMu is only held while the firings are
+// computed.
+func (jm *JobManager) fireSchedules(ctx xcontext.Context, serverID string, state *schedulerState) {
+	for _, f := range jm.dueFirings(ctx, serverID, state) {
+		if err := state.fire(ctx, f.schedule, f.fireTime); err != nil {
+			ctx.Errorf("Schedule %d failed to start a job: %v", f.schedule.ID, err)
+		}
+	}
+}
+
+// dueFirings lists the schedules of this server and returns the firings which
+// are due, updating the state of the scheduler.
+func (jm *JobManager) dueFirings(ctx xcontext.Context, serverID string, state *schedulerState) []scheduledFiring {
+	jm.schedulesMu.Lock()
+	defer jm.schedulesMu.Unlock()
+
+	schedules, err := jm.ssm.ListSchedules(ctx, serverID)
+	if err != nil {
+		ctx.Errorf("Failed to list schedules: %v", err)
+		return nil
+	}
+	now := jm.config.clock.Now()
+	active := make(map[types.ScheduleID]bool)
+	var firings []scheduledFiring
+	for _, schedule := range schedules {
+		if schedule.Paused {
+			continue
+		}
+		active[schedule.ID] = true
+
+		fireTime, deferred := state.deferred[schedule.ID]
+		if !deferred {
+			next, known := state.next[schedule.ID]
+			if !known {
+				state.next[schedule.ID] = jm.nextFireTime(ctx, schedule, now)
+				continue
+			}
+			if next.IsZero() || now.Before(next) {
+				continue
+			}
+			state.next[schedule.ID] = jm.nextFireTime(ctx, schedule, now)
+			fireTime = next
+		}
+
+		if schedule.LastJobID != 0 && jm.isRunning(schedule.LastJobID) {
+			switch schedule.OverlapPolicy {
+			case job.OverlapQueue:
+				ctx.Debugf("Schedule %d: job %d is still running, queueing", schedule.ID, schedule.LastJobID)
+				state.deferred[schedule.ID] = fireTime
+			case job.OverlapCancelPrevious:
+				if !deferred {
+					ctx.Infof("Schedule %d: cancelling job %d", schedule.ID, schedule.LastJobID)
+					if err := jm.CancelJob(schedule.LastJobID); err == nil {
+						_ = jm.emitEvent(ctx, schedule.LastJobID, job.EventJobCancelling)
+					}
+				}
+				state.deferred[schedule.ID] = fireTime
+			default:
+				ctx.Infof("Schedule %d: job %d is still running, skipping firing", schedule.ID, schedule.LastJobID)
+			}
+			continue
+		}
+		delete(state.deferred, schedule.ID)
+		firings = append(firings, scheduledFiring{schedule: schedule, fireTime: fireTime})
+	}
+
+	// Forget about paused and deleted schedules.
+	for scheduleID := range state.next {
+		if !active[scheduleID] {
+			delete(state.next, scheduleID)
+			delete(state.deferred, scheduleID)
+		}
+	}
+	return firings
+}
+
+func (jm *JobManager) nextFireTime(ctx xcontext.Context, schedule *job.Schedule, now time.Time) time.Time {
+	expr, err := cron.Parse(schedule.CronExpr)
+	if err != nil {
+		ctx.Errorf("Schedule %d has an invalid cron expression: %v", schedule.ID, err)
+		return time.Time{}
+	}
+	return expr.Next(now)
+}
+
+// fireSchedule starts a new job from the descriptor of a schedule.
+func (jm *JobManager) fireSchedule(ctx xcontext.Context, schedule *job.Schedule, fireTime time.Time) error {
+	// Jobs are stopped via CancelAll and PauseAll, they must not inherit
+	// the signals of the scheduler.
+	jobCtx := xcontext.WithResetSignalers(ctx)
+	j, err := jm.createJob(jobCtx, schedule.JobDescriptor, schedule.Requestor, schedule.ServerID)
+	if err != nil {
+		return err
+	}
+
+	payload := job.ScheduledEventPayload{ScheduleID: schedule.ID, FireTime: fireTime}
+	if err := jm.emitEventPayload(ctx, j.ID, job.EventJobScheduled, &payload); err != nil {
+		ctx.Errorf("failed to emit event: %v", err)
+	}
+	ctx.Infof("Schedule %d started job %d", schedule.ID, j.ID)
+	jm.startJob(jobCtx, j, nil)
+
+	// The schedule may have been paused or deleted since it was listed,
+	// record the firing on its current version.
+	jm.schedulesMu.Lock()
+	defer jm.schedulesMu.Unlock()
+	current, err := jm.ssm.GetSchedule(ctx, schedule.ID)
+	if err != nil {
+		return fmt.Errorf("could not record the firing: %w", err)
+	}
+	current.LastFireTime = fireTime
+	current.LastJobID = j.ID
+	if err := jm.ssm.UpdateSchedule(ctx, current); err != nil {
+		return fmt.Errorf("could not record the firing: %w", err)
+	}
+	return nil
+}
+
+// isRunning returns whether a job is being run by this JobManager.
+func (jm *JobManager) isRunning(jobID types.JobID) bool {
+	jm.jobsMu.Lock()
+	defer jm.jobsMu.Unlock()
+	_, ok := jm.jobs[jobID]
+	return ok
+}
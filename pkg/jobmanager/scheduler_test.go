@@ -0,0 +1,125 @@
+package jobmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/storage"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/linuxboot/contest/plugins/storage/memory"
+)
+
+type firing struct {
+	scheduleID types.ScheduleID
+	fireTime   time.Time
+}
+
+func TestSchedulerOverlapPolicies(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	clk := clock.NewMock()
+	clk.Set(time.Date(2023, 3, 1, 10, 0, 30, 0, time.UTC))
+
+	st, err := memory.New()
+	require.NoError(t, err)
+	vault := storage.NewSimpleEngineVault()
+	require.NoError(t, vault.StoreEngine(st, storage.SyncEngine))
+	require.NoError(t, vault.StoreEngine(st, storage.AsyncEngine))
+	jm := &JobManager{
+		config:             getConfig(OptionClock(clk)),
+		jobs:               make(map[types.JobID]*jobInfo),
+		ssm:                storage.NewScheduleStorageManager(vault),
+		frameworkEvManager: storage.NewFrameworkEventEmitterFetcher(vault),
+	}
+
+	addSchedule := func(serverID string, policy job.OverlapPolicy) types.ScheduleID {
+		id, err := jm.ssm.StoreSchedule(ctx, &job.Schedule{ServerID: serverID, CronExpr: "*/5 * * * *", OverlapPolicy: policy})
+		require.NoError(t, err)
+		return id
+	}
+	skipID := addSchedule("server", job.OverlapSkip)
+	queueID := addSchedule("server", job.OverlapQueue)
+	cancelID := addSchedule("server", job.OverlapCancelPrevious)
+	addSchedule("other-server", job.OverlapSkip)
+
+	// Fake firings start jobs which run until they are removed from jm.jobs.
+	var (
+		firings   []firing
+		cancelled []types.JobID
+		lastJobID types.JobID
+	)
+	state := schedulerState{
+		next:     make(map[types.ScheduleID]time.Time),
+		deferred: make(map[types.ScheduleID]time.Time),
+		fire: func(ctx xcontext.Context, schedule *job.Schedule, fireTime time.Time) error {
+			// Jobs are created without holding the schedules lock.
+			require.True(t, jm.schedulesMu.TryLock())
+			jm.schedulesMu.Unlock()
+			firings = append(firings, firing{schedule.ID, fireTime})
+			lastJobID++
+			jobID := lastJobID
+			jm.jobs[jobID] = &jobInfo{cancel: func() { cancelled = append(cancelled, jobID) }}
+			schedule.LastFireTime = fireTime
+			schedule.LastJobID = jobID
+			return jm.ssm.UpdateSchedule(ctx, schedule)
+		},
+	}
+	pass := func() []firing {
+		firings = nil
+		jm.fireSchedules(ctx, "server", &state)
+		return firings
+	}
+
+	// Nothing fires before the first activation time.
+	require.Empty(t, pass())
+	clk.Add(4 * time.Minute)
+	require.Empty(t, pass())
+
+	// 10:05, all the schedules of this server fire.
+	first := time.Date(2023, 3, 1, 10, 5, 0, 0, time.UTC)
+	clk.Add(time.Minute)
+	require.Equal(t, []firing{{skipID, first}, {queueID, first}, {cancelID, first}}, pass())
+
+	// 10:10, the jobs of the previous firings are still running.
+	second := time.Date(2023, 3, 1, 10, 10, 0, 0, time.UTC)
+	clk.Add(5 * time.Minute)
+	require.Empty(t, pass())
+	require.Equal(t, []types.JobID{3}, cancelled)
+	// The previous job of the cancel-previous schedule is cancelled only once.
+	clk.Add(10 * time.Second)
+	require.Empty(t, pass())
+	require.Equal(t, []types.JobID{3}, cancelled)
+
+	// Once jobs terminate, deferred firings happen. The skipped one is lost.
+	delete(jm.jobs, 1)
+	delete(jm.jobs, 2)
+	delete(jm.jobs, 3)
+	require.Equal(t, []firing{{queueID, second}, {cancelID, second}}, pass())
+
+	// Paused schedules don't fire.
+	delete(jm.jobs, 4)
+	delete(jm.jobs, 5)
+	schedule, err := jm.ssm.GetSchedule(ctx, skipID)
+	require.NoError(t, err)
+	schedule.Paused = true
+	require.NoError(t, jm.ssm.UpdateSchedule(ctx, schedule))
+	require.NoError(t, jm.ssm.DeleteSchedule(ctx, cancelID))
+	third := time.Date(2023, 3, 1, 10, 15, 0, 0, time.UTC)
+	clk.Add(5 * time.Minute)
+	require.Equal(t, []firing{{queueID, third}}, pass())
+	_, known := state.next[skipID]
+	require.False(t, known)
+	_, known = state.next[cancelID]
+	require.False(t, known)
+
+	schedule, err = jm.ssm.GetSchedule(ctx, queueID)
+	require.NoError(t, err)
+	require.Equal(t, third, schedule.LastFireTime)
+	require.Equal(t, types.JobID(6), schedule.LastJobID)
+}
@@ -19,61 +19,83 @@ import (
 func (jm *JobManager) start(ev *api.Event) *api.EventResponse {
 	msg := ev.Msg.(api.EventStartMsg)
 
+	j, err := jm.createJob(ev.Context, msg.JobDescriptor, string(ev.Msg.Requestor()), ev.ServerID)
+	if err != nil {
+		return &api.EventResponse{
+			Requestor: ev.Msg.Requestor(),
+			Err:       err,
+		}
+	}
+
+	jm.startJob(ev.Context, j, nil)
+
+	return &api.EventResponse{
+		JobID:     j.ID,
+		Requestor: ev.Msg.Requestor(),
+		Err:       nil,
+		Status: &job.Status{
+			Name:      j.Name,
+			State:     string(job.EventJobStarted),
+			StartTime: time.Now(),
+		},
+	}
+}
+
+// newJob validates a job descriptor submitted by a user and builds the
+// corresponding job. It also returns the descriptor as it has to be stored in
+// the job request.
+func (jm *JobManager) newJob(ctx xcontext.Context, jobDescriptor string) (*job.Job, string, error) {
 	var jd job.Descriptor
-	if err := json.Unmarshal([]byte(msg.JobDescriptor), &jd); err != nil {
-		return &api.EventResponse{Err: err}
+	if err := json.Unmarshal([]byte(jobDescriptor), &jd); err != nil {
+		return nil, "", err
 	}
 	// Check the compatibility of the JobDescriptor
 	if err := jd.CheckVersion(); err != nil {
-		return &api.EventResponse{Err: err}
+		return nil, "", err
 	}
 	if err := job.CheckTags(jd.Tags, false /* allowInternal */); err != nil {
-		return &api.EventResponse{Err: err}
+		return nil, "", err
 	}
 	// Add instance tag, if specified.
 	if jm.config.instanceTag != "" {
 		jd.Tags = job.AddTags(jd.Tags, jm.config.instanceTag)
 	}
-	j, err := NewJobFromDescriptor(ev.Context, jm.pluginRegistry, &jd)
+	j, err := NewJobFromDescriptor(ctx, jm.pluginRegistry, &jd)
 	if err != nil {
-		return &api.EventResponse{Err: err}
+		return nil, "", err
 	}
 	jdJSON, err := json.MarshalIndent(&jd, "", "    ")
 	if err != nil {
-		return &api.EventResponse{Err: err}
+		return nil, "", err
+	}
+	return j, string(jdJSON), nil
+}
+
+// createJob builds a job from its descriptor and stores the job request, which
+// assigns the job ID. The job is not started.
+func (jm *JobManager) createJob(ctx xcontext.Context, jobDescriptor, requestor, serverID string) (*job.Job, error) {
+	j, jdJSON, err := jm.newJob(ctx, jobDescriptor)
+	if err != nil {
+		return nil, err
 	}
 
 	// The job descriptor has been validated correctly, now use the JobRequestEmitter
 	// interface to obtain a JobRequest object with a valid id
 	request := job.Request{
 		JobName:            j.Name,
-		JobDescriptor:      string(jdJSON),
+		JobDescriptor:      jdJSON,
 		ExtendedDescriptor: j.ExtendedDescriptor,
-		Requestor:          string(ev.Msg.Requestor()),
-		ServerID:           ev.ServerID,
+		Requestor:          requestor,
+		ServerID:           serverID,
 		RequestTime:        time.Now(),
 	}
-	jobID, err := jm.jsm.StoreJobRequest(ev.Context, &request)
+	jobID, err := jm.jsm.StoreJobRequest(ctx, &request)
 	if err != nil {
-		return &api.EventResponse{
-			Requestor: ev.Msg.Requestor(),
-			Err:       fmt.Errorf("could not create job request: %v", err)}
+		return nil, fmt.Errorf("could not create job request: %v", err)
 	}
-
 	j.ID = jobID
-
-	jm.startJob(ev.Context, j, nil)
-
-	return &api.EventResponse{
-		JobID:     j.ID,
-		Requestor: ev.Msg.Requestor(),
-		Err:       nil,
-		Status: &job.Status{
-			Name:      j.Name,
-			State:     string(job.EventJobStarted),
-			StartTime: time.Now(),
-		},
-	}
+	j.Requestor = requestor
+	return j, nil
 }
 
 func (jm *JobManager) startJob(ctx xcontext.Context, j *job.Job, resumeState *job.PauseEventPayload) {
@@ -82,22 +104,35 @@ func (jm *JobManager) startJob(ctx xcontext.Context, j *job.Job, resumeState *jo
 	jobCtx, jobCancel := xcontext.WithCancel(ctx)
 	jobCtx, jobPause := xcontext.WithNotify(jobCtx, xcontext.ErrPaused)
 	jm.jobs[j.ID] = &jobInfo{job: j, pause: jobPause, cancel: jobCancel}
+	if jm.pausing {
+		jobPause()
+	}
 	go jm.runJob(jobCtx, j, resumeState)
 }
 
 func (jm *JobManager) runJob(ctx xcontext.Context, j *job.Job, resumeState *job.PauseEventPayload) {
+	// preempted is set if the job was paused for a job of higher priority, it
+	// is resumed right away to wait for targets again.
+	var preempted bool
 	defer func() {
+		if preempted {
+			// The entry of the job is replaced by the resumed one, which
+			// starts from the base context of the jobs rather than the one
+			// of the run which just finished.
+			err := jm.resumeJob(jm.jobsCtx, j.ID)
+			if err == nil {
+				return
+			}
+			_ = jm.emitErrEvent(ctx, j.ID, job.EventJobFailed, fmt.Errorf("Job %d failed resuming after preemption: %w", j.ID, err))
+		}
 		jm.jobsMu.Lock()
 		delete(jm.jobs, j.ID)
 		jm.jobsMu.Unlock()
 	}()
 
-	if metrics := ctx.Metrics(); metrics != nil {
-		// reflect the number of running jobs
-		metrics.IntGauge(perf.RUNNING_JOBS).Add(1)
-		//, when the job is done decrement the counter
-		defer metrics.IntGauge(perf.RUNNING_JOBS).Add(-1)
-	}
+	// reflect the number of running jobs, when the job is done decrement the counter
+	perf.AddJobs(ctx.Metrics(), "running", 1)
+	defer perf.AddJobs(ctx.Metrics(), "running", -1)
 
 	ctx = ctx.WithField("job_id", j.ID)
 
@@ -112,14 +147,28 @@ func (jm *JobManager) runJob(ctx xcontext.Context, j *job.Job, resumeState *job.
 	ctx.Debugf("Job %d: runner finished, err %v", j.ID, err)
 	switch err {
 	case xcontext.ErrCanceled:
+		jobFinished(ctx, "cancelled")
 		_ = jm.emitEvent(ctx, j.ID, job.EventJobCancelled)
 		return
 	case xcontext.ErrPaused:
+		jm.jobsMu.Lock()
+		if ji := jm.jobs[j.ID]; ji != nil && ji.pauseRequested {
+			resumeState.ResumeOnRequest = true
+		}
+		jm.jobsMu.Unlock()
 		if err := jm.emitEventPayload(ctx, j.ID, job.EventJobPaused, resumeState); err != nil {
+			jobFinished(ctx, "pause_failed")
 			_ = jm.emitErrEvent(ctx, j.ID, job.EventJobPauseFailed, fmt.Errorf("Job %+v failed pausing: %v", j, err))
 		} else {
+			jobFinished(ctx, "paused")
 			ctx.Infof("Successfully paused job %d (run %d, %d targets)", j.ID, resumeState.RunID, len(resumeState.Targets))
 			ctx.Debugf("Job %d pause state: %+v", j.ID, resumeState)
+			select {
+			case <-ctx.Until(xcontext.ErrPaused):
+				// the job manager is pausing too, the job resumes with the server
+			default:
+				preempted = resumeState.Preempted && !resumeState.ResumeOnRequest
+			}
 		}
 		return
 	}
@@ -128,6 +177,7 @@ func (jm *JobManager) runJob(ctx xcontext.Context, j *job.Job, resumeState *job.
 		// We were asked to pause but failed to do so.
 		pauseErr := fmt.Errorf("Job %+v failed pausing: %v", j, err)
 		ctx.Errorf("%v", pauseErr)
+		jobFinished(ctx, "pause_failed")
 		_ = jm.emitErrEvent(ctx, j.ID, job.EventJobPauseFailed, pauseErr)
 		return
 	default:
@@ -136,8 +186,10 @@ func (jm *JobManager) runJob(ctx xcontext.Context, j *job.Job, resumeState *job.
 	// at this point it is safe to emit the job status event. Note: this is
 	// checking `err` from the `jm.jobRunner.Run()` call above.
 	if err != nil {
+		jobFinished(ctx, "failed")
 		_ = jm.emitErrEvent(ctx, j.ID, job.EventJobFailed, fmt.Errorf("Job %d failed after %s: %w", j.ID, duration, err))
 	} else {
+		jobFinished(ctx, "completed")
 		ctx.Infof("Job %+v completed after %s", j, duration)
 		err = jm.emitEvent(ctx, j.ID, job.EventJobCompleted)
 		if err != nil {
@@ -145,3 +197,10 @@ func (jm *JobManager) runJob(ctx xcontext.Context, j *job.Job, resumeState *job.
 		}
 	}
 }
+
+// jobFinished counts a job which stopped running in the given state.
+func jobFinished(ctx xcontext.Context, state string) {
+	if metrics := ctx.Metrics(); metrics != nil {
+		metrics.WithTags(nil).WithTag("state", state).Count(perf.FINISHED_JOBS).Add(1)
+	}
+}
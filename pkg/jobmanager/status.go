@@ -87,7 +87,11 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 	var lastJobEventIdx int
 	for idx, ev := range jobEvents {
 		if ev.EventName == job.EventJobStarted {
-			startTime = ev.EmitTime
+			// jobs are started again when they leave the acquisition queue or
+			// are resumed, the start time is the first one
+			if startTime.IsZero() || ev.EmitTime.Before(startTime) {
+				startTime = ev.EmitTime
+			}
 		} else if _, ok := completionEvents[ev.EventName]; ok {
 			// A completion event has been seen for this Job. Only one completion event can be associated to the job
 			if endTime != nil && !endTime.IsZero() {
@@ -138,6 +142,39 @@ func (jm *JobManager) status(ev *api.Event) *api.EventResponse {
 		JobReport:   report,
 	}
 
+	// Link to the original job, if this one is a retry, or to the schedule
+	// which started it.
+	originEvents, err := jm.frameworkEvManager.Fetch(ctx,
+		frameworkevent.QueryJobID(jobID),
+		frameworkevent.QueryEventNames([]event.Name{job.EventJobRetry, job.EventJobScheduled}),
+	)
+	if err != nil {
+		evResp.Err = fmt.Errorf("could not fetch retry events: %v", err)
+		return &evResp
+	}
+	for _, originEv := range originEvents {
+		if originEv.Payload == nil {
+			continue
+		}
+		switch originEv.EventName {
+		case job.EventJobRetry:
+			var rp job.RetryEventPayload
+			if err := json.Unmarshal(*originEv.Payload, &rp); err != nil {
+				evResp.Err = fmt.Errorf("invalid retry event payload for job %d: %w", jobID, err)
+				return &evResp
+			}
+			jobStatus.RetryOf = rp.RetryOf
+		case job.EventJobScheduled:
+			var sp job.ScheduledEventPayload
+			if err := json.Unmarshal(*originEv.Payload, &sp); err != nil {
+				evResp.Err = fmt.Errorf("invalid scheduled event payload for job %d: %w", jobID, err)
+				return &evResp
+			}
+			jobStatus.ScheduledBy = sp.ScheduleID
+		}
+	}
+	jobStatus.QueuePosition = jm.jobRunner.QueuePosition(jobID)
+
 	jobStatus.RunStatuses, err = jm.jobRunner.BuildRunStatuses(ctx, currentJob)
 	if err != nil {
 		evResp.Err = fmt.Errorf("could not rebuild the statuses of the job: %v", err)
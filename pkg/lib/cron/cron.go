@@ -0,0 +1,246 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package cron implements parsing of cron expressions and the computation of
+// their activation times. The standard five fields format is supported:
+//
+//	minute hour day-of-month month day-of-week
+//
+// Each field accepts "*", single values, ranges ("1-5"), lists ("1,15,30")
+// and steps ("*/10", "0-30/5"). Months and days of the week can also be
+// referred to by their three letters English name ("jan", "mon"), and
+// Sunday is both 0 and 7. As in traditional cron, when both day-of-month and
+// day-of-week are restricted, a day matches if either of them matches. Only a
+// plain "*" leaves a field unrestricted, a step such as "*/2" restricts it.
+// The "@yearly", "@annually", "@monthly", "@weekly", "@daily", "@midnight"
+// and "@hourly" shortcuts are supported as well.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSearchYears bounds the search for the next activation time, so that
+// expressions which can never match (e.g. "0 0 30 2 *") terminate.
+const maxSearchYears = 5
+
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// bitset holds the values matched by a field, one bit per value.
+type bitset uint64
+
+func (b bitset) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day-of-month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: monthNames}
+	// day-of-week accepts 7 as an alias for Sunday, it is folded into 0 after
+	// parsing.
+	dowField = field{name: "day-of-week", min: 0, max: 7, names: dayNames}
+)
+
+// Expression is a parsed cron expression.
+type Expression struct {
+	expr    string
+	minute  bitset
+	hour    bitset
+	dom     bitset
+	month   bitset
+	dow     bitset
+	domStar bool
+	dowStar bool
+}
+
+// Parse parses a cron expression.
+func Parse(expr string) (*Expression, error) {
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@") {
+		s, ok := shortcuts[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron shortcut %q", spec)
+		}
+		spec = s
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+	e := Expression{expr: expr}
+	var err error
+	if e.minute, _, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if e.hour, _, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if e.dom, e.domStar, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if e.month, _, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if e.dow, e.dowStar, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if e.dow.has(7) {
+		e.dow = (e.dow &^ (1 << 7)) | 1
+	}
+	return &e, nil
+}
+
+// String returns the expression as it was passed to Parse.
+func (e *Expression) String() string {
+	return e.expr
+}
+
+// Next returns the first activation time strictly after t, in t's location.
+// The zero time is returned if the expression never matches.
+func (e *Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	yearLimit := t.Year() + maxSearchYears
+
+	// Every time a field wraps around, the more significant fields must be
+	// checked again.
+search:
+	for t.Year() <= yearLimit {
+		for !e.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+		for !e.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+		for !e.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			if t.Hour() == 0 {
+				continue search
+			}
+		}
+		for !e.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue search
+			}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (e *Expression) dayMatches(t time.Time) bool {
+	domMatch := e.dom.has(t.Day())
+	dowMatch := e.dow.has(int(t.Weekday()))
+	if !e.domStar && !e.dowStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// parse parses a field of a cron expression. It returns the matched values,
+// and whether the field is a plain "*".
+func (f field) parse(s string) (bitset, bool, error) {
+	var bits bitset
+	for _, item := range strings.Split(s, ",") {
+		b, err := f.parseItem(item)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s field %q: %w", f.name, s, err)
+		}
+		bits |= b
+	}
+	return bits, s == "*", nil
+}
+
+func (f field) parseItem(item string) (bitset, error) {
+	rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+	step := 1
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+			return 0, fmt.Errorf("invalid step %q", stepSpec)
+		}
+	}
+	var lo, hi int
+	switch {
+	case rangeSpec == "*":
+		lo, hi = f.min, f.max
+	case strings.Contains(rangeSpec, "-"):
+		loSpec, hiSpec, _ := strings.Cut(rangeSpec, "-")
+		var err error
+		if lo, err = f.value(loSpec); err != nil {
+			return 0, err
+		}
+		if hi, err = f.value(hiSpec); err != nil {
+			return 0, err
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("invalid range %q", rangeSpec)
+		}
+	default:
+		var err error
+		if lo, err = f.value(rangeSpec); err != nil {
+			return 0, err
+		}
+		hi = lo
+		// "5/15" means "from 5 to the end, every 15"
+		if hasStep {
+			hi = f.max
+		}
+	}
+	var bits bitset
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return v, nil
+}
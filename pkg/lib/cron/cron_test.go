@@ -0,0 +1,76 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	ts, err := time.Parse("2006-01-02 15:04", s)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestNext(t *testing.T) {
+	scenarios := []struct {
+		expr string
+		from string
+		next string
+	}{
+		{"* * * * *", "2023-03-01 10:00", "2023-03-01 10:01"},
+		{"*/15 * * * *", "2023-03-01 10:07", "2023-03-01 10:15"},
+		{"5/20 * * * *", "2023-03-01 10:45", "2023-03-01 11:05"},
+		{"0 2 * * *", "2023-03-01 10:00", "2023-03-02 02:00"},
+		{"30 1-3 * * *", "2023-03-01 02:30", "2023-03-01 03:30"},
+		{"0 0 1 * *", "2023-12-15 00:00", "2024-01-01 00:00"},
+		{"0 0 29 2 *", "2023-01-01 00:00", "2024-02-29 00:00"},
+		{"0 9 * * mon-fri", "2023-03-03 10:00", "2023-03-06 09:00"},
+		{"0 0 * * 7", "2023-03-01 00:00", "2023-03-05 00:00"},
+		{"0 0 * jan,jul *", "2023-03-01 00:00", "2023-07-01 00:00"},
+		// both day-of-month and day-of-week restricted: either matches
+		{"0 0 13 * fri", "2023-03-01 00:00", "2023-03-03 00:00"},
+		// a step over "*" is a restriction too
+		{"0 0 13 * */2", "2023-03-01 00:00", "2023-03-02 00:00"},
+		{"0 0 */2 * mon", "2023-03-01 00:00", "2023-03-03 00:00"},
+		{"@hourly", "2023-03-01 10:59", "2023-03-01 11:00"},
+		{"@weekly", "2023-03-01 10:00", "2023-03-05 00:00"},
+	}
+	for _, s := range scenarios {
+		e, err := Parse(s.expr)
+		require.NoError(t, err, s.expr)
+		require.Equal(t, mustTime(t, s.next), e.Next(mustTime(t, s.from)), s.expr)
+	}
+}
+
+func TestNextNeverMatches(t *testing.T) {
+	e, err := Parse("0 0 30 2 *")
+	require.NoError(t, err)
+	require.True(t, e.Next(mustTime(t, "2023-01-01 00:00")).IsZero())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+	}
+}
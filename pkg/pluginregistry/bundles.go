@@ -20,7 +20,11 @@ func (r *PluginRegistry) NewTestStepBundle(ctx xcontext.Context, testStepDescrip
 	if err != nil {
 		return nil, fmt.Errorf("could not get the desired TestStep (%s): %v", testStepDescriptor.Name, err)
 	}
-	if err := testStep.ValidateParameters(ctx, testStepDescriptor.Parameters); err != nil {
+	parameters, err := r.testStepParameters(testStepDescriptor.Name, testStepDescriptor.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("could not get parameters for test step %s: %v", testStepDescriptor.Name, err)
+	}
+	if err := testStep.ValidateParameters(ctx, parameters); err != nil {
 		return nil, fmt.Errorf("could not validate parameters for test step %s: %v", testStepDescriptor.Name, err)
 	}
 	allowedEvents, err := r.NewTestStepEvents(testStepDescriptor.Name)
@@ -34,8 +38,15 @@ func (r *PluginRegistry) NewTestStepBundle(ctx xcontext.Context, testStepDescrip
 	testStepBundle := test.TestStepBundle{
 		TestStep:      testStep,
 		TestStepLabel: label,
-		Parameters:    testStepDescriptor.Parameters,
+		Parameters:    parameters,
 		AllowedEvents: allowedEvents,
+		OnSuccess:     testStepDescriptor.OnSuccess,
+		OnFailure:     testStepDescriptor.OnFailure,
+		Finally:       testStepDescriptor.Finally,
+		MaxVisits:     testStepDescriptor.MaxVisits,
+		Retry:         testStepDescriptor.Retry,
+		MaxParallel:   testStepDescriptor.MaxParallel,
+		Resources:     testStepDescriptor.Resources,
 	}
 	return &testStepBundle, nil
 }
@@ -0,0 +1,106 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package pluginregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linuxboot/contest/pkg/test"
+)
+
+// AllTestSteps is the name under which defaults applying to all the test steps
+// are set with SetTestStepDefaults.
+const AllTestSteps = "*"
+
+// SetTestStepDefaults sets the default parameters of the test steps, by test
+// step name. They are applied to the test step descriptors when building the
+// test step bundles:
+//   - a parameter missing from the descriptor takes its default values;
+//   - if both the descriptor and the default have a single value, and both are
+//     JSON objects, they are merged recursively, the descriptor taking
+//     precedence. This allows e.g. to set a default "identity_file" in the
+//     options of the "transport" parameter.
+//
+// Defaults set for AllTestSteps are only merged into parameters which are in
+// the descriptor, as not all test steps accept the same parameters. Defaults
+// set for a test step take precedence over the ones for AllTestSteps.
+func (r *PluginRegistry) SetTestStepDefaults(defaults map[string]test.TestStepParameters) {
+	lowered := make(map[string]test.TestStepParameters, len(defaults))
+	for name, params := range defaults {
+		lowered[strings.ToLower(name)] = params
+	}
+	r.lock.Lock()
+	r.testStepDefaults = lowered
+	r.lock.Unlock()
+}
+
+// testStepParameters returns the parameters of a test step descriptor with the
+// defaults of the test step applied.
+func (r *PluginRegistry) testStepParameters(pluginName string, params test.TestStepParameters) (test.TestStepParameters, error) {
+	r.lock.RLock()
+	stepDefaults := r.testStepDefaults[strings.ToLower(pluginName)]
+	allDefaults := r.testStepDefaults[AllTestSteps]
+	r.lock.RUnlock()
+	if len(stepDefaults) == 0 && len(allDefaults) == 0 {
+		return params, nil
+	}
+
+	res := make(test.TestStepParameters, len(params))
+	for k, v := range params {
+		res[k] = v
+	}
+	for _, d := range []struct {
+		defaults   test.TestStepParameters
+		addMissing bool
+	}{
+		{stepDefaults, true},
+		{allDefaults, false},
+	} {
+		for k, defaultValues := range d.defaults {
+			values, ok := res[k]
+			if !ok {
+				if d.addMissing {
+					res[k] = defaultValues
+				}
+				continue
+			}
+			if len(values) != 1 || len(defaultValues) != 1 {
+				continue
+			}
+			merged, err := mergeJSONObjects(values[0].JSON(), defaultValues[0].JSON())
+			if err != nil {
+				return nil, fmt.Errorf("could not apply defaults to parameter %q: %w", k, err)
+			}
+			res[k] = []test.Param{{RawMessage: merged}}
+		}
+	}
+	return res, nil
+}
+
+// mergeJSONObjects merges the fields of defaults into value if both are JSON
+// objects, recursively. Otherwise value is returned unchanged.
+func mergeJSONObjects(value, defaults json.RawMessage) (json.RawMessage, error) {
+	var valueObj, defaultsObj map[string]json.RawMessage
+	if json.Unmarshal(value, &valueObj) != nil || json.Unmarshal(defaults, &defaultsObj) != nil ||
+		valueObj == nil || defaultsObj == nil {
+		return value, nil
+	}
+	for k, d := range defaultsObj {
+		v, ok := valueObj[k]
+		if !ok {
+			valueObj[k] = d
+			continue
+		}
+		merged, err := mergeJSONObjects(v, d)
+		if err != nil {
+			return nil, err
+		}
+		valueObj[k] = merged
+	}
+	return json.Marshal(valueObj)
+}
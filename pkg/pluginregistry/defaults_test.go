@@ -0,0 +1,55 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package pluginregistry
+
+import (
+	"testing"
+
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/test"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestStepDefaults(t *testing.T) {
+	pr := NewPluginRegistry(ctx)
+	require.NoError(t, pr.RegisterTestStep("AStep", NewAStep, []event.Name{}))
+	pr.SetTestStepDefaults(map[string]test.TestStepParameters{
+		AllTestSteps: {
+			"transport": {*test.NewParam(`{"options": {"identity_file": "/id", "user": "root"}}`)},
+			"unrelated": {*test.NewParam(`"x"`)},
+		},
+		"astep": {
+			"timeout": {*test.NewParam(`"5m"`)},
+			"args":    {*test.NewParam(`"-v"`)},
+		},
+	})
+
+	bundle, err := pr.NewTestStepBundle(ctx, test.TestStepDescriptor{
+		Name:  "AStep",
+		Label: "step",
+		Parameters: test.TestStepParameters{
+			"transport": {*test.NewParam(`{"proto": "ssh", "options": {"host": "dut", "user": "admin"}}`)},
+			"args":      {*test.NewParam(`"a"`), *test.NewParam(`"b"`)},
+		},
+	})
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"proto": "ssh", "options": {"host": "dut", "user": "admin", "identity_file": "/id"}}`,
+		string(bundle.Parameters.GetOne("transport").JSON()),
+	)
+	require.Equal(t, "5m", bundle.Parameters.GetOne("timeout").String())
+	require.Len(t, bundle.Parameters.Get("args"), 2)
+	require.NotContains(t, bundle.Parameters, "unrelated")
+}
+
+func TestTestStepDefaultsNone(t *testing.T) {
+	pr := NewPluginRegistry(ctx)
+	params := test.TestStepParameters{"args": {*test.NewParam(`"a"`)}}
+	res, err := pr.testStepParameters("AStep", params)
+	require.NoError(t, err)
+	require.Equal(t, params, res)
+}
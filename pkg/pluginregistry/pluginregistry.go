@@ -40,6 +40,10 @@ type PluginRegistry struct {
 
 	// Reporters collects a mapping of Plugin Name <-> Reporter constructor
 	Reporters map[string]job.ReporterFactory
+
+	// testStepDefaults are the default parameters of the TestSteps, see
+	// SetTestStepDefaults
+	testStepDefaults map[string]test.TestStepParameters
 }
 
 // NewPluginRegistry constructs a new empty plugin registry
@@ -0,0 +1,157 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package resource implements the semaphores limiting how many targets use a
+// resource at the same time, e.g. a shared HwaaS host or a file server. Named
+// resources are configured on the server and shared by all the jobs.
+package resource
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Semaphore counts the units of a resource in use and makes targets wait
+// until a unit is available.
+type Semaphore struct {
+	name string
+
+	mu       sync.Mutex
+	capacity uint
+	used     uint
+	changed  chan struct{} // Closed and replaced whenever a unit may have become available.
+}
+
+// NewSemaphore creates a semaphore with the given number of units.
+func NewSemaphore(name string, capacity uint) *Semaphore {
+	return &Semaphore{
+		name:     name,
+		capacity: capacity,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Name returns the name of the resource.
+func (s *Semaphore) Name() string {
+	return s.name
+}
+
+// Capacity returns the number of units of the resource.
+func (s *Semaphore) Capacity() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.capacity
+}
+
+// InUse returns the number of units currently taken.
+func (s *Semaphore) InUse() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+// TryAcquire takes a unit if one is available and reports whether it did.
+func (s *Semaphore) TryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used >= s.capacity {
+		return false
+	}
+	s.used++
+	return true
+}
+
+// Acquire takes a unit, waiting until one is available. It returns
+// xcontext.ErrPaused or xcontext.ErrCanceled if the context is paused or
+// canceled while waiting.
+func (s *Semaphore) Acquire(ctx xcontext.Context) error {
+	for {
+		s.mu.Lock()
+		if s.used < s.capacity {
+			s.used++
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Until(xcontext.ErrPaused):
+			return xcontext.ErrPaused
+		case <-ctx.Done():
+			return xcontext.ErrCanceled
+		}
+	}
+}
+
+// ForceAcquire takes a unit even if the semaphore is full. It accounts for
+// users which were already holding the resource, e.g. targets which were
+// running when a job was paused, so that new users wait for them.
+func (s *Semaphore) ForceAcquire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used++
+}
+
+// Release gives a unit back and wakes up the waiters.
+func (s *Semaphore) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used == 0 {
+		panic(fmt.Sprintf("resource %q released more times than acquired", s.name))
+	}
+	s.used--
+	s.notifyLocked()
+}
+
+func (s *Semaphore) setCapacity(capacity uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capacity = capacity
+	s.notifyLocked()
+}
+
+func (s *Semaphore) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+var (
+	resourcesMu sync.Mutex
+	resources   = map[string]*Semaphore{}
+)
+
+// SetCapacities configures the named resources of the server. Resources which
+// exist already keep the units in use and only change their capacity, so that
+// the configuration can be reloaded while jobs are running. Resources which
+// are not listed can no longer be looked up.
+func SetCapacities(capacities map[string]uint) {
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	newResources := make(map[string]*Semaphore, len(capacities))
+	for name, capacity := range capacities {
+		s := resources[name]
+		if s == nil {
+			s = NewSemaphore(name, capacity)
+		} else {
+			s.setCapacity(capacity)
+		}
+		newResources[name] = s
+	}
+	resources = newResources
+}
+
+// Get returns the semaphore of a named resource.
+func Get(name string) (*Semaphore, error) {
+	resourcesMu.Lock()
+	defer resourcesMu.Unlock()
+	s, ok := resources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package resource
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+func TestSemaphore(t *testing.T) {
+	s := NewSemaphore("r", 2)
+	require.True(t, s.TryAcquire())
+	require.NoError(t, s.Acquire(xcontext.Background()))
+	require.False(t, s.TryAcquire())
+	require.Equal(t, uint(2), s.InUse())
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- s.Acquire(xcontext.Background())
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a unit of a full semaphore")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.Release()
+	require.NoError(t, <-acquired)
+	require.Equal(t, uint(2), s.InUse())
+
+	s.ForceAcquire()
+	require.Equal(t, uint(3), s.InUse())
+	for i := 0; i < 3; i++ {
+		s.Release()
+	}
+	require.Panics(t, s.Release)
+}
+
+func TestSemaphoreAcquirePausedOrCanceled(t *testing.T) {
+	s := NewSemaphore("r", 1)
+	require.True(t, s.TryAcquire())
+
+	ctx, pause := xcontext.WithNotify(xcontext.Background(), xcontext.ErrPaused)
+	pause()
+	require.Equal(t, xcontext.ErrPaused, s.Acquire(ctx))
+
+	ctx, cancel := xcontext.WithCancel(xcontext.Background())
+	cancel()
+	require.Equal(t, xcontext.ErrCanceled, s.Acquire(ctx))
+	require.Equal(t, uint(1), s.InUse())
+}
+
+func TestSetCapacities(t *testing.T) {
+	defer SetCapacities(nil)
+
+	SetCapacities(map[string]uint{"a": 1, "b": 2})
+	a, err := Get("a")
+	require.NoError(t, err)
+	require.True(t, a.TryAcquire())
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- a.Acquire(xcontext.Background())
+	}()
+	// Reloading keeps the units in use and wakes up the waiters.
+	SetCapacities(map[string]uint{"a": 2})
+	require.NoError(t, <-acquired)
+	reloaded, err := Get("a")
+	require.NoError(t, err)
+	require.Same(t, a, reloaded)
+	require.Equal(t, uint(2), reloaded.InUse())
+
+	_, err = Get("b")
+	require.EqualError(t, err, `unknown resource "b"`)
+}
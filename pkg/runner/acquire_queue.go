@@ -0,0 +1,231 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// acquireRetryInterval is how often the jobs waiting for busy targets try to
+// acquire them again, in case they were released without the queue knowing,
+// e.g. by another server or because their locks expired.
+const acquireRetryInterval = 10 * time.Second
+
+// acquireQueue orders the target acquisitions of the jobs. Jobs take turns to
+// call the Acquire method of their target manager, one at a time, in order of:
+//   - priority, higher first,
+//   - fairness among requestors, the requestor which acquired targets least
+//     recently first,
+//   - arrival, first in first out.
+//
+// A job whose targets are busy waits in the queue, without blocking the jobs
+// behind it, until targets are released. Then all the waiting jobs try again
+// in order, so that the first job of the queue gets the released targets.
+type acquireQueue struct {
+	mu    sync.Mutex
+	clock clock.Clock
+
+	entries []*queueEntry
+	nextSeq uint64
+	// acquiring is set while a job of the queue calls Acquire.
+	acquiring bool
+	// acquisitions counts the successful acquisitions, lastAcquisition records
+	// the count per requestor.
+	acquisitions    uint64
+	lastAcquisition map[string]uint64
+	// changed is closed and replaced whenever a turn ends or targets are
+	// released, to wake up the jobs waiting on it.
+	changed chan struct{}
+}
+
+type queueEntry struct {
+	jobID     types.JobID
+	requestor string
+	priority  int
+	seq       uint64
+	// queued is set once the targets of the job were found busy, and until it
+	// acquires them. Only queued jobs have a position in the queue.
+	queued bool
+	// waiting is set while the job waits for targets to be released, so it is
+	// not given a turn.
+	waiting bool
+}
+
+func newAcquireQueue(clk clock.Clock) *acquireQueue {
+	return &acquireQueue{
+		clock:           clk,
+		lastAcquisition: make(map[string]uint64),
+		changed:         make(chan struct{}),
+	}
+}
+
+// broadcast wakes up all the waiting jobs. It must be called with q.mu held.
+func (q *acquireQueue) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// before returns whether a goes before b in the queue. It must be called with
+// q.mu held.
+func (q *acquireQueue) before(a, b *queueEntry) bool {
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if la, lb := q.lastAcquisition[a.requestor], q.lastAcquisition[b.requestor]; la != lb {
+		return la < lb
+	}
+	return a.seq < b.seq
+}
+
+// sort sorts the entries in queue order. It must be called with q.mu held.
+func (q *acquireQueue) sort() {
+	sort.SliceStable(q.entries, func(i, j int) bool {
+		return q.before(q.entries[i], q.entries[j])
+	})
+}
+
+// add enqueues a job which is about to acquire targets.
+func (q *acquireQueue) add(j *job.Job) *queueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.nextSeq++
+	e := &queueEntry{jobID: j.ID, requestor: j.Requestor, priority: j.Priority, seq: q.nextSeq}
+	q.entries = append(q.entries, e)
+	return e
+}
+
+// remove dequeues a job, whether it acquired its targets or gave up.
+func (q *acquireQueue) remove(e *queueEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, other := range q.entries {
+		if other == e {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			break
+		}
+	}
+	q.broadcast()
+}
+
+// waitTurn waits until it is the turn of the job to call Acquire, that is no
+// other job is acquiring targets and the job is the first of the queue which
+// is not waiting for targets to be released. Every successful call must be
+// followed by a call to endTurn.
+func (q *acquireQueue) waitTurn(ctx xcontext.Context, e *queueEntry) error {
+	for {
+		q.mu.Lock()
+		if !q.acquiring && q.firstReady() == e {
+			q.acquiring = true
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Until(xcontext.ErrPaused):
+			return xcontext.ErrPaused
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// firstReady returns the first entry of the queue which is not waiting for
+// targets to be released. It must be called with q.mu held.
+func (q *acquireQueue) firstReady() *queueEntry {
+	q.sort()
+	for _, e := range q.entries {
+		if !e.waiting {
+			return e
+		}
+	}
+	return nil
+}
+
+// endTurn ends the turn of the job. If the targets were busy, the job is
+// queued and waits for targets to be released before its next turn.
+func (q *acquireQueue) endTurn(e *queueEntry, acquired bool, busy bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.acquiring = false
+	if acquired {
+		q.acquisitions++
+		q.lastAcquisition[e.requestor] = q.acquisitions
+		e.queued = false
+	}
+	if busy {
+		e.queued = true
+		e.waiting = true
+	}
+	q.broadcast()
+}
+
+// waitRelease waits until targets are released, or for timeout at most. The
+// job is then given turns again.
+func (q *acquireQueue) waitRelease(ctx xcontext.Context, e *queueEntry, timeout time.Duration) error {
+	defer func() {
+		q.mu.Lock()
+		e.waiting = false
+		q.mu.Unlock()
+	}()
+	timer := q.clock.Timer(timeout)
+	defer timer.Stop()
+	for {
+		q.mu.Lock()
+		waiting, changed := e.waiting, q.changed
+		q.mu.Unlock()
+		if !waiting {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil
+		case <-ctx.Until(xcontext.ErrPaused):
+			return xcontext.ErrPaused
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// targetsReleased gives turns to all the jobs waiting for targets.
+func (q *acquireQueue) targetsReleased() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.entries {
+		e.waiting = false
+	}
+	q.broadcast()
+}
+
+// position returns the 1-based position of a job among the queued jobs, zero
+// if it is not queued.
+func (q *acquireQueue) position(jobID types.JobID) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sort()
+	position := 0
+	for _, e := range q.entries {
+		if !e.queued {
+			continue
+		}
+		position++
+		if e.jobID == jobID {
+			return position
+		}
+	}
+	return 0
+}
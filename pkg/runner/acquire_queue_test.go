@@ -0,0 +1,145 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// acquireTurn takes and ends a turn for the entry.
+func acquireTurn(t *testing.T, q *acquireQueue, e *queueEntry, acquired bool) {
+	require.NoError(t, q.waitTurn(xcontext.Background(), e))
+	q.endTurn(e, acquired, !acquired)
+}
+
+func TestAcquireQueueOrder(t *testing.T) {
+	q := newAcquireQueue(clock.NewMock())
+	newJob := func(id types.JobID, requestor string, priority int) *job.Job {
+		return &job.Job{ID: id, Requestor: requestor, Priority: priority}
+	}
+
+	// alice acquired targets before, so bob goes first within a priority
+	first := q.add(newJob(1, "alice", 0))
+	acquireTurn(t, q, first, true)
+	q.remove(first)
+
+	entries := []*queueEntry{
+		q.add(newJob(2, "alice", 0)),
+		q.add(newJob(3, "alice", 0)),
+		q.add(newJob(4, "bob", 0)),
+		q.add(newJob(5, "carol", 10)),
+	}
+	for _, e := range entries {
+		require.Equal(t, 0, q.position(e.jobID))
+	}
+	// all the targets are busy
+	for _, want := range []types.JobID{5, 4, 2, 3} {
+		q.mu.Lock()
+		e := q.firstReady()
+		q.mu.Unlock()
+		require.Equal(t, want, e.jobID)
+		acquireTurn(t, q, e, false)
+	}
+	require.Nil(t, q.firstReady())
+	for position, jobID := range []types.JobID{5, 4, 2, 3} {
+		require.Equal(t, position+1, q.position(jobID))
+	}
+	require.Equal(t, 0, q.position(1))
+
+	// job 3 gives up, and jobs behind it move up
+	q.remove(entries[1])
+	require.Equal(t, 0, q.position(3))
+	require.Equal(t, 3, q.position(2))
+}
+
+func TestAcquireQueueWaitRelease(t *testing.T) {
+	q := newAcquireQueue(clock.NewMock())
+	ctx := xcontext.Background()
+	busy := q.add(&job.Job{ID: 1, Requestor: "alice", Priority: 10})
+	acquireTurn(t, q, busy, false)
+
+	// the busy job does not block the jobs behind it
+	other := q.add(&job.Job{ID: 2, Requestor: "bob"})
+	acquireTurn(t, q, other, true)
+	q.remove(other)
+
+	released := make(chan error, 1)
+	go func() {
+		released <- q.waitRelease(ctx, busy, time.Hour)
+	}()
+	select {
+	case <-released:
+		t.Fatal("waitRelease returned before targets were released")
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.targetsReleased()
+	require.NoError(t, <-released)
+	require.Equal(t, 1, q.position(1))
+	acquireTurn(t, q, busy, true)
+	require.Equal(t, 0, q.position(1))
+}
+
+func TestAcquireQueuePause(t *testing.T) {
+	q := newAcquireQueue(clock.NewMock())
+	ctx, pause := xcontext.WithNotify(xcontext.Background(), xcontext.ErrPaused)
+	first := q.add(&job.Job{ID: 1})
+	second := q.add(&job.Job{ID: 2})
+	require.NoError(t, q.waitTurn(ctx, first))
+
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- q.waitTurn(ctx, second)
+	}()
+	pause()
+	require.Equal(t, xcontext.ErrPaused, <-waitErr)
+}
+
+func TestPreemptFor(t *testing.T) {
+	jr := NewJobRunner(nil, nil, clock.NewMock(), time.Minute)
+	preempted := make(map[types.JobID]bool)
+	addJob := func(id types.JobID, priority int, targetIDs ...string) {
+		ji := &jobInfo{jobID: id, priority: priority, pause: func() { preempted[id] = true }}
+		for _, targetID := range targetIDs {
+			ji.targets = append(ji.targets, &target.Target{ID: targetID})
+		}
+		jr.jobsMap[id] = ji
+	}
+	addJob(1, 0, "t1", "t2")
+	addJob(2, -1, "t3")
+	addJob(3, 0, "t4")
+	addJob(4, 5, "t5")
+	addJob(5, -1, "t6")
+
+	// job 2 has the lowest priority, then job 3 is the latest of priority 0
+	j := &job.Job{ID: 6, Priority: 5}
+	jr.preemptFor(xcontext.Background(), j, &target.BusyError{
+		TargetIDs: []string{"t1", "t2", "t3", "t4", "t5"},
+		Needed:    2,
+	})
+	require.Equal(t, map[types.JobID]bool{2: true, 3: true}, preempted)
+
+	// jobs being preempted count as releasing their targets
+	jr.preemptFor(xcontext.Background(), j, &target.BusyError{TargetIDs: []string{"t1", "t3", "t5"}, Needed: 1})
+	require.Equal(t, map[types.JobID]bool{2: true, 3: true}, preempted)
+	jr.preemptFor(xcontext.Background(), j, &target.BusyError{TargetIDs: []string{"t1", "t3", "t5"}})
+	require.Equal(t, map[types.JobID]bool{1: true, 2: true, 3: true}, preempted)
+
+	// paused jobs hold their targets until they are resumed
+	jr.jobsMap[5].paused = true
+	jr.preemptFor(xcontext.Background(), j, &target.BusyError{TargetIDs: []string{"t6"}})
+	require.Equal(t, map[types.JobID]bool{1: true, 2: true, 3: true}, preempted)
+	require.Error(t, jr.Pause(5, false))
+	require.Equal(t, map[types.JobID]bool{1: true, 2: true, 3: true}, preempted)
+}
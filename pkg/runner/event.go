@@ -6,6 +6,8 @@
 package runner
 
 import (
+	"github.com/insomniacslk/xjson"
+
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/types"
 )
@@ -20,3 +22,28 @@ var EventRunStarted = event.Name("RunStarted")
 
 // EventTestError indicates that a test failed.
 var EventTestError = event.Name("TestError")
+
+// EventTargetRetry indicates that a target failed a step and will be injected into it again.
+var EventTargetRetry = event.Name("TargetRetry")
+
+// TargetRetryPayload represents the payload carried by a TargetRetry event.
+// Attempt counts the attempts of the target within the step, it is unrelated
+// to the TestAttempt of the event header, which counts attempts of the whole test.
+type TargetRetryPayload struct {
+	Attempt     uint32
+	MaxAttempts uint32
+	Error       string
+	Delay       xjson.Duration
+}
+
+// EventTargetWaiting indicates that a target waits for a slot of a step or
+// for a resource before being injected into the step.
+var EventTargetWaiting = event.Name("TargetWaiting")
+
+// TargetWaitingPayload represents the payload carried by a TargetWaiting event.
+// Resource is the name of the resource the target waits for, it is empty if
+// the target waits because of the MaxParallel limit of the step.
+type TargetWaitingPayload struct {
+	Resource string `json:",omitempty"`
+	Capacity uint
+}
@@ -7,11 +7,15 @@ package runner
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
@@ -19,6 +23,7 @@ import (
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
 	"github.com/linuxboot/contest/pkg/xcontext/metrics/perf"
@@ -30,6 +35,16 @@ type jobInfo struct {
 	targets   []*target.Target
 	jobCtx    xcontext.Context
 	jobCancel func()
+	priority  int
+	// pause pauses the job. The targets are released if the job was preempted
+	// by a job of higher priority, or if releaseTargets is set.
+	pause          func()
+	preempted      bool
+	releaseTargets bool
+	// paused is set once the job is paused. Its entry is kept while it holds
+	// targets, so that their locks are refreshed, but it cannot be paused or
+	// preempted anymore.
+	paused bool
 }
 
 // JobRunner implements logic to run, cancel and stop Jobs
@@ -56,6 +71,9 @@ type JobRunner struct {
 	// clock is the time measurement device, mocked out in tests.
 	clock clock.Clock
 
+	// acquireQueue orders the target acquisitions of the jobs.
+	acquireQueue *acquireQueue
+
 	stopLockRefresh    chan struct{}
 	lockRefreshStopped chan struct{}
 }
@@ -69,11 +87,21 @@ type JobRunner struct {
 //                   last
 // * []job.Report:   all the final reports
 // * error:          an error, if any
-func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.PauseEventPayload) (*job.PauseEventPayload, error) {
+func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.PauseEventPayload) (pauseState *job.PauseEventPayload, err error) {
 	if resumeState != nil && resumeState.JobID != j.ID {
 		return nil, fmt.Errorf("wrong resume state, job id %d (want %d)", resumeState.JobID, j.ID)
 	}
 
+	ctx, jobSpan := tracing.Start(ctx, "job", tracing.JobID(j.ID), tracing.JobNameKey.String(j.Name))
+	// the span of the current run, if any, is ended with the job
+	var runSpan trace.Span
+	defer func() {
+		if runSpan != nil {
+			tracing.End(runSpan, err)
+		}
+		tracing.End(jobSpan, err)
+	}()
+
 	var (
 		runID           types.RunID = 1
 		testID                      = 1
@@ -87,15 +115,18 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 	ctx = xcontext.WithValue(ctx, types.KeyJobID, j.ID)
 	// .. Fields are for structured logging
 	ctx, jobCancel := xcontext.WithCancel(ctx.WithField("job_id", j.ID))
+	ctx, jobPause := xcontext.WithNotify(ctx, xcontext.ErrPaused)
 
 	jr.jobsMapLock.Lock()
-	jr.jobsMap[j.ID] = &jobInfo{jobID: j.ID, jobCtx: ctx, jobCancel: jobCancel}
+	jr.jobsMap[j.ID] = &jobInfo{jobID: j.ID, jobCtx: ctx, jobCancel: jobCancel, priority: j.Priority, pause: jobPause}
 	jr.jobsMapLock.Unlock()
 	defer func() {
-		if !keepJobEntry {
-			jr.jobsMapLock.Lock()
+		jr.jobsMapLock.Lock()
+		defer jr.jobsMapLock.Unlock()
+		if keepJobEntry {
+			jr.jobsMap[j.ID].paused = true
+		} else {
 			delete(jr.jobsMap, j.ID)
-			jr.jobsMapLock.Unlock()
 		}
 	}()
 
@@ -120,9 +151,23 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 
 	pauseTest := func(runID types.RunID, testID int, testAttempt uint32, targets []*target.Target, testRunnerState json.RawMessage) (*job.PauseEventPayload, error) {
 		ctx.Infof("pause requested for job ID %v", j.ID)
-		// Return without releasing targets and keep the job entry so locks continue to be refreshed
-		// all the way to server exit.
-		keepJobEntry = true
+		jr.jobsMapLock.Lock()
+		preempted, releaseTargets := jr.jobsMap[j.ID].preempted, jr.jobsMap[j.ID].releaseTargets
+		jr.jobsMapLock.Unlock()
+		if preempted || releaseTargets {
+			// Release the targets for other jobs, the test attempt starts over
+			// when the job is resumed.
+			if err := jr.releasePausedTargets(ctx, j, runID, testID, testAttempt, targets); err != nil {
+				ctx.Warnf("Failed to release the targets of paused job %d, keeping them: %v", j.ID, err)
+			} else {
+				targets, testRunnerState = nil, nil
+			}
+		}
+		if targets != nil {
+			// Return without releasing targets and keep the job entry so locks continue to be refreshed
+			// all the way to server exit.
+			keepJobEntry = true
+		}
 		return &job.PauseEventPayload{
 			Version:         job.CurrentPauseEventPayloadVersion,
 			JobID:           j.ID,
@@ -132,6 +177,7 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 			NextTestAttempt: nextTestAttempt,
 			Targets:         targets,
 			TestRunnerState: testRunnerState,
+			Preempted:       preempted,
 		}, xcontext.ErrPaused
 	}
 
@@ -139,6 +185,7 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 	for ; runID <= types.RunID(j.Runs) || j.Runs == 0; runID++ {
 		runCtx := xcontext.WithValue(ctx, types.KeyRunID, runID)
 		runCtx = runCtx.WithField("run_id", runID)
+		runCtx, runSpan = tracing.Start(runCtx, "run", tracing.JobID(j.ID), tracing.RunID(runID))
 		if runDelay > 0 {
 			nextRun := jr.clock.Now().Add(runDelay)
 			runCtx.Infof("Sleeping %s before the next run...", runDelay)
@@ -191,7 +238,14 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 					}
 				}
 
-				targets, testRunnerState, succeeded, runErr := jr.runTest(runCtx, j, runID, testID, testAttempt, usedResumeState)
+				testCtx, testSpan := tracing.Start(runCtx, "test",
+					tracing.JobID(j.ID),
+					tracing.RunID(runID),
+					tracing.TestNameKey.String(j.Tests[testID-1].Name),
+					tracing.TestAttemptKey.Int64(int64(testAttempt)),
+				)
+				targets, testRunnerState, succeeded, runErr := jr.runTest(testCtx, j, runID, testID, testAttempt, usedResumeState)
+				tracing.End(testSpan, runErr)
 				if runErr == xcontext.ErrPaused {
 					return pauseTest(runID, testID, testAttempt, targets, testRunnerState)
 				}
@@ -243,6 +297,9 @@ func (jr *JobRunner) Run(ctx xcontext.Context, j *job.Job, resumeState *job.Paus
 			}
 		}
 
+		tracing.End(runSpan, nil)
+		runSpan = nil
+
 		testID = 1
 		runDelay = j.RunInterval
 	}
@@ -290,6 +347,7 @@ func (jr *JobRunner) acquireTargets(
 	testID int,
 	targetLocker target.Locker,
 	resumeTargets []*target.Target,
+	deadline time.Time,
 ) ([]*target.Target, bool, error) {
 	t := j.Tests[testID-1]
 	bundle := t.TargetManagerBundle
@@ -301,14 +359,9 @@ func (jr *JobRunner) acquireTargets(
 		return resumeTargets, false, nil
 	}
 
-	var targets []*target.Target
-	if len(targets) == 0 {
-		var err error
-		targets, err = bundle.TargetManager.Acquire(
-			ctx, j.ID, j.TargetManagerAcquireTimeout+jr.targetLockDuration, bundle.AcquireParameters, targetLocker)
-		if err != nil {
-			return nil, false, err
-		}
+	targets, err := jr.acquireFromQueue(ctx, j, bundle, targetLocker, deadline)
+	if err != nil {
+		return nil, false, err
 	}
 	// Lock all the targets returned by Acquire.
 	// Targets can also be locked in the `Acquire` method, for
@@ -317,9 +370,13 @@ func (jr *JobRunner) acquireTargets(
 	// targets are locked before running the job.
 	// Locking an already-locked target (by the same owner)
 	// extends the locking deadline.
+	lockStart := time.Now()
 	if err := targetLocker.Lock(ctx, j.ID, jr.targetLockDuration, targets); err != nil {
 		return nil, false, fmt.Errorf("target locking failed: %w", err)
 	}
+	if metrics := ctx.Metrics(); metrics != nil {
+		perf.ObserveDuration(metrics.WithTags(nil), perf.TARGET_LOCK_DURATION, time.Since(lockStart))
+	}
 
 	// when the targets are acquired, update the counter
 	if metrics := ctx.Metrics(); metrics != nil {
@@ -329,6 +386,181 @@ func (jr *JobRunner) acquireTargets(
 	return targets, true, nil
 }
 
+// acquireFromQueue acquires targets when it is the turn of the job in the
+// acquisition queue. While the targets are busy, the job is queued and tries
+// again whenever targets are released, until the deadline.
+func (jr *JobRunner) acquireFromQueue(
+	ctx xcontext.Context,
+	j *job.Job,
+	bundle *target.TargetManagerBundle,
+	targetLocker target.Locker,
+	deadline time.Time,
+) ([]*target.Target, error) {
+	e := jr.acquireQueue.add(j)
+	defer jr.acquireQueue.remove(e)
+	queued := false
+	for {
+		if err := jr.acquireQueue.waitTurn(ctx, e); err != nil {
+			return nil, err
+		}
+		targets, err := bundle.TargetManager.Acquire(
+			ctx, j.ID, j.TargetManagerAcquireTimeout+jr.targetLockDuration, bundle.AcquireParameters, targetLocker)
+		busy := errors.Is(err, target.ErrTargetsBusy)
+		jr.acquireQueue.endTurn(e, err == nil, busy)
+		if busy {
+			jr.preemptFor(ctx, j, err)
+		} else {
+			if err == nil && queued {
+				// The job leaves the queue, it is running again.
+				_ = jr.emitEvent(ctx, j.ID, job.EventJobStarted, nil)
+			}
+			return targets, err
+		}
+		remaining := deadline.Sub(jr.clock.Now())
+		if remaining <= 0 {
+			return nil, err
+		}
+		if !queued {
+			queued = true
+			perf.AddJobs(ctx.Metrics(), "running", -1)
+			perf.AddJobs(ctx.Metrics(), "queued", 1)
+			defer func() {
+				perf.AddJobs(ctx.Metrics(), "queued", -1)
+				perf.AddJobs(ctx.Metrics(), "running", 1)
+			}()
+			ctx.Infof("Targets are busy, job %d is queued at position %d", j.ID, jr.acquireQueue.position(j.ID))
+			_ = jr.emitEvent(ctx, j.ID, job.EventJobQueued, nil)
+		}
+		wait := acquireRetryInterval
+		if remaining < wait {
+			wait = remaining
+		}
+		if err := jr.acquireQueue.waitRelease(ctx, e, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// preemptFor preempts the running jobs of lower priority than j which hold
+// targets it is waiting for, lowest priority and latest first, until enough
+// of the targets would be released. Preempted jobs are paused, release their
+// targets and are resumed by the job manager, queueing for targets again.
+func (jr *JobRunner) preemptFor(ctx xcontext.Context, j *job.Job, busyErr error) {
+	var be *target.BusyError
+	if !errors.As(busyErr, &be) || len(be.TargetIDs) == 0 {
+		return
+	}
+	wanted := make(map[string]bool, len(be.TargetIDs))
+	for _, id := range be.TargetIDs {
+		wanted[id] = true
+	}
+	needed := be.Needed
+	if needed <= 0 {
+		needed = len(wanted)
+	}
+
+	type holder struct {
+		ji   *jobInfo
+		held int
+	}
+	var holders []holder
+	jr.jobsMapLock.Lock()
+	defer jr.jobsMapLock.Unlock()
+	for _, ji := range jr.jobsMap {
+		// paused jobs keep their targets until they are resumed
+		if ji.jobID == j.ID || ji.priority >= j.Priority || ji.paused {
+			continue
+		}
+		held := 0
+		for _, t := range ji.targets {
+			if wanted[t.ID] {
+				held++
+			}
+		}
+		if held == 0 {
+			continue
+		}
+		if ji.preempted {
+			// already releasing its targets
+			needed -= held
+			continue
+		}
+		holders = append(holders, holder{ji: ji, held: held})
+	}
+	sort.Slice(holders, func(a, b int) bool {
+		if holders[a].ji.priority != holders[b].ji.priority {
+			return holders[a].ji.priority < holders[b].ji.priority
+		}
+		return holders[a].ji.jobID > holders[b].ji.jobID
+	})
+	for _, h := range holders {
+		if needed <= 0 {
+			break
+		}
+		ctx.Infof("Preempting job %d (priority %d) holding %d target(s) wanted by job %d (priority %d)",
+			h.ji.jobID, h.ji.priority, h.held, j.ID, j.Priority)
+		h.ji.preempted = true
+		h.ji.pause()
+		needed -= h.held
+	}
+}
+
+// releasePausedTargets releases the targets of a job paused during a test, as
+// done at the end of the test.
+func (jr *JobRunner) releasePausedTargets(
+	ctx xcontext.Context,
+	j *job.Job,
+	runID types.RunID,
+	testID int,
+	testAttempt uint32,
+	targets []*target.Target,
+) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	t := j.Tests[testID-1]
+	bundle := t.TargetManagerBundle
+	if err := bundle.TargetManager.Release(ctx, j.ID, targets, bundle.ReleaseParameters); err != nil {
+		return err
+	}
+	header := testevent.Header{JobID: j.ID, RunID: runID, TestName: t.Name, TestAttempt: testAttempt}
+	_ = jr.emitTargetEvents(ctx, storage.NewTestEventEmitter(jr.storageEngineVault, header), targets, target.EventTargetReleased)
+	jr.jobsMapLock.Lock()
+	jr.jobsMap[j.ID].targets = nil
+	jr.jobsMapLock.Unlock()
+	if err := target.GetLocker().Unlock(ctx, j.ID, targets); err != nil {
+		ctx.Warnf("Failed to unlock %d target(s) (%v): %v", len(targets), targets, err)
+	} else {
+		ctx.Infof("Unlocked %d target(s) of paused job ID %d", len(targets), j.ID)
+		jr.acquireQueue.targetsReleased()
+	}
+	if metrics := ctx.Metrics(); metrics != nil {
+		metrics.IntGauge(perf.ACQUIRED_TARGETS).Add(-int64(len(targets)))
+	}
+	return nil
+}
+
+// Pause pauses a running job. If releaseTargets is set, the job releases its
+// targets and its current test attempt starts over when it is resumed,
+// otherwise its targets stay locked until then.
+func (jr *JobRunner) Pause(jobID types.JobID, releaseTargets bool) error {
+	jr.jobsMapLock.Lock()
+	defer jr.jobsMapLock.Unlock()
+	ji, ok := jr.jobsMap[jobID]
+	if !ok || ji.paused {
+		return fmt.Errorf("job %d is not running", jobID)
+	}
+	ji.releaseTargets = releaseTargets
+	ji.pause()
+	return nil
+}
+
+// QueuePosition returns the 1-based position of a job in the target
+// acquisition queue, zero if it is not waiting for busy targets.
+func (jr *JobRunner) QueuePosition(jobID types.JobID) int {
+	return jr.acquireQueue.position(jobID)
+}
+
 func (jr *JobRunner) runTest(ctx xcontext.Context,
 	j *job.Job, runID types.RunID, testID int, testAttempt uint32,
 	resumeState *job.PauseEventPayload,
@@ -355,6 +587,9 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 
 	acquireCtx, acquireCancel := xcontext.WithTimeout(ctx, j.TargetManagerAcquireTimeout)
 	defer acquireCancel()
+	// jobs stop waiting for busy targets a bit before the timeout, so that
+	// their last acquisition error is reported rather than the timeout
+	acquireDeadline := jr.clock.Now().Add(j.TargetManagerAcquireTimeout / 10 * 9)
 
 	// the Acquire semantic is synchronous, so that the implementation
 	// is simpler on the user's side. We run it in a goroutine in
@@ -362,8 +597,10 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 	// TODO: Make lockers finish on context cancel and remove goroutine
 	errCh := make(chan error, 1)
 	go func() {
+		spanCtx, span := tracing.Start(acquireCtx, "acquire targets")
 		var err error
-		targets, acquired, err = jr.acquireTargets(acquireCtx, j, testID, tl, resumeTargets)
+		targets, acquired, err = jr.acquireTargets(spanCtx, j, testID, tl, resumeTargets, acquireDeadline)
+		tracing.End(span, err)
 		errCh <- err
 	}()
 
@@ -375,7 +612,7 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 
 			// Assume that all errors could be retried except cancellation as both
 			// target manager and target locking problems can disappear if retried
-			if acquireErr == xcontext.ErrCanceled {
+			if acquireErr == xcontext.ErrCanceled || acquireErr == xcontext.ErrPaused {
 				return nil, nil, false, acquireErr
 			}
 
@@ -413,9 +650,16 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 	default:
 	}
 
+	var testTargets []*target.Target
+	if runErr == nil {
+		// A test restricted to targets which were not acquired fails rather
+		// than passing on fewer targets.
+		testTargets, runErr = filterTargets(targets, t.TargetIDs)
+	}
+
 	if runErr == nil {
 		ctx.Infof("Run #%d: running test #%d for job '%s' (job ID: %d) on %d targets",
-			runID, testID, j.Name, j.ID, len(targets))
+			runID, testID, j.Name, j.ID, len(testTargets))
 
 		var testRunnerState json.RawMessage
 		if resumeState != nil {
@@ -435,13 +679,13 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 		testRunnerState, targetsResults, err := testRunner.Run(
 			runCtx,
 			t,
-			targets,
+			testTargets,
 			NewTestStepEventsEmitterFactory(jr.storageEngineVault, j.ID, runID, t.Name, testAttempt),
 			testRunnerState,
 		)
 		runCtx.Debugf("== test runner finished, err: %v", err)
 
-		succeed = len(targetsResults) == len(targets)
+		succeed = len(targetsResults) == len(testTargets)
 		for targetID, targetErr := range targetsResults {
 			if targetErr != nil {
 				ctx.Infof("target '%s' failed with err: '%v'", targetID, err)
@@ -471,6 +715,7 @@ func (jr *JobRunner) runTest(ctx xcontext.Context,
 		jr.jobsMapLock.Unlock()
 		if err := tl.Unlock(ctx, j.ID, targets); err == nil {
 			ctx.Infof("Unlocked %d target(s) for job ID %d", len(targets), j.ID)
+			jr.acquireQueue.targetsReleased()
 		} else {
 			ctx.Warnf("Failed to unlock %d target(s) (%v): %v", len(targets), targets, err)
 		}
@@ -635,8 +880,39 @@ func NewJobRunner(js storage.JobStorage, storageVault storage.EngineVault, clk c
 		testEvManager:         storage.NewTestEventFetcher(storageVault),
 		targetLockDuration:    lockDuration,
 		clock:                 clk,
+		acquireQueue:          newAcquireQueue(clk),
 		stopLockRefresh:       make(chan struct{}),
 		lockRefreshStopped:    make(chan struct{}),
 	}
 	return jr
 }
+
+// filterTargets returns the targets with the given IDs, or all the targets
+// if no IDs are given. It fails if any of the IDs is not among the targets.
+func filterTargets(targets []*target.Target, ids []string) ([]*target.Target, error) {
+	if len(ids) == 0 {
+		return targets, nil
+	}
+	keep := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		keep[id] = true
+	}
+	var filtered []*target.Target
+	for _, t := range targets {
+		if keep[t.ID] {
+			filtered = append(filtered, t)
+			delete(keep, t.ID)
+		}
+	}
+	var missing []string
+	for _, id := range ids {
+		if keep[id] {
+			missing = append(missing, id)
+			delete(keep, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("targets %s of the test were not acquired", strings.Join(missing, ", "))
+	}
+	return filtered, nil
+}
@@ -112,6 +112,187 @@ func (s *JobRunnerSuite) TestSimpleJobStartFinish() {
 `, s.MemoryStorage.GetTargetEvents(ctx, testName, "T1"))
 }
 
+// A test restricted to some target IDs only runs on them, but all the
+// targets are acquired and released.
+func (s *JobRunnerSuite) TestJobTargetIDs() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	var mu sync.Mutex
+	var resultTargets []*target.Target
+
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				mu.Lock()
+				defer mu.Unlock()
+				resultTargets = append(resultTargets, target)
+				return nil
+			})
+		},
+		nil,
+	))
+
+	j := job.Job{
+		ID:                          1,
+		Runs:                        1,
+		TargetManagerAcquireTimeout: 10 * time.Second,
+		TargetManagerReleaseTimeout: 10 * time.Second,
+		Tests: []*test.Test{
+			{
+				Name: testName,
+				TargetManagerBundle: &target.TargetManagerBundle{
+					AcquireParameters: targetlist.AcquireParameters{
+						Targets: []*target.Target{{ID: "T1"}, {ID: "T2"}},
+					},
+					TargetManager: targetlist.New(),
+				},
+				TestStepsBundles: []test.TestStepBundle{
+					s.NewStep(ctx, "test_step_label", stateFullStepName, nil),
+				},
+				TargetIDs: []string{"T2"},
+			},
+		},
+	}
+
+	jsm := storage.NewJobStorageManager(s.MemoryStorage.StorageEngineVault)
+	jr := NewJobRunner(jsm, s.MemoryStorage.StorageEngineVault, clock.New(), time.Second)
+
+	_, err := jr.Run(ctx, &j, nil)
+	require.NoError(s.T(), err)
+
+	require.Equal(s.T(), []*target.Target{{ID: "T2"}}, resultTargets)
+	require.Equal(s.T(), `
+{[1 1 SimpleTest 0 ][Target{ID: "T1"} TargetAcquired]}
+{[1 1 SimpleTest 0 ][Target{ID: "T1"} TargetReleased]}
+`, s.MemoryStorage.GetTargetEvents(ctx, testName, "T1"))
+	require.Equal(s.T(), `
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetAcquired]}
+{[1 1 SimpleTest 0 test_step_label][Target{ID: "T2"} TargetIn]}
+{[1 1 SimpleTest 0 test_step_label][Target{ID: "T2"} TargetOut]}
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetReleased]}
+`, s.MemoryStorage.GetTargetEvents(ctx, testName, "T2"))
+}
+
+// A test restricted to target IDs which were not all acquired fails instead
+// of running on the remaining targets, and the targets are released.
+func (s *JobRunnerSuite) TestJobTargetIDsMissing() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	var mu sync.Mutex
+	var resultTargets []*target.Target
+
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				mu.Lock()
+				defer mu.Unlock()
+				resultTargets = append(resultTargets, target)
+				return nil
+			})
+		},
+		nil,
+	))
+
+	for _, ids := range [][]string{{"T3"}, {"T2", "T3"}} {
+		resultTargets = nil
+		j := job.Job{
+			ID:                          1,
+			Runs:                        1,
+			TargetManagerAcquireTimeout: 10 * time.Second,
+			TargetManagerReleaseTimeout: 10 * time.Second,
+			Tests: []*test.Test{
+				{
+					Name: testName,
+					TargetManagerBundle: &target.TargetManagerBundle{
+						AcquireParameters: targetlist.AcquireParameters{
+							Targets: []*target.Target{{ID: "T1"}, {ID: "T2"}},
+						},
+						TargetManager: targetlist.New(),
+					},
+					TestStepsBundles: []test.TestStepBundle{
+						s.NewStep(ctx, "test_step_label", stateFullStepName, nil),
+					},
+					TargetIDs: ids,
+				},
+			},
+		}
+
+		jsm := storage.NewJobStorageManager(s.MemoryStorage.StorageEngineVault)
+		jr := NewJobRunner(jsm, s.MemoryStorage.StorageEngineVault, clock.New(), time.Second)
+
+		_, err := jr.Run(ctx, &j, nil)
+		require.EqualError(s.T(), err, "targets T3 of the test were not acquired")
+		require.Empty(s.T(), resultTargets)
+	}
+	require.Equal(s.T(), `
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetAcquired]}
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetReleased]}
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetAcquired]}
+{[1 1 SimpleTest 0 ][Target{ID: "T2"} TargetReleased]}
+`, s.MemoryStorage.GetTargetEvents(ctx, testName, "T2"))
+}
+
+// A target which passes a step but cannot be routed further because it has
+// visited the next step too many times fails in the status of the job.
+func (s *JobRunnerSuite) TestJobStatusMaxVisits() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				return nil
+			})
+		},
+		nil,
+	))
+
+	step1 := s.NewStep(ctx, "Step 1", stateFullStepName, nil)
+	step1.OnSuccess = "Step 2"
+	step1.MaxVisits = 2
+	step2 := s.NewStep(ctx, "Step 2", stateFullStepName, nil)
+	step2.OnSuccess = "Step 1"
+	step2.MaxVisits = 3
+
+	j := job.Job{
+		ID:                          1,
+		Runs:                        1,
+		TargetManagerAcquireTimeout: 10 * time.Second,
+		TargetManagerReleaseTimeout: 10 * time.Second,
+		Tests: []*test.Test{
+			{
+				Name: testName,
+				TargetManagerBundle: &target.TargetManagerBundle{
+					AcquireParameters: targetlist.AcquireParameters{
+						Targets: []*target.Target{{ID: "T1"}},
+					},
+					TargetManager: targetlist.New(),
+				},
+				TestStepsBundles: []test.TestStepBundle{step1, step2},
+			},
+		},
+	}
+
+	jsm := storage.NewJobStorageManager(s.MemoryStorage.StorageEngineVault)
+	jr := NewJobRunner(jsm, s.MemoryStorage.StorageEngineVault, clock.New(), time.Second)
+
+	_, err := jr.Run(ctx, &j, nil)
+	require.NoError(s.T(), err)
+
+	runStatus, err := jr.BuildRunStatus(ctx, job.RunCoordinates{JobID: j.ID, RunID: 1}, &j)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), runStatus.TestStatuses, 1)
+	stepStatuses := runStatus.TestStatuses[0].TestStepStatuses
+	require.Len(s.T(), stepStatuses, 2)
+	require.Len(s.T(), stepStatuses[0].TargetStatuses, 1)
+	require.Empty(s.T(), stepStatuses[0].TargetStatuses[0].Error)
+	require.Len(s.T(), stepStatuses[1].TargetStatuses, 1)
+	require.Equal(s.T(), "target entered step 'Step 1' 2 times", stepStatuses[1].TargetStatuses[0].Error)
+	require.Equal(s.T(), []job.TargetStatus{stepStatuses[1].TargetStatuses[0]}, runStatus.TestStatuses[0].TargetStatuses)
+}
+
 func (s *JobRunnerSuite) TestJobWithTestRetry() {
 	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
 	defer cancel()
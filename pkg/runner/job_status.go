@@ -8,6 +8,7 @@ package runner
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
@@ -55,7 +56,10 @@ func (jr *JobRunner) buildTargetStatuses(coordinates job.TestStepCoordinates, ta
 
 		evName := testEvent.Data.EventName
 		if evName == target.EventTargetIn {
+			// A target may enter a step multiple times when steps are routed, the latest visit wins.
 			targetStatus.InTime = testEvent.EmitTime
+			targetStatus.OutTime = time.Time{}
+			targetStatus.Error = ""
 		} else if evName == target.EventTargetOut {
 			targetStatus.OutTime = testEvent.EmitTime
 		} else if evName == target.EventTargetErr {
@@ -183,10 +187,29 @@ func (jr *JobRunner) buildTestStatus(ctx xcontext.Context, coordinates job.TestC
 	}
 
 	var targetStatuses []job.TargetStatus
-	// Keep track of the last TargetStatus seen for each Target
+	// Keep track of the last TargetStatus seen for each Target. Targets can be routed
+	// back and forth between regular steps, so the last one is the latest to be entered.
+	// A failure in a finally step fails a Target which has otherwise succeeded.
 	targetMap := make(map[string]job.TargetStatus)
-	for _, testStepStatus := range testStatus.TestStepStatuses {
+	for index, testStepStatus := range testStatus.TestStepStatuses {
+		if currentTest.TestStepsBundles[index].Finally {
+			continue
+		}
+		for _, targetStatus := range testStepStatus.TargetStatuses {
+			if last, ok := targetMap[targetStatus.Target.ID]; ok && last.InTime.After(targetStatus.InTime) {
+				continue
+			}
+			targetMap[targetStatus.Target.ID] = targetStatus
+		}
+	}
+	for index, testStepStatus := range testStatus.TestStepStatuses {
+		if !currentTest.TestStepsBundles[index].Finally {
+			continue
+		}
 		for _, targetStatus := range testStepStatus.TargetStatuses {
+			if last, ok := targetMap[targetStatus.Target.ID]; ok && last.Error != "" {
+				continue
+			}
 			targetMap[targetStatus.Target.ID] = targetStatus
 		}
 	}
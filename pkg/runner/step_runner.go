@@ -7,13 +7,16 @@ import (
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/linuxboot/contest/pkg/cerrors"
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/metrics/perf"
 )
 
 type AddTargetToStep func(ctx xcontext.Context, tgt *target.Target) error
@@ -45,10 +48,14 @@ type StepRunner struct {
 	resultErr         error
 	resultResumeState json.RawMessage
 	notifyStopped     func(err error)
+
+	targetSpans *tracing.TargetSpans
 }
 
 type stepTargetInfo struct {
 	targetInEmitted bool
+	// start is when the target was injected into the step
+	start time.Time
 }
 
 func (sti *stepTargetInfo) acquireTargetInEmission() bool {
@@ -96,10 +103,12 @@ func (sr *StepRunner) Run(
 	resultsChan := make(chan StepRunnerEvent, 1)
 	sr.resultsChan = resultsChan
 
+	sr.targetSpans = tracing.NewTargetSpans(ctx)
 	for _, resumeTarget := range resumeStateTargets {
 		sr.activeTargets[resumeTarget.ID] = &stepTargetInfo{
 			targetInEmitted: true,
 		}
+		sr.targetSpans.Start(resumeTarget.ID)
 	}
 
 	var activeLoopsCount int32 = 2
@@ -112,6 +121,9 @@ func (sr *StepRunner) Run(
 		close(sr.finishedCh)
 		sr.mu.Unlock()
 
+		// targets which did not leave the step, e.g. because it was paused
+		sr.targetSpans.EndAll(sr.getErr())
+
 		// if an error occurred we already sent notification
 		sr.notifyStopped(nil)
 		close(sr.resultsChan)
@@ -123,13 +135,13 @@ func (sr *StepRunner) Run(
 	stepOut := make(chan test.TestStepResult)
 	go func() {
 		defer finish()
-		sr.runningLoop(ctx, sr.input, stepOut, bundle, ev, resumeState)
+		sr.runningLoop(tracing.WithTargetSpans(ctx, sr.targetSpans), sr.input, stepOut, bundle, ev, resumeState)
 		ctx.Debugf("Running loop finished")
 	}()
 
 	go func() {
 		defer finish()
-		sr.outputLoop(ctx, stepOut, ev, bundle.TestStepLabel)
+		sr.outputLoop(ctx, stepOut, ev, bundle.TestStepLabel, bundle.TestStep.Name())
 		ctx.Debugf("Reading loop finished")
 	}()
 
@@ -166,7 +178,7 @@ func (sr *StepRunner) addTarget(
 			if targetInfo := sr.activeTargets[tgt.ID]; targetInfo != nil {
 				return nil, fmt.Errorf("target is already processed")
 			}
-			targetInfo := &stepTargetInfo{}
+			targetInfo := &stepTargetInfo{start: time.Now()}
 			sr.activeTargets[tgt.ID] = targetInfo
 			sr.inputWg.Add(1)
 			return targetInfo, nil
@@ -177,6 +189,7 @@ func (sr *StepRunner) addTarget(
 		}
 
 		defer sr.inputWg.Done()
+		sr.targetSpans.Start(tgt.ID)
 		select {
 		case sr.input <- tgt:
 			// we should always emit TargetIn before TargetOut or TargetError
@@ -200,6 +213,7 @@ func (sr *StepRunner) addTarget(
 	}()
 
 	if err != nil {
+		sr.targetSpans.End(tgt.ID, err)
 		sr.mu.Lock()
 		if sr.activeTargets[tgt.ID] == nil {
 			sr.setErrLocked(ctx,
@@ -283,6 +297,7 @@ func (sr *StepRunner) outputLoop(
 	stepOut chan test.TestStepResult,
 	ev testevent.Emitter,
 	testStepLabel string,
+	testStepName string,
 ) {
 	for {
 		select {
@@ -316,29 +331,33 @@ func (sr *StepRunner) outputLoop(
 			}
 			ctx.Infof("Obtained '%v' for target '%s'", res, res.Target.ID)
 
-			shouldEmitTargetIn, err := func() (bool, error) {
+			shouldEmitTargetIn, start, err := func() (bool, time.Time, error) {
 				sr.mu.Lock()
 				defer sr.mu.Unlock()
 
 				info, found := sr.activeTargets[res.Target.ID]
 				if !found {
-					return false, &cerrors.ErrTestStepReturnedUnexpectedResult{
+					return false, time.Time{}, &cerrors.ErrTestStepReturnedUnexpectedResult{
 						StepName: testStepLabel,
 						Target:   res.Target.ID,
 					}
 				}
 				if info == nil {
-					return false, &cerrors.ErrTestStepReturnedDuplicateResult{StepName: testStepLabel, Target: res.Target.ID}
+					return false, time.Time{}, &cerrors.ErrTestStepReturnedDuplicateResult{StepName: testStepLabel, Target: res.Target.ID}
 				}
 				sr.activeTargets[res.Target.ID] = nil
 
 				shouldEmitTargetIn := info.acquireTargetInEmission()
-				return shouldEmitTargetIn, nil
+				return shouldEmitTargetIn, info.start, nil
 			}()
 			if err != nil {
 				sr.setErr(ctx, err)
 				return
 			}
+			sr.targetSpans.End(res.Target.ID, res.Err)
+			if metrics := ctx.Metrics(); metrics != nil && !start.IsZero() {
+				perf.ObserveDuration(metrics.WithTags(nil).WithTag("step", testStepName), perf.STEP_TARGET_DURATION, time.Since(start))
+			}
 
 			if shouldEmitTargetIn {
 				if err := emitEvent(ctx, ev, target.EventTargetIn, res.Target, nil); err != nil {
@@ -9,6 +9,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -17,33 +19,43 @@ import (
 
 	"github.com/linuxboot/contest/pkg/cerrors"
 	"github.com/linuxboot/contest/pkg/config"
+	"github.com/linuxboot/contest/pkg/console"
 	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/resource"
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
 // TestRunner is the state associated with a test run.
 // Here's how a test run works:
-//  * Each target gets a targetState and a "target handler" - a goroutine that takes that particular
-//    target through each step of the pipeline in sequence. It injects the target, waits for the result,
-//    then moves on to the next step.
-//  * Each step of the pipeline gets a stepState and:
-//    - A "step runner" - a goroutine that is responsible for running the step's Run() method
-//    - A "step reader" - a goroutine that processes results and sends them on to target handlers that await them.
-//  * After starting all of the above, the main goroutine goes into "monitor" mode
-//    that checks on the pipeline's progress and is responsible for closing step input channels
-//    when all the targets have been injected.
-//  * Monitor loop finishes when all the targets have been injected into the last step
-//    or if a step has encountered an error.
-//  * We then wait for all the step runners and readers to shut down.
-//  * Once all the activity has died down, resulting state is examined and an error is returned, if any.
+//   - Each target gets a targetState and a "target handler" - a goroutine that takes that particular
+//     target through the steps of the pipeline. It injects the target, waits for the result,
+//     then moves on to the step the result routes it to: the next one by default, or the ones named
+//     by OnSuccess / OnFailure of the step. Targets that fail a step without an OnFailure route
+//     skip to the finally steps, which every target goes through before leaving the pipeline.
+//     Before injecting the target the handler takes a slot of the step if its parallelism is limited,
+//     and a unit of each resource used by the step, which are given back once the result is in.
+//   - Each step of the pipeline gets a stepState and:
+//   - A "step runner" - a goroutine that is responsible for running the step's Run() method
+//   - A "step reader" - a goroutine that processes results and sends them on to target handlers that await them.
+//   - If the test has a console recorder, the consoles of all the targets are recorded
+//     from the start to the end of the run, and the steps can match the logs.
+//   - After starting all of the above, the main goroutine goes into "monitor" mode
+//     that checks on the pipeline's progress and is responsible for closing step input channels
+//     when no running target can reach the step anymore.
+//   - Monitor loop finishes when the input channels of all the steps have been closed
+//     or if a step has encountered an error.
+//   - We then wait for all the step runners and readers to shut down.
+//   - Once all the activity has died down, resulting state is examined and an error is returned, if any.
 type TestRunner struct {
 	shutdownTimeout time.Duration // Time to wait for steps runners to finish a the end of the run
 
-	steps     []*stepState            // The pipeline, in order of execution
-	targets   map[string]*targetState // Target state lookup map
-	targetsWg sync.WaitGroup          // Tracks all the target handlers
+	steps        []*stepState            // The pipeline, in order of execution
+	firstFinally int                     // Index of the first finally step, len(steps) if there are none
+	targets      map[string]*targetState // Target state lookup map
+	targetsWg    sync.WaitGroup          // Tracks all the target handlers
 
 	// One mutex to rule them all, used to serialize access to all the state above.
 	// Could probably be split into several if necessary.
@@ -58,6 +70,15 @@ type stepState struct {
 
 	stepIndex int                 // Index of this step in the pipeline.
 	sb        test.TestStepBundle // The test bundle.
+	onSuccess int                 // Index of the step to go to on success.
+	onFailure int                 // Index of the step to go to on failure, -1 if the failure is final.
+	maxVisits uint                // Number of times a target may enter this step.
+
+	retryRegex *regexp.Regexp // Errors to retry, if restricted by the retry policy.
+
+	// Semaphores taken by targets running the step: the MaxParallel limit of
+	// the step first, if any, then the named resources sorted by name.
+	semaphores []*resource.Semaphore
 
 	ev                 testevent.Emitter
 	stepRunner         *StepRunner
@@ -86,9 +107,13 @@ type targetState struct {
 	tgt *target.Target
 
 	// This part of state gets serialized into JSON for resumption.
-	CurStep  int             `json:"S,omitempty"` // Current step number.
-	CurPhase targetStepPhase `json:"P,omitempty"` // Current phase of step execution.
-	Res      *xjson.Error    `json:"R,omitempty"` // Final result, if reached the end state.
+	CurStep  int             `json:"S,omitempty"`  // Current step number.
+	CurPhase targetStepPhase `json:"P,omitempty"`  // Current phase of step execution, end once the target is done.
+	Res      *xjson.Error    `json:"R,omitempty"`  // Final result, if reached the end state.
+	Visits   map[int]uint    `json:"VS,omitempty"` // Number of times the target entered each step.
+
+	StepAttempt uint32     `json:"A,omitempty"`  // Number of failed attempts at the current step.
+	RetryAt     *time.Time `json:"RA,omitempty"` // When to inject the target again after a failed attempt.
 
 	handlerRunning bool
 	resCh          chan error // Channel used to communicate result by the step runner.
@@ -99,12 +124,13 @@ type resumeStateStruct struct {
 	Version         int                     `json:"V"`
 	Targets         map[string]*targetState `json:"T"`
 	StepResumeState []json.RawMessage       `json:"SRS,omitempty"`
+	ConsoleOffsets  map[string]int64        `json:"CO,omitempty"` // Sizes of the recorded console logs.
 }
 
 // Resume state version we are compatible with.
 // When imcompatible changes are made to the state format, bump this.
 // Restoring incompatible state will abort the job.
-const resumeStateStructVersion = 2
+const resumeStateStructVersion = 3
 
 type TestStepEventsEmitterFactory interface {
 	New(testStepLabel string) testevent.Emitter
@@ -145,12 +171,12 @@ func (tr *TestRunner) Run(
 
 	// Initialize remaining fields of the target structures,
 	// build the map and kick off target processing.
-	minStep := len(tr.steps)
 	for _, tgt := range targets {
 		tgs := tr.targets[tgt.ID]
 		if tgs == nil {
 			tgs = &targetState{
 				CurPhase: targetStepPhaseInit,
+				Visits:   map[int]uint{0: 1},
 			}
 		}
 		tgs.tgt = tgt
@@ -159,14 +185,62 @@ func (tr *TestRunner) Run(
 		// i.e. reporting TargetIn event which may involve network I/O.
 		tgs.resCh = make(chan error, 1)
 		tr.targets[tgt.ID] = tgs
+	}
 
-		if tgs.CurStep < minStep {
-			minStep = tgs.CurStep
+	// Record the consoles of the targets during the whole test, the steps
+	// find the recorders in their context.
+	var recorders *console.Recorders
+	if t.ConsoleRecorder != nil {
+		recorders = console.Start(ctx, t.ConsoleRecorder, t.Name, targets, emitterFactory.New(""), rs.ConsoleOffsets)
+		defer recorders.Stop()
+		stepsCtx = console.WithRecorders(stepsCtx, recorders)
+	}
+
+	// Resolve the routes between the steps.
+	stepIndexes := make(map[string]int, len(t.TestStepsBundles))
+	tr.firstFinally = len(t.TestStepsBundles)
+	for i, sb := range t.TestStepsBundles {
+		stepIndexes[sb.TestStepLabel] = i
+		if sb.Finally && i < tr.firstFinally {
+			tr.firstFinally = i
 		}
 	}
+	resolveRoute := func(sb test.TestStepBundle, label string, def int) (int, error) {
+		if label == "" {
+			return def, nil
+		}
+		idx, ok := stepIndexes[label]
+		if !ok {
+			return 0, fmt.Errorf("step '%s' routes to unknown step '%s'", sb.TestStepLabel, label)
+		}
+		return idx, nil
+	}
 
 	// Set up the pipeline
 	for i, sb := range t.TestStepsBundles {
+		onSuccess, err := resolveRoute(sb, sb.OnSuccess, i+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		onFailure, err := resolveRoute(sb, sb.OnFailure, -1)
+		if err != nil {
+			return nil, nil, err
+		}
+		maxVisits := sb.MaxVisits
+		if maxVisits == 0 {
+			maxVisits = test.DefaultMaxStepVisits
+		}
+		var retryRegex *regexp.Regexp
+		if sb.Retry != nil && sb.Retry.ErrorRegex != "" {
+			if retryRegex, err = regexp.Compile(sb.Retry.ErrorRegex); err != nil {
+				return nil, nil, fmt.Errorf("invalid retry error regex for step '%s': %w", sb.TestStepLabel, err)
+			}
+		}
+		semaphores, err := stepSemaphores(sb)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		stepCtx, stepCancel := xcontext.WithCancel(stepsCtx)
 		stepCtx = stepCtx.WithField("step_index", strconv.Itoa(i))
 		stepCtx = stepCtx.WithField("step_label", sb.TestStepLabel)
@@ -189,6 +263,11 @@ func (tr *TestRunner) Run(
 			cancel:             stepCancel,
 			stepIndex:          i,
 			sb:                 sb,
+			onSuccess:          onSuccess,
+			onFailure:          onFailure,
+			maxVisits:          maxVisits,
+			retryRegex:         retryRegex,
+			semaphores:         semaphores,
 			ev:                 emitterFactory.New(sb.TestStepLabel),
 			stepRunner:         NewStepRunner(),
 			resumeState:        srs,
@@ -209,7 +288,7 @@ func (tr *TestRunner) Run(
 	}
 
 	// Run until no more progress can be made.
-	runErr := tr.runMonitor(ctx, minStep)
+	runErr := tr.runMonitor(ctx)
 	if runErr != nil {
 		ctx.Errorf("monitor returned error: %q, canceling", runErr)
 		stepsCancel()
@@ -279,6 +358,11 @@ func (tr *TestRunner) Run(
 		for _, ss := range tr.steps {
 			rs.StepResumeState = append(rs.StepResumeState, ss.resumeState)
 		}
+		if recorders != nil {
+			// The recording resumes at the end of the console logs.
+			recorders.Stop()
+			rs.ConsoleOffsets = recorders.Offsets()
+		}
 		resumeState, runErr = json.Marshal(rs)
 		if runErr != nil {
 			ctx.Errorf("unable to serialize the state: %s", runErr)
@@ -292,7 +376,7 @@ func (tr *TestRunner) Run(
 	for id, state := range tr.targets {
 		if state.Res != nil {
 			targetsResults[id] = state.Res.Unwrap()
-		} else if state.CurStep == len(tr.steps)-1 && state.CurPhase == targetStepPhaseEnd {
+		} else if state.CurPhase == targetStepPhaseEnd {
 			targetsResults[id] = nil
 		}
 	}
@@ -352,6 +436,61 @@ func (tr *TestRunner) waitStepRunners(ctx xcontext.Context) error {
 	return resultErr
 }
 
+// stepSemaphores returns the semaphores taken by the targets running a step.
+func stepSemaphores(sb test.TestStepBundle) ([]*resource.Semaphore, error) {
+	var semaphores []*resource.Semaphore
+	if sb.MaxParallel > 0 {
+		semaphores = append(semaphores, resource.NewSemaphore("", sb.MaxParallel))
+	}
+	// Resources are always taken in the same order, so that targets of
+	// different steps and jobs cannot deadlock each other.
+	names := append([]string(nil), sb.Resources...)
+	sort.Strings(names)
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+		sem, err := resource.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("step '%s': %w", sb.TestStepLabel, err)
+		}
+		semaphores = append(semaphores, sem)
+	}
+	return semaphores, nil
+}
+
+// acquireSlots takes the semaphores of the step for the target. When one is
+// not available, a TargetWaiting event tells what the target is waiting for.
+// Targets which were running the step when the job was paused take them
+// without waiting, since they are using them already.
+func (tr *TestRunner) acquireSlots(ctx xcontext.Context, tgs *targetState, ss *stepState, running bool) error {
+	for i, sem := range ss.semaphores {
+		if running {
+			sem.ForceAcquire()
+			continue
+		}
+		if sem.TryAcquire() {
+			continue
+		}
+		waiting := &TargetWaitingPayload{Resource: sem.Name(), Capacity: sem.Capacity()}
+		if waiting.Resource == "" {
+			ctx.Debugf("%s: waiting for one of the %d slots of %s", tgs, waiting.Capacity, ss)
+		} else {
+			ctx.Debugf("%s: waiting for resource %s", tgs, waiting.Resource)
+		}
+		if err := emitEvent(ctx, ss.ev, EventTargetWaiting, tgs.tgt, waiting); err != nil {
+			ctx.Errorf("failed to emit event: %s", err)
+		}
+		if err := sem.Acquire(ctx); err != nil {
+			for _, held := range ss.semaphores[:i] {
+				held.Release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 func (tr *TestRunner) injectTarget(ctx xcontext.Context, tgs *targetState, ss *stepState) error {
 	ctx.Debugf("%s: injecting into %s", tgs, ss)
 
@@ -383,11 +522,24 @@ func (tr *TestRunner) awaitTargetResult(ctx xcontext.Context, tgs *targetState,
 	processTargetResult := func(res error) {
 		ctx.Debugf("%s: result recd for %s", tgs, ss)
 		tr.mu.Lock()
-		if res != nil {
-			tgs.Res = xjson.NewError(res)
+		retry := tr.retryTargetLocked(tgs, ss, res)
+		var routeErr error
+		if retry == nil {
+			routeErr = tr.routeTargetLocked(ctx, tgs, ss, res)
 		}
-		tgs.CurPhase = targetStepPhaseEnd
 		tr.mu.Unlock()
+		if routeErr != nil {
+			if err := emitEvent(ctx, ss.ev, target.EventTargetErr, tgs.tgt, target.ErrPayload{Error: routeErr.Error()}); err != nil {
+				ctx.Errorf("failed to emit event: %s", err)
+			}
+		}
+		if retry != nil {
+			ctx.Infof("%s: attempt %d of %d at %s failed, retrying in %s: %v",
+				tgs, retry.Attempt, retry.MaxAttempts, ss, retry.Delay, res)
+			if err := emitEvent(ctx, ss.ev, EventTargetRetry, tgs.tgt, retry); err != nil {
+				ctx.Errorf("failed to emit event: %s", err)
+			}
+		}
 		tr.monitorCond.Signal()
 	}
 
@@ -427,14 +579,86 @@ func (tr *TestRunner) awaitTargetResult(ctx xcontext.Context, tgs *targetState,
 	}
 }
 
-// targetHandler takes a single target through each step of the pipeline in sequence.
-// It injects the target, waits for the result, then moves on to the next step.
+// retryTargetLocked checks whether the retry policy of the step allows another attempt
+// after a failure. If so, the target is scheduled to be injected into the same step again.
+func (tr *TestRunner) retryTargetLocked(tgs *targetState, ss *stepState, res error) *TargetRetryPayload {
+	policy := ss.sb.Retry
+	if res == nil || policy == nil || tgs.StepAttempt+1 >= policy.MaxAttempts {
+		return nil
+	}
+	if ss.retryRegex != nil && !ss.retryRegex.MatchString(res.Error()) {
+		return nil
+	}
+	tgs.StepAttempt++
+	delay := policy.Delay(tgs.StepAttempt + 1)
+	retryAt := time.Now().Add(delay)
+	tgs.RetryAt = &retryAt
+	tgs.CurPhase = targetStepPhaseInit
+	return &TargetRetryPayload{
+		Attempt:     tgs.StepAttempt,
+		MaxAttempts: policy.MaxAttempts,
+		Error:       res.Error(),
+		Delay:       xjson.Duration(delay),
+	}
+}
+
+// routeTargetLocked records the result of the step the target has just
+// finished and moves the target to the step it is routed to. If there is no
+// such step, the target is left in the end phase of the current one, which
+// marks it as done. If the target passed the step but cannot be routed any
+// further, the returned error fails it and must be reported for the step.
+func (tr *TestRunner) routeTargetLocked(ctx xcontext.Context, tgs *targetState, ss *stepState, res error) error {
+	var routeErr error
+	next := ss.onSuccess
+	switch {
+	case ss.sb.Finally:
+		// Finally steps do not change the route, but they can fail a target that succeeded so far.
+		if res != nil && tgs.Res == nil {
+			tgs.Res = xjson.NewError(res)
+		}
+	case res == nil:
+	case ss.onFailure >= 0:
+		ctx.Debugf("%s: failed %s, routing to %s: %v", tgs, ss, tr.steps[ss.onFailure], res)
+		next = ss.onFailure
+	default:
+		tgs.Res = xjson.NewError(res)
+		next = tr.firstFinally
+	}
+	if next < tr.firstFinally && tgs.Visits[next] >= tr.steps[next].maxVisits {
+		ctx.Debugf("%s: %s was entered %d times already, giving up", tgs, tr.steps[next], tgs.Visits[next])
+		if tgs.Res == nil {
+			if res == nil {
+				routeErr = fmt.Errorf("target entered step '%s' %d times",
+					tr.steps[next].sb.TestStepLabel, tr.steps[next].maxVisits)
+				res = routeErr
+			}
+			tgs.Res = xjson.NewError(res)
+		}
+		next = tr.firstFinally
+	}
+	if next >= len(tr.steps) {
+		tgs.CurPhase = targetStepPhaseEnd
+		return routeErr
+	}
+	tgs.StepAttempt = 0
+	tgs.RetryAt = nil
+	if tgs.Visits == nil {
+		tgs.Visits = make(map[int]uint)
+	}
+	tgs.Visits[next]++
+	tgs.CurStep = next
+	tgs.CurPhase = targetStepPhaseInit
+	return routeErr
+}
+
+// targetHandler takes a single target through the steps of the pipeline.
+// It injects the target, waits for the result, then moves on to the step it is routed to.
 func (tr *TestRunner) targetHandler(ctx xcontext.Context, tgs *targetState) {
 	ctx = ctx.WithField("target", tgs.tgt.ID)
 	ctx.Debugf("%s: target handler active", tgs)
 	// NB: CurStep may be non-zero on entry if resumed
 loop:
-	for i := tgs.CurStep; i < len(tr.steps); {
+	for len(tr.steps) > 0 {
 		// Early check for pause or cancellation.
 		select {
 		case <-ctx.Until(xcontext.ErrPaused):
@@ -445,8 +669,26 @@ loop:
 			break loop
 		default:
 		}
+		// Wait before injecting the target again if the previous attempt failed.
 		tr.mu.Lock()
-		ss := tr.steps[i]
+		retryAt := tgs.RetryAt
+		tr.mu.Unlock()
+		if retryAt != nil {
+			select {
+			case <-time.After(time.Until(*retryAt)):
+			case <-ctx.Until(xcontext.ErrPaused):
+				ctx.Debugf("%s: paused before retry", tgs)
+				break loop
+			case <-ctx.Done():
+				ctx.Debugf("%s: canceled before retry", tgs)
+				break loop
+			}
+			tr.mu.Lock()
+			tgs.RetryAt = nil
+			tr.mu.Unlock()
+		}
+		tr.mu.Lock()
+		ss := tr.steps[tgs.CurStep]
 		var inject bool
 		switch tgs.CurPhase {
 		case targetStepPhaseInit:
@@ -470,6 +712,12 @@ loop:
 		tr.mu.Unlock()
 		// Make sure we have a step runner active. If not, start one.
 		err := tr.runStepIfNeeded(ss)
+		// Take the slots of the step, waiting for them if needed.
+		held := false
+		if err == nil {
+			err = tr.acquireSlots(ctx, tgs, ss, !inject)
+			held = err == nil
+		}
 		// Inject the target.
 		if err == nil && inject {
 			err = tr.injectTarget(ctx, tgs, ss)
@@ -479,6 +727,11 @@ loop:
 		if err == nil {
 			err = tr.awaitTargetResult(ctx, tgs, ss)
 		}
+		if held {
+			for _, sem := range ss.semaphores {
+				sem.Release()
+			}
+		}
 		tr.mu.Lock()
 		if err != nil {
 			ctx.Errorf("%s", err)
@@ -493,16 +746,11 @@ loop:
 			tr.mu.Unlock()
 			break
 		}
-		if tgs.Res != nil {
-			tr.mu.Unlock()
+		done := tgs.CurPhase == targetStepPhaseEnd
+		tr.mu.Unlock()
+		if done {
 			break
 		}
-		i++
-		if i < len(tr.steps) {
-			tgs.CurStep = i
-			tgs.CurPhase = targetStepPhaseInit
-		}
-		tr.mu.Unlock()
 	}
 	tr.mu.Lock()
 	ctx.Debugf("%s: target handler finished", tgs)
@@ -523,8 +771,13 @@ func (tr *TestRunner) runStepIfNeeded(ss *stepState) error {
 	resumeState := ss.resumeState
 	ss.resumeState = nil
 
-	resultCh, addTarget, err := ss.stepRunner.Run(ss.ctx, ss.sb, ss.ev, resumeState, ss.resumeStateTargets)
+	stepCtx, stepSpan := tracing.Start(ss.ctx, "step", append(tracing.Coordinates(ss.ctx),
+		tracing.StepLabelKey.String(ss.sb.TestStepLabel),
+		tracing.StepNameKey.String(ss.sb.TestStep.Name()),
+	)...)
+	resultCh, addTarget, err := ss.stepRunner.Run(stepCtx, ss.sb, ss.ev, resumeState, ss.resumeStateTargets)
 	if err != nil {
+		tracing.End(stepSpan, err)
 		return fmt.Errorf("failed to stert a step runner for '%s': %v", ss.sb.TestStepLabel, err)
 	}
 	ss.addTarget = addTarget
@@ -535,6 +788,7 @@ func (tr *TestRunner) runStepIfNeeded(ss *stepState) error {
 			tr.mu.Lock()
 			defer tr.mu.Unlock()
 
+			tracing.End(stepSpan, ss.runErr)
 			ss.readingLoopRunning = false
 			tr.monitorCond.Signal()
 		}()
@@ -633,53 +887,89 @@ func (tr *TestRunner) checkStepRunnersFailed() error {
 	return nil
 }
 
+// successorsLocked returns the indexes of the steps a target may be routed to
+// after finishing the given step.
+func (tr *TestRunner) successorsLocked(step int) []int {
+	ss := tr.steps[step]
+	if ss.sb.Finally {
+		return []int{step + 1}
+	}
+	succ := []int{ss.onSuccess, tr.firstFinally}
+	if ss.onFailure >= 0 {
+		succ = append(succ, ss.onFailure)
+	}
+	return succ
+}
+
+// reachableStepsLocked returns the set of steps that the running targets may still be injected into.
+func (tr *TestRunner) reachableStepsLocked() []bool {
+	reachable := make([]bool, len(tr.steps))
+	for _, tgs := range tr.targets {
+		if !tgs.handlerRunning || tgs.CurPhase == targetStepPhaseEnd {
+			continue
+		}
+		var queue []int
+		if tgs.CurPhase < targetStepPhaseRun {
+			// Not injected into the current step yet.
+			queue = []int{tgs.CurStep}
+		} else {
+			queue = tr.successorsLocked(tgs.CurStep)
+			if retry := tr.steps[tgs.CurStep].sb.Retry; retry != nil && tgs.StepAttempt+1 < retry.MaxAttempts {
+				// The target may fail and be injected into the current step again.
+				queue = append(queue, tgs.CurStep)
+			}
+		}
+		seen := make(map[int]bool)
+		for len(queue) > 0 {
+			step := queue[0]
+			queue = queue[1:]
+			if step >= len(tr.steps) || seen[step] {
+				continue
+			}
+			seen[step] = true
+			reachable[step] = true
+			queue = append(queue, tr.successorsLocked(step)...)
+		}
+	}
+	return reachable
+}
+
 // runMonitor monitors progress of targets through the pipeline
 // and closes input channels of the steps to indicate that no more are expected.
 // It also monitors steps for critical errors and cancels the whole run.
 // Note: input channels remain open when cancellation is requested,
 // plugins are expected to handle it explicitly.
-func (tr *TestRunner) runMonitor(ctx xcontext.Context, minStep int) error {
+func (tr *TestRunner) runMonitor(ctx xcontext.Context) error {
 	ctx.Debugf("monitor: active")
-	if minStep < len(tr.steps) {
-		ctx.Debugf("monitor: starting at step %s", tr.steps[minStep])
-	}
 
 	// Run the main loop.
 	runErr := func() error {
 		tr.mu.Lock()
 		defer tr.mu.Unlock()
 
-		pass := 1
+		stopped := make([]bool, len(tr.steps))
+		numStopped := 0
 		var runErr error
-	stepLoop:
-		for step := minStep; step < len(tr.steps); pass++ {
-			ss := tr.steps[step]
-			ctx.Debugf("monitor pass %d: current step %s", pass, ss)
-			// Check if all the targets have either made it past the injection phase or terminated.
-			ok := true
-			for _, tgs := range tr.targets {
-				ctx.Debugf("monitor pass %d: %s: %s", pass, ss, tgs)
-				if !tgs.handlerRunning { // Not running anymore
+		for pass := 1; numStopped < len(tr.steps); pass++ {
+			if runErr = tr.checkStepRunnersFailed(); runErr != nil {
+				break
+			}
+			// Close the input channels of the steps that no running target can get to anymore.
+			// Targets only move along the routes, so a step never becomes reachable again.
+			reachable := tr.reachableStepsLocked()
+			for i, ss := range tr.steps {
+				if stopped[i] || reachable[i] {
 					continue
 				}
-				if tgs.CurStep < step || tgs.CurPhase < targetStepPhaseRun {
-					ctx.Debugf("monitor pass %d: %s: not all targets injected yet (%s)", pass, ss, tgs)
-					ok = false
-					break
-				}
+				ctx.Debugf("monitor pass %d: %s: no more targets, closing input channel", pass, ss)
+				ss.stepRunner.Stop()
+				stopped[i] = true
+				numStopped++
 			}
-			if runErr = tr.checkStepRunnersFailed(); runErr != nil {
-				break stepLoop
-			}
-			if !ok {
+			if numStopped < len(tr.steps) {
 				// Wait for notification: as progress is being made, we get notified.
 				tr.monitorCond.Wait()
-				continue
 			}
-			// All targets ok, close the step's input channel.
-			ctx.Debugf("monitor pass %d: %s: no more targets, closing input channel", pass, ss)
-			ss.stepRunner.Stop()
-			step++
 		}
 		return runErr
 	}()
@@ -6,24 +6,32 @@
 package runner
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/insomniacslk/xjson"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/linuxboot/contest/pkg/cerrors"
+	"github.com/linuxboot/contest/pkg/console"
 	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/resource"
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
 	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
 	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/linuxboot/contest/plugins/teststeps"
 	"github.com/linuxboot/contest/tests/common"
 	"github.com/linuxboot/contest/tests/common/goroutine_leak_check"
 	"github.com/linuxboot/contest/tests/plugins/teststeps/badtargets"
@@ -110,11 +118,21 @@ func (s *TestRunnerSuite) runWithTimeout(ctx xcontext.Context,
 	targets []*target.Target,
 	bundles []test.TestStepBundle,
 ) ([]byte, map[string]error, error) {
-	newCtx, cancel := xcontext.WithCancel(ctx)
-	test := &test.Test{
+	return s.runTestWithTimeout(ctx, tr, resumeState, runID, timeout, targets, &test.Test{
 		Name:             testName,
 		TestStepsBundles: bundles,
-	}
+	})
+}
+
+func (s *TestRunnerSuite) runTestWithTimeout(ctx xcontext.Context,
+	tr *TestRunner,
+	resumeState []byte,
+	runID types.RunID,
+	timeout time.Duration,
+	targets []*target.Target,
+	test *test.Test,
+) ([]byte, map[string]error, error) {
+	newCtx, cancel := xcontext.WithCancel(ctx)
 	emitterFactory := NewTestStepEventsEmitterFactory(s.MemoryStorage.StorageEngineVault, 1, runID, test.Name, 0)
 	resCh := make(chan runRes)
 	go func() {
@@ -292,6 +310,399 @@ func (s *TestRunnerSuite) Test3StepsNotReachedStepNotRun() {
 `, s.MemoryStorage.GetTargetEvents(ctx, testName, "T2"))
 }
 
+// Routed pipeline: T1 keeps failing step 1 and loops through recovery until it
+// runs out of visits, T2 fails step 2, T3 succeeds. Cleanup runs for everyone.
+func (s *TestRunnerSuite) TestRoutedStepsWithFinally() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	step1 := s.newTestStep(ctx, "Step 1", 0, "T1", "")
+	step1.OnSuccess = "Step 2"
+	step1.OnFailure = "Recover"
+	step1.MaxVisits = 2
+	recoverStep := s.newTestStep(ctx, "Recover", 0, "", "")
+	recoverStep.OnSuccess = "Step 1"
+	recoverStep.MaxVisits = 2
+	cleanup := s.newTestStep(ctx, "Cleanup", 0, "", "")
+	cleanup.Finally = true
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 5*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2"), tgt("T3")},
+		[]test.TestStepBundle{
+			step1,
+			recoverStep,
+			s.newTestStep(ctx, "Step 2", 0, "T2", ""),
+			cleanup,
+		},
+	)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), targetsResults, 3)
+	require.EqualError(s.T(), targetsResults["T1"], "target entered step 'Step 1' 2 times")
+	require.EqualError(s.T(), targetsResults["T2"], "target failed")
+	require.NoError(s.T(), targetsResults["T3"])
+
+	// T1 went through recovery twice, everyone went through cleanup.
+	t1Events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Equal(s.T(), 2, strings.Count(t1Events, `Step 1][Target{ID: "T1"} TargetIn]`))
+	require.Equal(s.T(), 2, strings.Count(t1Events, `Recover][Target{ID: "T1"} TargetOut]`))
+	require.Equal(s.T(), 1, strings.Count(t1Events, `Recover][Target{ID: "T1"} TargetErr "{\"Error\":\"target entered step 'Step 1' 2 times\"}"]`))
+	require.Equal(s.T(), 1, strings.Count(t1Events, `Cleanup][Target{ID: "T1"} TargetOut]`))
+	t2Events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T2")
+	require.NotContains(s.T(), t2Events, "Recover]")
+	require.Equal(s.T(), 1, strings.Count(t2Events, `Cleanup][Target{ID: "T2"} TargetOut]`))
+	require.Equal(s.T(), `
+{[1 1 SimpleTest 0 Step 1][Target{ID: "T3"} TargetIn]}
+{[1 1 SimpleTest 0 Step 1][Target{ID: "T3"} TestStartedEvent]}
+{[1 1 SimpleTest 0 Step 1][Target{ID: "T3"} TestFinishedEvent]}
+{[1 1 SimpleTest 0 Step 1][Target{ID: "T3"} TargetOut]}
+{[1 1 SimpleTest 0 Step 2][Target{ID: "T3"} TargetIn]}
+{[1 1 SimpleTest 0 Step 2][Target{ID: "T3"} TestStartedEvent]}
+{[1 1 SimpleTest 0 Step 2][Target{ID: "T3"} TestFinishedEvent]}
+{[1 1 SimpleTest 0 Step 2][Target{ID: "T3"} TargetOut]}
+{[1 1 SimpleTest 0 Cleanup][Target{ID: "T3"} TargetIn]}
+{[1 1 SimpleTest 0 Cleanup][Target{ID: "T3"} TestStartedEvent]}
+{[1 1 SimpleTest 0 Cleanup][Target{ID: "T3"} TestFinishedEvent]}
+{[1 1 SimpleTest 0 Cleanup][Target{ID: "T3"} TargetOut]}
+`, s.MemoryStorage.GetTargetEvents(ctx, testName, "T3"))
+}
+
+// A failing finally step fails targets which have succeeded so far.
+func (s *TestRunnerSuite) TestFinallyStepFails() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	cleanup := s.newTestStep(ctx, "Cleanup", 0, "T1", "")
+	cleanup.Finally = true
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2")},
+		[]test.TestStepBundle{
+			s.newTestStep(ctx, "Step 1", 0, "", ""),
+			cleanup,
+		},
+	)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), targetsResults, 2)
+	require.EqualError(s.T(), targetsResults["T1"], "target failed")
+	require.NoError(s.T(), targetsResults["T2"])
+}
+
+// Routes to unknown steps are rejected before anything runs.
+func (s *TestRunnerSuite) TestRouteToUnknownStep() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	step1 := s.newTestStep(ctx, "Step 1", 0, "", "")
+	step1.OnFailure = "Nonexistent"
+
+	tr := newTestRunner()
+	_, _, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1")},
+		[]test.TestStepBundle{step1},
+	)
+	require.Error(s.T(), err)
+}
+
+// A target failing its first attempt at a step with a retry policy gets injected again.
+func (s *TestRunnerSuite) TestStepRetryFlakyTarget() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				mu.Lock()
+				defer mu.Unlock()
+				attempts[target.ID]++
+				if target.ID == "T1" && attempts[target.ID] == 1 {
+					return fmt.Errorf("transient failure")
+				}
+				return nil
+			})
+		},
+		nil,
+	))
+	step := s.NewStep(ctx, "Step 1", stateFullStepName, nil)
+	step.Retry = &test.StepRetryPolicy{MaxAttempts: 3, Backoff: xjson.Duration(10 * time.Millisecond)}
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2")},
+		[]test.TestStepBundle{step},
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[string]error{
+		"T1": nil,
+		"T2": nil,
+	}, targetsResults)
+	require.Equal(s.T(), map[string]int{"T1": 2, "T2": 1}, attempts)
+
+	events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Equal(s.T(), 2, strings.Count(events, " TargetIn]"))
+	require.Equal(s.T(), 1, strings.Count(events, " TargetRetry "))
+	require.Contains(s.T(), events, `\"Attempt\":1,\"MaxAttempts\":3,\"Error\":\"transient failure\",\"Delay\":\"10ms\"`)
+	require.True(s.T(), strings.HasSuffix(events, " TargetOut]}\n"))
+}
+
+// A target failing all the attempts allowed by the retry policy fails the step.
+func (s *TestRunnerSuite) TestStepRetryExhausted() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	step := s.newTestStep(ctx, "Step 1", 0, "T1", "")
+	step.Retry = &test.StepRetryPolicy{MaxAttempts: 3, BackoffMultiplier: 2, Backoff: xjson.Duration(time.Millisecond)}
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1")},
+		[]test.TestStepBundle{step},
+	)
+	require.NoError(s.T(), err)
+	require.EqualError(s.T(), targetsResults["T1"], "target failed")
+	events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Equal(s.T(), 3, strings.Count(events, " TargetIn]"))
+	require.Equal(s.T(), 2, strings.Count(events, " TargetRetry "))
+}
+
+// Only errors matching the error regex of the retry policy are retried.
+func (s *TestRunnerSuite) TestStepRetryErrorRegex() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	step := s.newTestStep(ctx, "Step 1", 0, "T1", "")
+	step.Retry = &test.StepRetryPolicy{MaxAttempts: 3, ErrorRegex: "timeout"}
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1")},
+		[]test.TestStepBundle{step},
+	)
+	require.NoError(s.T(), err)
+	require.EqualError(s.T(), targetsResults["T1"], "target failed")
+	events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Equal(s.T(), 1, strings.Count(events, " TargetIn]"))
+	require.NotContains(s.T(), events, "TargetRetry")
+}
+
+// registerConcurrencyStep registers a step recording the highest number of
+// targets it processes at the same time.
+func (s *TestRunnerSuite) registerConcurrencyStep() *int {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+		},
+		nil,
+	))
+	return &maxRunning
+}
+
+// MaxParallel limits how many targets run a step at the same time.
+func (s *TestRunnerSuite) TestStepMaxParallel() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	maxRunning := s.registerConcurrencyStep()
+	step := s.NewStep(ctx, "Step 1", stateFullStepName, nil)
+	step.MaxParallel = 2
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2"), tgt("T3"), tgt("T4"), tgt("T5")},
+		[]test.TestStepBundle{step},
+	)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), targetsResults, 5)
+	require.Equal(s.T(), 2, *maxRunning)
+
+	waiting := 0
+	for _, id := range []string{"T1", "T2", "T3", "T4", "T5"} {
+		events := s.MemoryStorage.GetTargetEvents(ctx, testName, id)
+		waiting += strings.Count(events, " TargetWaiting ")
+		require.True(s.T(), strings.HasSuffix(events, " TargetOut]}\n"))
+	}
+	require.GreaterOrEqual(s.T(), waiting, 3)
+}
+
+// Steps using the same named resource share its capacity.
+func (s *TestRunnerSuite) TestStepResources() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	resource.SetCapacities(map[string]uint{"hwaas-host-3": 1})
+	defer resource.SetCapacities(nil)
+
+	maxRunning := s.registerConcurrencyStep()
+	step1 := s.NewStep(ctx, "Step 1", stateFullStepName, nil)
+	step1.Resources = []string{"hwaas-host-3"}
+	step2 := s.NewStep(ctx, "Step 2", stateFullStepName, nil)
+	step2.Resources = []string{"hwaas-host-3"}
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2"), tgt("T3")},
+		[]test.TestStepBundle{step1, step2},
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[string]error{"T1": nil, "T2": nil, "T3": nil}, targetsResults)
+	require.Equal(s.T(), 1, *maxRunning)
+
+	var events string
+	for _, id := range []string{"T1", "T2", "T3"} {
+		events += s.MemoryStorage.GetTargetEvents(ctx, testName, id)
+	}
+	require.Contains(s.T(), events, `\"Resource\":\"hwaas-host-3\",\"Capacity\":1`)
+	sem, err := resource.Get("hwaas-host-3")
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), sem.InUse())
+}
+
+// Steps cannot use resources which are not configured.
+func (s *TestRunnerSuite) TestStepUnknownResource() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	step := s.newTestStep(ctx, "Step 1", 0, "", "")
+	step.Resources = []string{"nonexistent"}
+
+	tr := newTestRunner()
+	_, _, err := s.runWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1")},
+		[]test.TestStepBundle{step},
+	)
+	require.EqualError(s.T(), err, `step 'Step 1': unknown resource "nonexistent"`)
+}
+
+// The consoles of the targets are recorded from the beginning of the test and
+// the steps can match the console logs.
+func (s *TestRunnerSuite) TestConsoleRecorder() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				rec := console.TargetRecorder(ctx, target.ID)
+				if rec == nil {
+					return fmt.Errorf("no console recorder")
+				}
+				ctx, cancel := xcontext.WithTimeout(ctx, time.Second)
+				defer cancel()
+				_, err := rec.Match(ctx, 0, []*regexp.Regexp{regexp.MustCompile("booted " + target.ID)})
+				return err
+			})
+		},
+		nil,
+	))
+
+	tr := newTestRunner()
+	_, targetsResults, err := s.runTestWithTimeout(ctx, tr, nil, 1, 2*time.Second,
+		[]*target.Target{tgt("T1"), tgt("T2")},
+		&test.Test{
+			Name: testName,
+			TestStepsBundles: []test.TestStepBundle{
+				s.newTestStep(ctx, "Step 1", 0, "", ""),
+				s.NewStep(ctx, "Step 2", stateFullStepName, nil),
+			},
+			ConsoleRecorder: &test.ConsoleRecorderConfig{
+				Source:        test.ConsoleSourceCommand,
+				Command:       []string{"sh", "-c", "echo booted {{ .ID }}; sleep 10"},
+				FlushInterval: xjson.Duration(10 * time.Millisecond),
+			},
+		},
+	)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), map[string]error{"T1": nil, "T2": nil}, targetsResults)
+
+	events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Contains(s.T(), events, `ConsoleOutput "{\"Offset\":0,\"Data\":\"booted T1\\n\"}"`)
+}
+
+// The recording of the consoles continues at the end of the console logs when
+// a paused test is resumed.
+func (s *TestRunnerSuite) TestConsoleRecorderPauseResume() {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	defer cancel()
+
+	// The second step waits for the console output of the resumed test.
+	require.NoError(s.T(), s.RegisterStateFullStep(
+		func(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+			return teststeps.ForEachTarget(stateFullStepName, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+				ctx, cancel := xcontext.WithTimeout(ctx, time.Second)
+				defer cancel()
+				log, err := console.TargetRecorder(ctx, target.ID).Match(ctx, 10, []*regexp.Regexp{regexp.MustCompile("booted")})
+				if err == nil && string(log) != "booted "+target.ID+"\n" {
+					err = fmt.Errorf("unexpected console log %q", log)
+				}
+				return err
+			})
+		},
+		nil,
+	))
+
+	newTest := func() *test.Test {
+		return &test.Test{
+			Name: testName,
+			TestStepsBundles: []test.TestStepBundle{
+				// T1 is paused in this step.
+				s.newTestStep(ctx, "Step 1", 0, "", "T1=200"),
+				s.NewStep(ctx, "Step 2", stateFullStepName, nil),
+			},
+			ConsoleRecorder: &test.ConsoleRecorderConfig{
+				Source:        test.ConsoleSourceCommand,
+				Command:       []string{"sh", "-c", "echo booted {{ .ID }}; sleep 10"},
+				FlushInterval: xjson.Duration(10 * time.Millisecond),
+			},
+		}
+	}
+
+	var resumeState []byte
+	{
+		tr := newTestRunner()
+		ctx1, pause := xcontext.WithNotify(ctx, xcontext.ErrPaused)
+		ctx1, cancel := xcontext.WithCancel(ctx1)
+		defer cancel()
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			pause()
+		}()
+		var err error
+		resumeState, _, err = s.runTestWithTimeout(ctx1, tr, nil, 1, 2*time.Second, []*target.Target{tgt("T1")}, newTest())
+		require.IsType(s.T(), xcontext.ErrPaused, err)
+	}
+	var rs resumeStateStruct
+	require.NoError(s.T(), json.Unmarshal(resumeState, &rs))
+	require.Equal(s.T(), map[string]int64{"T1": 10}, rs.ConsoleOffsets)
+
+	{
+		tr := newTestRunner()
+		_, targetsResults, err := s.runTestWithTimeout(ctx, tr, resumeState, 1, 2*time.Second, []*target.Target{tgt("T1")}, newTest())
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), map[string]error{"T1": nil}, targetsResults)
+	}
+
+	events := s.MemoryStorage.GetTargetEvents(ctx, testName, "T1")
+	require.Contains(s.T(), events, `ConsoleOutput "{\"Offset\":0,\"Data\":\"booted T1\\n\"}"`)
+	require.Contains(s.T(), events, `ConsoleOutput "{\"Offset\":10,\"Data\":\"booted T1\\n\"}"`)
+}
+
 // A misbehaving step that fails to shut down properly after processing targets
 // and does not return.
 func (s *TestRunnerSuite) TestNoReturnStepWithCorrectTargetForwarding() {
@@ -66,6 +66,7 @@ func (e TestEventEmitter) Emit(ctx xcontext.Context, data testevent.Data) error
 	if err := storage.StoreTestEvent(ctx, event); err != nil {
 		return fmt.Errorf("could not persist event data %v: %v", data, err)
 	}
+	notifyJobEvents(e.header.JobID)
 	return nil
 }
 
@@ -154,6 +155,7 @@ func (ev FrameworkEventEmitter) Emit(ctx xcontext.Context, event frameworkevent.
 	if err := storage.StoreFrameworkEvent(ctx, event); err != nil {
 		return fmt.Errorf("could not persist event %v: %v", event, err)
 	}
+	notifyJobEvents(event.JobID)
 	return nil
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/types"
 )
@@ -133,3 +134,30 @@ func TestFrameworkEventFetcherConsistency(t *testing.T) {
 	require.Equal(t, storage.GetEventRequestCount(), 2)
 	require.Equal(t, storageAsync.GetEventRequestCount(), 1)
 }
+
+func TestSubscribeJobEvents(t *testing.T) {
+	f := mockTestEventEmitterData()
+	vault := NewSimpleEngineVault()
+	_, _ = mockStorage(t, vault)
+
+	events, cancel := SubscribeJobEvents(f.header.JobID)
+	other, cancelOther := SubscribeJobEvents(f.header.JobID + 1)
+	defer cancelOther()
+
+	// Notifications are coalesced until the subscriber receives them.
+	em := NewTestEventEmitter(vault, f.header)
+	require.NoError(t, em.Emit(f.ctx, testevent.Data{EventName: f.allowedEvents[0]}))
+	require.NoError(t, em.Emit(f.ctx, testevent.Data{EventName: f.allowedEvents[1]}))
+	require.Len(t, events, 1)
+	<-events
+
+	fem := NewFrameworkEventEmitter(vault)
+	require.NoError(t, fem.Emit(f.ctx, frameworkevent.Event{JobID: f.header.JobID, EventName: "FrameworkEvent"}))
+	require.Len(t, events, 1)
+	require.Len(t, other, 0)
+
+	cancel()
+	<-events
+	require.NoError(t, em.Emit(f.ctx, testevent.Data{EventName: f.allowedEvents[0]}))
+	require.Len(t, events, 0)
+}
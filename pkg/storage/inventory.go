@@ -0,0 +1,94 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// InventoryStorage defines the interface that implements persistence for the
+// targets of the inventory
+type InventoryStorage interface {
+	// StoreInventoryTarget adds a new target to the inventory, it fails if a
+	// target with the same ID exists
+	StoreInventoryTarget(ctx xcontext.Context, t *inventory.Target) error
+	// UpdateInventoryTarget replaces an existing target of the inventory
+	UpdateInventoryTarget(ctx xcontext.Context, t *inventory.Target) error
+	GetInventoryTarget(ctx xcontext.Context, targetID string) (*inventory.Target, error)
+	// ListInventoryTargets returns all the targets of the inventory, sorted
+	// by ID
+	ListInventoryTargets(ctx xcontext.Context) ([]*inventory.Target, error)
+	DeleteInventoryTarget(ctx xcontext.Context, targetID string) error
+}
+
+// InventoryStorageManager implements InventoryStorage interface
+type InventoryStorageManager struct {
+	vault EngineVault
+}
+
+// StoreInventoryTarget submits a new inventory target to the storage layer
+func (ism InventoryStorageManager) StoreInventoryTarget(ctx xcontext.Context, t *inventory.Target) error {
+	storage, err := ism.vault.GetEngine(SyncEngine)
+	if err != nil {
+		return err
+	}
+
+	return storage.StoreInventoryTarget(ctx, t)
+}
+
+// UpdateInventoryTarget updates an inventory target in the storage layer
+func (ism InventoryStorageManager) UpdateInventoryTarget(ctx xcontext.Context, t *inventory.Target) error {
+	storage, err := ism.vault.GetEngine(SyncEngine)
+	if err != nil {
+		return err
+	}
+
+	return storage.UpdateInventoryTarget(ctx, t)
+}
+
+// GetInventoryTarget fetches an inventory target from the storage layer
+func (ism InventoryStorageManager) GetInventoryTarget(ctx xcontext.Context, targetID string) (*inventory.Target, error) {
+	engineType := SyncEngine
+	if !isStronglyConsistent(ctx) {
+		engineType = AsyncEngine
+	}
+	storage, err := ism.vault.GetEngine(engineType)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.GetInventoryTarget(ctx, targetID)
+}
+
+// ListInventoryTargets returns the targets of the inventory
+func (ism InventoryStorageManager) ListInventoryTargets(ctx xcontext.Context) ([]*inventory.Target, error) {
+	engineType := SyncEngine
+	if !isStronglyConsistent(ctx) {
+		engineType = AsyncEngine
+	}
+	storage, err := ism.vault.GetEngine(engineType)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.ListInventoryTargets(ctx)
+}
+
+// DeleteInventoryTarget removes a target from the inventory
+func (ism InventoryStorageManager) DeleteInventoryTarget(ctx xcontext.Context, targetID string) error {
+	storage, err := ism.vault.GetEngine(SyncEngine)
+	if err != nil {
+		return err
+	}
+
+	return storage.DeleteInventoryTarget(ctx, targetID)
+}
+
+// NewInventoryStorageManager creates a new InventoryStorageManager object
+func NewInventoryStorageManager(vault EngineVault) InventoryStorageManager {
+	return InventoryStorageManager{vault: vault}
+}
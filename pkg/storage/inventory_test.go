@@ -0,0 +1,52 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventoryStorageConsistency(t *testing.T) {
+	baseCtx := logrusctx.NewContext(logger.LevelDebug)
+	vault := NewSimpleEngineVault()
+	ism := NewInventoryStorageManager(vault)
+
+	var cases = []struct {
+		name   string
+		getter func(ctx xcontext.Context, ism *InventoryStorageManager)
+	}{
+		{
+			"TestGetInventoryTarget",
+			func(ctx xcontext.Context, ism *InventoryStorageManager) { _, _ = ism.GetInventoryTarget(ctx, "T1") },
+		},
+		{
+			"TestListInventoryTargets",
+			func(ctx xcontext.Context, ism *InventoryStorageManager) { _, _ = ism.ListInventoryTargets(ctx) },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage, storageAsync := mockStorage(t, vault)
+
+			// test with default context
+			tc.getter(baseCtx, &ism)
+			require.Equal(t, 1, storage.GetInventoryRequestCount())
+			require.Equal(t, 0, storageAsync.GetInventoryRequestCount())
+
+			// test with explicit relaxed consistency
+			ctx := WithConsistencyModel(baseCtx, ConsistentEventually)
+			tc.getter(ctx, &ism)
+			require.Equal(t, 1, storage.GetInventoryRequestCount())
+			require.Equal(t, 1, storageAsync.GetInventoryRequestCount())
+		})
+	}
+}
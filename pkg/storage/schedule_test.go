@@ -0,0 +1,52 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleStorageConsistency(t *testing.T) {
+	baseCtx := logrusctx.NewContext(logger.LevelDebug)
+	vault := NewSimpleEngineVault()
+	ssm := NewScheduleStorageManager(vault)
+
+	var cases = []struct {
+		name   string
+		getter func(ctx xcontext.Context, ssm *ScheduleStorageManager)
+	}{
+		{
+			"TestGetSchedule",
+			func(ctx xcontext.Context, ssm *ScheduleStorageManager) { _, _ = ssm.GetSchedule(ctx, 1) },
+		},
+		{
+			"TestListSchedules",
+			func(ctx xcontext.Context, ssm *ScheduleStorageManager) { _, _ = ssm.ListSchedules(ctx, "") },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			storage, storageAsync := mockStorage(t, vault)
+
+			// test with default context
+			tc.getter(baseCtx, &ssm)
+			require.Equal(t, 1, storage.GetScheduleRequestCount())
+			require.Equal(t, 0, storageAsync.GetScheduleRequestCount())
+
+			// test with explicit relaxed consistency
+			ctx := WithConsistencyModel(baseCtx, ConsistentEventually)
+			tc.getter(ctx, &ssm)
+			require.Equal(t, 1, storage.GetScheduleRequestCount())
+			require.Equal(t, 1, storageAsync.GetScheduleRequestCount())
+		})
+	}
+}
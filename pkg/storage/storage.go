@@ -24,6 +24,8 @@ const consistencyModelKey = "storage_consistency_model"
 type Storage interface {
 	JobStorage
 	EventStorage
+	ScheduleStorage
+	InventoryStorage
 
 	// Close flushes and releases resources associated with the storage engine.
 	Close() error
@@ -10,6 +10,7 @@ import (
 
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
 	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
@@ -17,8 +18,10 @@ import (
 )
 
 type nullStorage struct {
-	jobRequestCount   int
-	eventRequestCount int
+	jobRequestCount       int
+	eventRequestCount     int
+	scheduleRequestCount  int
+	inventoryRequestCount int
 }
 
 func (n *nullStorage) GetJobRequestCount() int {
@@ -29,6 +32,14 @@ func (n *nullStorage) GetEventRequestCount() int {
 	return n.eventRequestCount
 }
 
+func (n *nullStorage) GetScheduleRequestCount() int {
+	return n.scheduleRequestCount
+}
+
+func (n *nullStorage) GetInventoryRequestCount() int {
+	return n.inventoryRequestCount
+}
+
 // jobs interface
 func (n *nullStorage) StoreJobRequest(ctx xcontext.Context, request *job.Request) (types.JobID, error) {
 	n.jobRequestCount++
@@ -76,6 +87,50 @@ func (n *nullStorage) GetFrameworkEvent(ctx xcontext.Context, eventQuery *framew
 	return nil, nil
 }
 
+// schedules interface
+func (n *nullStorage) StoreSchedule(ctx xcontext.Context, schedule *job.Schedule) (types.ScheduleID, error) {
+	n.scheduleRequestCount++
+	return types.ScheduleID(0), nil
+}
+func (n *nullStorage) UpdateSchedule(ctx xcontext.Context, schedule *job.Schedule) error {
+	n.scheduleRequestCount++
+	return nil
+}
+func (n *nullStorage) GetSchedule(ctx xcontext.Context, scheduleID types.ScheduleID) (*job.Schedule, error) {
+	n.scheduleRequestCount++
+	return nil, nil
+}
+func (n *nullStorage) ListSchedules(ctx xcontext.Context, serverID string) ([]*job.Schedule, error) {
+	n.scheduleRequestCount++
+	return nil, nil
+}
+func (n *nullStorage) DeleteSchedule(ctx xcontext.Context, scheduleID types.ScheduleID) error {
+	n.scheduleRequestCount++
+	return nil
+}
+
+// inventory interface
+func (n *nullStorage) StoreInventoryTarget(ctx xcontext.Context, t *inventory.Target) error {
+	n.inventoryRequestCount++
+	return nil
+}
+func (n *nullStorage) UpdateInventoryTarget(ctx xcontext.Context, t *inventory.Target) error {
+	n.inventoryRequestCount++
+	return nil
+}
+func (n *nullStorage) GetInventoryTarget(ctx xcontext.Context, targetID string) (*inventory.Target, error) {
+	n.inventoryRequestCount++
+	return nil, nil
+}
+func (n *nullStorage) ListInventoryTargets(ctx xcontext.Context) ([]*inventory.Target, error) {
+	n.inventoryRequestCount++
+	return nil, nil
+}
+func (n *nullStorage) DeleteInventoryTarget(ctx xcontext.Context, targetID string) error {
+	n.inventoryRequestCount++
+	return nil
+}
+
 func (n *nullStorage) Close() error {
 	return nil
 }
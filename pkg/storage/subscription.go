@@ -0,0 +1,54 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package storage
+
+import (
+	"sync"
+
+	"github.com/linuxboot/contest/pkg/types"
+)
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[types.JobID]map[chan struct{}]struct{})
+)
+
+// SubscribeJobEvents returns a channel which is signalled whenever test or
+// framework events of a job are emitted by this process, and a function
+// which cancels the subscription. Notifications are coalesced: a subscriber
+// which is busy receives a single notification for all the events emitted
+// in the meantime, and is expected to fetch them from the storage.
+func SubscribeJobEvents(jobID types.JobID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if subscribers[jobID] == nil {
+		subscribers[jobID] = make(map[chan struct{}]struct{})
+	}
+	subscribers[jobID][ch] = struct{}{}
+
+	return ch, func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		delete(subscribers[jobID], ch)
+		if len(subscribers[jobID]) == 0 {
+			delete(subscribers, jobID)
+		}
+	}
+}
+
+// notifyJobEvents signals the subscribers of a job that new events have been
+// stored.
+func notifyJobEvents(jobID types.JobID) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	for ch := range subscribers[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -6,6 +6,7 @@
 package target
 
 import (
+	"errors"
 	"time"
 
 	"github.com/linuxboot/contest/pkg/types"
@@ -20,6 +21,51 @@ type TargetManagerFactory func() TargetManager
 // needed things to be able to load a TestStep.
 type TargetManagerLoader func() (string, TargetManagerFactory)
 
+// ErrTargetsBusy is wrapped by the errors of Acquire when the targets could not
+// be acquired because they are locked by other jobs. The job then waits in the
+// acquisition queue for targets to be released, rather than failing.
+var ErrTargetsBusy = errors.New("targets are locked by other jobs")
+
+// BusyError is an error of Acquire for targets locked by other jobs, which
+// tells the targets the job is waiting for. Running jobs of lower priority
+// holding them may be preempted. It matches ErrTargetsBusy.
+type BusyError struct {
+	// TargetIDs are the IDs of the targets Acquire would have returned if
+	// they were not locked.
+	TargetIDs []string
+	// Needed is the number of targets of TargetIDs missing to acquire enough
+	// of them, all of them if zero.
+	Needed int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *BusyError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return ErrTargetsBusy.Error()
+}
+
+// Is makes BusyError match ErrTargetsBusy.
+func (e *BusyError) Is(target error) bool {
+	return target == ErrTargetsBusy
+}
+
+// Unwrap returns the underlying error.
+func (e *BusyError) Unwrap() error {
+	return e.Err
+}
+
+// TargetIDs returns the IDs of targets.
+func TargetIDs(targets []*Target) []string {
+	ids := make([]string, 0, len(targets))
+	for _, t := range targets {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
 // TargetManager is an interface used to acquire and release the targets to
 // run tests on.
 type TargetManager interface {
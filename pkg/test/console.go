@@ -0,0 +1,71 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/insomniacslk/xjson"
+)
+
+// Sources of the consoles recorded during a test.
+const (
+	// ConsoleSourceDUT is the console of the DUT backend of the target, see pkg/dut.
+	ConsoleSourceDUT = "dut"
+	// ConsoleSourceFile is a local file, e.g. a pty, a serial device or a FIFO.
+	ConsoleSourceFile = "file"
+	// ConsoleSourceTCP is a TCP socket, e.g. a QEMU serial port with -serial tcp::PORT,server.
+	ConsoleSourceTCP = "tcp"
+	// ConsoleSourceUnix is a unix socket, e.g. a QEMU serial port with -serial unix:PATH,server.
+	ConsoleSourceUnix = "unix"
+	// ConsoleSourceCommand is the output of a local command, e.g. ssh to a PiKVM reading its serial port.
+	ConsoleSourceCommand = "command"
+)
+
+// ConsoleRecorderConfig configures the recording of the console of every
+// target during the whole test, see pkg/console. The console log is emitted
+// as chunks in ConsoleOutput events, or written to a file in ArtifactDir. The
+// Address and Command are expanded as templates for each target.
+type ConsoleRecorderConfig struct {
+	Source string
+	// Port is the index of the console of the DUT backend for the dut source.
+	Port int `json:",omitempty"`
+	// Address is the path of the file or of the unix socket, or the
+	// host:port of the TCP socket.
+	Address string `json:",omitempty"`
+	// Command is the command and its arguments for the command source.
+	Command []string `json:",omitempty"`
+	// ChunkSize is the maximum size of the data of a ConsoleOutput event.
+	ChunkSize uint `json:",omitempty"`
+	// FlushInterval is the longest time console output waits before being
+	// emitted.
+	FlushInterval xjson.Duration `json:",omitempty"`
+	// ArtifactDir, if set, is the directory where the console logs are
+	// written instead of being emitted as events.
+	ArtifactDir string `json:",omitempty"`
+}
+
+// Validate checks that the source of the console is set up.
+func (c *ConsoleRecorderConfig) Validate() error {
+	switch c.Source {
+	case ConsoleSourceDUT:
+	case ConsoleSourceFile, ConsoleSourceTCP, ConsoleSourceUnix:
+		if c.Address == "" {
+			return fmt.Errorf("console source %s requires an address", c.Source)
+		}
+	case ConsoleSourceCommand:
+		if len(c.Command) == 0 {
+			return errors.New("console source command requires a command")
+		}
+	default:
+		return fmt.Errorf("unknown console source %q", c.Source)
+	}
+	if c.FlushInterval < 0 {
+		return errors.New("console flush interval cannot be negative")
+	}
+	return nil
+}
@@ -9,10 +9,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
+	"time"
+
+	"github.com/insomniacslk/xjson"
 
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/resource"
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
@@ -75,12 +80,139 @@ type TestStepsDescriptors struct {
 	TestSteps []*TestStepDescriptor
 }
 
+// Validate checks that the routing between the steps of a test is consistent:
+// routes must point to existing regular steps, finally steps must come after
+// all regular steps and cannot route targets themselves. The resources used by
+// the steps must be configured on the server.
+func (d TestStepsDescriptors) Validate() error {
+	labels := make(map[string]*TestStepDescriptor, len(d.TestSteps))
+	for _, ts := range d.TestSteps {
+		if ts == nil {
+			return errors.New("test step descriptor cannot be nil")
+		}
+		labels[ts.Label] = ts
+		if ts.Retry != nil {
+			if err := ts.Retry.Validate(); err != nil {
+				return fmt.Errorf("invalid retry policy for step %q: %w", ts.Label, err)
+			}
+		}
+		for _, name := range ts.Resources {
+			if _, err := resource.Get(name); err != nil {
+				return fmt.Errorf("step %q: %w", ts.Label, err)
+			}
+		}
+	}
+	seenFinally := false
+	for _, ts := range d.TestSteps {
+		if ts.Finally {
+			seenFinally = true
+			if ts.OnSuccess != "" || ts.OnFailure != "" {
+				return fmt.Errorf("finally step %q cannot have OnSuccess or OnFailure routes", ts.Label)
+			}
+			continue
+		}
+		if seenFinally {
+			return fmt.Errorf("step %q must come before all finally steps", ts.Label)
+		}
+		for _, route := range []string{ts.OnSuccess, ts.OnFailure} {
+			if route == "" {
+				continue
+			}
+			dst, ok := labels[route]
+			if !ok {
+				return fmt.Errorf("step %q routes to unknown step %q", ts.Label, route)
+			}
+			if dst.Finally {
+				return fmt.Errorf("step %q cannot route to finally step %q", ts.Label, route)
+			}
+		}
+	}
+	return nil
+}
+
+// StepRetryPolicy describes how a target that fails a step is injected into
+// the same step again before the failure is considered final.
+type StepRetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts uint32
+	// Backoff is the delay before the first retry.
+	Backoff xjson.Duration `json:",omitempty"`
+	// BackoffMultiplier multiplies the delay after every retry, the delay is
+	// constant if not specified.
+	BackoffMultiplier float64 `json:",omitempty"`
+	// MaxBackoff caps the delay between attempts, if specified.
+	MaxBackoff xjson.Duration `json:",omitempty"`
+	// ErrorRegex restricts retries to errors matching it, if specified.
+	ErrorRegex string `json:",omitempty"`
+}
+
+// Validate performs sanity checks on the retry policy.
+func (p *StepRetryPolicy) Validate() error {
+	if p.Backoff < 0 || p.MaxBackoff < 0 {
+		return errors.New("backoff cannot be negative")
+	}
+	if p.BackoffMultiplier != 0 && p.BackoffMultiplier < 1 {
+		return fmt.Errorf("backoff multiplier must be at least 1, got %v", p.BackoffMultiplier)
+	}
+	if p.ErrorRegex != "" {
+		if _, err := regexp.Compile(p.ErrorRegex); err != nil {
+			return fmt.Errorf("invalid error regex: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delay returns how long to wait before the given attempt, attempts being
+// numbered from 1.
+func (p *StepRetryPolicy) Delay(attempt uint32) time.Duration {
+	delay := float64(p.Backoff)
+	if p.BackoffMultiplier > 1 {
+		for i := uint32(2); i < attempt; i++ {
+			delay *= p.BackoffMultiplier
+			if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+				break
+			}
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	return time.Duration(delay)
+}
+
+// DefaultMaxStepVisits is the number of times a target may enter a step when
+// MaxVisits is not specified.
+const DefaultMaxStepVisits = 1
+
 // TestStepDescriptor is the definition of a test step matching a test step
 // configuration.
+//
+// By default targets that succeed a step proceed to the next one, while
+// targets that fail it skip to the finally steps and then leave the test.
+// OnSuccess and OnFailure override this by naming the label of the step the
+// target should go to instead. A failure routed via OnFailure is not final:
+// the result of the target is decided by the steps it visits afterwards.
+// Finally steps run for every target once it is done with the regular steps,
+// whatever the outcome. Since routes may form loops, MaxVisits limits how many
+// times a target can enter the step (DefaultMaxStepVisits if zero).
+// Retry makes a target that fails the step try it again, a failure is only
+// routed once all attempts are exhausted.
+// MaxParallel limits how many targets of the test run the step at the same
+// time (no limit if zero), and each target running the step also takes a unit
+// of every named resource in Resources, which are shared by all the jobs of
+// the server. Targets wait for a free slot before being injected.
 type TestStepDescriptor struct {
-	Name       string
-	Label      string
-	Parameters TestStepParameters
+	Name        string
+	Label       string
+	Parameters  TestStepParameters
+	OnSuccess   string           `json:",omitempty"`
+	OnFailure   string           `json:",omitempty"`
+	Finally     bool             `json:",omitempty"`
+	MaxVisits   uint             `json:",omitempty"`
+	Retry       *StepRetryPolicy `json:",omitempty"`
+	MaxParallel uint             `json:",omitempty"`
+	Resources   []string         `json:",omitempty"`
 }
 
 // TestStepBundle bundles the selected TestStep together with its parameters as
@@ -90,11 +222,19 @@ type TestStepBundle struct {
 	TestStepLabel string
 	Parameters    TestStepParameters
 	AllowedEvents map[event.Name]bool
+	OnSuccess     string
+	OnFailure     string
+	Finally       bool
+	MaxVisits     uint
+	Retry         *StepRetryPolicy
+	MaxParallel   uint
+	Resources     []string
 }
 
 // TestStepResult is used by TestSteps to report result for a particular target.
 // Empty Err means success, non-empty indicates failure.
-// Failed targets do not proceed to further steps in this run.
+// Failed targets do not proceed to further regular steps in this run, unless
+// the step routes them elsewhere via OnFailure.
 type TestStepResult struct {
 	Target *target.Target
 	Err    error
@@ -8,8 +8,13 @@ package test
 import (
 	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/insomniacslk/xjson"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/resource"
 )
 
 type paramSubStructure struct {
@@ -47,3 +52,64 @@ func TestTestStepParametersUnmarshalNested(t *testing.T) {
 	require.Equal(t, "bar", substruct.Val2)
 	require.Equal(t, "baz", substruct.More_nesting["foobar"])
 }
+
+func steps(ts ...*TestStepDescriptor) TestStepsDescriptors {
+	return TestStepsDescriptors{TestName: "test", TestSteps: ts}
+}
+
+func TestTestStepsDescriptorsValidate(t *testing.T) {
+	require.NoError(t, steps(
+		&TestStepDescriptor{Label: "flash", OnFailure: "recover", OnSuccess: "boot", MaxVisits: 2},
+		&TestStepDescriptor{Label: "recover", OnSuccess: "flash"},
+		&TestStepDescriptor{Label: "boot"},
+		&TestStepDescriptor{Label: "cleanup", Finally: true},
+	).Validate())
+
+	// unknown route
+	require.Error(t, steps(
+		&TestStepDescriptor{Label: "flash", OnFailure: "recover"},
+	).Validate())
+	// routing to a finally step
+	require.Error(t, steps(
+		&TestStepDescriptor{Label: "flash", OnFailure: "cleanup"},
+		&TestStepDescriptor{Label: "cleanup", Finally: true},
+	).Validate())
+	// finally step with a route
+	require.Error(t, steps(
+		&TestStepDescriptor{Label: "flash"},
+		&TestStepDescriptor{Label: "cleanup", Finally: true, OnSuccess: "flash"},
+	).Validate())
+	// regular step after a finally step
+	require.Error(t, steps(
+		&TestStepDescriptor{Label: "cleanup", Finally: true},
+		&TestStepDescriptor{Label: "flash"},
+	).Validate())
+
+	// resource not configured on the server
+	require.Error(t, steps(
+		&TestStepDescriptor{Label: "flash", Resources: []string{"hwaas-host-3"}},
+	).Validate())
+	resource.SetCapacities(map[string]uint{"hwaas-host-3": 2})
+	defer resource.SetCapacities(nil)
+	require.NoError(t, steps(
+		&TestStepDescriptor{Label: "flash", Resources: []string{"hwaas-host-3"}, MaxParallel: 4},
+	).Validate())
+}
+
+func TestStepRetryPolicy(t *testing.T) {
+	policy := StepRetryPolicy{
+		MaxAttempts:       5,
+		Backoff:           xjson.Duration(time.Second),
+		BackoffMultiplier: 2,
+		MaxBackoff:        xjson.Duration(3 * time.Second),
+	}
+	require.NoError(t, policy.Validate())
+	require.Equal(t, time.Second, policy.Delay(2))
+	require.Equal(t, 2*time.Second, policy.Delay(3))
+	require.Equal(t, 3*time.Second, policy.Delay(4))
+	require.Equal(t, 3*time.Second, policy.Delay(5))
+
+	require.Error(t, (&StepRetryPolicy{BackoffMultiplier: 0.5}).Validate())
+	require.Error(t, (&StepRetryPolicy{ErrorRegex: "("}).Validate())
+	require.Error(t, steps(&TestStepDescriptor{Label: "ping", Retry: &StepRetryPolicy{ErrorRegex: "("}}).Validate())
+}
@@ -8,6 +8,7 @@ package test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/insomniacslk/xjson"
 	"github.com/linuxboot/contest/pkg/target"
@@ -26,6 +27,8 @@ type Test struct {
 	TargetManagerBundle *target.TargetManagerBundle
 	TestFetcherBundle   *TestFetcherBundle
 	RetryParameters     RetryParameters
+	ConsoleRecorder     *ConsoleRecorderConfig
+	TargetIDs           []string
 }
 
 // TestDescriptor models the JSON encoded blob which is given as input to the
@@ -37,6 +40,16 @@ type TestDescriptor struct {
 
 	RetryParameters RetryParameters
 
+	// ConsoleRecorder, if set, records the console of the targets during the
+	// whole test.
+	ConsoleRecorder *ConsoleRecorderConfig `json:",omitempty"`
+
+	// TargetIDs, if set, restricts the test to the acquired targets with
+	// these IDs, e.g. to retry a job on the targets which failed it. The
+	// other targets are still acquired and released with the test. The job
+	// fails if any of these targets is not acquired.
+	TargetIDs []string `json:",omitempty"`
+
 	// TargetManager-related parameters
 	TargetManagerName              string
 	TargetManagerAcquireParameters json.RawMessage
@@ -55,5 +68,10 @@ func (d *TestDescriptor) Validate() error {
 	if d.TestFetcherName == "" {
 		return errors.New("test fetcher name cannot be empty")
 	}
+	if d.ConsoleRecorder != nil {
+		if err := d.ConsoleRecorder.Validate(); err != nil {
+			return fmt.Errorf("invalid console recorder: %w", err)
+		}
+	}
 	return nil
 }
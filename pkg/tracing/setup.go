@@ -0,0 +1,143 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// Exporters of the spans.
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+	// ExporterOTLP sends the spans to an OpenTelemetry collector with OTLP
+	// over HTTP.
+	ExporterOTLP = "otlp"
+	// ExporterFile appends the spans to a file as JSON, for offline use.
+	ExporterFile = "file"
+)
+
+// DefaultServiceName is the service name of the spans if none is configured.
+const DefaultServiceName = "contest"
+
+// Config is the configuration of the exporter of the spans.
+type Config struct {
+	// Exporter is one of ExporterNone (or empty), ExporterOTLP and
+	// ExporterFile.
+	Exporter string
+	// Endpoint is, for ExporterOTLP, the URL of the collector, e.g.
+	// http://localhost:4318. A plain host:port uses HTTPS. For ExporterFile
+	// it is the path of the file.
+	Endpoint string
+	// ServiceName is the service name of the spans, DefaultServiceName if
+	// empty.
+	ServiceName string
+}
+
+// Setup creates the exporter of cfg, and returns the Tracer reporting to it,
+// to be installed in the root context with bundles.OptionTracer. The Tracer
+// is nil if tracing is disabled. The returned function flushes the pending
+// spans and stops the exporter; it must be called before exiting.
+func Setup(ctx context.Context, cfg Config) (*Tracer, func(context.Context) error, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return nil, func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = newOTLPExporter(ctx, cfg.Endpoint)
+	case ExporterFile:
+		exporter, err = newFileExporter(cfg.Endpoint)
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName)))
+	if err != nil {
+		_ = exporter.Shutdown(ctx)
+		return nil, nil, fmt.Errorf("could not create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	return NewTracer(provider), provider.Shutdown, nil
+}
+
+func newOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("the otlp trace exporter needs an endpoint")
+	}
+	var opts []otlptracehttp.Option
+	u, err := url.Parse(endpoint)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		opts = append(opts, otlptracehttp.WithEndpoint(u.Host))
+		if u.Scheme == "http" {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create otlp trace exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+// fileExporter is a stdouttrace exporter writing to a file, which is closed
+// when the exporter shuts down.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func newFileExporter(path string) (sdktrace.SpanExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the file trace exporter needs a file path")
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open trace file: %w", err)
+	}
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("could not create file trace exporter: %w", err)
+	}
+	return &fileExporter{Exporter: exporter, file: file}, nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.Shutdown.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
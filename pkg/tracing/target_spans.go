@@ -0,0 +1,113 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tracing
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// TargetSpans holds the spans of the targets going through a test step. Test
+// steps receive all their targets on the same context, so the spans of the
+// targets are looked up by target ID with WithTargetSpan to trace the work
+// done for a target under its span.
+type TargetSpans struct {
+	// tracer is the tracer of the test step, nil if it is not traced.
+	tracer *Tracer
+	// attrs are the attributes of the spans of the targets besides their ID.
+	attrs []attribute.KeyValue
+
+	mu    sync.Mutex
+	spans map[string]trace.Span
+}
+
+// targetSpansKey is the key of the TargetSpans in the values of a context.
+type targetSpansKey struct{}
+
+// NewTargetSpans creates the TargetSpans of the test step traced by the
+// current span of ctx. The spans of the targets carry the job and run IDs of
+// ctx.
+func NewTargetSpans(ctx xcontext.Context) *TargetSpans {
+	tracer, _ := ctx.Tracer().(*Tracer)
+	return &TargetSpans{
+		tracer: tracer,
+		attrs:  Coordinates(ctx),
+		spans:  make(map[string]trace.Span),
+	}
+}
+
+// WithTargetSpans returns a copy of ctx carrying ts, to be passed to the test
+// step.
+func WithTargetSpans(ctx xcontext.Context, ts *TargetSpans) xcontext.Context {
+	return xcontext.WithValue(ctx, targetSpansKey{}, ts)
+}
+
+// Start starts the span of a target entering the test step.
+func (ts *TargetSpans) Start(targetID string) {
+	if ts.tracer == nil {
+		return
+	}
+	span := ts.tracer.start("target", append([]attribute.KeyValue{TargetID(targetID)}, ts.attrs...)...)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if prev := ts.spans[targetID]; prev != nil {
+		prev.End()
+	}
+	ts.spans[targetID] = span
+}
+
+// End ends the span of a target leaving the test step with the result err.
+func (ts *TargetSpans) End(targetID string, err error) {
+	ts.mu.Lock()
+	span := ts.spans[targetID]
+	delete(ts.spans, targetID)
+	ts.mu.Unlock()
+	if span != nil {
+		End(span, err)
+	}
+}
+
+// EndAll ends the spans of the targets still in the test step, e.g. when the
+// step is paused or canceled.
+func (ts *TargetSpans) EndAll(err error) {
+	ts.mu.Lock()
+	spans := ts.spans
+	ts.spans = make(map[string]trace.Span)
+	ts.mu.Unlock()
+	for _, span := range spans {
+		End(span, err)
+	}
+}
+
+// get returns the span of a target, if it is in the test step.
+func (ts *TargetSpans) get(targetID string) trace.Span {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.spans[targetID]
+}
+
+// WithTargetSpan returns a copy of the context of a test step with the span of
+// the target as current span, so that the spans started by the test step for
+// this target are its children. If the target has no span, ctx is returned.
+func WithTargetSpan(ctx xcontext.Context, targetID string) xcontext.Context {
+	ts, ok := ctx.Value(targetSpansKey{}).(*TargetSpans)
+	if !ok {
+		return ctx
+	}
+	span := ts.get(targetID)
+	if span == nil {
+		return ctx
+	}
+	tracer, ok := ctx.Tracer().(*Tracer)
+	if !ok {
+		return ctx
+	}
+	return ctx.WithTracer(tracer.withParent(span))
+}
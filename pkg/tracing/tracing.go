@@ -0,0 +1,179 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package tracing traces the execution of the jobs with OpenTelemetry. The
+// spans follow the structure of a job: job → run → test → step → target, and
+// the plugins can add their own spans under the one of the target they work
+// on, e.g. the transports trace the processes they run and the files they
+// copy.
+//
+// Tracer implements xcontext.Tracer and is installed in the root context with
+// bundles.OptionTracer. It carries the current span, so that the spans follow
+// the derivation of the contexts. Without it, Start returns spans which record
+// nothing and tracing costs close to nothing.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// instrumentationName is the name of the tracer of ConTest.
+const instrumentationName = "github.com/linuxboot/contest"
+
+// Attribute keys of the ConTest spans.
+const (
+	JobIDKey       = attribute.Key("contest.job_id")
+	JobNameKey     = attribute.Key("contest.job_name")
+	RunIDKey       = attribute.Key("contest.run_id")
+	TestNameKey    = attribute.Key("contest.test_name")
+	TestAttemptKey = attribute.Key("contest.test_attempt")
+	StepLabelKey   = attribute.Key("contest.step_label")
+	StepNameKey    = attribute.Key("contest.step_name")
+	TargetIDKey    = attribute.Key("contest.target_id")
+)
+
+// JobID returns the attribute of a job ID.
+func JobID(id types.JobID) attribute.KeyValue {
+	return JobIDKey.Int64(int64(id))
+}
+
+// RunID returns the attribute of a run ID.
+func RunID(id types.RunID) attribute.KeyValue {
+	return RunIDKey.Int64(int64(id))
+}
+
+// TargetID returns the attribute of a target ID.
+func TargetID(id string) attribute.KeyValue {
+	return TargetIDKey.String(id)
+}
+
+// Coordinates returns the attributes of the job and run IDs of ctx, if set.
+func Coordinates(ctx xcontext.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if jobID, ok := types.JobIDFromContext(ctx); ok {
+		attrs = append(attrs, JobID(jobID))
+	}
+	if runID, ok := types.RunIDFromContext(ctx); ok {
+		attrs = append(attrs, RunID(runID))
+	}
+	return attrs
+}
+
+var _ xcontext.Tracer = &Tracer{}
+
+// Tracer implements xcontext.Tracer with OpenTelemetry. The spans it starts
+// are children of its current span, and the fields of the context are set as
+// their attributes.
+type Tracer struct {
+	tracer trace.Tracer
+	// parent is the current span, nil for the root context.
+	parent trace.Span
+	fields []attribute.KeyValue
+}
+
+// NewTracer returns a Tracer reporting the spans to provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(instrumentationName)}
+}
+
+func (t *Tracer) start(name string, attrs ...attribute.KeyValue) trace.Span {
+	parentCtx := context.Background()
+	if t.parent != nil {
+		parentCtx = trace.ContextWithSpan(parentCtx, t.parent)
+	}
+	_, span := t.tracer.Start(parentCtx, name, trace.WithAttributes(t.fields...), trace.WithAttributes(attrs...))
+	return span
+}
+
+// withParent returns a copy of t with span as current span.
+func (t *Tracer) withParent(span trace.Span) *Tracer {
+	return &Tracer{tracer: t.tracer, parent: span, fields: t.fields}
+}
+
+// StartSpan implements xcontext.Tracer.
+func (t *Tracer) StartSpan(label string) xcontext.TimeSpan {
+	return &timeSpan{span: t.start(label), startTime: time.Now()}
+}
+
+// WithField implements xcontext.Tracer.
+func (t *Tracer) WithField(key string, value interface{}) xcontext.Tracer {
+	return t.WithFields(xcontext.Fields{key: value})
+}
+
+// WithFields implements xcontext.Tracer.
+func (t *Tracer) WithFields(fields xcontext.Fields) xcontext.Tracer {
+	result := &Tracer{tracer: t.tracer, parent: t.parent}
+	result.fields = make([]attribute.KeyValue, 0, len(t.fields)+len(fields))
+	result.fields = append(result.fields, t.fields...)
+	for key, value := range fields {
+		result.fields = append(result.fields, fieldAttribute(key, value))
+	}
+	return result
+}
+
+func fieldAttribute(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
+
+// timeSpan implements xcontext.TimeSpan.
+type timeSpan struct {
+	span      trace.Span
+	startTime time.Time
+}
+
+// Finish implements xcontext.TimeSpan.
+func (s *timeSpan) Finish() time.Duration {
+	s.span.End()
+	return time.Since(s.startTime)
+}
+
+// Start starts a span as a child of the current span of ctx, and returns a
+// copy of ctx with the new span as current span. The span must be ended with
+// End.
+func Start(ctx xcontext.Context, name string, attrs ...attribute.KeyValue) (xcontext.Context, trace.Span) {
+	t, ok := ctx.Tracer().(*Tracer)
+	if !ok {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	span := t.start(name, attrs...)
+	return ctx.WithTracer(t.withParent(span)), span
+}
+
+// End ends a span, with an error status if err is not nil. A pause is not
+// considered an error: it is recorded as an event of the span.
+func End(span trace.Span, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, xcontext.ErrPaused):
+		span.AddEvent("paused")
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,157 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// setupRecorder returns a context traced by a Tracer which records the
+// spans.
+func setupRecorder() (xcontext.Context, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return xcontext.Background().WithTracer(NewTracer(provider)), recorder
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	res := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		res[span.Name()] = span
+	}
+	return res
+}
+
+func TestStartHierarchy(t *testing.T) {
+	ctx, recorder := setupRecorder()
+
+	ctx, job := Start(ctx, "job", JobID(1))
+	ctx, run := Start(ctx, "run", RunID(2))
+	_, test := Start(ctx, "test")
+	End(test, errors.New("test failed"))
+	End(run, xcontext.ErrPaused)
+	End(job, nil)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 3)
+	require.False(t, spans["job"].Parent().IsValid())
+	require.Equal(t, spans["job"].SpanContext().SpanID(), spans["run"].Parent().SpanID())
+	require.Equal(t, spans["run"].SpanContext().SpanID(), spans["test"].Parent().SpanID())
+	require.Equal(t, spans["job"].SpanContext().TraceID(), spans["test"].SpanContext().TraceID())
+	require.Contains(t, spans["job"].Attributes(), JobID(1))
+
+	require.Equal(t, codes.Error, spans["test"].Status().Code)
+	require.Equal(t, codes.Unset, spans["run"].Status().Code)
+	require.Len(t, spans["run"].Events(), 1)
+	require.Equal(t, "paused", spans["run"].Events()[0].Name)
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	ctx := xcontext.Background()
+	spanCtx, span := Start(ctx, "job")
+	require.Equal(t, ctx, spanCtx)
+	require.False(t, span.SpanContext().IsValid())
+	End(span, errors.New("failed"))
+
+	ts := NewTargetSpans(ctx)
+	pluginCtx := WithTargetSpans(ctx, ts)
+	ts.Start("T1")
+	require.Equal(t, pluginCtx, WithTargetSpan(pluginCtx, "T1"))
+	ts.End("T1", nil)
+}
+
+func TestTracerFieldsAndTimeSpans(t *testing.T) {
+	ctx, recorder := setupRecorder()
+
+	ctx = ctx.WithField("job_id", 3).WithField("requestor", "alice")
+	ctx, job := Start(ctx, "job")
+	ctx.Tracer().StartSpan("lock").Finish()
+	End(job, nil)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 2)
+	require.Contains(t, spans["job"].Attributes(), attribute.Int("job_id", 3))
+	require.Contains(t, spans["job"].Attributes(), attribute.String("requestor", "alice"))
+	require.Equal(t, spans["job"].SpanContext().SpanID(), spans["lock"].Parent().SpanID())
+	require.Contains(t, spans["lock"].Attributes(), attribute.String("requestor", "alice"))
+}
+
+func TestTargetSpans(t *testing.T) {
+	ctx, recorder := setupRecorder()
+	ctx = xcontext.WithValue(ctx, types.KeyJobID, types.JobID(1))
+	ctx = xcontext.WithValue(ctx, types.KeyRunID, types.RunID(2))
+
+	stepCtx, step := Start(ctx, "step", Coordinates(ctx)...)
+	ts := NewTargetSpans(stepCtx)
+	pluginCtx := WithTargetSpans(stepCtx, ts)
+
+	ts.Start("T1")
+	ts.Start("T2")
+	_, exec := Start(WithTargetSpan(pluginCtx, "T1"), "exec")
+	End(exec, nil)
+	ts.End("T1", nil)
+	ts.EndAll(xcontext.ErrPaused)
+	End(step, nil)
+
+	// unknown targets keep the span of the step
+	require.Equal(t, pluginCtx, WithTargetSpan(pluginCtx, "T3"))
+
+	ended := recorder.Ended()
+	require.Len(t, ended, 4)
+	var targets []sdktrace.ReadOnlySpan
+	for _, span := range ended {
+		if span.Name() == "target" {
+			targets = append(targets, span)
+			require.Equal(t, step.SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+	require.Len(t, targets, 2)
+	require.Contains(t, targets[0].Attributes(), TargetID("T1"))
+	require.Contains(t, targets[0].Attributes(), JobID(1))
+	require.Contains(t, targets[0].Attributes(), RunID(2))
+	require.Equal(t, targets[0].SpanContext().SpanID(), spansByName(recorder)["exec"].Parent().SpanID())
+	require.Contains(t, targets[1].Attributes(), TargetID("T2"))
+}
+
+func TestSetupFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	tracer, shutdown, err := Setup(context.Background(), Config{Exporter: ExporterFile, Endpoint: path})
+	require.NoError(t, err)
+
+	_, span := Start(xcontext.Background().WithTracer(tracer), "job", JobID(42))
+	End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Name":"job"`)
+	require.Contains(t, string(data), `"contest.job_id"`)
+}
+
+func TestSetupInvalid(t *testing.T) {
+	_, _, err := Setup(context.Background(), Config{Exporter: "jaeger"})
+	require.Error(t, err)
+	_, _, err = Setup(context.Background(), Config{Exporter: ExporterFile})
+	require.Error(t, err)
+
+	tracer, shutdown, err := Setup(context.Background(), Config{})
+	require.NoError(t, err)
+	require.Nil(t, tracer)
+	require.NoError(t, shutdown(context.Background()))
+}
@@ -0,0 +1,486 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/insomniacslk/xjson"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	contestlistener "github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1"
+	"github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1/contestlistenerconnect"
+)
+
+// GRPC communicates with ConTest Server via the ConTestService exposed by the
+// grpclistener plugin, using a connect client.
+// GRPC implements the Transport interface
+type GRPC struct {
+	Addr string
+	// HTTPClient is the client used for the requests. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+func (g *GRPC) Version(ctx xcontext.Context, requestor string) (*api.VersionResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Version(ctx, connect.NewRequest(&contestlistener.VersionRequest{
+		Requestor: requestor,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("Version request failed: %w", err)
+	}
+	return &api.VersionResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataVersion{Version: resp.Msg.Version},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Start(ctx xcontext.Context, requestor string, jobDescriptor string) (*api.StartResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.StartJob(ctx, connect.NewRequest(&contestlistener.StartJobRequest{
+		Requestor: requestor,
+		Job:       []byte(jobDescriptor),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("StartJob request failed: %w", err)
+	}
+	return &api.StartResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataStart{JobID: types.JobID(resp.Msg.JobId)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Stop(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.StopResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.StopJob(ctx, connect.NewRequest(&contestlistener.StopJobRequest{
+		Requestor: requestor,
+		JobId:     int32(jobID),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("StopJob request failed: %w", err)
+	}
+	return &api.StopResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataStop{},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Status(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.StatusResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.GetJobStatus(ctx, connect.NewRequest(&contestlistener.GetJobStatusRequest{
+		Requestor: requestor,
+		JobId:     int32(jobID),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("GetJobStatus request failed: %w", err)
+	}
+	data := api.ResponseDataStatus{}
+	if resp.Msg.Status != nil {
+		if data.Status, err = jobStatusFromProto(jobID, resp.Msg.Status); err != nil {
+			return nil, fmt.Errorf("cannot decode job status: %v", err)
+		}
+	}
+	return &api.StatusResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     data,
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Retry(ctx xcontext.Context, requestor string, jobID types.JobID, failedTargetsOnly bool) (*api.RetryResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.RetryJob(ctx, connect.NewRequest(&contestlistener.RetryJobRequest{
+		Requestor:         requestor,
+		JobId:             int32(jobID),
+		FailedTargetsOnly: failedTargetsOnly,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("RetryJob request failed: %w", err)
+	}
+	return &api.RetryResponse{
+		ServerID: resp.Msg.ServerId,
+		Data: api.ResponseDataRetry{
+			JobID:    types.JobID(resp.Msg.JobId),
+			NewJobID: types.JobID(resp.Msg.NewJobId),
+		},
+		Err: newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Pause(ctx xcontext.Context, requestor string, jobID types.JobID, releaseTargets bool) (*api.PauseResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.PauseJob(ctx, connect.NewRequest(&contestlistener.PauseJobRequest{
+		Requestor:      requestor,
+		JobId:          int32(jobID),
+		ReleaseTargets: releaseTargets,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("PauseJob request failed: %w", err)
+	}
+	return &api.PauseResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataPause{JobID: types.JobID(resp.Msg.JobId)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) Resume(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.ResumeResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ResumeJob(ctx, connect.NewRequest(&contestlistener.ResumeJobRequest{
+		Requestor: requestor,
+		JobId:     int32(jobID),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("ResumeJob request failed: %w", err)
+	}
+	return &api.ResumeResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataResume{JobID: types.JobID(resp.Msg.JobId)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) List(ctx xcontext.Context, requestor string, states []job.State, tags []string) (*api.ListResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	req := &contestlistener.ListJobsRequest{
+		Requestor: requestor,
+		Tags:      tags,
+	}
+	for _, st := range states {
+		req.States = append(req.States, st.String())
+	}
+	resp, err := client.ListJobs(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("ListJobs request failed: %w", err)
+	}
+	var data api.ResponseDataList
+	for _, jobID := range resp.Msg.JobIds {
+		data.JobIDs = append(data.JobIDs, types.JobID(jobID))
+	}
+	return &api.ListResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     data,
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) AddSchedule(ctx xcontext.Context, requestor string, cronExpr string, overlapPolicy job.OverlapPolicy, jobDescriptor string) (*api.AddScheduleResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.AddSchedule(ctx, connect.NewRequest(&contestlistener.AddScheduleRequest{
+		Requestor:     requestor,
+		CronExpr:      cronExpr,
+		OverlapPolicy: string(overlapPolicy),
+		Job:           []byte(jobDescriptor),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("AddSchedule request failed: %w", err)
+	}
+	return &api.AddScheduleResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataAddSchedule{ScheduleID: types.ScheduleID(resp.Msg.ScheduleId)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) ListSchedules(ctx xcontext.Context, requestor string) (*api.ListSchedulesResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListSchedules(ctx, connect.NewRequest(&contestlistener.ListSchedulesRequest{
+		Requestor: requestor,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("ListSchedules request failed: %w", err)
+	}
+	var data api.ResponseDataListSchedules
+	for _, msg := range resp.Msg.Schedules {
+		schedule := &job.Schedule{
+			ID:            types.ScheduleID(msg.ScheduleId),
+			Requestor:     msg.Requestor,
+			ServerID:      msg.ServerId,
+			CreateTime:    msg.CreateTime.AsTime(),
+			CronExpr:      msg.CronExpr,
+			OverlapPolicy: job.OverlapPolicy(msg.OverlapPolicy),
+			Paused:        msg.Paused,
+			JobDescriptor: string(msg.Job),
+			LastJobID:     types.JobID(msg.LastJobId),
+		}
+		if msg.LastFireTime != nil {
+			schedule.LastFireTime = msg.LastFireTime.AsTime()
+		}
+		data.Schedules = append(data.Schedules, schedule)
+	}
+	return &api.ListSchedulesResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     data,
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) PauseSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID, paused bool) (*api.PauseScheduleResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.PauseSchedule(ctx, connect.NewRequest(&contestlistener.PauseScheduleRequest{
+		Requestor:  requestor,
+		ScheduleId: int32(scheduleID),
+		Paused:     paused,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("PauseSchedule request failed: %w", err)
+	}
+	return &api.PauseScheduleResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataPauseSchedule{ScheduleID: scheduleID, Paused: paused},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) DeleteSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID) (*api.DeleteScheduleResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DeleteSchedule(ctx, connect.NewRequest(&contestlistener.DeleteScheduleRequest{
+		Requestor:  requestor,
+		ScheduleId: int32(scheduleID),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("DeleteSchedule request failed: %w", err)
+	}
+	return &api.DeleteScheduleResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataDeleteSchedule{ScheduleID: scheduleID},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) AddInventoryTarget(ctx xcontext.Context, requestor string, t inventory.Target) (*api.AddInventoryTargetResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.AddInventoryTarget(ctx, connect.NewRequest(&contestlistener.AddInventoryTargetRequest{
+		Requestor: requestor,
+		Target:    inventoryTargetToProto(&t),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("AddInventoryTarget request failed: %w", err)
+	}
+	return &api.AddInventoryTargetResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataAddInventoryTarget{Target: inventoryTargetFromProto(resp.Msg.Target)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) ListInventoryTargets(ctx xcontext.Context, requestor string, selector string) (*api.ListInventoryTargetsResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListInventoryTargets(ctx, connect.NewRequest(&contestlistener.ListInventoryTargetsRequest{
+		Requestor: requestor,
+		Selector:  selector,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("ListInventoryTargets request failed: %w", err)
+	}
+	var data api.ResponseDataListInventoryTargets
+	for _, msg := range resp.Msg.Targets {
+		data.Targets = append(data.Targets, inventoryTargetFromProto(msg))
+	}
+	return &api.ListInventoryTargetsResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     data,
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) RemoveInventoryTarget(ctx xcontext.Context, requestor string, targetID string) (*api.RemoveInventoryTargetResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.RemoveInventoryTarget(ctx, connect.NewRequest(&contestlistener.RemoveInventoryTargetRequest{
+		Requestor: requestor,
+		TargetId:  targetID,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("RemoveInventoryTarget request failed: %w", err)
+	}
+	return &api.RemoveInventoryTargetResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataRemoveInventoryTarget{TargetID: targetID},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) DrainInventoryTarget(ctx xcontext.Context, requestor string, targetID string, drained bool) (*api.DrainInventoryTargetResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.DrainInventoryTarget(ctx, connect.NewRequest(&contestlistener.DrainInventoryTargetRequest{
+		Requestor: requestor,
+		TargetId:  targetID,
+		Drained:   drained,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("DrainInventoryTarget request failed: %w", err)
+	}
+	return &api.DrainInventoryTargetResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataDrainInventoryTarget{Target: inventoryTargetFromProto(resp.Msg.Target)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) LabelInventoryTarget(ctx xcontext.Context, requestor string, targetID string, set map[string]string, remove []string) (*api.LabelInventoryTargetResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.LabelInventoryTarget(ctx, connect.NewRequest(&contestlistener.LabelInventoryTargetRequest{
+		Requestor: requestor,
+		TargetId:  targetID,
+		Set:       set,
+		Remove:    remove,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("LabelInventoryTarget request failed: %w", err)
+	}
+	return &api.LabelInventoryTargetResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataLabelInventoryTarget{Target: inventoryTargetFromProto(resp.Msg.Target)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func (g *GRPC) SetInventoryTargetHealth(ctx xcontext.Context, requestor string, targetID string, health inventory.Health) (*api.SetInventoryTargetHealthResponse, error) {
+	client, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.SetInventoryTargetHealth(ctx, connect.NewRequest(&contestlistener.SetInventoryTargetHealthRequest{
+		Requestor: requestor,
+		TargetId:  targetID,
+		Health:    string(health),
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("SetInventoryTargetHealth request failed: %w", err)
+	}
+	return &api.SetInventoryTargetHealthResponse{
+		ServerID: resp.Msg.ServerId,
+		Data:     api.ResponseDataSetInventoryTargetHealth{Target: inventoryTargetFromProto(resp.Msg.Target)},
+		Err:      newError(resp.Msg.Error),
+	}, nil
+}
+
+func inventoryTargetToProto(t *inventory.Target) *contestlistener.InventoryTarget {
+	msg := &contestlistener.InventoryTarget{
+		TargetId: t.ID,
+		Fqdn:     t.FQDN,
+		Labels:   t.Labels,
+		Health:   string(t.Health),
+		Drained:  t.Drained,
+	}
+	if t.PrimaryIPv4 != nil {
+		msg.PrimaryIpv4 = t.PrimaryIPv4.String()
+	}
+	if t.PrimaryIPv6 != nil {
+		msg.PrimaryIpv6 = t.PrimaryIPv6.String()
+	}
+	return msg
+}
+
+// inventoryTargetFromProto converts a target returned by the server, it
+// returns nil if msg is nil, e.g. on errors.
+func inventoryTargetFromProto(msg *contestlistener.InventoryTarget) *inventory.Target {
+	if msg == nil {
+		return nil
+	}
+	t := &inventory.Target{
+		ID:          msg.TargetId,
+		FQDN:        msg.Fqdn,
+		PrimaryIPv4: net.ParseIP(msg.PrimaryIpv4),
+		PrimaryIPv6: net.ParseIP(msg.PrimaryIpv6),
+		Labels:      msg.Labels,
+		Health:      inventory.Health(msg.Health),
+		Drained:     msg.Drained,
+	}
+	if msg.UpdateTime != nil {
+		t.UpdateTime = msg.UpdateTime.AsTime()
+	}
+	return t
+}
+
+func (g *GRPC) client() (contestlistenerconnect.ConTestServiceClient, error) {
+	u, err := url.Parse(g.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server address '%s': %v", g.Addr, err)
+	}
+	if u.Scheme == "" {
+		return nil, errors.New("server URL scheme not specified")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme '%s', please specify either http or https", u.Scheme)
+	}
+	httpClient := g.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return contestlistenerconnect.NewConTestServiceClient(httpClient, u.String()), nil
+}
+
+func newError(msg string) *xjson.Error {
+	if msg == "" {
+		return nil
+	}
+	return xjson.NewError(errors.New(msg))
+}
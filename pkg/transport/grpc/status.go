@@ -0,0 +1,187 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/types"
+	contestlistener "github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// jobStatusFromProto converts the status of a job returned by the server. The
+// coordinates of the runs, tests, steps and targets are rebuilt from the
+// nesting of the messages.
+func jobStatusFromProto(jobID types.JobID, msg *contestlistener.JobStatus) (*job.Status, error) {
+	status := &job.Status{
+		Name:          msg.Name,
+		State:         msg.State,
+		StateErrMsg:   msg.StateError,
+		StartTime:     timestampFromProto(msg.StartTime),
+		RetryOf:       types.JobID(msg.RetryOf),
+		ScheduledBy:   types.ScheduleID(msg.ScheduledBy),
+		QueuePosition: int(msg.QueuePosition),
+	}
+	if msg.EndTime != nil {
+		endTime := msg.EndTime.AsTime()
+		status.EndTime = &endTime
+	}
+	for _, runMsg := range msg.RunStatuses {
+		status.RunStatuses = append(status.RunStatuses, runStatusFromProto(jobID, runMsg))
+	}
+	if len(status.RunStatuses) > 0 {
+		status.RunStatus = &status.RunStatuses[len(status.RunStatuses)-1]
+	}
+	if msg.JobReport != nil {
+		jobReport, err := jobReportFromProto(jobID, msg.JobReport)
+		if err != nil {
+			return nil, err
+		}
+		status.JobReport = jobReport
+	}
+	return status, nil
+}
+
+func runStatusFromProto(jobID types.JobID, msg *contestlistener.RunStatus) job.RunStatus {
+	runStatus := job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: jobID, RunID: types.RunID(msg.RunId)},
+		StartTime:      timestampFromProto(msg.StartTime),
+	}
+	for _, testMsg := range msg.TestStatuses {
+		testCoordinates := job.TestCoordinates{RunCoordinates: runStatus.RunCoordinates, TestName: testMsg.TestName}
+		testStatus := job.TestStatus{
+			TestCoordinates: testCoordinates,
+			TargetStatuses:  targetStatusesFromProto(testCoordinates, testMsg.TargetStatuses),
+		}
+		for _, stepMsg := range testMsg.TestStepStatuses {
+			testStatus.TestStepStatuses = append(testStatus.TestStepStatuses, job.TestStepStatus{
+				TestStepCoordinates: job.TestStepCoordinates{
+					TestCoordinates: testCoordinates,
+					TestStepName:    stepMsg.TestStepName,
+					TestStepLabel:   stepMsg.TestStepLabel,
+				},
+				Events:         testEventsFromProto(jobID, stepMsg.Events),
+				TargetStatuses: targetStatusesFromProto(testCoordinates, stepMsg.TargetStatuses),
+			})
+		}
+		runStatus.TestStatuses = append(runStatus.TestStatuses, testStatus)
+	}
+	return runStatus
+}
+
+func targetStatusesFromProto(testCoordinates job.TestCoordinates, msgs []*contestlistener.TargetStatus) []job.TargetStatus {
+	var targetStatuses []job.TargetStatus
+	for _, msg := range msgs {
+		targetStatuses = append(targetStatuses, job.TargetStatus{
+			TestStepCoordinates: job.TestStepCoordinates{
+				TestCoordinates: testCoordinates,
+				TestStepName:    msg.TestStepName,
+				TestStepLabel:   msg.TestStepLabel,
+			},
+			Target:  targetFromProto(msg.Target),
+			InTime:  timestampFromProto(msg.InTime),
+			OutTime: timestampFromProto(msg.OutTime),
+			Error:   msg.Error,
+			Events:  testEventsFromProto(testCoordinates.JobID, msg.Events),
+		})
+	}
+	return targetStatuses
+}
+
+func targetFromProto(msg *contestlistener.Target) *target.Target {
+	if msg == nil {
+		return nil
+	}
+	return &target.Target{
+		ID:                 msg.TargetId,
+		FQDN:               msg.Fqdn,
+		PrimaryIPv4:        net.ParseIP(msg.PrimaryIpv4),
+		PrimaryIPv6:        net.ParseIP(msg.PrimaryIpv6),
+		TargetManagerState: msg.TargetManagerState,
+	}
+}
+
+func testEventsFromProto(jobID types.JobID, msgs []*contestlistener.TestEvent) []testevent.Event {
+	var events []testevent.Event
+	for _, msg := range msgs {
+		ev := testevent.Event{
+			SequenceID: msg.SequenceId,
+			EmitTime:   timestampFromProto(msg.EmitTime),
+			Header: &testevent.Header{
+				JobID:         jobID,
+				RunID:         types.RunID(msg.RunId),
+				TestName:      msg.TestName,
+				TestAttempt:   msg.TestAttempt,
+				TestStepLabel: msg.TestStepLabel,
+			},
+			Data: &testevent.Data{EventName: event.Name(msg.EventName)},
+		}
+		if msg.TargetId != "" {
+			ev.Data.Target = &target.Target{ID: msg.TargetId}
+		}
+		if msg.Payload != nil {
+			payload := json.RawMessage(msg.Payload)
+			ev.Data.Payload = &payload
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func jobReportFromProto(jobID types.JobID, msg *contestlistener.JobReport) (*job.JobReport, error) {
+	jobReport := &job.JobReport{JobID: jobID}
+	for _, runMsg := range msg.RunReports {
+		var runReports []*job.Report
+		for _, reportMsg := range runMsg.Reports {
+			report, err := reportFromProto(jobID, reportMsg)
+			if err != nil {
+				return nil, err
+			}
+			runReports = append(runReports, report)
+		}
+		jobReport.RunReports = append(jobReport.RunReports, runReports)
+	}
+	for _, reportMsg := range msg.FinalReports {
+		report, err := reportFromProto(jobID, reportMsg)
+		if err != nil {
+			return nil, err
+		}
+		jobReport.FinalReports = append(jobReport.FinalReports, report)
+	}
+	return jobReport, nil
+}
+
+func reportFromProto(jobID types.JobID, msg *contestlistener.Report) (*job.Report, error) {
+	report := &job.Report{
+		JobID:        jobID,
+		RunID:        types.RunID(msg.RunId),
+		ReporterName: msg.ReporterName,
+		ReportTime:   timestampFromProto(msg.ReportTime),
+		Success:      msg.Success,
+	}
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, &report.Data); err != nil {
+			return nil, fmt.Errorf("cannot decode the data of the %s report: %v", msg.ReporterName, err)
+		}
+	}
+	return report, nil
+}
+
+// timestampFromProto converts a timestamp, an unset timestamp is the zero time.
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
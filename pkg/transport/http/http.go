@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
@@ -100,9 +101,12 @@ func (h *HTTP) Status(ctx xcontext.Context, requestor string, jobID types.JobID)
 	return &api.StatusResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
 }
 
-func (h *HTTP) Retry(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.RetryResponse, error) {
+func (h *HTTP) Retry(ctx xcontext.Context, requestor string, jobID types.JobID, failedTargetsOnly bool) (*api.RetryResponse, error) {
 	params := url.Values{}
 	params.Add("jobID", strconv.Itoa(int(jobID)))
+	if failedTargetsOnly {
+		params.Add("failedOnly", "true")
+	}
 	resp, err := h.request(ctx, requestor, "retry", params)
 	if err != nil {
 		return nil, err
@@ -116,6 +120,41 @@ func (h *HTTP) Retry(ctx xcontext.Context, requestor string, jobID types.JobID)
 	return &api.RetryResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
 }
 
+func (h *HTTP) Pause(ctx xcontext.Context, requestor string, jobID types.JobID, releaseTargets bool) (*api.PauseResponse, error) {
+	params := url.Values{}
+	params.Add("jobID", strconv.Itoa(int(jobID)))
+	if releaseTargets {
+		params.Add("releaseTargets", "true")
+	}
+	resp, err := h.request(ctx, requestor, "pause", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataPause{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.PauseResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) Resume(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.ResumeResponse, error) {
+	params := url.Values{}
+	params.Add("jobID", strconv.Itoa(int(jobID)))
+	resp, err := h.request(ctx, requestor, "resume", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataResume{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.ResumeResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
 func (h *HTTP) List(ctx xcontext.Context, requestor string, states []job.State, tags []string) (*api.ListResponse, error) {
 	params := url.Values{}
 	if len(states) > 0 {
@@ -141,6 +180,180 @@ func (h *HTTP) List(ctx xcontext.Context, requestor string, states []job.State,
 	return &api.ListResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
 }
 
+func (h *HTTP) AddSchedule(ctx xcontext.Context, requestor string, cronExpr string, overlapPolicy job.OverlapPolicy, jobDescriptor string) (*api.AddScheduleResponse, error) {
+	params := url.Values{}
+	params.Add("cron", cronExpr)
+	params.Add("overlap", string(overlapPolicy))
+	params.Add("jobDesc", jobDescriptor)
+	resp, err := h.request(ctx, requestor, "addschedule", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataAddSchedule{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.AddScheduleResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) ListSchedules(ctx xcontext.Context, requestor string) (*api.ListSchedulesResponse, error) {
+	resp, err := h.request(ctx, requestor, "listschedules", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataListSchedules{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.ListSchedulesResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) PauseSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID, paused bool) (*api.PauseScheduleResponse, error) {
+	params := url.Values{}
+	params.Add("scheduleID", strconv.FormatUint(uint64(scheduleID), 10))
+	params.Add("paused", strconv.FormatBool(paused))
+	resp, err := h.request(ctx, requestor, "pauseschedule", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataPauseSchedule{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.PauseScheduleResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) DeleteSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID) (*api.DeleteScheduleResponse, error) {
+	params := url.Values{}
+	params.Add("scheduleID", strconv.FormatUint(uint64(scheduleID), 10))
+	resp, err := h.request(ctx, requestor, "deleteschedule", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataDeleteSchedule{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.DeleteScheduleResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) AddInventoryTarget(ctx xcontext.Context, requestor string, t inventory.Target) (*api.AddInventoryTargetResponse, error) {
+	params := url.Values{}
+	params.Add("targetID", t.ID)
+	params.Add("fqdn", t.FQDN)
+	if t.PrimaryIPv4 != nil {
+		params.Add("ipv4", t.PrimaryIPv4.String())
+	}
+	if t.PrimaryIPv6 != nil {
+		params.Add("ipv6", t.PrimaryIPv6.String())
+	}
+	params.Add("labels", t.LabelsString())
+	params.Add("health", string(t.Health))
+	resp, err := h.request(ctx, requestor, "addinventorytarget", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataAddInventoryTarget{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.AddInventoryTargetResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) ListInventoryTargets(ctx xcontext.Context, requestor string, selector string) (*api.ListInventoryTargetsResponse, error) {
+	params := url.Values{}
+	params.Add("selector", selector)
+	resp, err := h.request(ctx, requestor, "listinventorytargets", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataListInventoryTargets{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.ListInventoryTargetsResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) RemoveInventoryTarget(ctx xcontext.Context, requestor string, targetID string) (*api.RemoveInventoryTargetResponse, error) {
+	params := url.Values{}
+	params.Add("targetID", targetID)
+	resp, err := h.request(ctx, requestor, "removeinventorytarget", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataRemoveInventoryTarget{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.RemoveInventoryTargetResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) DrainInventoryTarget(ctx xcontext.Context, requestor string, targetID string, drained bool) (*api.DrainInventoryTargetResponse, error) {
+	params := url.Values{}
+	params.Add("targetID", targetID)
+	params.Add("drained", strconv.FormatBool(drained))
+	resp, err := h.request(ctx, requestor, "draininventorytarget", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataDrainInventoryTarget{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.DrainInventoryTargetResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) LabelInventoryTarget(ctx xcontext.Context, requestor string, targetID string, set map[string]string, remove []string) (*api.LabelInventoryTargetResponse, error) {
+	params := url.Values{}
+	params.Add("targetID", targetID)
+	params.Add("set", inventory.FormatLabels(set))
+	params.Add("remove", strings.Join(remove, ","))
+	resp, err := h.request(ctx, requestor, "labelinventorytarget", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataLabelInventoryTarget{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.LabelInventoryTargetResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
+func (h *HTTP) SetInventoryTargetHealth(ctx xcontext.Context, requestor string, targetID string, health inventory.Health) (*api.SetInventoryTargetHealthResponse, error) {
+	params := url.Values{}
+	params.Add("targetID", targetID)
+	params.Add("health", string(health))
+	resp, err := h.request(ctx, requestor, "setinventorytargethealth", params)
+	if err != nil {
+		return nil, err
+	}
+	data := api.ResponseDataSetInventoryTargetHealth{}
+	if string(resp.Data) != "" {
+		if err := json.Unmarshal([]byte(resp.Data), &data); err != nil {
+			return nil, fmt.Errorf("cannot decode json response: %v", err)
+		}
+	}
+	return &api.SetInventoryTargetHealthResponse{ServerID: resp.ServerID, Data: data, Err: resp.Error}, nil
+}
+
 func (h *HTTP) request(ctx xcontext.Context, requestor string, verb string, params url.Values) (*HTTPPartiallyDecodedResponse, error) {
 	logger := xcontext.LoggerFrom(ctx)
 
@@ -7,6 +7,7 @@ package transport
 
 import (
 	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
@@ -19,6 +20,18 @@ type Transport interface {
 	Start(ctx xcontext.Context, requestor string, jobDescriptor string) (*api.StartResponse, error)
 	Stop(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.StopResponse, error)
 	Status(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.StatusResponse, error)
-	Retry(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.RetryResponse, error)
+	Retry(ctx xcontext.Context, requestor string, jobID types.JobID, failedTargetsOnly bool) (*api.RetryResponse, error)
+	Pause(ctx xcontext.Context, requestor string, jobID types.JobID, releaseTargets bool) (*api.PauseResponse, error)
+	Resume(ctx xcontext.Context, requestor string, jobID types.JobID) (*api.ResumeResponse, error)
 	List(ctx xcontext.Context, requestor string, states []job.State, tags []string) (*api.ListResponse, error)
+	AddSchedule(ctx xcontext.Context, requestor string, cronExpr string, overlapPolicy job.OverlapPolicy, jobDescriptor string) (*api.AddScheduleResponse, error)
+	ListSchedules(ctx xcontext.Context, requestor string) (*api.ListSchedulesResponse, error)
+	PauseSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID, paused bool) (*api.PauseScheduleResponse, error)
+	DeleteSchedule(ctx xcontext.Context, requestor string, scheduleID types.ScheduleID) (*api.DeleteScheduleResponse, error)
+	AddInventoryTarget(ctx xcontext.Context, requestor string, t inventory.Target) (*api.AddInventoryTargetResponse, error)
+	ListInventoryTargets(ctx xcontext.Context, requestor string, selector string) (*api.ListInventoryTargetsResponse, error)
+	RemoveInventoryTarget(ctx xcontext.Context, requestor string, targetID string) (*api.RemoveInventoryTargetResponse, error)
+	DrainInventoryTarget(ctx xcontext.Context, requestor string, targetID string, drained bool) (*api.DrainInventoryTargetResponse, error)
+	LabelInventoryTarget(ctx xcontext.Context, requestor string, targetID string, set map[string]string, remove []string) (*api.LabelInventoryTargetResponse, error)
+	SetInventoryTargetHealth(ctx xcontext.Context, requestor string, targetID string, health inventory.Health) (*api.SetInventoryTargetHealthResponse, error)
 }
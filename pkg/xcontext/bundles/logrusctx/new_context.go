@@ -77,11 +77,15 @@ func NewContext(logLevel logger.Level, opts ...bundles.Option) xcontext.Context
 		})
 	}
 
-	prometheusRegistry := prometheus.NewRegistry()
+	metrics := cfg.Metrics
+	if metrics == nil {
+		prometheusRegistry := prometheus.NewRegistry()
+		metrics = prometheusadapter.New(prometheusRegistry, prometheusRegistry)
+	}
 	ctx := xcontext.NewContext(
 		context.Background(), "",
 		logrusadapter.Adapter.Convert(entry),
-		prometheusadapter.New(prometheusRegistry, prometheusRegistry),
+		metrics,
 		cfg.Tracer,
 		nil, nil)
 	return ctx
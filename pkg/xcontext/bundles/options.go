@@ -63,6 +63,16 @@ func (opt OptionTracer) apply(cfg *Config) {
 	cfg.Tracer = opt.Tracer
 }
 
+// OptionMetrics defines the metrics handler of the context. By default a
+// Prometheus handler with a private registry is used.
+type OptionMetrics struct {
+	xcontext.Metrics
+}
+
+func (opt OptionMetrics) apply(cfg *Config) {
+	cfg.Metrics = opt.Metrics
+}
+
 // OptionTimestampFormat defines the format of timestamps while logging.
 type OptionTimestampFormat string
 
@@ -77,6 +87,7 @@ type Config struct {
 	TimestampFormat    string
 	VerboseCaller      bool
 	Tracer             xcontext.Tracer
+	Metrics            xcontext.Metrics
 	Format             LogFormat
 }
 
@@ -51,10 +51,14 @@ func NewContext(logLevel logger.Level, opts ...bundles.Option) xcontext.Context
 		panic(err)
 	}
 	loggerInstance := logger.ConvertLogger(loggerRaw.Sugar())
-	prometheusRegistry := prometheus.NewRegistry()
+	metrics := cfg.Metrics
+	if metrics == nil {
+		prometheusRegistry := prometheus.NewRegistry()
+		metrics = prometheusadapter.New(prometheusRegistry, prometheusRegistry)
+	}
 	ctx := xcontext.NewContext(
 		stdCtx, "",
-		loggerInstance, prometheusadapter.New(prometheusRegistry, prometheusRegistry), cfg.Tracer,
+		loggerInstance, metrics, cfg.Tracer,
 		nil, nil)
 	return ctx
 }
@@ -73,3 +73,19 @@ type Count interface {
 	// In terms of Prometheus the "tags" are called "labels".
 	WithOverriddenTags(Fields) Count
 }
+
+// Histogram is a metric which counts observations in buckets.
+//
+// See also https://prometheus.io/docs/concepts/metric_types/
+type Histogram interface {
+	// Observe adds the observation "v" to the metric.
+	Observe(v float64)
+}
+
+// HistogramMetrics is implemented by the handlers of metrics which support
+// histograms.
+type HistogramMetrics interface {
+	// Histogram returns the histogram metric with key "key". The upper
+	// bounds of its buckets are set when the metric is created.
+	Histogram(key string, buckets []float64) Histogram
+}
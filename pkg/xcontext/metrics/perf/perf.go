@@ -1,7 +1,61 @@
 package perf
 
+import (
+	"time"
+
+	"github.com/linuxboot/contest/pkg/xcontext/metrics"
+)
+
 // perf counter keys constants
 const (
 	ACQUIRED_TARGETS string = "acquired_targets"
-	RUNNING_JOBS     string = "running_jobs"
+	// RUNNING_JOBS was the number of running jobs.
+	//
+	// Deprecated: it is not reported anymore, use JOBS with the "running" state.
+	RUNNING_JOBS string = "running_jobs"
+
+	// JOBS is the number of jobs run by this server, by "state" (running, or
+	// queued waiting for busy targets).
+	JOBS string = "jobs"
+
+	// FINISHED_JOBS counts the jobs which stopped running, by final "state".
+	FINISHED_JOBS string = "finished_jobs"
+	// STEP_TARGET_DURATION is the time spent by targets in test steps, by "step" name.
+	STEP_TARGET_DURATION string = "step_target_duration"
+	// TARGET_LOCK_DURATION is the time taken to lock the targets of a job run.
+	TARGET_LOCK_DURATION string = "target_lock_duration"
+	// API_REQUEST_DURATION is the time taken to handle API requests, by "verb".
+	API_REQUEST_DURATION string = "api_request_duration"
+	// STORAGE_FLUSHES counts the flushes of the event buffers of the storage,
+	// by "kind" of events.
+	STORAGE_FLUSHES string = "storage_flushes"
+	// STORAGE_FLUSHED_EVENTS counts the events written by the flushes of the
+	// event buffers of the storage, by "kind" of events.
+	STORAGE_FLUSHED_EVENTS string = "storage_flushed_events"
 )
+
+// DurationBuckets are the upper bounds, in seconds, of the buckets of the
+// duration histograms. They go from API requests to long test steps.
+var DurationBuckets = []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900, 3600, 14400}
+
+// ObserveDuration records a duration in the histogram "key_seconds". Handlers
+// of metrics which don't support histograms only count the observations, in
+// the Count "key".
+func ObserveDuration(m metrics.Metrics, key string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	if hm, ok := m.(metrics.HistogramMetrics); ok {
+		hm.Histogram(key+"_seconds", DurationBuckets).Observe(d.Seconds())
+		return
+	}
+	m.Count(key).Add(1)
+}
+
+// AddJobs adds delta to the number of jobs in the given state.
+func AddJobs(m metrics.Metrics, state string, delta int64) {
+	if m == nil {
+		return
+	}
+	m.WithTags(nil).WithTag("state", state).IntGauge(JOBS).Add(delta)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package perf
+
+import (
+	"testing"
+	"time"
+
+	prometheusadapter "github.com/linuxboot/contest/pkg/xcontext/metrics/prometheus"
+	"github.com/linuxboot/contest/pkg/xcontext/metrics/simplemetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := prometheusadapter.New(registry, registry)
+	ObserveDuration(m.WithTag("verb", "start"), API_REQUEST_DURATION, 1500*time.Millisecond)
+	ObserveDuration(m.WithTag("verb", "start"), API_REQUEST_DURATION, 500*time.Millisecond)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, API_REQUEST_DURATION+"_seconds", families[0].GetName())
+	require.Len(t, families[0].Metric, 1)
+	histogram := families[0].Metric[0].GetHistogram()
+	require.Equal(t, uint64(2), histogram.GetSampleCount())
+	require.Equal(t, 2.0, histogram.GetSampleSum())
+	require.Len(t, histogram.Bucket, len(DurationBuckets))
+	for _, b := range histogram.Bucket {
+		switch {
+		case b.GetUpperBound() < 0.5:
+			require.Equal(t, uint64(0), b.GetCumulativeCount(), b.GetUpperBound())
+		case b.GetUpperBound() < 5:
+			require.Equal(t, uint64(1), b.GetCumulativeCount(), b.GetUpperBound())
+		default:
+			require.Equal(t, uint64(2), b.GetCumulativeCount(), b.GetUpperBound())
+		}
+	}
+}
+
+func TestObserveDurationWithoutHistograms(t *testing.T) {
+	m := simplemetrics.New()
+	ObserveDuration(m, API_REQUEST_DURATION, time.Second)
+	ObserveDuration(m, API_REQUEST_DURATION, time.Second)
+
+	require.Equal(t, uint64(2), m.Count(API_REQUEST_DURATION).Add(0))
+}
+
+func TestAddJobs(t *testing.T) {
+	m := simplemetrics.New()
+	AddJobs(m, "running", 2)
+	AddJobs(m, "queued", 1)
+	AddJobs(m, "running", -1)
+
+	require.Equal(t, int64(1), m.WithTag("state", "running").IntGauge(JOBS).Add(0))
+	require.Equal(t, int64(1), m.WithTag("state", "queued").IntGauge(JOBS).Add(0))
+}
+
+func TestObserveDurationNilMetrics(t *testing.T) {
+	require.NotPanics(t, func() {
+		ObserveDuration(nil, API_REQUEST_DURATION, time.Second)
+	})
+}
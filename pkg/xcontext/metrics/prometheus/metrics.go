@@ -17,7 +17,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-var _ metrics.Metrics = &Metrics{}
+var (
+	_ metrics.Metrics          = &Metrics{}
+	_ metrics.HistogramMetrics = &Metrics{}
+)
 
 type Fields = fields.Fields
 
@@ -98,6 +101,21 @@ func (v *GaugeVec) GetMetricWith(labels prometheus.Labels) (prometheus.Gauge, er
 	return v.GaugeVec.GetMetricWith(labelsWithPlaceholders(labels, v.PossibleLabels))
 }
 
+type HistogramVec struct {
+	*prometheus.HistogramVec
+	Key            string
+	Buckets        []float64
+	PossibleLabels []string
+}
+
+func (v *HistogramVec) AddPossibleLabels(newLabels []string) {
+	v.PossibleLabels = mergeSortedStrings(v.PossibleLabels, newLabels...)
+}
+
+func (v *HistogramVec) GetMetricWith(labels prometheus.Labels) (prometheus.Observer, error) {
+	return v.HistogramVec.GetMetricWith(labelsWithPlaceholders(labels, v.PossibleLabels))
+}
+
 type storage struct {
 	locker     sync.Mutex
 	registerer prometheus.Registerer
@@ -105,6 +123,7 @@ type storage struct {
 	count      map[string]*CounterVec
 	gauge      map[string]*GaugeVec
 	intGauge   map[string]*GaugeVec
+	histogram  map[string]*HistogramVec
 }
 
 // Metrics implements a wrapper of prometheus metrics to implement
@@ -139,6 +158,7 @@ func New(registerer prometheus.Registerer, gatherer prometheus.Gatherer) *Metric
 			count:      map[string]*CounterVec{},
 			gauge:      map[string]*GaugeVec{},
 			intGauge:   map[string]*GaugeVec{},
+			histogram:  map[string]*HistogramVec{},
 		},
 	}
 	return m
@@ -147,7 +167,7 @@ func New(registerer prometheus.Registerer, gatherer prometheus.Gatherer) *Metric
 func (m *Metrics) List() []prometheus.Collector {
 	m.storage.locker.Lock()
 	defer m.storage.locker.Unlock()
-	result := make([]prometheus.Collector, 0, len(m.storage.count)+len(m.storage.gauge)+len(m.storage.intGauge))
+	result := make([]prometheus.Collector, 0, len(m.storage.count)+len(m.storage.gauge)+len(m.storage.intGauge)+len(m.storage.histogram))
 
 	for _, count := range m.storage.count {
 		result = append(result, count.CounterVec)
@@ -161,6 +181,10 @@ func (m *Metrics) List() []prometheus.Collector {
 		result = append(result, intGauge.GaugeVec)
 	}
 
+	for _, histogram := range m.storage.histogram {
+		result = append(result, histogram.HistogramVec)
+	}
+
 	return result
 }
 
@@ -345,6 +369,66 @@ func (m *Metrics) IntGauge(key string) metrics.IntGauge {
 	return &IntGauge{Metrics: m, Key: key, GaugeVec: gaugeVec, Gauge: gauge}
 }
 
+func (m *Metrics) getOrCreateHistogramVec(key string, buckets []float64, possibleLabelNames []string) *HistogramVec {
+	histogramVec := m.histogram[key]
+	if histogramVec != nil {
+		return histogramVec
+	}
+
+	histogramVec = &HistogramVec{
+		HistogramVec: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    key,
+			Buckets: buckets,
+		}, possibleLabelNames),
+		Key:            key,
+		Buckets:        buckets,
+		PossibleLabels: possibleLabelNames,
+	}
+
+	m.histogram[key] = histogramVec
+
+	if m.registerer != nil {
+		err := m.registerer.Register(histogramVec)
+		if err != nil {
+			panic(fmt.Sprintf("key: '%v', err: %v", key, err))
+		}
+	}
+
+	return histogramVec
+}
+
+func (m *Metrics) deleteHistogramVec(histogramVec *HistogramVec) {
+	if m.registerer != nil {
+		if !unregister(m.registerer, histogramVec.HistogramVec) {
+			panic(histogramVec)
+		}
+	}
+	delete(m.histogram, histogramVec.Key)
+}
+
+// Histogram implements metrics.HistogramMetrics (see the description in the
+// interface). The metric is exported under "key" with the suffixes added by
+// Prometheus ("_bucket", "_sum" and "_count").
+func (m *Metrics) Histogram(key string, buckets []float64) metrics.Histogram {
+	m.storage.locker.Lock()
+	defer m.storage.locker.Unlock()
+
+	histogramVec := m.getOrCreateHistogramVec(key, buckets, m.labelNames)
+
+	observer, err := histogramVec.GetMetricWith(m.labels)
+	if err != nil {
+		m.deleteHistogramVec(histogramVec)
+		histogramVec.AddPossibleLabels(m.labelNames)
+		histogramVec = m.getOrCreateHistogramVec(key, histogramVec.Buckets, histogramVec.PossibleLabels)
+		observer, err = histogramVec.GetMetricWith(m.labels)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return observer
+}
+
 // WithTag implements context.Metrics (see the description in the interface).
 func (m *Metrics) WithTag(key string, value interface{}) metrics.Metrics {
 	result := &Metrics{
@@ -63,6 +63,26 @@ func TestMetricsList(t *testing.T) {
 	}
 }
 
+func TestHistogram(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New(registry, registry)
+	m.WithTag("step", "a").(*Metrics).Histogram("test", []float64{1, 10}).Observe(5)
+	// a new label recreates the metric
+	m.WithTags(Fields{"step": "a", "verb": "b"}).(*Metrics).Histogram("test", []float64{1, 10}).Observe(0.5)
+	m.WithTag("step", "a").(*Metrics).Histogram("test", nil).Observe(20)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	require.Equal(t, "test", families[0].GetName())
+	var count uint64
+	for _, metric := range families[0].Metric {
+		require.Len(t, metric.GetHistogram().Bucket, 2)
+		count += metric.GetHistogram().GetSampleCount()
+	}
+	require.Equal(t, uint64(2), count)
+}
+
 func TestMetricsRegistererDoubleUse(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	metrics0 := New(registry, nil)
@@ -0,0 +1,76 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package compositelistener implements an api.Listener serving several API
+// listeners at once, e.g. the HTTP and the gRPC ones.
+package compositelistener
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// CompositeListener implements the api.Listener interface by serving all its
+// listeners on the same API, so that the events of all of them are handled
+// by the same JobManager.
+//
+// The listeners are independent: a listener which fails or returns does not
+// stop the other ones. Serve returns when all the listeners have returned,
+// which happens when the context is cancelled.
+type CompositeListener struct {
+	listeners []api.Listener
+}
+
+// New instantiates a CompositeListener serving the given listeners.
+func New(listeners ...api.Listener) *CompositeListener {
+	return &CompositeListener{listeners: listeners}
+}
+
+// Serve implements the api.Listener.Serve interface method. It serves every
+// listener in its own goroutine, with its own child context, and returns the
+// errors of the listeners which failed.
+func (cl *CompositeListener) Serve(ctx xcontext.Context, a *api.API) error {
+	if a == nil {
+		return errors.New("API object is nil")
+	}
+	if len(cl.listeners) == 0 {
+		return errors.New("no listener to serve")
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errMsg []string
+	)
+	for i, l := range cl.listeners {
+		name := fmt.Sprintf("%d:%T", i, l)
+		lCtx, cancel := xcontext.WithCancel(ctx.WithField("listener", name))
+		wg.Add(1)
+		go func(l api.Listener) {
+			defer wg.Done()
+			defer cancel()
+			err := l.Serve(lCtx, a)
+			if err != nil {
+				lCtx.Errorf("Listener failed: %v", err)
+				mu.Lock()
+				errMsg = append(errMsg, fmt.Sprintf("listener %s: %v", name, err))
+				mu.Unlock()
+				return
+			}
+			lCtx.Infof("Listener shut down")
+		}(l)
+	}
+	wg.Wait()
+
+	if len(errMsg) > 0 {
+		return fmt.Errorf("%d of %d listeners failed: %s", len(errMsg), len(cl.listeners), strings.Join(errMsg, "; "))
+	}
+	return nil
+}
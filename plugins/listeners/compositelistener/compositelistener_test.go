@@ -0,0 +1,126 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package compositelistener
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+// fakeListener stops the job jobID through the API, then serves until its
+// context is cancelled, unless err is set.
+type fakeListener struct {
+	jobID types.JobID
+	err   error
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (l *fakeListener) Serve(ctx xcontext.Context, a *api.API) error {
+	if l.err != nil {
+		return l.err
+	}
+	if _, err := a.Stop(ctx, "unit-test", l.jobID); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	l.mu.Lock()
+	l.stopped = true
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *fakeListener) isStopped() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stopped
+}
+
+// fakeJobManager answers the API events and reports the job IDs they are for.
+func fakeJobManager(ctx xcontext.Context, a *api.API) <-chan types.JobID {
+	jobIDs := make(chan types.JobID, 10)
+	go func() {
+		for {
+			select {
+			case ev := <-a.Events:
+				jobIDs <- ev.Msg.(api.EventStopMsg).JobID
+				ev.RespCh <- &api.EventResponse{Requestor: ev.Msg.Requestor()}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return jobIDs
+}
+
+func serve(t *testing.T, listeners ...api.Listener) (xcontext.CancelFunc, <-chan types.JobID, <-chan error) {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	a, err := api.New(api.OptionServerID("unit-test"))
+	require.NoError(t, err)
+	jobIDs := fakeJobManager(ctx, a)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- New(listeners...).Serve(ctx, a)
+	}()
+	return cancel, jobIDs, errCh
+}
+
+func TestServeAllListeners(t *testing.T) {
+	l1, l2 := &fakeListener{jobID: 1}, &fakeListener{jobID: 2}
+	cancel, jobIDs, errCh := serve(t, l1, l2)
+
+	received := []types.JobID{<-jobIDs, <-jobIDs}
+	require.ElementsMatch(t, []types.JobID{1, 2}, received)
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancellation")
+	}
+	require.True(t, l1.isStopped())
+	require.True(t, l2.isStopped())
+}
+
+func TestServeIndependentListeners(t *testing.T) {
+	failing, serving := &fakeListener{err: errors.New("address already in use")}, &fakeListener{jobID: 2}
+	cancel, jobIDs, errCh := serve(t, failing, serving)
+
+	// the failure of a listener does not stop the other ones
+	require.Equal(t, types.JobID(2), <-jobIDs)
+	select {
+	case err := <-errCh:
+		t.Fatalf("Serve returned before cancellation: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	err := <-errCh
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 of 2 listeners failed")
+	require.Contains(t, err.Error(), "address already in use")
+	require.True(t, serving.isStopped())
+}
+
+func TestServeNoListener(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	a, err := api.New(api.OptionServerID("unit-test"))
+	require.NoError(t, err)
+	require.Error(t, New().Serve(ctx, a))
+	require.Error(t, New(&fakeListener{}).Serve(ctx, nil))
+}
@@ -0,0 +1,98 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+
+	"github.com/bufbuild/connect-go"
+
+	"github.com/linuxboot/contest/pkg/api"
+)
+
+// Authenticator maps the identity of a client to the requestor of its API
+// calls. The TLS connection state is nil if the client did not connect over
+// TLS. An error rejects the call as unauthenticated.
+type Authenticator func(ctx context.Context, header http.Header, tlsState *tls.ConnectionState) (api.EventRequestor, error)
+
+// CommonNameAuthenticator uses the common name of the verified client
+// certificate as requestor. It is meant to be used with mutual TLS.
+func CommonNameAuthenticator(_ context.Context, _ http.Header, tlsState *tls.ConnectionState) (api.EventRequestor, error) {
+	if tlsState == nil || len(tlsState.VerifiedChains) == 0 || len(tlsState.VerifiedChains[0]) == 0 {
+		return "", errors.New("no verified client certificate")
+	}
+	cn := tlsState.VerifiedChains[0][0].Subject.CommonName
+	if cn == "" {
+		return "", errors.New("client certificate has no common name")
+	}
+	return api.EventRequestor(cn), nil
+}
+
+type tlsStateKey struct{}
+
+type requestorKey struct{}
+
+// withTLSState makes the TLS connection state of the HTTP requests available
+// to the authInterceptor.
+func withTLSState(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			r = r.WithContext(context.WithValue(r.Context(), tlsStateKey{}, r.TLS))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// authInterceptor authenticates every call with an Authenticator, and stores
+// the resulting requestor in the context of the call.
+type authInterceptor struct {
+	authenticate Authenticator
+}
+
+func (i *authInterceptor) requestorContext(ctx context.Context, header http.Header) (context.Context, error) {
+	tlsState, _ := ctx.Value(tlsStateKey{}).(*tls.ConnectionState)
+	requestor, err := i.authenticate(ctx, header, tlsState)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	return context.WithValue(ctx, requestorKey{}, requestor), nil
+}
+
+func (i *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := i.requestorContext(ctx, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.requestorContext(ctx, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// requestorFrom returns the authenticated requestor of a call if any,
+// otherwise the one declared by the client in the request.
+func requestorFrom(ctx context.Context, declared string) api.EventRequestor {
+	if requestor, ok := ctx.Value(requestorKey{}).(api.EventRequestor); ok {
+		return requestor
+	}
+	return api.EventRequestor(declared)
+}
@@ -29,6 +29,24 @@ const (
 type ConTestServiceClient interface {
 	StartJob(context.Context, *connect_go.Request[contestlistener.StartJobRequest]) (*connect_go.Response[contestlistener.StartJobResponse], error)
 	StatusJob(context.Context, *connect_go.Request[contestlistener.StatusJobRequest]) (*connect_go.ServerStreamForClient[contestlistener.StatusJobResponse], error)
+	WatchJob(context.Context, *connect_go.Request[contestlistener.WatchJobRequest]) (*connect_go.ServerStreamForClient[contestlistener.WatchJobResponse], error)
+	GetJobStatus(context.Context, *connect_go.Request[contestlistener.GetJobStatusRequest]) (*connect_go.Response[contestlistener.GetJobStatusResponse], error)
+	StopJob(context.Context, *connect_go.Request[contestlistener.StopJobRequest]) (*connect_go.Response[contestlistener.StopJobResponse], error)
+	RetryJob(context.Context, *connect_go.Request[contestlistener.RetryJobRequest]) (*connect_go.Response[contestlistener.RetryJobResponse], error)
+	PauseJob(context.Context, *connect_go.Request[contestlistener.PauseJobRequest]) (*connect_go.Response[contestlistener.PauseJobResponse], error)
+	ResumeJob(context.Context, *connect_go.Request[contestlistener.ResumeJobRequest]) (*connect_go.Response[contestlistener.ResumeJobResponse], error)
+	ListJobs(context.Context, *connect_go.Request[contestlistener.ListJobsRequest]) (*connect_go.Response[contestlistener.ListJobsResponse], error)
+	Version(context.Context, *connect_go.Request[contestlistener.VersionRequest]) (*connect_go.Response[contestlistener.VersionResponse], error)
+	AddSchedule(context.Context, *connect_go.Request[contestlistener.AddScheduleRequest]) (*connect_go.Response[contestlistener.AddScheduleResponse], error)
+	ListSchedules(context.Context, *connect_go.Request[contestlistener.ListSchedulesRequest]) (*connect_go.Response[contestlistener.ListSchedulesResponse], error)
+	PauseSchedule(context.Context, *connect_go.Request[contestlistener.PauseScheduleRequest]) (*connect_go.Response[contestlistener.PauseScheduleResponse], error)
+	DeleteSchedule(context.Context, *connect_go.Request[contestlistener.DeleteScheduleRequest]) (*connect_go.Response[contestlistener.DeleteScheduleResponse], error)
+	AddInventoryTarget(context.Context, *connect_go.Request[contestlistener.AddInventoryTargetRequest]) (*connect_go.Response[contestlistener.AddInventoryTargetResponse], error)
+	ListInventoryTargets(context.Context, *connect_go.Request[contestlistener.ListInventoryTargetsRequest]) (*connect_go.Response[contestlistener.ListInventoryTargetsResponse], error)
+	RemoveInventoryTarget(context.Context, *connect_go.Request[contestlistener.RemoveInventoryTargetRequest]) (*connect_go.Response[contestlistener.RemoveInventoryTargetResponse], error)
+	DrainInventoryTarget(context.Context, *connect_go.Request[contestlistener.DrainInventoryTargetRequest]) (*connect_go.Response[contestlistener.DrainInventoryTargetResponse], error)
+	LabelInventoryTarget(context.Context, *connect_go.Request[contestlistener.LabelInventoryTargetRequest]) (*connect_go.Response[contestlistener.LabelInventoryTargetResponse], error)
+	SetInventoryTargetHealth(context.Context, *connect_go.Request[contestlistener.SetInventoryTargetHealthRequest]) (*connect_go.Response[contestlistener.SetInventoryTargetHealthResponse], error)
 }
 
 // NewConTestServiceClient constructs a client for the contest.v1.ConTestService service. By
@@ -51,13 +69,121 @@ func NewConTestServiceClient(httpClient connect_go.HTTPClient, baseURL string, o
 			baseURL+"/contest.v1.ConTestService/StatusJob",
 			opts...,
 		),
+		watchJob: connect_go.NewClient[contestlistener.WatchJobRequest, contestlistener.WatchJobResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/WatchJob",
+			opts...,
+		),
+		getJobStatus: connect_go.NewClient[contestlistener.GetJobStatusRequest, contestlistener.GetJobStatusResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/GetJobStatus",
+			opts...,
+		),
+		stopJob: connect_go.NewClient[contestlistener.StopJobRequest, contestlistener.StopJobResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/StopJob",
+			opts...,
+		),
+		retryJob: connect_go.NewClient[contestlistener.RetryJobRequest, contestlistener.RetryJobResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/RetryJob",
+			opts...,
+		),
+		pauseJob: connect_go.NewClient[contestlistener.PauseJobRequest, contestlistener.PauseJobResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/PauseJob",
+			opts...,
+		),
+		resumeJob: connect_go.NewClient[contestlistener.ResumeJobRequest, contestlistener.ResumeJobResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/ResumeJob",
+			opts...,
+		),
+		listJobs: connect_go.NewClient[contestlistener.ListJobsRequest, contestlistener.ListJobsResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/ListJobs",
+			opts...,
+		),
+		version: connect_go.NewClient[contestlistener.VersionRequest, contestlistener.VersionResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/Version",
+			opts...,
+		),
+		addSchedule: connect_go.NewClient[contestlistener.AddScheduleRequest, contestlistener.AddScheduleResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/AddSchedule",
+			opts...,
+		),
+		listSchedules: connect_go.NewClient[contestlistener.ListSchedulesRequest, contestlistener.ListSchedulesResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/ListSchedules",
+			opts...,
+		),
+		pauseSchedule: connect_go.NewClient[contestlistener.PauseScheduleRequest, contestlistener.PauseScheduleResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/PauseSchedule",
+			opts...,
+		),
+		deleteSchedule: connect_go.NewClient[contestlistener.DeleteScheduleRequest, contestlistener.DeleteScheduleResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/DeleteSchedule",
+			opts...,
+		),
+		addInventoryTarget: connect_go.NewClient[contestlistener.AddInventoryTargetRequest, contestlistener.AddInventoryTargetResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/AddInventoryTarget",
+			opts...,
+		),
+		listInventoryTargets: connect_go.NewClient[contestlistener.ListInventoryTargetsRequest, contestlistener.ListInventoryTargetsResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/ListInventoryTargets",
+			opts...,
+		),
+		removeInventoryTarget: connect_go.NewClient[contestlistener.RemoveInventoryTargetRequest, contestlistener.RemoveInventoryTargetResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/RemoveInventoryTarget",
+			opts...,
+		),
+		drainInventoryTarget: connect_go.NewClient[contestlistener.DrainInventoryTargetRequest, contestlistener.DrainInventoryTargetResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/DrainInventoryTarget",
+			opts...,
+		),
+		labelInventoryTarget: connect_go.NewClient[contestlistener.LabelInventoryTargetRequest, contestlistener.LabelInventoryTargetResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/LabelInventoryTarget",
+			opts...,
+		),
+		setInventoryTargetHealth: connect_go.NewClient[contestlistener.SetInventoryTargetHealthRequest, contestlistener.SetInventoryTargetHealthResponse](
+			httpClient,
+			baseURL+"/contest.v1.ConTestService/SetInventoryTargetHealth",
+			opts...,
+		),
 	}
 }
 
 // conTestServiceClient implements ConTestServiceClient.
 type conTestServiceClient struct {
-	startJob  *connect_go.Client[contestlistener.StartJobRequest, contestlistener.StartJobResponse]
-	statusJob *connect_go.Client[contestlistener.StatusJobRequest, contestlistener.StatusJobResponse]
+	startJob                 *connect_go.Client[contestlistener.StartJobRequest, contestlistener.StartJobResponse]
+	statusJob                *connect_go.Client[contestlistener.StatusJobRequest, contestlistener.StatusJobResponse]
+	watchJob                 *connect_go.Client[contestlistener.WatchJobRequest, contestlistener.WatchJobResponse]
+	getJobStatus             *connect_go.Client[contestlistener.GetJobStatusRequest, contestlistener.GetJobStatusResponse]
+	stopJob                  *connect_go.Client[contestlistener.StopJobRequest, contestlistener.StopJobResponse]
+	retryJob                 *connect_go.Client[contestlistener.RetryJobRequest, contestlistener.RetryJobResponse]
+	pauseJob                 *connect_go.Client[contestlistener.PauseJobRequest, contestlistener.PauseJobResponse]
+	resumeJob                *connect_go.Client[contestlistener.ResumeJobRequest, contestlistener.ResumeJobResponse]
+	listJobs                 *connect_go.Client[contestlistener.ListJobsRequest, contestlistener.ListJobsResponse]
+	version                  *connect_go.Client[contestlistener.VersionRequest, contestlistener.VersionResponse]
+	addSchedule              *connect_go.Client[contestlistener.AddScheduleRequest, contestlistener.AddScheduleResponse]
+	listSchedules            *connect_go.Client[contestlistener.ListSchedulesRequest, contestlistener.ListSchedulesResponse]
+	pauseSchedule            *connect_go.Client[contestlistener.PauseScheduleRequest, contestlistener.PauseScheduleResponse]
+	deleteSchedule           *connect_go.Client[contestlistener.DeleteScheduleRequest, contestlistener.DeleteScheduleResponse]
+	addInventoryTarget       *connect_go.Client[contestlistener.AddInventoryTargetRequest, contestlistener.AddInventoryTargetResponse]
+	listInventoryTargets     *connect_go.Client[contestlistener.ListInventoryTargetsRequest, contestlistener.ListInventoryTargetsResponse]
+	removeInventoryTarget    *connect_go.Client[contestlistener.RemoveInventoryTargetRequest, contestlistener.RemoveInventoryTargetResponse]
+	drainInventoryTarget     *connect_go.Client[contestlistener.DrainInventoryTargetRequest, contestlistener.DrainInventoryTargetResponse]
+	labelInventoryTarget     *connect_go.Client[contestlistener.LabelInventoryTargetRequest, contestlistener.LabelInventoryTargetResponse]
+	setInventoryTargetHealth *connect_go.Client[contestlistener.SetInventoryTargetHealthRequest, contestlistener.SetInventoryTargetHealthResponse]
 }
 
 // StartJob calls contest.v1.ConTestService.StartJob.
@@ -70,10 +196,118 @@ func (c *conTestServiceClient) StatusJob(ctx context.Context, req *connect_go.Re
 	return c.statusJob.CallServerStream(ctx, req)
 }
 
+// WatchJob calls contest.v1.ConTestService.WatchJob.
+func (c *conTestServiceClient) WatchJob(ctx context.Context, req *connect_go.Request[contestlistener.WatchJobRequest]) (*connect_go.ServerStreamForClient[contestlistener.WatchJobResponse], error) {
+	return c.watchJob.CallServerStream(ctx, req)
+}
+
+// GetJobStatus calls contest.v1.ConTestService.GetJobStatus.
+func (c *conTestServiceClient) GetJobStatus(ctx context.Context, req *connect_go.Request[contestlistener.GetJobStatusRequest]) (*connect_go.Response[contestlistener.GetJobStatusResponse], error) {
+	return c.getJobStatus.CallUnary(ctx, req)
+}
+
+// StopJob calls contest.v1.ConTestService.StopJob.
+func (c *conTestServiceClient) StopJob(ctx context.Context, req *connect_go.Request[contestlistener.StopJobRequest]) (*connect_go.Response[contestlistener.StopJobResponse], error) {
+	return c.stopJob.CallUnary(ctx, req)
+}
+
+// RetryJob calls contest.v1.ConTestService.RetryJob.
+func (c *conTestServiceClient) RetryJob(ctx context.Context, req *connect_go.Request[contestlistener.RetryJobRequest]) (*connect_go.Response[contestlistener.RetryJobResponse], error) {
+	return c.retryJob.CallUnary(ctx, req)
+}
+
+// PauseJob calls contest.v1.ConTestService.PauseJob.
+func (c *conTestServiceClient) PauseJob(ctx context.Context, req *connect_go.Request[contestlistener.PauseJobRequest]) (*connect_go.Response[contestlistener.PauseJobResponse], error) {
+	return c.pauseJob.CallUnary(ctx, req)
+}
+
+// ResumeJob calls contest.v1.ConTestService.ResumeJob.
+func (c *conTestServiceClient) ResumeJob(ctx context.Context, req *connect_go.Request[contestlistener.ResumeJobRequest]) (*connect_go.Response[contestlistener.ResumeJobResponse], error) {
+	return c.resumeJob.CallUnary(ctx, req)
+}
+
+// ListJobs calls contest.v1.ConTestService.ListJobs.
+func (c *conTestServiceClient) ListJobs(ctx context.Context, req *connect_go.Request[contestlistener.ListJobsRequest]) (*connect_go.Response[contestlistener.ListJobsResponse], error) {
+	return c.listJobs.CallUnary(ctx, req)
+}
+
+// Version calls contest.v1.ConTestService.Version.
+func (c *conTestServiceClient) Version(ctx context.Context, req *connect_go.Request[contestlistener.VersionRequest]) (*connect_go.Response[contestlistener.VersionResponse], error) {
+	return c.version.CallUnary(ctx, req)
+}
+
+// AddSchedule calls contest.v1.ConTestService.AddSchedule.
+func (c *conTestServiceClient) AddSchedule(ctx context.Context, req *connect_go.Request[contestlistener.AddScheduleRequest]) (*connect_go.Response[contestlistener.AddScheduleResponse], error) {
+	return c.addSchedule.CallUnary(ctx, req)
+}
+
+// ListSchedules calls contest.v1.ConTestService.ListSchedules.
+func (c *conTestServiceClient) ListSchedules(ctx context.Context, req *connect_go.Request[contestlistener.ListSchedulesRequest]) (*connect_go.Response[contestlistener.ListSchedulesResponse], error) {
+	return c.listSchedules.CallUnary(ctx, req)
+}
+
+// PauseSchedule calls contest.v1.ConTestService.PauseSchedule.
+func (c *conTestServiceClient) PauseSchedule(ctx context.Context, req *connect_go.Request[contestlistener.PauseScheduleRequest]) (*connect_go.Response[contestlistener.PauseScheduleResponse], error) {
+	return c.pauseSchedule.CallUnary(ctx, req)
+}
+
+// DeleteSchedule calls contest.v1.ConTestService.DeleteSchedule.
+func (c *conTestServiceClient) DeleteSchedule(ctx context.Context, req *connect_go.Request[contestlistener.DeleteScheduleRequest]) (*connect_go.Response[contestlistener.DeleteScheduleResponse], error) {
+	return c.deleteSchedule.CallUnary(ctx, req)
+}
+
+// AddInventoryTarget calls contest.v1.ConTestService.AddInventoryTarget.
+func (c *conTestServiceClient) AddInventoryTarget(ctx context.Context, req *connect_go.Request[contestlistener.AddInventoryTargetRequest]) (*connect_go.Response[contestlistener.AddInventoryTargetResponse], error) {
+	return c.addInventoryTarget.CallUnary(ctx, req)
+}
+
+// ListInventoryTargets calls contest.v1.ConTestService.ListInventoryTargets.
+func (c *conTestServiceClient) ListInventoryTargets(ctx context.Context, req *connect_go.Request[contestlistener.ListInventoryTargetsRequest]) (*connect_go.Response[contestlistener.ListInventoryTargetsResponse], error) {
+	return c.listInventoryTargets.CallUnary(ctx, req)
+}
+
+// RemoveInventoryTarget calls contest.v1.ConTestService.RemoveInventoryTarget.
+func (c *conTestServiceClient) RemoveInventoryTarget(ctx context.Context, req *connect_go.Request[contestlistener.RemoveInventoryTargetRequest]) (*connect_go.Response[contestlistener.RemoveInventoryTargetResponse], error) {
+	return c.removeInventoryTarget.CallUnary(ctx, req)
+}
+
+// DrainInventoryTarget calls contest.v1.ConTestService.DrainInventoryTarget.
+func (c *conTestServiceClient) DrainInventoryTarget(ctx context.Context, req *connect_go.Request[contestlistener.DrainInventoryTargetRequest]) (*connect_go.Response[contestlistener.DrainInventoryTargetResponse], error) {
+	return c.drainInventoryTarget.CallUnary(ctx, req)
+}
+
+// LabelInventoryTarget calls contest.v1.ConTestService.LabelInventoryTarget.
+func (c *conTestServiceClient) LabelInventoryTarget(ctx context.Context, req *connect_go.Request[contestlistener.LabelInventoryTargetRequest]) (*connect_go.Response[contestlistener.LabelInventoryTargetResponse], error) {
+	return c.labelInventoryTarget.CallUnary(ctx, req)
+}
+
+// SetInventoryTargetHealth calls contest.v1.ConTestService.SetInventoryTargetHealth.
+func (c *conTestServiceClient) SetInventoryTargetHealth(ctx context.Context, req *connect_go.Request[contestlistener.SetInventoryTargetHealthRequest]) (*connect_go.Response[contestlistener.SetInventoryTargetHealthResponse], error) {
+	return c.setInventoryTargetHealth.CallUnary(ctx, req)
+}
+
 // ConTestServiceHandler is an implementation of the contest.v1.ConTestService service.
 type ConTestServiceHandler interface {
 	StartJob(context.Context, *connect_go.Request[contestlistener.StartJobRequest]) (*connect_go.Response[contestlistener.StartJobResponse], error)
 	StatusJob(context.Context, *connect_go.Request[contestlistener.StatusJobRequest], *connect_go.ServerStream[contestlistener.StatusJobResponse]) error
+	WatchJob(context.Context, *connect_go.Request[contestlistener.WatchJobRequest], *connect_go.ServerStream[contestlistener.WatchJobResponse]) error
+	GetJobStatus(context.Context, *connect_go.Request[contestlistener.GetJobStatusRequest]) (*connect_go.Response[contestlistener.GetJobStatusResponse], error)
+	StopJob(context.Context, *connect_go.Request[contestlistener.StopJobRequest]) (*connect_go.Response[contestlistener.StopJobResponse], error)
+	RetryJob(context.Context, *connect_go.Request[contestlistener.RetryJobRequest]) (*connect_go.Response[contestlistener.RetryJobResponse], error)
+	PauseJob(context.Context, *connect_go.Request[contestlistener.PauseJobRequest]) (*connect_go.Response[contestlistener.PauseJobResponse], error)
+	ResumeJob(context.Context, *connect_go.Request[contestlistener.ResumeJobRequest]) (*connect_go.Response[contestlistener.ResumeJobResponse], error)
+	ListJobs(context.Context, *connect_go.Request[contestlistener.ListJobsRequest]) (*connect_go.Response[contestlistener.ListJobsResponse], error)
+	Version(context.Context, *connect_go.Request[contestlistener.VersionRequest]) (*connect_go.Response[contestlistener.VersionResponse], error)
+	AddSchedule(context.Context, *connect_go.Request[contestlistener.AddScheduleRequest]) (*connect_go.Response[contestlistener.AddScheduleResponse], error)
+	ListSchedules(context.Context, *connect_go.Request[contestlistener.ListSchedulesRequest]) (*connect_go.Response[contestlistener.ListSchedulesResponse], error)
+	PauseSchedule(context.Context, *connect_go.Request[contestlistener.PauseScheduleRequest]) (*connect_go.Response[contestlistener.PauseScheduleResponse], error)
+	DeleteSchedule(context.Context, *connect_go.Request[contestlistener.DeleteScheduleRequest]) (*connect_go.Response[contestlistener.DeleteScheduleResponse], error)
+	AddInventoryTarget(context.Context, *connect_go.Request[contestlistener.AddInventoryTargetRequest]) (*connect_go.Response[contestlistener.AddInventoryTargetResponse], error)
+	ListInventoryTargets(context.Context, *connect_go.Request[contestlistener.ListInventoryTargetsRequest]) (*connect_go.Response[contestlistener.ListInventoryTargetsResponse], error)
+	RemoveInventoryTarget(context.Context, *connect_go.Request[contestlistener.RemoveInventoryTargetRequest]) (*connect_go.Response[contestlistener.RemoveInventoryTargetResponse], error)
+	DrainInventoryTarget(context.Context, *connect_go.Request[contestlistener.DrainInventoryTargetRequest]) (*connect_go.Response[contestlistener.DrainInventoryTargetResponse], error)
+	LabelInventoryTarget(context.Context, *connect_go.Request[contestlistener.LabelInventoryTargetRequest]) (*connect_go.Response[contestlistener.LabelInventoryTargetResponse], error)
+	SetInventoryTargetHealth(context.Context, *connect_go.Request[contestlistener.SetInventoryTargetHealthRequest]) (*connect_go.Response[contestlistener.SetInventoryTargetHealthResponse], error)
 }
 
 // NewConTestServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -93,6 +327,96 @@ func NewConTestServiceHandler(svc ConTestServiceHandler, opts ...connect_go.Hand
 		svc.StatusJob,
 		opts...,
 	))
+	mux.Handle("/contest.v1.ConTestService/WatchJob", connect_go.NewServerStreamHandler(
+		"/contest.v1.ConTestService/WatchJob",
+		svc.WatchJob,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/GetJobStatus", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/GetJobStatus",
+		svc.GetJobStatus,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/StopJob", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/StopJob",
+		svc.StopJob,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/RetryJob", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/RetryJob",
+		svc.RetryJob,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/PauseJob", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/PauseJob",
+		svc.PauseJob,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/ResumeJob", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/ResumeJob",
+		svc.ResumeJob,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/ListJobs", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/ListJobs",
+		svc.ListJobs,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/Version", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/Version",
+		svc.Version,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/AddSchedule", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/AddSchedule",
+		svc.AddSchedule,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/ListSchedules", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/ListSchedules",
+		svc.ListSchedules,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/PauseSchedule", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/PauseSchedule",
+		svc.PauseSchedule,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/DeleteSchedule", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/DeleteSchedule",
+		svc.DeleteSchedule,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/AddInventoryTarget", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/AddInventoryTarget",
+		svc.AddInventoryTarget,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/ListInventoryTargets", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/ListInventoryTargets",
+		svc.ListInventoryTargets,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/RemoveInventoryTarget", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/RemoveInventoryTarget",
+		svc.RemoveInventoryTarget,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/DrainInventoryTarget", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/DrainInventoryTarget",
+		svc.DrainInventoryTarget,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/LabelInventoryTarget", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/LabelInventoryTarget",
+		svc.LabelInventoryTarget,
+		opts...,
+	))
+	mux.Handle("/contest.v1.ConTestService/SetInventoryTargetHealth", connect_go.NewUnaryHandler(
+		"/contest.v1.ConTestService/SetInventoryTargetHealth",
+		svc.SetInventoryTargetHealth,
+		opts...,
+	))
 	return "/contest.v1.ConTestService/", mux
 }
 
@@ -106,3 +430,75 @@ func (UnimplementedConTestServiceHandler) StartJob(context.Context, *connect_go.
 func (UnimplementedConTestServiceHandler) StatusJob(context.Context, *connect_go.Request[contestlistener.StatusJobRequest], *connect_go.ServerStream[contestlistener.StatusJobResponse]) error {
 	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.StatusJob is not implemented"))
 }
+
+func (UnimplementedConTestServiceHandler) WatchJob(context.Context, *connect_go.Request[contestlistener.WatchJobRequest], *connect_go.ServerStream[contestlistener.WatchJobResponse]) error {
+	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.WatchJob is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) GetJobStatus(context.Context, *connect_go.Request[contestlistener.GetJobStatusRequest]) (*connect_go.Response[contestlistener.GetJobStatusResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.GetJobStatus is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) StopJob(context.Context, *connect_go.Request[contestlistener.StopJobRequest]) (*connect_go.Response[contestlistener.StopJobResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.StopJob is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) RetryJob(context.Context, *connect_go.Request[contestlistener.RetryJobRequest]) (*connect_go.Response[contestlistener.RetryJobResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.RetryJob is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) PauseJob(context.Context, *connect_go.Request[contestlistener.PauseJobRequest]) (*connect_go.Response[contestlistener.PauseJobResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.PauseJob is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) ResumeJob(context.Context, *connect_go.Request[contestlistener.ResumeJobRequest]) (*connect_go.Response[contestlistener.ResumeJobResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.ResumeJob is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) ListJobs(context.Context, *connect_go.Request[contestlistener.ListJobsRequest]) (*connect_go.Response[contestlistener.ListJobsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.ListJobs is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) Version(context.Context, *connect_go.Request[contestlistener.VersionRequest]) (*connect_go.Response[contestlistener.VersionResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.Version is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) AddSchedule(context.Context, *connect_go.Request[contestlistener.AddScheduleRequest]) (*connect_go.Response[contestlistener.AddScheduleResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.AddSchedule is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) ListSchedules(context.Context, *connect_go.Request[contestlistener.ListSchedulesRequest]) (*connect_go.Response[contestlistener.ListSchedulesResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.ListSchedules is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) PauseSchedule(context.Context, *connect_go.Request[contestlistener.PauseScheduleRequest]) (*connect_go.Response[contestlistener.PauseScheduleResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.PauseSchedule is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) DeleteSchedule(context.Context, *connect_go.Request[contestlistener.DeleteScheduleRequest]) (*connect_go.Response[contestlistener.DeleteScheduleResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.DeleteSchedule is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) AddInventoryTarget(context.Context, *connect_go.Request[contestlistener.AddInventoryTargetRequest]) (*connect_go.Response[contestlistener.AddInventoryTargetResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.AddInventoryTarget is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) ListInventoryTargets(context.Context, *connect_go.Request[contestlistener.ListInventoryTargetsRequest]) (*connect_go.Response[contestlistener.ListInventoryTargetsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.ListInventoryTargets is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) RemoveInventoryTarget(context.Context, *connect_go.Request[contestlistener.RemoveInventoryTargetRequest]) (*connect_go.Response[contestlistener.RemoveInventoryTargetResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.RemoveInventoryTarget is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) DrainInventoryTarget(context.Context, *connect_go.Request[contestlistener.DrainInventoryTargetRequest]) (*connect_go.Response[contestlistener.DrainInventoryTargetResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.DrainInventoryTarget is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) LabelInventoryTarget(context.Context, *connect_go.Request[contestlistener.LabelInventoryTargetRequest]) (*connect_go.Response[contestlistener.LabelInventoryTargetResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.LabelInventoryTarget is not implemented"))
+}
+
+func (UnimplementedConTestServiceHandler) SetInventoryTargetHealth(context.Context, *connect_go.Request[contestlistener.SetInventoryTargetHealthRequest]) (*connect_go.Response[contestlistener.SetInventoryTargetHealthResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("contest.v1.ConTestService.SetInventoryTargetHealth is not implemented"))
+}
@@ -2,22 +2,33 @@ package grpclistener
 
 import (
 	context "context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"sort"
 	"time"
 
 	grpcreflect "github.com/bufbuild/connect-grpcreflect-go"
 	"github.com/linuxboot/contest/pkg/api"
 	"github.com/linuxboot/contest/pkg/buffer"
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
 	contestlistener "github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1"
 	"github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1/contestlistenerconnect"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/bufbuild/connect-go"
 )
@@ -27,6 +38,7 @@ type Endpoint struct {
 }
 
 type GRPCListener struct {
+	config
 	listenAddr string
 }
 
@@ -38,13 +50,106 @@ type GRPCServer struct {
 
 var waitForUpdate = 5 * time.Second
 
-func New(listenAddr string) *GRPCListener {
-	return &GRPCListener{listenAddr: listenAddr}
+var errRequestorNotSet = connect.NewError(connect.CodeInvalidArgument, errors.New("Requestor is not set"))
+
+var errShuttingDown = errors.New("the server is shutting down")
+
+// watchResyncInterval is how often WatchJob looks for new events of a running
+// job without being notified, which is the case for the events emitted by
+// other servers sharing the storage.
+var watchResyncInterval = 30 * time.Second
+
+// New returns a GRPCListener serving the ConTestService on listenAddr.
+func New(listenAddr string, opts ...Option) *GRPCListener {
+	return &GRPCListener{listenAddr: listenAddr, config: getConfig(opts...)}
+}
+
+func (grpcl *GRPCListener) tlsConfig() (*tls.Config, error) {
+	if grpcl.tlsCertFile == "" && grpcl.tlsKeyFile == "" {
+		if grpcl.clientCAFile != "" {
+			return nil, errors.New("a client CA requires a TLS certificate and key")
+		}
+		return nil, nil
+	}
+	if grpcl.tlsCertFile == "" || grpcl.tlsKeyFile == "" {
+		return nil, errors.New("both a TLS certificate and key are required")
+	}
+	cert, err := tls.LoadX509KeyPair(grpcl.tlsCertFile, grpcl.tlsKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if grpcl.clientCAFile != "" {
+		caPEM, err := os.ReadFile(grpcl.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", grpcl.clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
 }
 
+// Serve implements the api.Listener.Serve interface method. It serves the
+// ConTestService until ctx is cancelled, then drains the in-flight requests.
 func (grpcl *GRPCListener) Serve(ctx xcontext.Context, a *api.API) error {
+	if a == nil {
+		return errors.New("API object is nil")
+	}
 	ctx.Infof("Starting GRPCListener...\n")
 
+	tlsConfig, err := grpcl.tlsConfig()
+	if err != nil {
+		return fmt.Errorf("GRPC listener failed: %w", err)
+	}
+	s := &http.Server{
+		Addr:      grpcl.listenAddr,
+		Handler:   newHandler(ctx, a, grpcl.authenticator),
+		TLSConfig: tlsConfig,
+	}
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("GRPC listener failed: %w", err)
+	}
+
+	errCh := make(chan error, 1)
+	// start the listener asynchronously, and report errors and completion via
+	// channels.
+	go func() {
+		if tlsConfig != nil {
+			errCh <- s.ServeTLS(ln, "", "")
+		} else {
+			errCh <- s.Serve(ln)
+		}
+	}()
+	ctx.Infof("Started GRPC API listener on %s (TLS: %t)", ln.Addr(), tlsConfig != nil)
+	// wait for cancellation or for completion
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("GRPC listener failed: %w", err)
+	case <-ctx.Done():
+		ctx.Debugf("Received server shut down request")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), grpcl.shutdownTimeout)
+		defer cancel()
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			ctx.Warnf("Graceful shutdown failed, closing connections: %v", err)
+			return s.Close()
+		}
+		return nil
+	}
+}
+
+// newHandler returns the HTTP handler serving the ConTestService and the
+// reflection API, over HTTP/2 without TLS too. If authenticator is not nil,
+// it identifies the requestor of every ConTestService call.
+func newHandler(ctx xcontext.Context, a *api.API, authenticator Authenticator) http.Handler {
 	mux := http.NewServeMux()
 
 	// Add reflection API
@@ -56,30 +161,16 @@ func (grpcl *GRPCListener) Serve(ctx xcontext.Context, a *api.API) error {
 	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
 
 	// Add our gRPC Service
+	var handlerOpts []connect.HandlerOption
+	if authenticator != nil {
+		handlerOpts = append(handlerOpts, connect.WithInterceptors(&authInterceptor{authenticate: authenticator}))
+	}
 	mux.Handle(contestlistenerconnect.NewConTestServiceHandler(&GRPCServer{
 		ctx:       ctx,
 		api:       a,
 		Endpoints: make(map[int]*Endpoint),
-	}))
-
-	errCh := make(chan error, 1)
-	// start the listener asynchronously, and report errors and completion via
-	// channels.
-	go func() {
-		errCh <- http.ListenAndServe(
-			":8080",
-			h2c.NewHandler(mux, &http2.Server{}),
-		)
-	}()
-	ctx.Infof("Started GRPC API listener on :8080")
-	// wait for cancellation or for completion
-	select {
-	case err := <-errCh:
-		return err
-	case <-ctx.Done():
-		ctx.Debugf("Received server shut down request")
-		return nil
-	}
+	}, handlerOpts...))
+	return h2c.NewHandler(withTLSState(mux), &http2.Server{})
 }
 
 func (s *GRPCServer) StartJob(ctx context.Context, req *connect.Request[contestlistener.StartJobRequest]) (*connect.Response[contestlistener.StartJobResponse], error) {
@@ -94,7 +185,8 @@ func (s *GRPCServer) StartJob(ctx context.Context, req *connect.Request[contestl
 		}), fmt.Errorf("Job is nil")
 	}
 
-	if req.Msg.Requestor == "" {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
 		s.ctx.Errorf("Requestor is not set")
 
 		return connect.NewResponse(&contestlistener.StartJobResponse{
@@ -103,7 +195,7 @@ func (s *GRPCServer) StartJob(ctx context.Context, req *connect.Request[contestl
 		}), fmt.Errorf("Requestor is not set")
 	}
 
-	resp, err := s.api.Start(s.ctx, api.EventRequestor(req.Msg.Requestor), string(req.Msg.Job))
+	resp, err := s.api.Start(s.ctx, requestor, string(req.Msg.Job))
 	if err != nil {
 		return connect.NewResponse(&contestlistener.StartJobResponse{
 			JobId: 0,
@@ -112,8 +204,9 @@ func (s *GRPCServer) StartJob(ctx context.Context, req *connect.Request[contestl
 	}
 	if resp.Err != nil {
 		return connect.NewResponse(&contestlistener.StartJobResponse{
-			JobId: 0,
-			Error: resp.Err.Error(),
+			JobId:    0,
+			Error:    resp.Err.Error(),
+			ServerId: resp.ServerID,
 		}), err
 	}
 
@@ -128,13 +221,15 @@ func (s *GRPCServer) StartJob(ctx context.Context, req *connect.Request[contestl
 	}
 
 	return connect.NewResponse(&contestlistener.StartJobResponse{
-		JobId: int32(r.JobID),
-		Error: "",
+		JobId:    int32(r.JobID),
+		Error:    "",
+		ServerId: resp.ServerID,
 	}), nil
 }
 
 func (s *GRPCServer) StatusJob(ctx context.Context, req *connect.Request[contestlistener.StatusJobRequest], stream *connect.ServerStream[contestlistener.StatusJobResponse]) error {
-	if req.Msg.Requestor == "" {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
 		s.ctx.Errorf("Requestor is not set")
 
 		return fmt.Errorf("Requestor is not set")
@@ -148,7 +243,7 @@ func (s *GRPCServer) StatusJob(ctx context.Context, req *connect.Request[contest
 
 	for {
 
-		resp, err := s.getResponseFromAPI(req.Msg)
+		resp, err := s.getResponseFromAPI(requestor, req.Msg)
 		if err != nil {
 			s.ctx.Errorf("getResponseFromAPI: %w", err)
 
@@ -179,15 +274,21 @@ func (s *GRPCServer) StatusJob(ctx context.Context, req *connect.Request[contest
 		fmt.Printf("Job State: %s\n", resp.Status.State)
 
 		// Job is not running anymore
-		if resp.Status.State != string(job.EventJobStarted) {
+		if resp.Status.State != string(job.EventJobStarted) && resp.Status.State != string(job.EventJobQueued) {
 			break
 		}
 
 		// Buffer was full - let's poll faster again.
-		time.Sleep(waitForUpdate)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.ctx.Done():
+			return connect.NewError(connect.CodeUnavailable, errShuttingDown)
+		case <-time.After(waitForUpdate):
+		}
 	}
 
-	resp, err := s.getResponseFromAPI(req.Msg)
+	resp, err := s.getResponseFromAPI(requestor, req.Msg)
 	if err != nil {
 		s.ctx.Errorf("getResponseFromAPI: %w", err)
 
@@ -220,8 +321,544 @@ func (s *GRPCServer) StatusJob(ctx context.Context, req *connect.Request[contest
 	return nil
 }
 
-func (s *GRPCServer) getResponseFromAPI(msg *contestlistener.StatusJobRequest) (api.ResponseDataStatus, error) {
-	apiResp, err := s.api.Status(s.ctx, api.EventRequestor(msg.Requestor), types.JobID(msg.JobId))
+// GetJobStatus returns the current status of a job. Unlike StatusJob, it does
+// not wait for the job to complete.
+func (s *GRPCServer) GetJobStatus(ctx context.Context, req *connect.Request[contestlistener.GetJobStatusRequest]) (*connect.Response[contestlistener.GetJobStatusResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.Status(s.ctx, requestor, types.JobID(req.Msg.JobId))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.Status() = '%w'", err))
+	}
+	msg := &contestlistener.GetJobStatusResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataStatus); ok && data.Status != nil {
+		if msg.Status, err = jobStatusToProto(data.Status); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("unable to convert status: %w", err))
+		}
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// StopJob requests the cancellation of a job.
+func (s *GRPCServer) StopJob(ctx context.Context, req *connect.Request[contestlistener.StopJobRequest]) (*connect.Response[contestlistener.StopJobResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.Stop(s.ctx, requestor, types.JobID(req.Msg.JobId))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.Stop() = '%w'", err))
+	}
+	return connect.NewResponse(&contestlistener.StopJobResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}), nil
+}
+
+// RetryJob starts a new job from the descriptor of a completed one.
+func (s *GRPCServer) RetryJob(ctx context.Context, req *connect.Request[contestlistener.RetryJobRequest]) (*connect.Response[contestlistener.RetryJobResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.Retry(s.ctx, requestor, types.JobID(req.Msg.JobId), req.Msg.FailedTargetsOnly)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.Retry() = '%w'", err))
+	}
+	msg := &contestlistener.RetryJobResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataRetry); ok {
+		msg.JobId = int32(data.JobID)
+		msg.NewJobId = int32(data.NewJobID)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// PauseJob pauses a running job, keeping or releasing its targets.
+func (s *GRPCServer) PauseJob(ctx context.Context, req *connect.Request[contestlistener.PauseJobRequest]) (*connect.Response[contestlistener.PauseJobResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.Pause(s.ctx, requestor, types.JobID(req.Msg.JobId), req.Msg.ReleaseTargets)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.Pause() = '%w'", err))
+	}
+	msg := &contestlistener.PauseJobResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataPause); ok {
+		msg.JobId = int32(data.JobID)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// ResumeJob resumes a paused job.
+func (s *GRPCServer) ResumeJob(ctx context.Context, req *connect.Request[contestlistener.ResumeJobRequest]) (*connect.Response[contestlistener.ResumeJobResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.Resume(s.ctx, requestor, types.JobID(req.Msg.JobId))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.Resume() = '%w'", err))
+	}
+	msg := &contestlistener.ResumeJobResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataResume); ok {
+		msg.JobId = int32(data.JobID)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// ListJobs lists the jobs matching the requested states and tags.
+func (s *GRPCServer) ListJobs(ctx context.Context, req *connect.Request[contestlistener.ListJobsRequest]) (*connect.Response[contestlistener.ListJobsResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	var fields []storage.JobQueryField
+	if len(req.Msg.States) > 0 {
+		var states []job.State
+		for _, sts := range req.Msg.States {
+			st, err := job.ParseState(sts)
+			if err != nil {
+				return nil, connect.NewError(connect.CodeInvalidArgument, err)
+			}
+			states = append(states, st)
+		}
+		fields = append(fields, storage.QueryJobStates(states...))
+	}
+	if len(req.Msg.Tags) > 0 {
+		fields = append(fields, storage.QueryJobTags(req.Msg.Tags...))
+	}
+	jobQuery, err := storage.BuildJobQuery(fields...)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid query: %w", err))
+	}
+	resp, err := s.api.List(s.ctx, requestor, jobQuery)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.List() = '%w'", err))
+	}
+	msg := &contestlistener.ListJobsResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataList); ok {
+		for _, jobID := range data.JobIDs {
+			msg.JobIds = append(msg.JobIds, int32(jobID))
+		}
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// Version returns the version of the API.
+func (s *GRPCServer) Version(ctx context.Context, req *connect.Request[contestlistener.VersionRequest]) (*connect.Response[contestlistener.VersionResponse], error) {
+	resp := s.api.Version()
+	msg := &contestlistener.VersionResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataVersion); ok {
+		msg.Version = data.Version
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// AddSchedule registers a job to be started periodically.
+func (s *GRPCServer) AddSchedule(ctx context.Context, req *connect.Request[contestlistener.AddScheduleRequest]) (*connect.Response[contestlistener.AddScheduleResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.AddSchedule(s.ctx, requestor, req.Msg.CronExpr, job.OverlapPolicy(req.Msg.OverlapPolicy), string(req.Msg.Job))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.AddSchedule() = '%w'", err))
+	}
+	msg := &contestlistener.AddScheduleResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataAddSchedule); ok {
+		msg.ScheduleId = int32(data.ScheduleID)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// ListSchedules lists the schedules owned by the server.
+func (s *GRPCServer) ListSchedules(ctx context.Context, req *connect.Request[contestlistener.ListSchedulesRequest]) (*connect.Response[contestlistener.ListSchedulesResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.ListSchedules(s.ctx, requestor)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.ListSchedules() = '%w'", err))
+	}
+	msg := &contestlistener.ListSchedulesResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataListSchedules); ok {
+		for _, schedule := range data.Schedules {
+			msg.Schedules = append(msg.Schedules, scheduleToProto(schedule))
+		}
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// PauseSchedule pauses or resumes a schedule.
+func (s *GRPCServer) PauseSchedule(ctx context.Context, req *connect.Request[contestlistener.PauseScheduleRequest]) (*connect.Response[contestlistener.PauseScheduleResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.PauseSchedule(s.ctx, requestor, types.ScheduleID(req.Msg.ScheduleId), req.Msg.Paused)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.PauseSchedule() = '%w'", err))
+	}
+	return connect.NewResponse(&contestlistener.PauseScheduleResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}), nil
+}
+
+// DeleteSchedule deletes a schedule.
+func (s *GRPCServer) DeleteSchedule(ctx context.Context, req *connect.Request[contestlistener.DeleteScheduleRequest]) (*connect.Response[contestlistener.DeleteScheduleResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.DeleteSchedule(s.ctx, requestor, types.ScheduleID(req.Msg.ScheduleId))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.DeleteSchedule() = '%w'", err))
+	}
+	return connect.NewResponse(&contestlistener.DeleteScheduleResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}), nil
+}
+
+// AddInventoryTarget adds a target to the inventory.
+func (s *GRPCServer) AddInventoryTarget(ctx context.Context, req *connect.Request[contestlistener.AddInventoryTargetRequest]) (*connect.Response[contestlistener.AddInventoryTargetResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	target, err := inventoryTargetFromProto(req.Msg.Target)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	resp, err := s.api.AddInventoryTarget(s.ctx, requestor, target)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.AddInventoryTarget() = '%w'", err))
+	}
+	msg := &contestlistener.AddInventoryTargetResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataAddInventoryTarget); ok && data.Target != nil {
+		msg.Target = inventoryTargetToProto(data.Target)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// ListInventoryTargets lists the targets of the inventory matching a label selector.
+func (s *GRPCServer) ListInventoryTargets(ctx context.Context, req *connect.Request[contestlistener.ListInventoryTargetsRequest]) (*connect.Response[contestlistener.ListInventoryTargetsResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.ListInventoryTargets(s.ctx, requestor, req.Msg.Selector)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.ListInventoryTargets() = '%w'", err))
+	}
+	msg := &contestlistener.ListInventoryTargetsResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataListInventoryTargets); ok {
+		for _, t := range data.Targets {
+			msg.Targets = append(msg.Targets, inventoryTargetToProto(t))
+		}
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// RemoveInventoryTarget removes a target from the inventory.
+func (s *GRPCServer) RemoveInventoryTarget(ctx context.Context, req *connect.Request[contestlistener.RemoveInventoryTargetRequest]) (*connect.Response[contestlistener.RemoveInventoryTargetResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.RemoveInventoryTarget(s.ctx, requestor, req.Msg.TargetId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.RemoveInventoryTarget() = '%w'", err))
+	}
+	msg := &contestlistener.RemoveInventoryTargetResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// DrainInventoryTarget drains a target of the inventory, or puts it back in service.
+func (s *GRPCServer) DrainInventoryTarget(ctx context.Context, req *connect.Request[contestlistener.DrainInventoryTargetRequest]) (*connect.Response[contestlistener.DrainInventoryTargetResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.DrainInventoryTarget(s.ctx, requestor, req.Msg.TargetId, req.Msg.Drained)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.DrainInventoryTarget() = '%w'", err))
+	}
+	msg := &contestlistener.DrainInventoryTargetResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataDrainInventoryTarget); ok && data.Target != nil {
+		msg.Target = inventoryTargetToProto(data.Target)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// LabelInventoryTarget sets and removes labels of a target of the inventory.
+func (s *GRPCServer) LabelInventoryTarget(ctx context.Context, req *connect.Request[contestlistener.LabelInventoryTargetRequest]) (*connect.Response[contestlistener.LabelInventoryTargetResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.LabelInventoryTarget(s.ctx, requestor, req.Msg.TargetId, req.Msg.Set, req.Msg.Remove)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.LabelInventoryTarget() = '%w'", err))
+	}
+	msg := &contestlistener.LabelInventoryTargetResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataLabelInventoryTarget); ok && data.Target != nil {
+		msg.Target = inventoryTargetToProto(data.Target)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// SetInventoryTargetHealth sets the health state of a target of the inventory.
+func (s *GRPCServer) SetInventoryTargetHealth(ctx context.Context, req *connect.Request[contestlistener.SetInventoryTargetHealthRequest]) (*connect.Response[contestlistener.SetInventoryTargetHealthResponse], error) {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return nil, errRequestorNotSet
+	}
+	resp, err := s.api.SetInventoryTargetHealth(s.ctx, requestor, req.Msg.TargetId, inventory.Health(req.Msg.Health))
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.SetInventoryTargetHealth() = '%w'", err))
+	}
+	msg := &contestlistener.SetInventoryTargetHealthResponse{
+		ServerId: resp.ServerID,
+		Error:    errorString(resp.Err),
+	}
+	if data, ok := resp.Data.(api.ResponseDataSetInventoryTargetHealth); ok && data.Target != nil {
+		msg.Target = inventoryTargetToProto(data.Target)
+	}
+	return connect.NewResponse(msg), nil
+}
+
+// WatchJob streams the test and framework events of a job, starting from the
+// sequence IDs in the request. New events are read from the event storage
+// whenever this server emits events of the job, so a client can resume an
+// interrupted stream without missing events. Jobs started by other servers
+// can be watched too, their events are picked up every watchResyncInterval.
+// The stream ends after the last events of a completed job have been sent.
+func (s *GRPCServer) WatchJob(ctx context.Context, req *connect.Request[contestlistener.WatchJobRequest], stream *connect.ServerStream[contestlistener.WatchJobResponse]) error {
+	requestor := requestorFrom(ctx, req.Msg.Requestor)
+	if requestor == "" {
+		return errRequestorNotSet
+	}
+
+	jobID := types.JobID(req.Msg.JobId)
+	// Subscribe before the first read, so that no event emitted in between is missed.
+	notifications, unsubscribe := storage.SubscribeJobEvents(jobID)
+	defer unsubscribe()
+	resync := time.NewTicker(watchResyncInterval)
+	defer resync.Stop()
+	testEventsFrom := req.Msg.TestEventSequenceId
+	frameworkEventsFrom := req.Msg.FrameworkEventSequenceId
+	for {
+		apiResp, err := s.api.JobEvents(s.ctx, requestor, jobID, testEventsFrom, frameworkEventsFrom)
+		if err != nil {
+			return connect.NewError(connect.CodeUnavailable, fmt.Errorf("api.JobEvents() = '%w'", err))
+		}
+		if apiResp.Err != nil {
+			return connect.NewError(connect.CodeNotFound, apiResp.Err)
+		}
+		data, ok := apiResp.Data.(api.ResponseDataEvents)
+		if !ok {
+			return connect.NewError(connect.CodeInternal, fmt.Errorf("unknown Message"))
+		}
+
+		for _, msg := range watchJobResponses(data.TestEvents, data.FrameworkEvents) {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+		for _, ev := range data.TestEvents {
+			if ev.SequenceID >= testEventsFrom {
+				testEventsFrom = ev.SequenceID + 1
+			}
+		}
+		for _, ev := range data.FrameworkEvents {
+			if ev.SequenceID >= frameworkEventsFrom {
+				frameworkEventsFrom = ev.SequenceID + 1
+			}
+		}
+
+		// The job state is fetched before the events, so once the job is
+		// completed all of its events have been sent.
+		if isCompletionState(data.JobState) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.ctx.Done():
+			return connect.NewError(connect.CodeUnavailable, errShuttingDown)
+		case <-notifications:
+		case <-resync.C:
+		}
+	}
+}
+
+// watchJobResponses converts test and framework events to WatchJob messages,
+// ordered by emit time.
+func watchJobResponses(testEvents []testevent.Event, frameworkEvents []frameworkevent.Event) []*contestlistener.WatchJobResponse {
+	type timedResponse struct {
+		emitTime time.Time
+		resp     *contestlistener.WatchJobResponse
+	}
+	responses := make([]timedResponse, 0, len(testEvents)+len(frameworkEvents))
+	for _, ev := range testEvents {
+		responses = append(responses, timedResponse{
+			emitTime: ev.EmitTime,
+			resp: &contestlistener.WatchJobResponse{
+				Event: &contestlistener.WatchJobResponse_TestEvent{TestEvent: testEventToProto(ev)},
+			},
+		})
+	}
+	for _, ev := range frameworkEvents {
+		msg := &contestlistener.FrameworkEvent{
+			SequenceId: ev.SequenceID,
+			EmitTime:   timestamppb.New(ev.EmitTime),
+			EventName:  string(ev.EventName),
+		}
+		if ev.Payload != nil {
+			msg.Payload = []byte(*ev.Payload)
+		}
+		responses = append(responses, timedResponse{
+			emitTime: ev.EmitTime,
+			resp: &contestlistener.WatchJobResponse{
+				Event: &contestlistener.WatchJobResponse_FrameworkEvent{FrameworkEvent: msg},
+			},
+		})
+	}
+	sort.SliceStable(responses, func(i, j int) bool {
+		return responses[i].emitTime.Before(responses[j].emitTime)
+	})
+
+	msgs := make([]*contestlistener.WatchJobResponse, 0, len(responses))
+	for _, r := range responses {
+		msgs = append(msgs, r.resp)
+	}
+	return msgs
+}
+
+func scheduleToProto(schedule *job.Schedule) *contestlistener.Schedule {
+	msg := &contestlistener.Schedule{
+		ScheduleId:    int32(schedule.ID),
+		Requestor:     schedule.Requestor,
+		ServerId:      schedule.ServerID,
+		CreateTime:    timestamppb.New(schedule.CreateTime),
+		CronExpr:      schedule.CronExpr,
+		OverlapPolicy: string(schedule.OverlapPolicy),
+		Paused:        schedule.Paused,
+		Job:           []byte(schedule.JobDescriptor),
+		LastJobId:     int32(schedule.LastJobID),
+	}
+	if !schedule.LastFireTime.IsZero() {
+		msg.LastFireTime = timestamppb.New(schedule.LastFireTime)
+	}
+	return msg
+}
+
+func inventoryTargetToProto(t *inventory.Target) *contestlistener.InventoryTarget {
+	msg := &contestlistener.InventoryTarget{
+		TargetId:   t.ID,
+		Fqdn:       t.FQDN,
+		Labels:     t.Labels,
+		Health:     string(t.Health),
+		Drained:    t.Drained,
+		UpdateTime: timestamppb.New(t.UpdateTime),
+	}
+	if t.PrimaryIPv4 != nil {
+		msg.PrimaryIpv4 = t.PrimaryIPv4.String()
+	}
+	if t.PrimaryIPv6 != nil {
+		msg.PrimaryIpv6 = t.PrimaryIPv6.String()
+	}
+	return msg
+}
+
+func inventoryTargetFromProto(msg *contestlistener.InventoryTarget) (inventory.Target, error) {
+	if msg == nil {
+		return inventory.Target{}, errors.New("missing target")
+	}
+	t := inventory.Target{
+		ID:      msg.TargetId,
+		FQDN:    msg.Fqdn,
+		Labels:  msg.Labels,
+		Health:  inventory.Health(msg.Health),
+		Drained: msg.Drained,
+	}
+	if msg.PrimaryIpv4 != "" {
+		if t.PrimaryIPv4 = net.ParseIP(msg.PrimaryIpv4); t.PrimaryIPv4 == nil {
+			return t, fmt.Errorf("invalid ipv4 address %q", msg.PrimaryIpv4)
+		}
+	}
+	if msg.PrimaryIpv6 != "" {
+		if t.PrimaryIPv6 = net.ParseIP(msg.PrimaryIpv6); t.PrimaryIPv6 == nil {
+			return t, fmt.Errorf("invalid ipv6 address %q", msg.PrimaryIpv6)
+		}
+	}
+	return t, nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func isCompletionState(state string) bool {
+	for _, eventName := range job.JobCompletionEvents {
+		if state == string(eventName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *GRPCServer) getResponseFromAPI(requestor api.EventRequestor, msg *contestlistener.StatusJobRequest) (api.ResponseDataStatus, error) {
+	apiResp, err := s.api.Status(s.ctx, requestor, types.JobID(msg.JobId))
 	if err != nil {
 		s.ctx.Errorf("api.Status() = '%v'", err)
 
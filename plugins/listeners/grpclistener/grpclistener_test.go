@@ -0,0 +1,489 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/api"
+	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/storage"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/transport/grpc"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	contestlistener "github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1"
+	"github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1/contestlistenerconnect"
+	"github.com/linuxboot/contest/plugins/storage/memory"
+)
+
+// fakeJobManager answers API events the way the JobManager would, using the
+// given function to build the responses.
+func fakeJobManager(ctx xcontext.Context, a *api.API, handle func(ev *api.Event) *api.EventResponse) {
+	go func() {
+		for {
+			select {
+			case ev := <-a.Events:
+				ev.RespCh <- handle(ev)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func newTestServer(t *testing.T, handle func(ev *api.Event) *api.EventResponse) string {
+	return newTestServerWithAuth(t, nil, handle)
+}
+
+func newTestServerWithAuth(t *testing.T, authenticator Authenticator, handle func(ev *api.Event) *api.EventResponse) string {
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	a, err := api.New(api.OptionServerID("unit-test"))
+	require.NoError(t, err)
+	fakeJobManager(ctx, a, handle)
+	srv := httptest.NewServer(newHandler(ctx, a, authenticator))
+	t.Cleanup(func() {
+		srv.Close()
+		cancel()
+	})
+	return srv.URL
+}
+
+// jobStatusFixture returns a status which uses every field that GetJobStatus
+// conveys.
+func jobStatusFixture() *job.Status {
+	startTime := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(time.Hour)
+	payload := json.RawMessage(`{"Msg":"hello"}`)
+	runCoordinates := job.RunCoordinates{JobID: 3, RunID: 1}
+	testCoordinates := job.TestCoordinates{RunCoordinates: runCoordinates, TestName: "test"}
+	stepCoordinates := job.TestStepCoordinates{TestCoordinates: testCoordinates, TestStepName: "cmd", TestStepLabel: "step"}
+	targetStatus := job.TargetStatus{
+		TestStepCoordinates: stepCoordinates,
+		Target: &target.Target{
+			ID:                 "T1",
+			FQDN:               "t1.example.com",
+			PrimaryIPv4:        net.ParseIP("10.0.0.1"),
+			PrimaryIPv6:        net.ParseIP("2001:db8::1"),
+			TargetManagerState: json.RawMessage(`{"lease":1}`),
+		},
+		InTime:  startTime.Add(time.Minute),
+		OutTime: startTime.Add(2 * time.Minute),
+		Error:   "target failed",
+		Events: []testevent.Event{{
+			SequenceID: 12,
+			EmitTime:   startTime.Add(time.Minute),
+			Header:     &testevent.Header{JobID: 3, RunID: 1, TestName: "test", TestAttempt: 1, TestStepLabel: "step"},
+			Data:       &testevent.Data{EventName: "CmdStdout", Target: &target.Target{ID: "T1"}, Payload: &payload},
+		}},
+	}
+	runStatus := job.RunStatus{
+		RunCoordinates: runCoordinates,
+		StartTime:      startTime,
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates: testCoordinates,
+			TestStepStatuses: []job.TestStepStatus{{
+				TestStepCoordinates: stepCoordinates,
+				Events: []testevent.Event{{
+					SequenceID: 11,
+					EmitTime:   startTime,
+					Header:     &testevent.Header{JobID: 3, RunID: 1, TestName: "test", TestStepLabel: "step"},
+					Data:       &testevent.Data{EventName: "TestStepRunningEvent"},
+				}},
+				TargetStatuses: []job.TargetStatus{targetStatus},
+			}},
+			TargetStatuses: []job.TargetStatus{targetStatus},
+		}},
+	}
+	report := &job.Report{
+		JobID:        3,
+		RunID:        1,
+		ReporterName: "TargetSuccess",
+		ReportTime:   endTime,
+		Success:      true,
+		Data:         map[string]interface{}{"Achieved": "1/1"},
+	}
+	status := &job.Status{
+		Name:          "test",
+		State:         string(job.EventJobFailed),
+		StateErrMsg:   "job failed",
+		StartTime:     startTime,
+		EndTime:       &endTime,
+		RunStatuses:   []job.RunStatus{runStatus},
+		RetryOf:       2,
+		ScheduledBy:   5,
+		QueuePosition: 1,
+		JobReport: &job.JobReport{
+			JobID:        3,
+			RunReports:   [][]*job.Report{{report}},
+			FinalReports: []*job.Report{{JobID: 3, ReporterName: "noop", ReportTime: endTime, Data: "done"}},
+		},
+	}
+	status.RunStatus = &status.RunStatuses[0]
+	return status
+}
+
+func TestTransportParity(t *testing.T) {
+	var listQuery *storage.JobQuery
+	schedule := &job.Schedule{
+		ID:            6,
+		Requestor:     "unit-test",
+		ServerID:      "unit-test",
+		CreateTime:    time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+		CronExpr:      "*/5 * * * *",
+		OverlapPolicy: job.OverlapQueue,
+		JobDescriptor: "{}",
+		LastFireTime:  time.Date(2023, 3, 1, 10, 5, 0, 0, time.UTC),
+		LastJobID:     7,
+	}
+	status := jobStatusFixture()
+	addr := newTestServer(t, func(ev *api.Event) *api.EventResponse {
+		resp := &api.EventResponse{Requestor: ev.Msg.Requestor()}
+		switch msg := ev.Msg.(type) {
+		case api.EventStopMsg:
+			if msg.JobID != 3 {
+				resp.Err = errors.New("unknown job")
+			}
+		case api.EventStatusMsg:
+			resp.Status = status
+		case api.EventRetryMsg:
+			assert.True(t, msg.FailedTargetsOnly)
+			resp.JobID = 4
+		case api.EventPauseMsg:
+			assert.True(t, msg.ReleaseTargets)
+		case api.EventResumeMsg:
+			if msg.JobID != 3 {
+				resp.Err = errors.New("job is not paused")
+			}
+		case api.EventListMsg:
+			listQuery = msg.Query
+			resp.JobIDs = []types.JobID{1, 2}
+		case api.EventAddScheduleMsg:
+			assert.Equal(t, "*/5 * * * *", msg.CronExpr)
+			assert.Equal(t, job.OverlapQueue, msg.OverlapPolicy)
+			resp.ScheduleID = 6
+		case api.EventListSchedulesMsg:
+			resp.Schedules = []*job.Schedule{schedule}
+		case api.EventPauseScheduleMsg:
+			assert.True(t, msg.Paused)
+		case api.EventDeleteScheduleMsg:
+			if msg.ScheduleID != 6 {
+				resp.Err = errors.New("unknown schedule")
+			}
+		}
+		return resp
+	})
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	tr := &grpc.GRPC{Addr: addr}
+
+	version, err := tr.Version(ctx, "unit-test")
+	require.NoError(t, err)
+	require.Equal(t, "unit-test", version.ServerID)
+	require.Equal(t, uint32(api.CurrentAPIVersion), version.Data.Version)
+
+	stop, err := tr.Stop(ctx, "unit-test", 3)
+	require.NoError(t, err)
+	require.Nil(t, stop.Err)
+	stop, err = tr.Stop(ctx, "unit-test", 5)
+	require.NoError(t, err)
+	require.NotNil(t, stop.Err)
+	require.Contains(t, stop.Err.Error(), "unknown job")
+
+	statusResp, err := tr.Status(ctx, "unit-test", 3)
+	require.NoError(t, err)
+	require.Equal(t, status, statusResp.Data.Status)
+
+	retry, err := tr.Retry(ctx, "unit-test", 3, true)
+	require.NoError(t, err)
+	require.Equal(t, types.JobID(3), retry.Data.JobID)
+	require.Equal(t, types.JobID(4), retry.Data.NewJobID)
+
+	pause, err := tr.Pause(ctx, "unit-test", 3, true)
+	require.NoError(t, err)
+	require.Nil(t, pause.Err)
+	require.Equal(t, types.JobID(3), pause.Data.JobID)
+
+	resume, err := tr.Resume(ctx, "unit-test", 5)
+	require.NoError(t, err)
+	require.NotNil(t, resume.Err)
+	require.Contains(t, resume.Err.Error(), "job is not paused")
+
+	list, err := tr.List(ctx, "unit-test", []job.State{job.JobStateFailed}, []string{"foo"})
+	require.NoError(t, err)
+	require.Equal(t, []types.JobID{1, 2}, list.Data.JobIDs)
+	require.NotNil(t, listQuery)
+	require.Equal(t, []job.State{job.JobStateFailed}, listQuery.States)
+	require.Equal(t, []string{"foo"}, listQuery.Tags)
+
+	addSchedule, err := tr.AddSchedule(ctx, "unit-test", "*/5 * * * *", job.OverlapQueue, "{}")
+	require.NoError(t, err)
+	require.Nil(t, addSchedule.Err)
+	require.Equal(t, types.ScheduleID(6), addSchedule.Data.ScheduleID)
+
+	schedules, err := tr.ListSchedules(ctx, "unit-test")
+	require.NoError(t, err)
+	require.Equal(t, []*job.Schedule{schedule}, schedules.Data.Schedules)
+
+	pauseSchedule, err := tr.PauseSchedule(ctx, "unit-test", 6, true)
+	require.NoError(t, err)
+	require.Nil(t, pauseSchedule.Err)
+	require.True(t, pauseSchedule.Data.Paused)
+
+	deleteSchedule, err := tr.DeleteSchedule(ctx, "unit-test", 8)
+	require.NoError(t, err)
+	require.NotNil(t, deleteSchedule.Err)
+	require.Contains(t, deleteSchedule.Err.Error(), "unknown schedule")
+
+	_, err = tr.Stop(ctx, "", 3)
+	require.Error(t, err)
+	require.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestTransportParityInventory(t *testing.T) {
+	target := &inventory.Target{
+		ID:          "T1",
+		FQDN:        "t1.example.com",
+		PrimaryIPv4: net.ParseIP("10.0.0.1"),
+		PrimaryIPv6: net.ParseIP("2001:db8::1"),
+		Labels:      map[string]string{"board": "X", "bmc": "openbmc"},
+		Health:      inventory.HealthHealthy,
+		UpdateTime:  time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	addr := newTestServer(t, func(ev *api.Event) *api.EventResponse {
+		resp := &api.EventResponse{Requestor: ev.Msg.Requestor()}
+		switch msg := ev.Msg.(type) {
+		case api.EventAddInventoryTargetMsg:
+			assert.Equal(t, target.ID, msg.Target.ID)
+			assert.Equal(t, target.PrimaryIPv4, msg.Target.PrimaryIPv4)
+			assert.Equal(t, target.Labels, msg.Target.Labels)
+			resp.InventoryTarget = target
+		case api.EventListInventoryTargetsMsg:
+			assert.Equal(t, "board=X", msg.Selector)
+			resp.InventoryTargets = []*inventory.Target{target}
+		case api.EventRemoveInventoryTargetMsg:
+			if msg.TargetID != "T1" {
+				resp.Err = errors.New("unknown target")
+			}
+		case api.EventDrainInventoryTargetMsg:
+			assert.True(t, msg.Drained)
+			resp.InventoryTarget = target
+		case api.EventLabelInventoryTargetMsg:
+			assert.Equal(t, map[string]string{"rev": "2"}, msg.Set)
+			assert.Equal(t, []string{"bmc"}, msg.Remove)
+			resp.InventoryTarget = target
+		case api.EventSetInventoryTargetHealthMsg:
+			assert.Equal(t, inventory.HealthUnhealthy, msg.Health)
+			resp.InventoryTarget = target
+		}
+		return resp
+	})
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	tr := &grpc.GRPC{Addr: addr}
+
+	add, err := tr.AddInventoryTarget(ctx, "unit-test", *target)
+	require.NoError(t, err)
+	require.Nil(t, add.Err)
+	require.Equal(t, target, add.Data.Target)
+
+	list, err := tr.ListInventoryTargets(ctx, "unit-test", "board=X")
+	require.NoError(t, err)
+	require.Equal(t, []*inventory.Target{target}, list.Data.Targets)
+
+	remove, err := tr.RemoveInventoryTarget(ctx, "unit-test", "T2")
+	require.NoError(t, err)
+	require.NotNil(t, remove.Err)
+	require.Contains(t, remove.Err.Error(), "unknown target")
+
+	drain, err := tr.DrainInventoryTarget(ctx, "unit-test", "T1", true)
+	require.NoError(t, err)
+	require.Equal(t, target, drain.Data.Target)
+
+	label, err := tr.LabelInventoryTarget(ctx, "unit-test", "T1", map[string]string{"rev": "2"}, []string{"bmc"})
+	require.NoError(t, err)
+	require.Equal(t, target, label.Data.Target)
+
+	health, err := tr.SetInventoryTargetHealth(ctx, "unit-test", "T1", inventory.HealthUnhealthy)
+	require.NoError(t, err)
+	require.Equal(t, target, health.Data.Target)
+}
+
+func TestWatchJob(t *testing.T) {
+	// The stream must be driven by the events emitted by the job, not by resyncs.
+	defer func(d time.Duration) { watchResyncInterval = d }(watchResyncInterval)
+	watchResyncInterval = time.Hour
+
+	memoryStorage, err := memory.New()
+	require.NoError(t, err)
+	vault := storage.NewSimpleEngineVault()
+	require.NoError(t, vault.StoreEngine(memoryStorage, storage.SyncEngine))
+	emitter := storage.NewFrameworkEventEmitter(vault)
+
+	emitTime := time.Now()
+	payload := json.RawMessage(`{"a":1}`)
+	var calls []api.EventEventsMsg
+	addr := newTestServer(t, func(ev *api.Event) *api.EventResponse {
+		msg := ev.Msg.(api.EventEventsMsg)
+		calls = append(calls, msg)
+		resp := &api.EventResponse{Requestor: ev.Msg.Requestor(), JobID: msg.JobID}
+		if len(calls) < 3 {
+			// the job makes progress, which wakes up the watcher
+			assert.NoError(t, emitter.Emit(ev.Context, frameworkevent.Event{JobID: msg.JobID, EventName: "Progress"}))
+		}
+		switch len(calls) {
+		case 1:
+			resp.JobState = string(job.EventJobStarted)
+			resp.FrameworkEvents = []frameworkevent.Event{
+				{SequenceID: 7, JobID: msg.JobID, EventName: job.EventJobStarted, EmitTime: emitTime},
+			}
+			resp.TestEvents = []testevent.Event{{
+				SequenceID: 10,
+				EmitTime:   emitTime.Add(time.Second),
+				Header:     &testevent.Header{JobID: msg.JobID, RunID: 1, TestName: "test", TestStepLabel: "step"},
+				Data:       &testevent.Data{EventName: "TargetIn", Payload: &payload},
+			}}
+		case 2:
+			resp.JobState = string(job.EventJobStarted)
+		default:
+			resp.JobState = string(job.EventJobCompleted)
+			resp.FrameworkEvents = []frameworkevent.Event{
+				{SequenceID: 8, JobID: msg.JobID, EventName: job.EventJobCompleted, EmitTime: emitTime.Add(2 * time.Second)},
+			}
+		}
+		return resp
+	})
+
+	client := contestlistenerconnect.NewConTestServiceClient(http.DefaultClient, addr)
+	stream, err := client.WatchJob(context.Background(), connect.NewRequest(&contestlistener.WatchJobRequest{
+		JobId:     2,
+		Requestor: "unit-test",
+	}))
+	require.NoError(t, err)
+	var received []*contestlistener.WatchJobResponse
+	for stream.Receive() {
+		received = append(received, stream.Msg())
+	}
+	require.NoError(t, stream.Err())
+
+	require.Len(t, received, 3)
+	require.Equal(t, string(job.EventJobStarted), received[0].GetFrameworkEvent().GetEventName())
+	testEvent := received[1].GetTestEvent()
+	require.NotNil(t, testEvent)
+	require.Equal(t, uint64(10), testEvent.SequenceId)
+	require.Equal(t, "step", testEvent.TestStepLabel)
+	require.Equal(t, "TargetIn", testEvent.EventName)
+	require.JSONEq(t, `{"a":1}`, string(testEvent.Payload))
+	require.Equal(t, string(job.EventJobCompleted), received[2].GetFrameworkEvent().GetEventName())
+
+	require.Len(t, calls, 3)
+	require.Equal(t, uint64(0), calls[0].TestEventsFrom)
+	require.Equal(t, uint64(11), calls[1].TestEventsFrom)
+	require.Equal(t, uint64(8), calls[1].FrameworkEventsFrom)
+}
+
+func TestServeListenAddrAndShutdown(t *testing.T) {
+	// find a free port
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	ctx, cancel := xcontext.WithCancel(logrusctx.NewContext(logger.LevelDebug))
+	a, err := api.New(api.OptionServerID("unit-test"))
+	require.NoError(t, err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- New(addr).Serve(ctx, a)
+	}()
+
+	tr := &grpc.GRPC{Addr: "http://" + addr}
+	require.Eventually(t, func() bool {
+		_, err := tr.Version(ctx, "unit-test")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after cancellation")
+	}
+	// the socket must have been released
+	ln, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	require.NoError(t, ln.Close())
+}
+
+func TestServeInvalidTLS(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	a, err := api.New(api.OptionServerID("unit-test"))
+	require.NoError(t, err)
+	err = New("127.0.0.1:0", OptionClientCA("ca.pem")).Serve(ctx, a)
+	require.Error(t, err)
+	err = New("127.0.0.1:0", OptionTLS{CertFile: "cert.pem"}).Serve(ctx, a)
+	require.Error(t, err)
+}
+
+func TestAuthenticator(t *testing.T) {
+	var requestors []api.EventRequestor
+	authenticator := func(ctx context.Context, header http.Header, tlsState *tls.ConnectionState) (api.EventRequestor, error) {
+		token := header.Get("Authorization")
+		if token != "Bearer secret" {
+			return "", errors.New("invalid token")
+		}
+		return "ci-bot", nil
+	}
+	addr := newTestServerWithAuth(t, authenticator, func(ev *api.Event) *api.EventResponse {
+		requestors = append(requestors, ev.Msg.Requestor())
+		return &api.EventResponse{Requestor: ev.Msg.Requestor()}
+	})
+
+	client := contestlistenerconnect.NewConTestServiceClient(http.DefaultClient, addr)
+	req := connect.NewRequest(&contestlistener.StopJobRequest{JobId: 1, Requestor: "someone-else"})
+	_, err := client.StopJob(context.Background(), req)
+	require.Error(t, err)
+	require.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	require.Empty(t, requestors)
+
+	req.Header().Set("Authorization", "Bearer secret")
+	_, err = client.StopJob(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, []api.EventRequestor{"ci-bot"}, requestors)
+}
+
+func TestCommonNameAuthenticator(t *testing.T) {
+	_, err := CommonNameAuthenticator(context.Background(), nil, nil)
+	require.Error(t, err)
+	_, err = CommonNameAuthenticator(context.Background(), nil, &tls.ConnectionState{})
+	require.Error(t, err)
+
+	requestor, err := CommonNameAuthenticator(context.Background(), nil, &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "ci-bot"}},
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, api.EventRequestor("ci-bot"), requestor)
+}
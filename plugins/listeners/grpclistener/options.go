@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"time"
+)
+
+// DefaultShutdownTimeout is the default time given to in-flight requests to
+// complete when the listener is shut down.
+const DefaultShutdownTimeout = 10 * time.Second
+
+// Option is an additional argument to method New to change the behavior
+// of the GRPCListener.
+type Option interface {
+	apply(*config)
+}
+
+type config struct {
+	tlsCertFile     string
+	tlsKeyFile      string
+	clientCAFile    string
+	authenticator   Authenticator
+	shutdownTimeout time.Duration
+}
+
+func getConfig(opts ...Option) config {
+	cfg := config{
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+	for _, opt := range opts {
+		opt.apply(&cfg)
+	}
+	return cfg
+}
+
+// OptionTLS enables TLS using the given certificate and key files.
+type OptionTLS struct {
+	CertFile string
+	KeyFile  string
+}
+
+func (opt OptionTLS) apply(config *config) {
+	config.tlsCertFile = opt.CertFile
+	config.tlsKeyFile = opt.KeyFile
+}
+
+// OptionClientCA enables mutual TLS: clients must present a certificate signed
+// by one of the CAs in the given PEM file. It requires OptionTLS.
+type OptionClientCA string
+
+func (opt OptionClientCA) apply(config *config) {
+	config.clientCAFile = string(opt)
+}
+
+// OptionAuthenticator sets the Authenticator used to identify the clients.
+type OptionAuthenticator struct {
+	Authenticator
+}
+
+func (opt OptionAuthenticator) apply(config *config) {
+	config.authenticator = opt.Authenticator
+}
+
+// OptionShutdownTimeout is the time given to in-flight requests to complete
+// when the listener is shut down, after which their connections are closed.
+type OptionShutdownTimeout time.Duration
+
+func (opt OptionShutdownTimeout) apply(config *config) {
+	config.shutdownTimeout = time.Duration(opt)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package grpclistener
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	contestlistener "github.com/linuxboot/contest/plugins/listeners/grpclistener/gen/contest/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// jobStatusToProto converts the status of a job. The coordinates of the runs,
+// tests, steps and targets are implied by the nesting of the messages.
+func jobStatusToProto(status *job.Status) (*contestlistener.JobStatus, error) {
+	msg := &contestlistener.JobStatus{
+		Name:          status.Name,
+		State:         status.State,
+		StateError:    status.StateErrMsg,
+		StartTime:     timestampToProto(status.StartTime),
+		RetryOf:       int32(status.RetryOf),
+		ScheduledBy:   int32(status.ScheduledBy),
+		QueuePosition: int32(status.QueuePosition),
+	}
+	if status.EndTime != nil {
+		msg.EndTime = timestamppb.New(*status.EndTime)
+	}
+	for _, runStatus := range status.RunStatuses {
+		msg.RunStatuses = append(msg.RunStatuses, runStatusToProto(runStatus))
+	}
+	if status.JobReport != nil {
+		report, err := jobReportToProto(status.JobReport)
+		if err != nil {
+			return nil, err
+		}
+		msg.JobReport = report
+	}
+	return msg, nil
+}
+
+func runStatusToProto(runStatus job.RunStatus) *contestlistener.RunStatus {
+	msg := &contestlistener.RunStatus{
+		RunId:     uint64(runStatus.RunID),
+		StartTime: timestampToProto(runStatus.StartTime),
+	}
+	for _, testStatus := range runStatus.TestStatuses {
+		testMsg := &contestlistener.TestStatus{
+			TestName:       testStatus.TestName,
+			TargetStatuses: targetStatusesToProto(testStatus.TargetStatuses),
+		}
+		for _, stepStatus := range testStatus.TestStepStatuses {
+			testMsg.TestStepStatuses = append(testMsg.TestStepStatuses, &contestlistener.TestStepStatus{
+				TestStepName:   stepStatus.TestStepName,
+				TestStepLabel:  stepStatus.TestStepLabel,
+				Events:         testEventsToProto(stepStatus.Events),
+				TargetStatuses: targetStatusesToProto(stepStatus.TargetStatuses),
+			})
+		}
+		msg.TestStatuses = append(msg.TestStatuses, testMsg)
+	}
+	return msg
+}
+
+func targetStatusesToProto(targetStatuses []job.TargetStatus) []*contestlistener.TargetStatus {
+	var msgs []*contestlistener.TargetStatus
+	for _, targetStatus := range targetStatuses {
+		msgs = append(msgs, &contestlistener.TargetStatus{
+			TestStepName:  targetStatus.TestStepName,
+			TestStepLabel: targetStatus.TestStepLabel,
+			Target:        targetToProto(targetStatus.Target),
+			InTime:        timestampToProto(targetStatus.InTime),
+			OutTime:       timestampToProto(targetStatus.OutTime),
+			Error:         targetStatus.Error,
+			Events:        testEventsToProto(targetStatus.Events),
+		})
+	}
+	return msgs
+}
+
+func targetToProto(t *target.Target) *contestlistener.Target {
+	if t == nil {
+		return nil
+	}
+	msg := &contestlistener.Target{
+		TargetId:           t.ID,
+		Fqdn:               t.FQDN,
+		TargetManagerState: t.TargetManagerState,
+	}
+	if t.PrimaryIPv4 != nil {
+		msg.PrimaryIpv4 = t.PrimaryIPv4.String()
+	}
+	if t.PrimaryIPv6 != nil {
+		msg.PrimaryIpv6 = t.PrimaryIPv6.String()
+	}
+	return msg
+}
+
+func testEventsToProto(events []testevent.Event) []*contestlistener.TestEvent {
+	var msgs []*contestlistener.TestEvent
+	for _, ev := range events {
+		msgs = append(msgs, testEventToProto(ev))
+	}
+	return msgs
+}
+
+func testEventToProto(ev testevent.Event) *contestlistener.TestEvent {
+	msg := &contestlistener.TestEvent{
+		SequenceId: ev.SequenceID,
+		EmitTime:   timestamppb.New(ev.EmitTime),
+	}
+	if ev.Header != nil {
+		msg.RunId = uint64(ev.Header.RunID)
+		msg.TestName = ev.Header.TestName
+		msg.TestAttempt = ev.Header.TestAttempt
+		msg.TestStepLabel = ev.Header.TestStepLabel
+	}
+	if ev.Data != nil {
+		msg.EventName = string(ev.Data.EventName)
+		if ev.Data.Target != nil {
+			msg.TargetId = ev.Data.Target.ID
+		}
+		if ev.Data.Payload != nil {
+			msg.Payload = []byte(*ev.Data.Payload)
+		}
+	}
+	return msg
+}
+
+func jobReportToProto(jobReport *job.JobReport) (*contestlistener.JobReport, error) {
+	msg := &contestlistener.JobReport{}
+	for _, runReports := range jobReport.RunReports {
+		runMsg := &contestlistener.RunReports{}
+		for _, report := range runReports {
+			reportMsg, err := reportToProto(report)
+			if err != nil {
+				return nil, err
+			}
+			runMsg.Reports = append(runMsg.Reports, reportMsg)
+		}
+		msg.RunReports = append(msg.RunReports, runMsg)
+	}
+	for _, report := range jobReport.FinalReports {
+		reportMsg, err := reportToProto(report)
+		if err != nil {
+			return nil, err
+		}
+		msg.FinalReports = append(msg.FinalReports, reportMsg)
+	}
+	return msg, nil
+}
+
+func reportToProto(report *job.Report) (*contestlistener.Report, error) {
+	data, err := json.Marshal(report.Data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal the data of the %s report: %w", report.ReporterName, err)
+	}
+	return &contestlistener.Report{
+		RunId:        uint64(report.RunID),
+		ReporterName: report.ReporterName,
+		ReportTime:   timestamppb.New(report.ReportTime),
+		Success:      report.Success,
+		Data:         data,
+	}, nil
+}
+
+// timestampToProto converts a time, leaving the timestamp unset if t is zero.
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
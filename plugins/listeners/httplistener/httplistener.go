@@ -10,13 +10,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/linuxboot/contest/pkg/api"
-	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
@@ -81,6 +82,46 @@ func strToJobID(s string) (types.JobID, error) {
 	return types.JobID(jobIDInt), nil
 }
 
+func strToScheduleID(s string) (types.ScheduleID, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, errors.New("schedule ID cannot be empty")
+	}
+	scheduleIDInt, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return types.ScheduleID(scheduleIDInt), nil
+}
+
+// formToInventoryTarget reads an inventory target from the form values of an
+// addinventorytarget request.
+func formToInventoryTarget(r *http.Request) (inventory.Target, error) {
+	t := inventory.Target{
+		ID:     r.PostFormValue("targetID"),
+		FQDN:   r.PostFormValue("fqdn"),
+		Health: inventory.Health(r.PostFormValue("health")),
+	}
+	for _, addr := range []struct {
+		field string
+		ip    *net.IP
+	}{
+		{"ipv4", &t.PrimaryIPv4},
+		{"ipv6", &t.PrimaryIPv6},
+	} {
+		if value := r.PostFormValue(addr.field); value != "" {
+			if *addr.ip = net.ParseIP(value); *addr.ip == nil {
+				return t, fmt.Errorf("invalid %s address %q", addr.field, value)
+			}
+		}
+	}
+	labels, err := inventory.ParseLabels(r.PostFormValue("labels"))
+	if err != nil {
+		return t, err
+	}
+	t.Labels = labels
+	return t, nil
+}
+
 type apiHandler struct {
 	ctx xcontext.Context
 	api *api.API
@@ -156,16 +197,54 @@ func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			errMsg = fmt.Sprintf("Retry failed: %v", err)
 			break
 		}
-		if resp, err = h.api.Retry(ctx, requestor, jobID); err != nil {
+		var failedOnly bool
+		if failedOnlyStr := r.PostFormValue("failedOnly"); failedOnlyStr != "" {
+			if failedOnly, err = strconv.ParseBool(failedOnlyStr); err != nil {
+				httpStatus = http.StatusBadRequest
+				errMsg = fmt.Sprintf("Retry failed: invalid failedOnly value: %v", err)
+				break
+			}
+		}
+		if resp, err = h.api.Retry(ctx, requestor, jobID, failedOnly); err != nil {
 			httpStatus = http.StatusBadRequest
 			errMsg = fmt.Sprintf("Retry failed: %v", err)
 		}
+	case "pause":
+		jobID, err := strToJobID(jobIDStr)
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Pause failed: %v", err)
+			break
+		}
+		var releaseTargets bool
+		if releaseTargetsStr := r.PostFormValue("releaseTargets"); releaseTargetsStr != "" {
+			if releaseTargets, err = strconv.ParseBool(releaseTargetsStr); err != nil {
+				httpStatus = http.StatusBadRequest
+				errMsg = fmt.Sprintf("Pause failed: invalid releaseTargets value: %v", err)
+				break
+			}
+		}
+		if resp, err = h.api.Pause(ctx, requestor, jobID, releaseTargets); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Pause failed: %v", err)
+		}
+	case "resume":
+		jobID, err := strToJobID(jobIDStr)
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Resume failed: %v", err)
+			break
+		}
+		if resp, err = h.api.Resume(ctx, requestor, jobID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("Resume failed: %v", err)
+		}
 	case "list":
 		var fields []storage.JobQueryField
 		if statesStr := r.PostFormValue("states"); len(statesStr) > 0 {
 			var states []job.State
 			for _, sts := range strings.Split(statesStr, ",") {
-				st, err := job.EventNameToJobState(event.Name(sts))
+				st, err := job.ParseState(sts)
 				if err != nil {
 					httpStatus = http.StatusBadRequest
 					errMsg = fmt.Sprintf("List failed: %v", err)
@@ -188,6 +267,103 @@ func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			httpStatus = http.StatusBadRequest
 			errMsg = fmt.Sprintf("List failed: %v", err)
 		}
+	case "addschedule":
+		if jobDesc == "" {
+			httpStatus = http.StatusBadRequest
+			errMsg = "Missing job description"
+			break
+		}
+		overlapPolicy := job.OverlapPolicy(r.PostFormValue("overlap"))
+		if resp, err = h.api.AddSchedule(ctx, requestor, r.PostFormValue("cron"), overlapPolicy, jobDesc); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("AddSchedule failed: %v", err)
+		}
+	case "listschedules":
+		if resp, err = h.api.ListSchedules(ctx, requestor); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("ListSchedules failed: %v", err)
+		}
+	case "pauseschedule":
+		scheduleID, err := strToScheduleID(r.PostFormValue("scheduleID"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("PauseSchedule failed: %v", err)
+			break
+		}
+		paused, err := strconv.ParseBool(r.PostFormValue("paused"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("PauseSchedule failed: invalid paused value: %v", err)
+			break
+		}
+		if resp, err = h.api.PauseSchedule(ctx, requestor, scheduleID, paused); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("PauseSchedule failed: %v", err)
+		}
+	case "deleteschedule":
+		scheduleID, err := strToScheduleID(r.PostFormValue("scheduleID"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("DeleteSchedule failed: %v", err)
+			break
+		}
+		if resp, err = h.api.DeleteSchedule(ctx, requestor, scheduleID); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("DeleteSchedule failed: %v", err)
+		}
+	case "addinventorytarget":
+		t, err := formToInventoryTarget(r)
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("AddInventoryTarget failed: %v", err)
+			break
+		}
+		if resp, err = h.api.AddInventoryTarget(ctx, requestor, t); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("AddInventoryTarget failed: %v", err)
+		}
+	case "listinventorytargets":
+		if resp, err = h.api.ListInventoryTargets(ctx, requestor, r.PostFormValue("selector")); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("ListInventoryTargets failed: %v", err)
+		}
+	case "removeinventorytarget":
+		if resp, err = h.api.RemoveInventoryTarget(ctx, requestor, r.PostFormValue("targetID")); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("RemoveInventoryTarget failed: %v", err)
+		}
+	case "draininventorytarget":
+		drained, err := strconv.ParseBool(r.PostFormValue("drained"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("DrainInventoryTarget failed: invalid drained value: %v", err)
+			break
+		}
+		if resp, err = h.api.DrainInventoryTarget(ctx, requestor, r.PostFormValue("targetID"), drained); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("DrainInventoryTarget failed: %v", err)
+		}
+	case "labelinventorytarget":
+		set, err := inventory.ParseLabels(r.PostFormValue("set"))
+		if err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("LabelInventoryTarget failed: %v", err)
+			break
+		}
+		var remove []string
+		if value := r.PostFormValue("remove"); value != "" {
+			remove = strings.Split(value, ",")
+		}
+		if resp, err = h.api.LabelInventoryTarget(ctx, requestor, r.PostFormValue("targetID"), set, remove); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("LabelInventoryTarget failed: %v", err)
+		}
+	case "setinventorytargethealth":
+		health := inventory.Health(r.PostFormValue("health"))
+		if resp, err = h.api.SetInventoryTargetHealth(ctx, requestor, r.PostFormValue("targetID"), health); err != nil {
+			httpStatus = http.StatusBadRequest
+			errMsg = fmt.Sprintf("SetInventoryTargetHealth failed: %v", err)
+		}
 	case "version":
 		resp = h.api.Version()
 	default:
@@ -0,0 +1,247 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package junit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Name defines the name of the reporter used within the plugin registry
+var Name = "JUnit"
+
+// Parameters contains the parameters of both the run and the final reporter.
+// They are optional.
+type Parameters struct {
+	// OmitOutput excludes the Stdout and Stderr events of the steps from the
+	// report.
+	OmitOutput bool
+}
+
+// TestSuites is the root element of a JUnit XML report.
+type TestSuites struct {
+	XMLName    xml.Name    `xml:"testsuites"`
+	Name       string      `xml:"name,attr,omitempty"`
+	Tests      int         `xml:"tests,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Time       float64     `xml:"time,attr"`
+	TestSuites []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite groups the test cases of a test.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Time      float64    `xml:"time,attr"`
+	Timestamp string     `xml:"timestamp,attr,omitempty"`
+	TestCases []TestCase `xml:"testcase"`
+}
+
+// TestCase is the result of a target in a test step.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	Classname string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+	SystemErr string   `xml:"system-err,omitempty"`
+}
+
+// Failure describes why a test case failed.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitReporter implements a reporter which renders the results of a job as
+// JUnit XML, with one testsuite per test and one testcase per target and
+// test step.
+type JUnitReporter struct {
+}
+
+func validateParameters(params []byte) (interface{}, error) {
+	var p Parameters
+	if len(params) == 0 {
+		return p, nil
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ValidateRunParameters validates the parameters for the run reporter
+func (r *JUnitReporter) ValidateRunParameters(params []byte) (interface{}, error) {
+	return validateParameters(params)
+}
+
+// ValidateFinalParameters validates the parameters for the final reporter
+func (r *JUnitReporter) ValidateFinalParameters(params []byte) (interface{}, error) {
+	return validateParameters(params)
+}
+
+// Name returns the Name of the reporter
+func (r *JUnitReporter) Name() string {
+	return Name
+}
+
+// RunReport renders the results of a run as JUnit XML.
+func (r *JUnitReporter) RunReport(ctx xcontext.Context, parameters interface{}, runStatus *job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	params, ok := parameters.(Parameters)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type Parameters")
+	}
+	suites := TestSuites{}
+	for _, testStatus := range runStatus.TestStatuses {
+		suites.add(newTestSuite(testStatus.TestName, &testStatus, params))
+	}
+	return render(&suites)
+}
+
+// FinalReport renders the results of all the runs of a job as JUnit XML, with
+// one testsuite per test and run.
+func (r *JUnitReporter) FinalReport(ctx xcontext.Context, parameters interface{}, runStatuses []job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	params, ok := parameters.(Parameters)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type Parameters")
+	}
+	suites := TestSuites{}
+	for _, runStatus := range runStatuses {
+		for _, testStatus := range runStatus.TestStatuses {
+			name := fmt.Sprintf("%s (run %d)", testStatus.TestName, runStatus.RunID)
+			suites.add(newTestSuite(name, &testStatus, params))
+		}
+	}
+	return render(&suites)
+}
+
+func render(suites *TestSuites) (bool, interface{}, error) {
+	out, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return false, nil, fmt.Errorf("could not render JUnit XML: %w", err)
+	}
+	return suites.Failures == 0, xml.Header + string(out) + "\n", nil
+}
+
+func (s *TestSuites) add(suite TestSuite) {
+	s.Tests += suite.Tests
+	s.Failures += suite.Failures
+	s.Time += suite.Time
+	s.TestSuites = append(s.TestSuites, suite)
+}
+
+// newTestSuite adds a test case for every target and step of the test. Only
+// the step a target failed the test in has a failure, the errors of the steps
+// the target recovered from are in the output of their test cases. Targets
+// which failed without going through any step, e.g. because they could not be
+// acquired, get a test case of their own.
+func newTestSuite(name string, testStatus *job.TestStatus, params Parameters) TestSuite {
+	suite := TestSuite{Name: name}
+	var start time.Time
+	add := func(tc TestCase, targetStatus *job.TargetStatus) {
+		if !targetStatus.InTime.IsZero() && (start.IsZero() || targetStatus.InTime.Before(start)) {
+			start = targetStatus.InTime
+		}
+		suite.Tests++
+		if tc.Failure != nil {
+			suite.Failures++
+		}
+		suite.Time += tc.Time
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	for _, stepStatus := range testStatus.TestStepStatuses {
+		for _, targetStatus := range stepStatus.TargetStatuses {
+			add(newTestCase(testStatus.TestName, &targetStatus, testStatus.FailedIn(&targetStatus), params), &targetStatus)
+		}
+	}
+	for _, targetStatus := range testStatus.TargetStatuses {
+		if targetStatus.Target == nil && targetStatus.Error != "" {
+			add(newTestCase(testStatus.TestName, &targetStatus, true, params), &targetStatus)
+		}
+	}
+	if !start.IsZero() {
+		suite.Timestamp = start.UTC().Format(time.RFC3339)
+	}
+	return suite
+}
+
+func newTestCase(testName string, targetStatus *job.TargetStatus, failed bool, params Parameters) TestCase {
+	targetID := "unknown"
+	if targetStatus.Target != nil {
+		targetID = targetStatus.Target.ID
+	}
+	step := targetStatus.TestStepLabel
+	if step == "" {
+		step = "target acquisition"
+	}
+	tc := TestCase{
+		Name:      fmt.Sprintf("%s [%s]", step, targetID),
+		Classname: testName,
+	}
+	if !targetStatus.InTime.IsZero() && !targetStatus.OutTime.IsZero() {
+		tc.Time = targetStatus.OutTime.Sub(targetStatus.InTime).Seconds()
+	}
+	if failed {
+		tc.Failure = &Failure{Message: firstLine(targetStatus.Error), Text: targetStatus.Error}
+	}
+	if !params.OmitOutput {
+		tc.SystemOut = eventsOutput(targetStatus.Events, events.EventStdout)
+		tc.SystemErr = eventsOutput(targetStatus.Events, events.EventStderr)
+	}
+	if !failed && targetStatus.Error != "" {
+		tc.SystemErr += fmt.Sprintf("recovered from: %s\n", strings.TrimRight(targetStatus.Error, "\n"))
+	}
+	return tc
+}
+
+// eventsOutput concatenates the messages of the events with the given name.
+func eventsOutput(targetEvents []testevent.Event, name event.Name) string {
+	var out strings.Builder
+	for _, ev := range targetEvents {
+		if ev.Data == nil || ev.Data.EventName != name || ev.Data.Payload == nil {
+			continue
+		}
+		var payload struct {
+			Msg string
+		}
+		if err := json.Unmarshal(*ev.Data.Payload, &payload); err != nil || payload.Msg == "" {
+			out.Write(*ev.Data.Payload)
+		} else {
+			out.WriteString(payload.Msg)
+		}
+		if !strings.HasSuffix(out.String(), "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+func firstLine(s string) string {
+	if idx := strings.IndexByte(s, '\n'); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+// New builds a new JUnitReporter
+func New() job.Reporter {
+	return &JUnitReporter{}
+}
+
+// Load returns the name and factory which are needed to register the Reporter
+func Load() (string, job.ReporterFactory) {
+	return Name, New
+}
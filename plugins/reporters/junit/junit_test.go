@@ -0,0 +1,154 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package junit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+var ctx = logrusctx.NewContext(logger.LevelDebug)
+
+func stdoutEvent(msg string) testevent.Event {
+	payload := json.RawMessage(`{"Msg":` + string(mustMarshal(msg)) + `}`)
+	return testevent.Event{Data: &testevent.Data{EventName: events.EventStdout, Payload: &payload}}
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func targetStatus(step, targetID, errMsg string, in time.Time, evs ...testevent.Event) job.TargetStatus {
+	ts := job.TargetStatus{
+		Target:  &target.Target{ID: targetID},
+		InTime:  in,
+		OutTime: in.Add(2 * time.Second),
+		Error:   errMsg,
+		Events:  evs,
+	}
+	ts.TestStepLabel = step
+	return ts
+}
+
+func testRunStatus() job.RunStatus {
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	failed := targetStatus("flash", "dut2", "flash failed\ndetails", now)
+	ping := targetStatus("ping", "dut1", "", now.Add(2*time.Second))
+	return job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 1, RunID: 1},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates: job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{
+				{TargetStatuses: []job.TargetStatus{
+					targetStatus("flash", "dut1", "", now, stdoutEvent("flashed <ok>")),
+					failed,
+				}},
+				{TargetStatuses: []job.TargetStatus{ping}},
+			},
+			TargetStatuses: []job.TargetStatus{ping, failed},
+		}},
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	r := New()
+	params, err := r.ValidateRunParameters(nil)
+	require.NoError(t, err)
+	rs := testRunStatus()
+	success, data, err := r.RunReport(ctx, params, &rs, nil)
+	require.NoError(t, err)
+	require.False(t, success)
+
+	report, ok := data.(string)
+	require.True(t, ok)
+	var suites TestSuites
+	require.NoError(t, xml.Unmarshal([]byte(report), &suites))
+	require.Equal(t, 3, suites.Tests)
+	require.Equal(t, 1, suites.Failures)
+	require.Len(t, suites.TestSuites, 1)
+
+	suite := suites.TestSuites[0]
+	require.Equal(t, "boot", suite.Name)
+	require.Equal(t, "2023-01-02T03:04:05Z", suite.Timestamp)
+	require.Len(t, suite.TestCases, 3)
+	require.Equal(t, "flash [dut1]", suite.TestCases[0].Name)
+	require.Equal(t, "boot", suite.TestCases[0].Classname)
+	require.Equal(t, float64(2), suite.TestCases[0].Time)
+	require.Nil(t, suite.TestCases[0].Failure)
+	require.Equal(t, "flashed <ok>\n", suite.TestCases[0].SystemOut)
+	require.NotNil(t, suite.TestCases[1].Failure)
+	require.Equal(t, "flash failed", suite.TestCases[1].Failure.Message)
+	require.Equal(t, "flash failed\ndetails", suite.TestCases[1].Failure.Text)
+	require.Equal(t, "ping [dut1]", suite.TestCases[2].Name)
+}
+
+func TestReportOmitOutput(t *testing.T) {
+	r := New()
+	params, err := r.ValidateFinalParameters([]byte(`{"OmitOutput": true}`))
+	require.NoError(t, err)
+	_, data, err := r.FinalReport(ctx, params, []job.RunStatus{testRunStatus(), testRunStatus()}, nil)
+	require.NoError(t, err)
+
+	var suites TestSuites
+	require.NoError(t, xml.Unmarshal([]byte(data.(string)), &suites))
+	require.Len(t, suites.TestSuites, 2)
+	require.Equal(t, "boot (run 1)", suites.TestSuites[0].Name)
+	require.Equal(t, 6, suites.Tests)
+	require.Empty(t, suites.TestSuites[0].TestCases[0].SystemOut)
+}
+
+func TestReportRecoveredTarget(t *testing.T) {
+	// dut1 failed "flash" and recovered through "recover", dut2 could not be
+	// acquired.
+	now := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	recovered := targetStatus("recover", "dut1", "", now.Add(2*time.Second))
+	acquireErr := job.TargetStatus{Error: "no targets"}
+	rs := job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 1, RunID: 1},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates: job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{
+				{TargetStatuses: []job.TargetStatus{targetStatus("flash", "dut1", "flash failed", now)}},
+				{TargetStatuses: []job.TargetStatus{recovered}},
+			},
+			TargetStatuses: []job.TargetStatus{recovered, acquireErr},
+		}},
+	}
+
+	success, data, err := New().RunReport(ctx, Parameters{}, &rs, nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	var suites TestSuites
+	require.NoError(t, xml.Unmarshal([]byte(data.(string)), &suites))
+	require.Equal(t, 3, suites.Tests)
+	require.Equal(t, 1, suites.Failures)
+	cases := suites.TestSuites[0].TestCases
+	require.Nil(t, cases[0].Failure)
+	require.Equal(t, "recovered from: flash failed\n", cases[0].SystemErr)
+	require.Nil(t, cases[1].Failure)
+	require.Equal(t, "target acquisition [unknown]", cases[2].Name)
+	require.Equal(t, "no targets", cases[2].Failure.Message)
+
+	rs.TestStatuses[0].TargetStatuses = rs.TestStatuses[0].TargetStatuses[:1]
+	success, _, err = New().RunReport(ctx, Parameters{}, &rs, nil)
+	require.NoError(t, err)
+	require.True(t, success)
+}
@@ -0,0 +1,355 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package metricthreshold
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Name defines the name of the reporter used within the plugin registry
+var Name = "MetricThreshold"
+
+// MetricSelector selects the Metric events that a threshold or a baseline
+// applies to. Empty fields match everything.
+type MetricSelector struct {
+	Metric        string
+	Tags          map[string]string
+	TestName      string
+	TestStepLabel string
+}
+
+func (s MetricSelector) validate() error {
+	if s.Metric == "" {
+		return errors.New("metric name cannot be empty")
+	}
+	return nil
+}
+
+func (s MetricSelector) String() string {
+	str := s.Metric
+	if len(s.Tags) > 0 {
+		str += fmt.Sprintf("%v", s.Tags)
+	}
+	if s.TestName != "" {
+		str += fmt.Sprintf(" in test %q", s.TestName)
+	}
+	if s.TestStepLabel != "" {
+		str += fmt.Sprintf(" in step %q", s.TestStepLabel)
+	}
+	return str
+}
+
+// Threshold is an assertion on every sample of a metric within a run.
+type Threshold struct {
+	MetricSelector
+	Min *float64
+	Max *float64
+}
+
+// Baseline is an assertion on the average of a metric in every run.
+type Baseline struct {
+	MetricSelector
+	// Value is the reference average. If not set, the average of the first
+	// run of the job is used.
+	Value *float64
+	// MaxRegression is the maximum relative regression of the average
+	// against the baseline, e.g. 0.1 for 10%.
+	MaxRegression float64
+	// HigherIsBetter tells whether a decrease of the metric is a regression,
+	// like for a benchmark score, rather than an increase, like for a boot
+	// time.
+	HigherIsBetter bool
+}
+
+// RunParameters contains the parameters necessary for the run reporter to
+// elaborate the results of the Job
+type RunParameters struct {
+	Thresholds []Threshold
+}
+
+// FinalParameters contains the parameters necessary for the final reporter to
+// elaborate the results of the Job
+type FinalParameters struct {
+	Baselines []Baseline
+}
+
+// Stats summarizes the samples of a metric.
+type Stats struct {
+	Samples int
+	Min     float64
+	Max     float64
+	Avg     float64
+}
+
+// ThresholdResult is the outcome of a Threshold in a run report.
+type ThresholdResult struct {
+	Threshold  string
+	Stats      Stats
+	Pass       bool
+	Violations []string `json:",omitempty"`
+}
+
+// BaselineResult is the outcome of a Baseline in the final report.
+type BaselineResult struct {
+	Baseline    string
+	Value       float64
+	RunAverages map[types.RunID]float64
+	Pass        bool
+	Violations  []string `json:",omitempty"`
+}
+
+// MetricThresholdReporter implements a reporter which asserts on the metrics
+// emitted by the test steps as Metric events.
+type MetricThresholdReporter struct {
+}
+
+// ValidateRunParameters validates the parameters for the run reporter
+func (r *MetricThresholdReporter) ValidateRunParameters(params []byte) (interface{}, error) {
+	var rp RunParameters
+	if err := json.Unmarshal(params, &rp); err != nil {
+		return nil, err
+	}
+	if len(rp.Thresholds) == 0 {
+		return nil, errors.New("at least one threshold is required")
+	}
+	for idx, th := range rp.Thresholds {
+		if err := th.validate(); err != nil {
+			return nil, fmt.Errorf("invalid threshold %d: %w", idx, err)
+		}
+		if th.Min == nil && th.Max == nil {
+			return nil, fmt.Errorf("invalid threshold %d: at least one of Min and Max is required", idx)
+		}
+		if th.Min != nil && th.Max != nil && *th.Min > *th.Max {
+			return nil, fmt.Errorf("invalid threshold %d: Min is greater than Max", idx)
+		}
+	}
+	return rp, nil
+}
+
+// ValidateFinalParameters validates the parameters for the final reporter
+func (r *MetricThresholdReporter) ValidateFinalParameters(params []byte) (interface{}, error) {
+	var fp FinalParameters
+	if err := json.Unmarshal(params, &fp); err != nil {
+		return nil, err
+	}
+	if len(fp.Baselines) == 0 {
+		return nil, errors.New("at least one baseline is required")
+	}
+	for idx, bl := range fp.Baselines {
+		if err := bl.validate(); err != nil {
+			return nil, fmt.Errorf("invalid baseline %d: %w", idx, err)
+		}
+		if bl.MaxRegression < 0 {
+			return nil, fmt.Errorf("invalid baseline %d: MaxRegression cannot be negative", idx)
+		}
+	}
+	return fp, nil
+}
+
+// Name returns the Name of the reporter
+func (r *MetricThresholdReporter) Name() string {
+	return Name
+}
+
+type sample struct {
+	metric        events.Metric
+	testName      string
+	testStepLabel string
+	targetID      string
+}
+
+func (s sample) matches(sel MetricSelector) bool {
+	return s.metric.Name == sel.Metric &&
+		s.metric.HasTags(sel.Tags) &&
+		(sel.TestName == "" || s.testName == sel.TestName) &&
+		(sel.TestStepLabel == "" || s.testStepLabel == sel.TestStepLabel)
+}
+
+// fetchSamples returns the metrics emitted in a run.
+func fetchSamples(ctx xcontext.Context, ev testevent.Fetcher, coords job.RunCoordinates) ([]sample, error) {
+	metricEvents, err := ev.Fetch(ctx,
+		testevent.QueryJobID(coords.JobID),
+		testevent.QueryRunID(coords.RunID),
+		testevent.QueryEventName(events.EventMetric),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch metric events for run %d: %w", coords.RunID, err)
+	}
+	var samples []sample
+	for _, mev := range metricEvents {
+		if mev.Data == nil || mev.Data.Payload == nil {
+			continue
+		}
+		var s sample
+		if err := json.Unmarshal(*mev.Data.Payload, &s.metric); err != nil {
+			ctx.Warnf("Invalid metric payload %q: %v", string(*mev.Data.Payload), err)
+			continue
+		}
+		if mev.Header != nil {
+			s.testName = mev.Header.TestName
+			s.testStepLabel = mev.Header.TestStepLabel
+		}
+		if mev.Data.Target != nil {
+			s.targetID = mev.Data.Target.ID
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+func selectSamples(samples []sample, sel MetricSelector) []sample {
+	var selected []sample
+	for _, s := range samples {
+		if s.matches(sel) {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+func computeStats(samples []sample) Stats {
+	stats := Stats{Samples: len(samples)}
+	if len(samples) == 0 {
+		return stats
+	}
+	stats.Min, stats.Max = math.Inf(1), math.Inf(-1)
+	var sum float64
+	for _, s := range samples {
+		stats.Min = math.Min(stats.Min, s.metric.Value)
+		stats.Max = math.Max(stats.Max, s.metric.Value)
+		sum += s.metric.Value
+	}
+	stats.Avg = sum / float64(len(samples))
+	return stats
+}
+
+// RunReport checks every sample of the metrics in the run against the thresholds.
+func (r *MetricThresholdReporter) RunReport(ctx xcontext.Context, parameters interface{}, runStatus *job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	reportParameters, ok := parameters.(RunParameters)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type RunParameters")
+	}
+	samples, err := fetchSamples(ctx, ev, runStatus.RunCoordinates)
+	if err != nil {
+		return false, nil, err
+	}
+
+	runSuccess := true
+	var results []ThresholdResult
+	for _, th := range reportParameters.Thresholds {
+		selected := selectSamples(samples, th.MetricSelector)
+		result := ThresholdResult{
+			Threshold: th.MetricSelector.String(),
+			Stats:     computeStats(selected),
+		}
+		if len(selected) == 0 {
+			result.Violations = append(result.Violations, "no samples found")
+		}
+		for _, s := range selected {
+			if th.Min != nil && s.metric.Value < *th.Min {
+				result.Violations = append(result.Violations,
+					fmt.Sprintf("target %s: %v%s is below the minimum %v", s.targetID, s.metric.Value, s.metric.Unit, *th.Min))
+			}
+			if th.Max != nil && s.metric.Value > *th.Max {
+				result.Violations = append(result.Violations,
+					fmt.Sprintf("target %s: %v%s is above the maximum %v", s.targetID, s.metric.Value, s.metric.Unit, *th.Max))
+			}
+		}
+		result.Pass = len(result.Violations) == 0
+		if !result.Pass {
+			runSuccess = false
+		}
+		results = append(results, result)
+	}
+	return runSuccess, results, nil
+}
+
+// FinalReport checks the average of the metrics in every run against the baselines.
+func (r *MetricThresholdReporter) FinalReport(ctx xcontext.Context, parameters interface{}, runStatuses []job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	reportParameters, ok := parameters.(FinalParameters)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type FinalParameters")
+	}
+	runSamples := make([][]sample, 0, len(runStatuses))
+	for _, runStatus := range runStatuses {
+		samples, err := fetchSamples(ctx, ev, runStatus.RunCoordinates)
+		if err != nil {
+			return false, nil, err
+		}
+		runSamples = append(runSamples, samples)
+	}
+
+	success := true
+	var results []BaselineResult
+	for _, bl := range reportParameters.Baselines {
+		result := BaselineResult{
+			Baseline:    bl.MetricSelector.String(),
+			RunAverages: make(map[types.RunID]float64),
+		}
+		var haveBaseline bool
+		if bl.Value != nil {
+			result.Value, haveBaseline = *bl.Value, true
+		}
+		for idx, runStatus := range runStatuses {
+			stats := computeStats(selectSamples(runSamples[idx], bl.MetricSelector))
+			if stats.Samples == 0 {
+				result.Violations = append(result.Violations, fmt.Sprintf("run %d: no samples found", runStatus.RunID))
+				continue
+			}
+			result.RunAverages[runStatus.RunID] = stats.Avg
+			if !haveBaseline {
+				// the first run with samples is the baseline
+				result.Value, haveBaseline = stats.Avg, true
+				continue
+			}
+			if regression := relativeRegression(result.Value, stats.Avg, bl.HigherIsBetter); regression > bl.MaxRegression {
+				result.Violations = append(result.Violations,
+					fmt.Sprintf("run %d: average %v regressed by %.2f%% against the baseline %v", runStatus.RunID, stats.Avg, regression*100, result.Value))
+			}
+		}
+		result.Pass = len(result.Violations) == 0
+		if !result.Pass {
+			success = false
+		}
+		results = append(results, result)
+	}
+	return success, results, nil
+}
+
+// relativeRegression returns how much worse value is than baseline, relative
+// to the baseline. It is negative if value is better.
+func relativeRegression(baseline, value float64, higherIsBetter bool) float64 {
+	diff := value - baseline
+	if higherIsBetter {
+		diff = -diff
+	}
+	if baseline == 0 {
+		if diff > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return diff / math.Abs(baseline)
+}
+
+// New builds a new MetricThresholdReporter
+func New() job.Reporter {
+	return &MetricThresholdReporter{}
+}
+
+// Load returns the name and factory which are needed to register the Reporter
+func Load() (string, job.ReporterFactory) {
+	return Name, New
+}
@@ -0,0 +1,138 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package metricthreshold
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+var ctx = logrusctx.NewContext(logger.LevelDebug)
+
+// fakeFetcher returns the metrics of a run, ignoring the other query fields.
+type fakeFetcher map[types.RunID][]events.Metric
+
+func (f fakeFetcher) Fetch(ctx xcontext.Context, fields ...testevent.QueryField) ([]testevent.Event, error) {
+	query, err := testevent.BuildQuery(fields...)
+	if err != nil {
+		return nil, err
+	}
+	var evs []testevent.Event
+	for idx, m := range f[query.RunID] {
+		payload, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		msg := json.RawMessage(payload)
+		evs = append(evs, testevent.Event{
+			Header: &testevent.Header{JobID: query.JobID, RunID: query.RunID, TestName: "test", TestStepLabel: "bench"},
+			Data: &testevent.Data{
+				EventName: events.EventMetric,
+				Target:    &target.Target{ID: string(rune('A' + idx))},
+				Payload:   &msg,
+			},
+		})
+	}
+	return evs, nil
+}
+
+func runStatus(runID types.RunID) job.RunStatus {
+	return job.RunStatus{RunCoordinates: job.RunCoordinates{JobID: 1, RunID: runID}}
+}
+
+func TestValidateParameters(t *testing.T) {
+	r := New()
+	_, err := r.ValidateRunParameters([]byte(`{"Thresholds": [{"Metric": "BootTime", "Max": 10}]}`))
+	require.NoError(t, err)
+	_, err = r.ValidateRunParameters([]byte(`{"Thresholds": [{"Metric": "BootTime"}]}`))
+	require.Error(t, err)
+	_, err = r.ValidateRunParameters([]byte(`{"Thresholds": [{"Max": 10}]}`))
+	require.Error(t, err)
+	_, err = r.ValidateRunParameters([]byte(`{"Thresholds": [{"Metric": "BootTime", "Min": 10, "Max": 1}]}`))
+	require.Error(t, err)
+
+	_, err = r.ValidateFinalParameters([]byte(`{"Baselines": [{"Metric": "BootTime", "MaxRegression": 0.1}]}`))
+	require.NoError(t, err)
+	_, err = r.ValidateFinalParameters([]byte(`{"Baselines": [{"Metric": "BootTime", "MaxRegression": -1}]}`))
+	require.Error(t, err)
+}
+
+func TestRunReport(t *testing.T) {
+	r := New()
+	fetcher := fakeFetcher{
+		1: {
+			{Name: "BootTime", Value: 8, Unit: "s"},
+			{Name: "BootTime", Value: 12, Unit: "s"},
+			{Name: "Score", Value: 100, Tags: map[string]string{"mode": "cpu"}},
+			{Name: "Score", Value: 5, Tags: map[string]string{"mode": "memory"}},
+		},
+	}
+	params, err := r.ValidateRunParameters([]byte(`{"Thresholds": [
+		{"Metric": "Score", "Tags": {"mode": "cpu"}, "Min": 50},
+		{"Metric": "BootTime", "Max": 10}
+	]}`))
+	require.NoError(t, err)
+	rs := runStatus(1)
+	success, report, err := r.RunReport(ctx, params, &rs, fetcher)
+	require.NoError(t, err)
+	require.False(t, success)
+	results := report.([]ThresholdResult)
+	require.Len(t, results, 2)
+	require.True(t, results[0].Pass)
+	require.Equal(t, 1, results[0].Stats.Samples)
+	require.False(t, results[1].Pass)
+	require.Equal(t, Stats{Samples: 2, Min: 8, Max: 12, Avg: 10}, results[1].Stats)
+	require.Len(t, results[1].Violations, 1)
+	require.Contains(t, results[1].Violations[0], "target B")
+
+	// a threshold on a metric which was not emitted fails
+	params, err = r.ValidateRunParameters([]byte(`{"Thresholds": [{"Metric": "Missing", "Min": 1}]}`))
+	require.NoError(t, err)
+	success, _, err = r.RunReport(ctx, params, &rs, fetcher)
+	require.NoError(t, err)
+	require.False(t, success)
+}
+
+func TestFinalReport(t *testing.T) {
+	r := New()
+	fetcher := fakeFetcher{
+		1: {{Name: "BootTime", Value: 10}, {Name: "Score", Value: 100}},
+		2: {{Name: "BootTime", Value: 10.5}, {Name: "Score", Value: 80}},
+		3: {{Name: "BootTime", Value: 12}, {Name: "Score", Value: 120}},
+	}
+	params, err := r.ValidateFinalParameters([]byte(`{"Baselines": [
+		{"Metric": "BootTime", "MaxRegression": 0.1},
+		{"Metric": "Score", "Value": 90, "MaxRegression": 0.05, "HigherIsBetter": true}
+	]}`))
+	require.NoError(t, err)
+	success, report, err := r.FinalReport(ctx, params, []job.RunStatus{runStatus(1), runStatus(2), runStatus(3)}, fetcher)
+	require.NoError(t, err)
+	require.False(t, success)
+	results := report.([]BaselineResult)
+	require.Len(t, results, 2)
+
+	// boot time: baseline from the first run, only run 3 regressed by more than 10%
+	require.Equal(t, float64(10), results[0].Value)
+	require.Len(t, results[0].Violations, 1)
+	require.Contains(t, results[0].Violations[0], "run 3")
+
+	// score: explicit baseline, only run 2 is lower by more than 5%
+	require.Equal(t, float64(90), results[1].Value)
+	require.Len(t, results[1].Violations, 1)
+	require.Contains(t, results[1].Violations[0], "run 2")
+	require.Equal(t, map[types.RunID]float64{1: 100, 2: 80, 3: 120}, results[1].RunAverages)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Name defines the name of the reporter used within the plugin registry
+var Name = "TAP"
+
+// TAPReporter implements a reporter which renders the results of a job in the
+// Test Anything Protocol (version 13), with one test point per target and
+// test step.
+type TAPReporter struct {
+}
+
+// ValidateRunParameters validates the parameters for the run reporter
+func (r *TAPReporter) ValidateRunParameters(params []byte) (interface{}, error) {
+	var s string
+	return s, nil
+}
+
+// ValidateFinalParameters validates the parameters for the final reporter
+func (r *TAPReporter) ValidateFinalParameters(params []byte) (interface{}, error) {
+	var s string
+	return s, nil
+}
+
+// Name returns the Name of the reporter
+func (r *TAPReporter) Name() string {
+	return Name
+}
+
+type testPoint struct {
+	description string
+	failed      bool
+	// err is the error of the target in the step, which is also reported
+	// for the steps the target recovered from.
+	err string
+}
+
+// testPoints adds a test point for every target and step of the tests. Only
+// the step a target failed the test in is not ok. Targets which failed
+// without going through any step, e.g. because they could not be acquired,
+// get a test point of their own.
+func testPoints(prefix string, runStatus *job.RunStatus) []testPoint {
+	var points []testPoint
+	add := func(testName string, targetStatus *job.TargetStatus, failed bool) {
+		targetID := "unknown"
+		if targetStatus.Target != nil {
+			targetID = targetStatus.Target.ID
+		}
+		step := targetStatus.TestStepLabel
+		if step == "" {
+			step = "target acquisition"
+		}
+		points = append(points, testPoint{
+			description: fmt.Sprintf("%s%s / %s [%s]", prefix, testName, step, targetID),
+			failed:      failed,
+			err:         targetStatus.Error,
+		})
+	}
+	for _, testStatus := range runStatus.TestStatuses {
+		for _, stepStatus := range testStatus.TestStepStatuses {
+			for _, targetStatus := range stepStatus.TargetStatuses {
+				add(testStatus.TestName, &targetStatus, testStatus.FailedIn(&targetStatus))
+			}
+		}
+		for _, targetStatus := range testStatus.TargetStatuses {
+			if targetStatus.Target == nil && targetStatus.Error != "" {
+				add(testStatus.TestName, &targetStatus, true)
+			}
+		}
+	}
+	return points
+}
+
+func render(points []testPoint) (bool, interface{}, error) {
+	var out strings.Builder
+	success := true
+	out.WriteString("TAP version 13\n")
+	fmt.Fprintf(&out, "1..%d\n", len(points))
+	for idx, p := range points {
+		if p.failed {
+			success = false
+			fmt.Fprintf(&out, "not ok %d - %s\n", idx+1, p.description)
+		} else {
+			fmt.Fprintf(&out, "ok %d - %s\n", idx+1, p.description)
+		}
+		if p.err == "" {
+			continue
+		}
+		out.WriteString("  ---\n  message: |\n")
+		for _, line := range strings.Split(strings.TrimRight(p.err, "\n"), "\n") {
+			fmt.Fprintf(&out, "    %s\n", line)
+		}
+		out.WriteString("  ...\n")
+	}
+	return success, out.String(), nil
+}
+
+// RunReport renders the results of a run as TAP.
+func (r *TAPReporter) RunReport(ctx xcontext.Context, parameters interface{}, runStatus *job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	return render(testPoints("", runStatus))
+}
+
+// FinalReport renders the results of all the runs of a job as TAP.
+func (r *TAPReporter) FinalReport(ctx xcontext.Context, parameters interface{}, runStatuses []job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	var points []testPoint
+	for idx := range runStatuses {
+		points = append(points, testPoints(fmt.Sprintf("run %d: ", runStatuses[idx].RunID), &runStatuses[idx])...)
+	}
+	return render(points)
+}
+
+// New builds a new TAPReporter
+func New() job.Reporter {
+	return &TAPReporter{}
+}
+
+// Load returns the name and factory which are needed to register the Reporter
+func Load() (string, job.ReporterFactory) {
+	return Name, New
+}
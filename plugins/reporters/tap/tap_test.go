@@ -0,0 +1,91 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+func TestRunReport(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	ok := job.TargetStatus{Target: &target.Target{ID: "dut1"}}
+	ok.TestStepLabel = "flash"
+	failed := job.TargetStatus{Target: &target.Target{ID: "dut2"}, Error: "flash failed\ndetails"}
+	failed.TestStepLabel = "flash"
+	rs := job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 1, RunID: 1},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates:  job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{{TargetStatuses: []job.TargetStatus{ok, failed}}},
+			TargetStatuses:   []job.TargetStatus{ok, failed},
+		}},
+	}
+
+	success, data, err := New().RunReport(ctx, "", &rs, nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	require.Equal(t, `TAP version 13
+1..2
+ok 1 - boot / flash [dut1]
+not ok 2 - boot / flash [dut2]
+  ---
+  message: |
+    flash failed
+    details
+  ...
+`, data)
+}
+
+func TestRunReportRecoveredTarget(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	// dut1 failed "flash" and recovered through "recover", dut2 could not be
+	// acquired.
+	failed := job.TargetStatus{Target: &target.Target{ID: "dut1"}, Error: "flash failed"}
+	failed.TestStepLabel = "flash"
+	recovered := job.TargetStatus{Target: &target.Target{ID: "dut1"}}
+	recovered.TestStepLabel = "recover"
+	rs := job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 1, RunID: 1},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates: job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{
+				{TargetStatuses: []job.TargetStatus{failed}},
+				{TargetStatuses: []job.TargetStatus{recovered}},
+			},
+			TargetStatuses: []job.TargetStatus{recovered, {Error: "no targets"}},
+		}},
+	}
+
+	success, data, err := New().RunReport(ctx, "", &rs, nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	require.Equal(t, `TAP version 13
+1..3
+ok 1 - boot / flash [dut1]
+  ---
+  message: |
+    flash failed
+  ...
+ok 2 - boot / recover [dut1]
+not ok 3 - boot / target acquisition [unknown]
+  ---
+  message: |
+    no targets
+  ...
+`, data)
+
+	rs.TestStatuses[0].TargetStatuses = rs.TestStatuses[0].TargetStatuses[:1]
+	success, _, err = New().RunReport(ctx, "", &rs, nil)
+	require.NoError(t, err)
+	require.True(t, success)
+}
@@ -0,0 +1,332 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/insomniacslk/xjson"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Name defines the name of the reporter used within the plugin registry
+var Name = "Webhook"
+
+// Defaults for the optional parameters.
+const (
+	DefaultMethod          = http.MethodPost
+	DefaultContentType     = "application/json"
+	DefaultSignatureHeader = "X-Contest-Signature"
+	DefaultTimeout         = 10 * time.Second
+	DefaultRetryInterval   = time.Second
+)
+
+// DefaultTemplate renders a JSON summary of the results.
+const DefaultTemplate = `{"job_id": {{ .JobID }}, "run_id": {{ .RunID }}, "success": {{ .Success }}, "failed_targets": {{ json .FailedTargets }}}`
+
+// Parameters contains the parameters of both the run and the final reporter.
+type Parameters struct {
+	URL         string
+	Method      string
+	Headers     map[string]string
+	ContentType string
+	// Template is a text/template for the request body, executed on a
+	// TemplateData. The "json" function encodes its argument as JSON.
+	Template string
+	// OnlyOnFailure only sends the notification if a target failed.
+	OnlyOnFailure bool
+	// Retries is the number of times a request is retried after a network
+	// error or a 5xx response. The interval between retries doubles at every
+	// attempt.
+	Retries       uint
+	RetryInterval xjson.Duration
+	Timeout       xjson.Duration
+	// The body is signed with HMAC-SHA256 using HMACSecret, or the content of
+	// the environment variable HMACSecretEnv of the server, and the signature
+	// is sent as "sha256=<hex>" in SignatureHeader.
+	HMACSecret      string
+	HMACSecretEnv   string
+	SignatureHeader string
+}
+
+type config struct {
+	Parameters
+	tmpl *template.Template
+}
+
+// TemplateData is the data the body template is executed on.
+type TemplateData struct {
+	JobID types.JobID
+	// RunID is 0 for the final report.
+	RunID types.RunID
+	// Success is true if no target failed.
+	Success       bool
+	FailedTargets []FailedTarget
+	// RunStatuses holds the status of the run for a run report, and the
+	// statuses of all the runs for a final report.
+	RunStatuses []job.RunStatus
+}
+
+// FailedTarget identifies a target which failed a test, and the step it
+// failed.
+type FailedTarget struct {
+	RunID         types.RunID
+	TestName      string
+	TestStepLabel string
+	TargetID      string
+	Error         string
+}
+
+// Report is the data of the reports of the Webhook reporter.
+type Report struct {
+	// Sent is false if the notification was not sent because of
+	// OnlyOnFailure, or if all the attempts failed.
+	Sent       bool
+	Attempts   uint
+	StatusCode int    `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+// WebhookReporter implements a reporter which notifies an HTTP endpoint of
+// the results of a job.
+type WebhookReporter struct {
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func validateParameters(params []byte) (interface{}, error) {
+	var cfg config
+	if err := json.Unmarshal(params, &cfg.Parameters); err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme '%s', please specify either http or https", u.Scheme)
+	}
+	if cfg.Method == "" {
+		cfg.Method = DefaultMethod
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = DefaultContentType
+	}
+	if cfg.Template == "" {
+		cfg.Template = DefaultTemplate
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = DefaultSignatureHeader
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = xjson.Duration(DefaultTimeout)
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = xjson.Duration(DefaultRetryInterval)
+	}
+	if cfg.Timeout < 0 || cfg.RetryInterval < 0 {
+		return nil, errors.New("Timeout and RetryInterval cannot be negative")
+	}
+	if cfg.HMACSecret != "" && cfg.HMACSecretEnv != "" {
+		return nil, errors.New("only one of HMACSecret and HMACSecretEnv can be set")
+	}
+	if cfg.tmpl, err = template.New("body").Funcs(templateFuncs).Parse(cfg.Template); err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return cfg, nil
+}
+
+// ValidateRunParameters validates the parameters for the run reporter
+func (r *WebhookReporter) ValidateRunParameters(params []byte) (interface{}, error) {
+	return validateParameters(params)
+}
+
+// ValidateFinalParameters validates the parameters for the final reporter
+func (r *WebhookReporter) ValidateFinalParameters(params []byte) (interface{}, error) {
+	return validateParameters(params)
+}
+
+// Name returns the Name of the reporter
+func (r *WebhookReporter) Name() string {
+	return Name
+}
+
+// RunReport notifies the endpoint of the results of a run.
+func (r *WebhookReporter) RunReport(ctx xcontext.Context, parameters interface{}, runStatus *job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	cfg, ok := parameters.(config)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type config")
+	}
+	data := newTemplateData(runStatus.JobID, runStatus.RunID, []job.RunStatus{*runStatus})
+	return cfg.notify(ctx, data)
+}
+
+// FinalReport notifies the endpoint of the results of all the runs of a job.
+func (r *WebhookReporter) FinalReport(ctx xcontext.Context, parameters interface{}, runStatuses []job.RunStatus, ev testevent.Fetcher) (bool, interface{}, error) {
+	cfg, ok := parameters.(config)
+	if !ok {
+		return false, nil, fmt.Errorf("report parameters should be of type config")
+	}
+	var jobID types.JobID
+	if len(runStatuses) > 0 {
+		jobID = runStatuses[0].JobID
+	}
+	data := newTemplateData(jobID, 0, runStatuses)
+	return cfg.notify(ctx, data)
+}
+
+// newTemplateData lists the targets which failed a test. The outcome of a test
+// for a target is its final status in the test, which also names the step the
+// target failed.
+func newTemplateData(jobID types.JobID, runID types.RunID, runStatuses []job.RunStatus) *TemplateData {
+	data := &TemplateData{
+		JobID:       jobID,
+		RunID:       runID,
+		RunStatuses: runStatuses,
+	}
+	for _, runStatus := range runStatuses {
+		for _, testStatus := range runStatus.TestStatuses {
+			for _, targetStatus := range testStatus.TargetStatuses {
+				if targetStatus.Error == "" {
+					continue
+				}
+				var targetID string
+				if targetStatus.Target != nil {
+					targetID = targetStatus.Target.ID
+				}
+				data.FailedTargets = append(data.FailedTargets, FailedTarget{
+					RunID:         runStatus.RunID,
+					TestName:      testStatus.TestName,
+					TestStepLabel: targetStatus.TestStepLabel,
+					TargetID:      targetID,
+					Error:         targetStatus.Error,
+				})
+			}
+		}
+	}
+	data.Success = len(data.FailedTargets) == 0
+	return data
+}
+
+// notify sends the notification. The report is successful if no target
+// failed, whether the notification was delivered is in the report data and a
+// delivery failure is returned as error.
+func (cfg *config) notify(ctx xcontext.Context, data *TemplateData) (bool, interface{}, error) {
+	report := Report{}
+	if cfg.OnlyOnFailure && data.Success {
+		ctx.Debugf("No target failed, not sending the notification")
+		return data.Success, report, nil
+	}
+
+	var body bytes.Buffer
+	if err := cfg.tmpl.Execute(&body, data); err != nil {
+		report.Error = fmt.Sprintf("could not render the template: %v", err)
+		return data.Success, report, errors.New(report.Error)
+	}
+	signature, err := cfg.signature(body.Bytes())
+	if err != nil {
+		report.Error = err.Error()
+		return data.Success, report, err
+	}
+
+	client := &http.Client{Timeout: time.Duration(cfg.Timeout)}
+	interval := time.Duration(cfg.RetryInterval)
+	for {
+		report.Attempts++
+		var retriable bool
+		report.StatusCode, retriable, err = cfg.send(ctx, client, body.Bytes(), signature)
+		if err == nil {
+			report.Sent = true
+			report.Error = ""
+			return data.Success, report, nil
+		}
+		report.Error = err.Error()
+		if !retriable || report.Attempts > cfg.Retries {
+			return data.Success, report, fmt.Errorf("could not send the notification after %d attempts: %w", report.Attempts, err)
+		}
+		ctx.Warnf("Sending the notification failed, retrying in %v: %v", interval, err)
+		select {
+		case <-ctx.Done():
+			return data.Success, report, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+func (cfg *config) signature(body []byte) (string, error) {
+	secret := cfg.HMACSecret
+	if cfg.HMACSecretEnv != "" {
+		secret = os.Getenv(cfg.HMACSecretEnv)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", cfg.HMACSecretEnv)
+		}
+	}
+	if secret == "" {
+		return "", nil
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// send makes a single request, and returns the status code and whether the
+// request can be retried on error.
+func (cfg *config) send(ctx xcontext.Context, client *http.Client, body []byte, signature string) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, fmt.Errorf("could not create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", cfg.ContentType)
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if signature != "" {
+		req.Header.Set(cfg.SignatureHeader, signature)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, true, err
+	}
+	defer resp.Body.Close()
+	// drain the body so that the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+	return resp.StatusCode, resp.StatusCode >= 500, fmt.Errorf("unexpected response status %s", resp.Status)
+}
+
+// New builds a new WebhookReporter
+func New() job.Reporter {
+	return &WebhookReporter{}
+}
+
+// Load returns the name and factory which are needed to register the Reporter
+func Load() (string, job.ReporterFactory) {
+	return Name, New
+}
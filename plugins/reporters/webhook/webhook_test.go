@@ -0,0 +1,212 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+var ctx = logrusctx.NewContext(logger.LevelDebug)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// recorder is an HTTP endpoint which records the requests, and replies with
+// the given status codes in order, then with 200.
+type recorder struct {
+	mu       sync.Mutex
+	requests []request
+	statuses []int
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.requests = append(rec.requests, request{header: r.Header, body: body})
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func runStatus(errMsg string) *job.RunStatus {
+	ts := job.TargetStatus{Target: &target.Target{ID: "dut1"}, Error: errMsg}
+	ts.TestStepLabel = "flash"
+	return &job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 3, RunID: 2},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates:  job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{{TargetStatuses: []job.TargetStatus{ts}}},
+			TargetStatuses:   []job.TargetStatus{ts},
+		}},
+	}
+}
+
+func params(t *testing.T, p map[string]interface{}) interface{} {
+	b, err := json.Marshal(p)
+	require.NoError(t, err)
+	v, err := New().ValidateRunParameters(b)
+	require.NoError(t, err)
+	return v
+}
+
+func TestValidateParameters(t *testing.T) {
+	r := New()
+	_, err := r.ValidateRunParameters([]byte(`{"URL": "ftp://example.com"}`))
+	require.Error(t, err)
+	_, err = r.ValidateRunParameters([]byte(`{"URL": "http://example.com", "Template": "{{ .Foo"}`))
+	require.Error(t, err)
+	_, err = r.ValidateFinalParameters([]byte(`{"URL": "http://example.com", "HMACSecret": "a", "HMACSecretEnv": "B"}`))
+	require.Error(t, err)
+}
+
+func TestDefaultTemplateAndSignature(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	p := params(t, map[string]interface{}{
+		"URL":        srv.URL,
+		"Headers":    map[string]string{"Authorization": "Bearer token"},
+		"HMACSecret": "secret",
+	})
+	success, data, err := New().RunReport(ctx, p, runStatus("flash failed"), nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	require.Equal(t, Report{Sent: true, Attempts: 1, StatusCode: http.StatusOK}, data)
+
+	require.Len(t, rec.requests, 1)
+	req := rec.requests[0]
+	require.Equal(t, "Bearer token", req.header.Get("Authorization"))
+	require.Equal(t, "application/json", req.header.Get("Content-Type"))
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(req.body)
+	require.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), req.header.Get(DefaultSignatureHeader))
+
+	var body struct {
+		JobID         int            `json:"job_id"`
+		RunID         int            `json:"run_id"`
+		Success       bool           `json:"success"`
+		FailedTargets []FailedTarget `json:"failed_targets"`
+	}
+	require.NoError(t, json.Unmarshal(req.body, &body))
+	require.Equal(t, 3, body.JobID)
+	require.Equal(t, 2, body.RunID)
+	require.False(t, body.Success)
+	require.Len(t, body.FailedTargets, 1)
+	require.Equal(t, "dut1", body.FailedTargets[0].TargetID)
+	require.Equal(t, "flash failed", body.FailedTargets[0].Error)
+}
+
+func TestCustomTemplate(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	p := params(t, map[string]interface{}{
+		"URL":      srv.URL,
+		"Template": `{"text": "job {{ .JobID }}{{ range .FailedTargets }} {{ .TargetID }}:{{ .TestStepLabel }}{{ end }}"}`,
+	})
+	_, _, err := New().FinalReport(ctx, p, []job.RunStatus{*runStatus("failed")}, nil)
+	require.NoError(t, err)
+	require.Len(t, rec.requests, 1)
+	require.JSONEq(t, `{"text": "job 3 dut1:flash"}`, string(rec.requests[0].body))
+}
+
+func TestRecoveredTarget(t *testing.T) {
+	// dut1 failed "flash" and went through "recover" before passing "flash"
+	// again, dut2 failed "recover" twice.
+	status := func(step, targetID, errMsg string) job.TargetStatus {
+		ts := job.TargetStatus{Target: &target.Target{ID: targetID}, Error: errMsg}
+		ts.TestStepLabel = step
+		return ts
+	}
+	rs := job.RunStatus{
+		RunCoordinates: job.RunCoordinates{JobID: 3, RunID: 2},
+		TestStatuses: []job.TestStatus{{
+			TestCoordinates: job.TestCoordinates{TestName: "boot"},
+			TestStepStatuses: []job.TestStepStatus{
+				{TargetStatuses: []job.TargetStatus{status("flash", "dut1", "flash failed"), status("flash", "dut1", "")}},
+				{TargetStatuses: []job.TargetStatus{status("recover", "dut1", ""), status("recover", "dut2", "stuck"), status("recover", "dut2", "stuck")}},
+			},
+			TargetStatuses: []job.TargetStatus{status("flash", "dut1", ""), status("recover", "dut2", "stuck")},
+		}},
+	}
+
+	data := newTemplateData(3, 2, []job.RunStatus{rs})
+	require.False(t, data.Success)
+	require.Equal(t, []FailedTarget{{RunID: 2, TestName: "boot", TestStepLabel: "recover", TargetID: "dut2", Error: "stuck"}}, data.FailedTargets)
+
+	rs.TestStatuses[0].TargetStatuses = rs.TestStatuses[0].TargetStatuses[:1]
+	require.True(t, newTemplateData(3, 2, []job.RunStatus{rs}).Success)
+}
+
+func TestOnlyOnFailure(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	p := params(t, map[string]interface{}{"URL": srv.URL, "OnlyOnFailure": true})
+	success, data, err := New().RunReport(ctx, p, runStatus(""), nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.False(t, data.(Report).Sent)
+	require.Empty(t, rec.requests)
+
+	success, data, err = New().RunReport(ctx, p, runStatus("failed"), nil)
+	require.NoError(t, err)
+	require.False(t, success)
+	require.True(t, data.(Report).Sent)
+	require.Len(t, rec.requests, 1)
+}
+
+func TestRetries(t *testing.T) {
+	rec := &recorder{statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	p := params(t, map[string]interface{}{"URL": srv.URL, "Retries": 2, "RetryInterval": "1ms"})
+	success, data, err := New().RunReport(ctx, p, runStatus(""), nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, uint(3), data.(Report).Attempts)
+
+	// client errors are not retried
+	rec.statuses = []int{http.StatusBadRequest}
+	success, data, err = New().RunReport(ctx, p, runStatus(""), nil)
+	require.Error(t, err)
+	require.True(t, success)
+	require.False(t, data.(Report).Sent)
+	require.Equal(t, uint(1), data.(Report).Attempts)
+	require.Equal(t, http.StatusBadRequest, data.(Report).StatusCode)
+
+	// retries are exhausted, the report still carries the run outcome
+	rec.statuses = []int{500, 500, 500}
+	success, data, err = New().RunReport(ctx, p, runStatus("failed"), nil)
+	require.Error(t, err)
+	require.False(t, success)
+	require.False(t, data.(Report).Sent)
+	require.Equal(t, uint(3), data.(Report).Attempts)
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
 	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
@@ -31,6 +32,9 @@ type Memory struct {
 	frameworkEvents []frameworkevent.Event
 	jobIDCounter    types.JobID
 	jobInfo         map[types.JobID]*jobInfo
+	scheduleCounter types.ScheduleID
+	schedules       map[types.ScheduleID]*job.Schedule
+	inventory       map[string]*inventory.Target
 }
 
 type jobInfo struct {
@@ -41,7 +45,7 @@ type jobInfo struct {
 }
 
 func emptyEventQuery(eventQuery *event.Query) bool {
-	return eventQuery.JobID == 0 && len(eventQuery.EventNames) == 0 && eventQuery.EmittedStartTime.IsZero() && eventQuery.EmittedEndTime.IsZero()
+	return eventQuery.JobID == 0 && len(eventQuery.EventNames) == 0 && eventQuery.EmittedStartTime.IsZero() && eventQuery.EmittedEndTime.IsZero() && eventQuery.SequenceIDStart == 0
 }
 
 // emptyFrameworkEventQuery returns whether the Query contains only default values
@@ -105,6 +109,10 @@ func eventTimeMatch(queryStartTime, queryEndTime time.Time, emittedTime time.Tim
 	return true
 }
 
+func eventSequenceIDMatch(querySequenceIDStart, sequenceID uint64) bool {
+	return sequenceID >= querySequenceIDStart
+}
+
 func eventTestMatch(queryTestName, testName string) bool {
 	if queryTestName != "" && testName != queryTestName {
 		return false
@@ -135,6 +143,7 @@ func (m *Memory) GetTestEvents(_ xcontext.Context, eventQuery *testevent.Query)
 			eventRunMatch(eventQuery.RunID, event.Header.RunID) &&
 			eventNameMatch(eventQuery.EventNames, event.Data.EventName) &&
 			eventTimeMatch(eventQuery.EmittedStartTime, eventQuery.EmittedEndTime, event.EmitTime) &&
+			eventSequenceIDMatch(eventQuery.SequenceIDStart, event.SequenceID) &&
 			eventTestMatch(eventQuery.TestName, event.Header.TestName) &&
 			eventTestStepMatch(eventQuery.TestStepLabel, event.Header.TestStepLabel) {
 			matchingTestEvents = append(matchingTestEvents, event)
@@ -151,6 +160,9 @@ func (m *Memory) Reset() error {
 	m.frameworkEvents = []frameworkevent.Event{}
 	m.jobInfo = make(map[types.JobID]*jobInfo)
 	m.jobIDCounter = 1
+	m.schedules = make(map[types.ScheduleID]*job.Schedule)
+	m.scheduleCounter = 1
+	m.inventory = make(map[string]*inventory.Target)
 	return nil
 }
 
@@ -315,13 +327,145 @@ func (m *Memory) GetFrameworkEvent(_ xcontext.Context, eventQuery *frameworkeven
 	for _, event := range m.frameworkEvents {
 		if eventJobMatch(eventQuery.JobID, event.JobID) &&
 			eventNameMatch(eventQuery.EventNames, event.EventName) &&
-			eventTimeMatch(eventQuery.EmittedStartTime, eventQuery.EmittedEndTime, event.EmitTime) {
+			eventTimeMatch(eventQuery.EmittedStartTime, eventQuery.EmittedEndTime, event.EmitTime) &&
+			eventSequenceIDMatch(eventQuery.SequenceIDStart, event.SequenceID) {
 			matchingFrameworkEvents = append(matchingFrameworkEvents, event)
 		}
 	}
 	return matchingFrameworkEvents, nil
 }
 
+// StoreSchedule stores a new schedule
+func (m *Memory) StoreSchedule(_ xcontext.Context, schedule *job.Schedule) (types.ScheduleID, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	scheduleID := m.scheduleCounter
+	m.scheduleCounter++
+	stored := *schedule
+	stored.ID = scheduleID
+	m.schedules[scheduleID] = &stored
+	return scheduleID, nil
+}
+
+// UpdateSchedule updates the pause state and the last firing of a schedule
+func (m *Memory) UpdateSchedule(_ xcontext.Context, schedule *job.Schedule) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stored := m.schedules[schedule.ID]
+	if stored == nil {
+		return fmt.Errorf("could not find schedule with id %v", schedule.ID)
+	}
+	stored.Paused = schedule.Paused
+	stored.LastFireTime = schedule.LastFireTime
+	stored.LastJobID = schedule.LastJobID
+	return nil
+}
+
+// GetSchedule retrieves a schedule
+func (m *Memory) GetSchedule(_ xcontext.Context, scheduleID types.ScheduleID) (*job.Schedule, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stored := m.schedules[scheduleID]
+	if stored == nil {
+		return nil, fmt.Errorf("could not find schedule with id %v", scheduleID)
+	}
+	schedule := *stored
+	return &schedule, nil
+}
+
+// ListSchedules returns the schedules owned by a server, sorted by ID
+func (m *Memory) ListSchedules(_ xcontext.Context, serverID string) ([]*job.Schedule, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var res []*job.Schedule
+	for _, stored := range m.schedules {
+		if serverID != "" && stored.ServerID != serverID {
+			continue
+		}
+		schedule := *stored
+		res = append(res, &schedule)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+// DeleteSchedule removes a schedule
+func (m *Memory) DeleteSchedule(_ xcontext.Context, scheduleID types.ScheduleID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.schedules[scheduleID] == nil {
+		return fmt.Errorf("could not find schedule with id %v", scheduleID)
+	}
+	delete(m.schedules, scheduleID)
+	return nil
+}
+
+// copyInventoryTarget returns a copy of t which does not share its labels.
+func copyInventoryTarget(t *inventory.Target) *inventory.Target {
+	c := *t
+	c.Labels = make(map[string]string, len(t.Labels))
+	for k, v := range t.Labels {
+		c.Labels[k] = v
+	}
+	return &c
+}
+
+// StoreInventoryTarget adds a new target to the inventory
+func (m *Memory) StoreInventoryTarget(_ xcontext.Context, t *inventory.Target) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.inventory[t.ID] != nil {
+		return fmt.Errorf("target %q is already in the inventory", t.ID)
+	}
+	m.inventory[t.ID] = copyInventoryTarget(t)
+	return nil
+}
+
+// UpdateInventoryTarget replaces a target of the inventory
+func (m *Memory) UpdateInventoryTarget(_ xcontext.Context, t *inventory.Target) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.inventory[t.ID] == nil {
+		return fmt.Errorf("could not find inventory target with id %q", t.ID)
+	}
+	m.inventory[t.ID] = copyInventoryTarget(t)
+	return nil
+}
+
+// GetInventoryTarget retrieves a target of the inventory
+func (m *Memory) GetInventoryTarget(_ xcontext.Context, targetID string) (*inventory.Target, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	stored := m.inventory[targetID]
+	if stored == nil {
+		return nil, fmt.Errorf("could not find inventory target with id %q", targetID)
+	}
+	return copyInventoryTarget(stored), nil
+}
+
+// ListInventoryTargets returns the targets of the inventory, sorted by ID
+func (m *Memory) ListInventoryTargets(_ xcontext.Context) ([]*inventory.Target, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var res []*inventory.Target
+	for _, stored := range m.inventory {
+		res = append(res, copyInventoryTarget(stored))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+// DeleteInventoryTarget removes a target from the inventory
+func (m *Memory) DeleteInventoryTarget(_ xcontext.Context, targetID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.inventory[targetID] == nil {
+		return fmt.Errorf("could not find inventory target with id %q", targetID)
+	}
+	delete(m.inventory, targetID)
+	return nil
+}
+
 // Close flushes pending events and closes the database connection.
 func (m *Memory) Close() error {
 	m.lock.Lock()
@@ -330,6 +474,8 @@ func (m *Memory) Close() error {
 	m.testEvents = nil
 	m.frameworkEvents = nil
 	m.jobInfo = nil
+	m.schedules = nil
+	m.inventory = nil
 	return nil
 }
 
@@ -341,8 +487,11 @@ func (m *Memory) Version() (uint64, error) {
 // New create a new Memory events storage backend
 func New() (storage.ResettableStorage, error) {
 	m := &Memory{
-		jobInfo:      make(map[types.JobID]*jobInfo),
-		jobIDCounter: 1,
+		jobInfo:         make(map[types.JobID]*jobInfo),
+		jobIDCounter:    1,
+		schedules:       make(map[types.ScheduleID]*job.Schedule),
+		scheduleCounter: 1,
+		inventory:       make(map[string]*inventory.Target),
 	}
 	return m, nil
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/types"
 	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/metrics/perf"
 
 	"github.com/google/go-safeweb/safesql"
 )
@@ -75,6 +76,10 @@ func buildEventQuery(baseQuery safesql.TrustedSQLString, eventQuery *event.Query
 		selectClauses = append(selectClauses, safesql.New("emit_time<=?"))
 		fields = append(fields, eventQuery.EmittedStartTime)
 	}
+	if eventQuery != nil && eventQuery.SequenceIDStart != 0 {
+		selectClauses = append(selectClauses, safesql.New("event_id>=?"))
+		fields = append(fields, eventQuery.SequenceIDStart)
+	}
 	return selectClauses, fields
 }
 
@@ -211,7 +216,7 @@ func (r *RDBMS) flushTestEventsLocked() error {
 
 	insertStatement := safesql.New("insert into test_events (job_id, run_id, test_name, test_attempt, test_step_label, event_name, target_id, payload, emit_time) values (?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	for _, event := range r.buffTestEvents {
-		_, err := r.db.Exec(
+		_, err := r.exec(
 			insertStatement,
 			TestEventJobID(event),
 			TestEventRunID(event),
@@ -226,11 +231,23 @@ func (r *RDBMS) flushTestEventsLocked() error {
 			return fmt.Errorf("could not store event in database: %v", err)
 		}
 	}
+	r.countFlush("test", len(r.buffTestEvents))
 	r.buffTestEvents = nil
 
 	return nil
 }
 
+// countFlush reports the flush of a buffer of events of the given kind to the
+// metrics. Flushes of empty buffers are not counted.
+func (r *RDBMS) countFlush(kind string, events int) {
+	if r.metrics == nil || events == 0 {
+		return
+	}
+	m := r.metrics.WithTags(nil).WithTag("kind", kind)
+	m.Count(perf.STORAGE_FLUSHES).Add(1)
+	m.Count(perf.STORAGE_FLUSHED_EVENTS).Add(uint64(events))
+}
+
 // flushTestEvents forces a flush of the pending test events to the database.
 func (r *RDBMS) flushTestEvents() error {
 	r.testEventsLock.Lock()
@@ -259,7 +276,7 @@ func (r *RDBMS) GetTestEvents(ctx xcontext.Context, eventQuery *testevent.Query)
 
 	var results []testevent.Event
 	ctx.Debugf("Executing query: %s, fields: %v", query, fields)
-	rows, err := r.db.Query(query, fields...)
+	rows, err := r.query(query, fields...)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +381,7 @@ func (r *RDBMS) flushFrameworkEventsLocked() error {
 	// TODO: put this into a transaction.
 	jobStateUpdates := map[types.JobID]job.State{}
 	for _, event := range r.buffFrameworkEvents {
-		_, err := r.db.Exec(
+		_, err := r.exec(
 			safesql.New(insertFEStmt),
 			FrameworkEventJobID(event),
 			FrameworkEventName(event),
@@ -378,10 +395,11 @@ func (r *RDBMS) flushFrameworkEventsLocked() error {
 		}
 	}
 	for jobID, state := range jobStateUpdates {
-		if _, err := r.db.Exec(safesql.New(updateJobStateStmt), state, jobID); err != nil {
+		if _, err := r.exec(safesql.New(updateJobStateStmt), state, jobID); err != nil {
 			return fmt.Errorf("could not update state of job %d: %w", jobID, err)
 		}
 	}
+	r.countFlush("framework", len(r.buffFrameworkEvents))
 	r.buffFrameworkEvents = nil
 	return nil
 }
@@ -412,7 +430,7 @@ func (r *RDBMS) GetFrameworkEvent(ctx xcontext.Context, eventQuery *frameworkeve
 	}
 	results := []frameworkevent.Event{}
 	ctx.Debugf("Executing query: %s, fields: %v", query, fields)
-	rows, err := r.db.Query(query, fields...)
+	rows, err := r.query(query, fields...)
 	if err != nil {
 		return nil, err
 	}
@@ -14,6 +14,7 @@ import (
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/storage"
+	"github.com/linuxboot/contest/pkg/xcontext/metrics"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/linuxboot/contest/tools/migration/rdbms/migrationlib"
@@ -42,14 +43,16 @@ type tx interface {
 }
 
 // RDBMS implements a storage engine which stores ConTest information in a relational
-// database via the database/sql package. With the current implementation, only MySQL
-// is officially supported. Within MySQL, the current limitations are the following:
+// database via the database/sql package. With the current implementation, MySQL and
+// PostgreSQL are officially supported, see Dialect. Within MySQL, the current
+// limitations are the following:
 //
 // It's not possible to use prepared statements. Not all MySQL connectors
 // implementing database/sql support prepared statements, so the plugin cannot
 // depend on them.
 type RDBMS struct {
 	dbURI, driverName string
+	dialect           Dialect
 
 	buffTestEvents      []testevent.Event
 	buffFrameworkEvents []frameworkevent.Event
@@ -74,6 +77,9 @@ type RDBMS struct {
 	testEventsFlushInterval      time.Duration
 	frameworkEventsFlushSize     int
 	frameworkEventsFlushInterval time.Duration
+
+	// metrics, if set, receives the statistics of the flushes of the buffers
+	metrics metrics.Metrics
 }
 
 func (r *RDBMS) lockTx() {
@@ -103,7 +109,7 @@ func (r *RDBMS) BeginTx() (storage.TransactionalStorage, error) {
 		return nil, err
 	}
 
-	return &RDBMS{db: tx, sqlDB: r.sqlDB, closeCh: r.closeCh, closeWG: r.closeWG}, nil
+	return &RDBMS{db: tx, sqlDB: r.sqlDB, dialect: r.dialect, metrics: r.metrics, closeCh: r.closeCh, closeWG: r.closeWG}, nil
 }
 
 // Commit persists the current transaction, if there is one active
@@ -148,8 +154,15 @@ func (r *RDBMS) Reset() error {
 		safesql.New("final_reports"),
 		safesql.New("test_events"),
 		safesql.New("framework_events"),
+		safesql.New("schedules"),
+		safesql.New("inventory"),
 	} {
-		if _, err := r.db.Exec(safesql.TrustedSQLStringConcat(safesql.New("TRUNCATE TABLE "), t)); err != nil {
+		stmt := safesql.TrustedSQLStringConcat(safesql.New("TRUNCATE TABLE "), t)
+		if r.dialect == DialectPostgres {
+			// like on MySQL, reset the auto-increment counters
+			stmt = safesql.TrustedSQLStringConcat(stmt, safesql.New(" RESTART IDENTITY"))
+		}
+		if _, err := r.exec(stmt); err != nil {
 			return err
 		}
 	}
@@ -158,7 +171,7 @@ func (r *RDBMS) Reset() error {
 
 func (r *RDBMS) init() error {
 
-	driverName := "mysql"
+	driverName := r.dialect.driverName()
 	if r.driverName != "" {
 		driverName = r.driverName
 	}
@@ -255,13 +268,29 @@ func FrameworkEventsFlushInterval(flushInterval time.Duration) Opt {
 }
 
 // DriverName allows using a mysql-compatible driver (e.g. a wrapper around mysql
-// or a syntax-compatible variant).
+// or a syntax-compatible variant), or a driver for the dialect set by SQLDialect.
 func DriverName(name string) Opt {
 	return func(rdbms *RDBMS) {
 		rdbms.driverName = name
 	}
 }
 
+// SQLDialect sets the SQL dialect of the database, DialectMySQL by default.
+// The driver defaults to the one of the dialect.
+func SQLDialect(dialect Dialect) Opt {
+	return func(rdbms *RDBMS) {
+		rdbms.dialect = dialect
+	}
+}
+
+// Metrics sets the metrics the statistics of the flushes of the event buffers
+// are reported to.
+func Metrics(m metrics.Metrics) Opt {
+	return func(rdbms *RDBMS) {
+		rdbms.metrics = m
+	}
+}
+
 // New creates a RDBMS events storage backend with default parameters
 func New(dbURI string, opts ...Opt) (storage.Storage, error) {
 
@@ -0,0 +1,176 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/google/go-safeweb/safesql"
+
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+const (
+	insertInventoryTargetStmt = "insert into inventory (target_id, fqdn, ipv4, ipv6, labels, health, drained, update_time) values (?, ?, ?, ?, ?, ?, ?, ?)"
+	updateInventoryTargetStmt = "update inventory set fqdn = ?, ipv4 = ?, ipv6 = ?, labels = ?, health = ?, drained = ?, update_time = ? where target_id = ?"
+	deleteInventoryTargetStmt = "delete from inventory where target_id = ?"
+	selectInventoryStmt       = "select target_id, fqdn, ipv4, ipv6, labels, health, drained, update_time from inventory"
+	inventoryTargetCondition  = " where target_id = ?"
+	inventoryOrder            = " order by target_id asc"
+)
+
+// inventoryTargetColumns returns the values of the columns of a target, but
+// its ID, in the order of the insert and update statements.
+func inventoryTargetColumns(t *inventory.Target) ([]interface{}, error) {
+	labels := t.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labelsJSON, err := json.Marshal(labels)
+	if err != nil {
+		return nil, fmt.Errorf("could not serialize labels of target %q: %w", t.ID, err)
+	}
+	return []interface{}{t.FQDN, ipString(t.PrimaryIPv4), ipString(t.PrimaryIPv6), string(labelsJSON), string(t.Health), t.Drained, t.UpdateTime}, nil
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// StoreInventoryTarget adds a new target to the inventory
+func (r *RDBMS) StoreInventoryTarget(_ xcontext.Context, t *inventory.Target) error {
+	r.lockTx()
+	defer r.unlockTx()
+
+	columns, err := inventoryTargetColumns(t)
+	if err != nil {
+		return err
+	}
+	if _, err := r.exec(safesql.New(insertInventoryTargetStmt), append([]interface{}{t.ID}, columns...)...); err != nil {
+		return fmt.Errorf("could not store target %q in inventory: %w", t.ID, err)
+	}
+	return nil
+}
+
+// UpdateInventoryTarget replaces a target of the inventory
+func (r *RDBMS) UpdateInventoryTarget(_ xcontext.Context, t *inventory.Target) error {
+	r.lockTx()
+	defer r.unlockTx()
+
+	columns, err := inventoryTargetColumns(t)
+	if err != nil {
+		return err
+	}
+	result, err := r.exec(safesql.New(updateInventoryTargetStmt), append(columns, t.ID)...)
+	if err != nil {
+		return fmt.Errorf("could not update inventory target %q: %w", t.ID, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		// MySQL does not count rows whose values did not change, make sure
+		// the target exists.
+		if _, err := r.getInventoryTarget(t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInventoryTarget retrieves a target of the inventory
+func (r *RDBMS) GetInventoryTarget(_ xcontext.Context, targetID string) (*inventory.Target, error) {
+	r.lockTx()
+	defer r.unlockTx()
+
+	return r.getInventoryTarget(targetID)
+}
+
+func (r *RDBMS) getInventoryTarget(targetID string) (*inventory.Target, error) {
+	targets, err := r.selectInventoryTargets(
+		safesql.TrustedSQLStringConcat(safesql.New(selectInventoryStmt), safesql.New(inventoryTargetCondition)),
+		targetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("could not find inventory target with id %q", targetID)
+	}
+	return targets[0], nil
+}
+
+// ListInventoryTargets returns the targets of the inventory, sorted by ID
+func (r *RDBMS) ListInventoryTargets(_ xcontext.Context) ([]*inventory.Target, error) {
+	r.lockTx()
+	defer r.unlockTx()
+
+	return r.selectInventoryTargets(safesql.TrustedSQLStringConcat(safesql.New(selectInventoryStmt), safesql.New(inventoryOrder)))
+}
+
+// DeleteInventoryTarget removes a target from the inventory
+func (r *RDBMS) DeleteInventoryTarget(_ xcontext.Context, targetID string) error {
+	r.lockTx()
+	defer r.unlockTx()
+
+	result, err := r.exec(safesql.New(deleteInventoryTargetStmt), targetID)
+	if err != nil {
+		return fmt.Errorf("could not delete inventory target %q: %w", targetID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete inventory target %q: %w", targetID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("could not find inventory target with id %q", targetID)
+	}
+	return nil
+}
+
+func (r *RDBMS) selectInventoryTargets(query safesql.TrustedSQLString, args ...interface{}) ([]*inventory.Target, error) {
+	rows, err := r.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list inventory targets: %w", err)
+	}
+	defer rows.Close()
+
+	var targets []*inventory.Target
+	for rows.Next() {
+		var (
+			t            inventory.Target
+			ipv4, ipv6   string
+			labels       string
+			targetHealth string
+		)
+		err := rows.Scan(
+			&t.ID,
+			&t.FQDN,
+			&ipv4,
+			&ipv6,
+			&labels,
+			&targetHealth,
+			&t.Drained,
+			&t.UpdateTime,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not read inventory target: %w", err)
+		}
+		t.PrimaryIPv4 = net.ParseIP(ipv4)
+		t.PrimaryIPv6 = net.ParseIP(ipv6)
+		t.Health = inventory.Health(targetHealth)
+		if err := json.Unmarshal([]byte(labels), &t.Labels); err != nil {
+			return nil, fmt.Errorf("could not read labels of inventory target %q: %w", t.ID, err)
+		}
+		targets = append(targets, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not list inventory targets: %w", err)
+	}
+	return targets, nil
+}
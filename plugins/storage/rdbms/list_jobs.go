@@ -85,7 +85,7 @@ func (r *RDBMS) ListJobs(_ xcontext.Context, query *storage.JobQuery) ([]types.J
 	parts = append(parts, safesql.New("ORDER BY jobs.job_id"))
 	stmt := safesql.TrustedSQLStringJoin(parts, safesql.New(" "))
 
-	rows, err := r.db.Query(stmt, qargs...)
+	rows, err := r.query(stmt, qargs...)
 	if err != nil {
 		return nil, fmt.Errorf("could not list jobs in states (sql: %q): %w", stmt, err)
 	}
@@ -28,13 +28,13 @@ func (r *RDBMS) StoreReport(_ xcontext.Context, report *job.Report) error {
 	defer r.unlockTx()
 
 	if report.RunID > 0 {
-		if _, err := r.db.Exec(
+		if _, err := r.exec(
 			safesql.New("insert into run_reports (job_id, run_id, reporter_name, success, report_time, data) values (?, ?, ?, ?, ?, ?)"),
 			report.JobID, report.RunID, report.ReporterName, report.Success, report.ReportTime, reportJSON); err != nil {
 			return fmt.Errorf("could not store run report for job %v: %v", report.JobID, err)
 		}
 	} else {
-		if _, err := r.db.Exec(
+		if _, err := r.exec(
 			safesql.New("insert into final_reports (job_id, reporter_name, success, report_time, data) values (?, ?, ?, ?, ?)"),
 			report.JobID, report.ReporterName, report.Success, report.ReportTime, reportJSON); err != nil {
 			return fmt.Errorf("could not store final report for job %v: %v", report.JobID, err)
@@ -59,7 +59,7 @@ func (r *RDBMS) GetJobReport(ctx xcontext.Context, jobID types.JobID) (*job.JobR
 	// the code below assumes sorted results by ascending run number.
 	selectStatement := safesql.New("select success, report_time, reporter_name, run_id, data from run_reports where job_id = ? order by run_id asc, reporter_name asc")
 	ctx.Debugf("Executing query: %s", selectStatement)
-	rows, err := r.db.Query(selectStatement, jobID)
+	rows, err := r.query(selectStatement, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("could not get run report for job %v: %v", jobID, err)
 	}
@@ -123,7 +123,7 @@ func (r *RDBMS) GetJobReport(ctx xcontext.Context, jobID types.JobID) (*job.JobR
 	// get final reports
 	selectStatement = safesql.New("select success, report_time, reporter_name, data from final_reports where job_id = ? order by reporter_name asc")
 	ctx.Debugf("Executing query: %s", selectStatement)
-	rows2, err := r.db.Query(selectStatement, jobID)
+	rows2, err := r.query(selectStatement, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("could not get final report for job %v: %v", jobID, err)
 	}
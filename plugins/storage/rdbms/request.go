@@ -44,18 +44,14 @@ func (r *RDBMS) StoreJobRequest(_ xcontext.Context, request *job.Request) (types
 	}
 
 	// store job descriptor
-	result, err := r.db.Exec(safesql.New(insertJobStmt), request.JobName, request.JobDescriptor, extendedDescriptor, request.Requestor, request.ServerID, request.RequestTime)
+	lastID, err := r.insert(safesql.New(insertJobStmt), safesql.New("job_id"), request.JobName, request.JobDescriptor, extendedDescriptor, request.Requestor, request.ServerID, request.RequestTime)
 	if err != nil {
 		return jobID, fmt.Errorf("could not store job request in database: %w", err)
 	}
-	lastID, err := result.LastInsertId()
-	if err != nil {
-		return jobID, fmt.Errorf("could not extract id of last request inserted into db")
-	}
 	jobID = types.JobID(lastID)
 
 	for _, tag := range desc.Tags {
-		if _, err := r.db.Exec(safesql.New(insertJobTagStmt), jobID, tag); err != nil {
+		if _, err := r.exec(safesql.New(insertJobTagStmt), jobID, tag); err != nil {
 			return 0, fmt.Errorf("could not store job tag in the database: %w", err)
 		}
 	}
@@ -71,7 +67,7 @@ func (r *RDBMS) GetJobRequest(ctx xcontext.Context, jobID types.JobID) (*job.Req
 
 	selectStatement := safesql.New("select job_id, name, requestor, server_id, request_time, descriptor,  extended_descriptor from jobs where job_id = ?")
 	ctx.Debugf("Executing query: %s", selectStatement)
-	rows, err := r.db.Query(selectStatement, jobID)
+	rows, err := r.query(selectStatement, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("could not get job request with id %v: %v", jobID, err)
 	}
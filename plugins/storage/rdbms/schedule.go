@@ -0,0 +1,157 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package rdbms
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/go-safeweb/safesql"
+
+	"github.com/linuxboot/contest/pkg/job"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+const (
+	insertScheduleStmt  = "insert into schedules (requestor, server_id, create_time, cron_expr, overlap_policy, paused, descriptor) values (?, ?, ?, ?, ?, ?, ?)"
+	updateScheduleStmt  = "update schedules set paused = ?, last_fire_time = ?, last_job_id = ? where schedule_id = ?"
+	deleteScheduleStmt  = "delete from schedules where schedule_id = ?"
+	selectScheduleStmt  = "select schedule_id, requestor, server_id, create_time, cron_expr, overlap_policy, paused, descriptor, last_fire_time, last_job_id from schedules"
+	scheduleIDCondition = " where schedule_id = ?"
+	serverIDCondition   = " where server_id = ?"
+	scheduleOrder       = " order by schedule_id asc"
+)
+
+// StoreSchedule stores a new schedule in the database
+func (r *RDBMS) StoreSchedule(_ xcontext.Context, schedule *job.Schedule) (types.ScheduleID, error) {
+	r.lockTx()
+	defer r.unlockTx()
+
+	lastID, err := r.insert(safesql.New(insertScheduleStmt), safesql.New("schedule_id"),
+		schedule.Requestor, schedule.ServerID, schedule.CreateTime, schedule.CronExpr,
+		string(schedule.OverlapPolicy), schedule.Paused, schedule.JobDescriptor)
+	if err != nil {
+		return 0, fmt.Errorf("could not store schedule in database: %w", err)
+	}
+	return types.ScheduleID(lastID), nil
+}
+
+// UpdateSchedule updates the pause state and the last firing of a schedule
+func (r *RDBMS) UpdateSchedule(_ xcontext.Context, schedule *job.Schedule) error {
+	r.lockTx()
+	defer r.unlockTx()
+
+	lastFireTime := sql.NullTime{Time: schedule.LastFireTime, Valid: !schedule.LastFireTime.IsZero()}
+	result, err := r.exec(safesql.New(updateScheduleStmt), schedule.Paused, lastFireTime, schedule.LastJobID, schedule.ID)
+	if err != nil {
+		return fmt.Errorf("could not update schedule %d: %w", schedule.ID, err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		// MySQL does not count rows whose values did not change, make sure
+		// the schedule exists.
+		if _, err := r.getSchedule(schedule.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSchedule retrieves a schedule from the database
+func (r *RDBMS) GetSchedule(_ xcontext.Context, scheduleID types.ScheduleID) (*job.Schedule, error) {
+	r.lockTx()
+	defer r.unlockTx()
+
+	return r.getSchedule(scheduleID)
+}
+
+func (r *RDBMS) getSchedule(scheduleID types.ScheduleID) (*job.Schedule, error) {
+	schedules, err := r.selectSchedules(
+		safesql.TrustedSQLStringConcat(safesql.New(selectScheduleStmt), safesql.New(scheduleIDCondition)),
+		scheduleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, fmt.Errorf("could not find schedule with id %v", scheduleID)
+	}
+	return schedules[0], nil
+}
+
+// ListSchedules returns the schedules owned by a server, sorted by ID
+func (r *RDBMS) ListSchedules(_ xcontext.Context, serverID string) ([]*job.Schedule, error) {
+	r.lockTx()
+	defer r.unlockTx()
+
+	if serverID == "" {
+		return r.selectSchedules(safesql.TrustedSQLStringConcat(safesql.New(selectScheduleStmt), safesql.New(scheduleOrder)))
+	}
+	return r.selectSchedules(
+		safesql.TrustedSQLStringConcat(safesql.New(selectScheduleStmt), safesql.New(serverIDCondition), safesql.New(scheduleOrder)),
+		serverID,
+	)
+}
+
+// DeleteSchedule removes a schedule from the database
+func (r *RDBMS) DeleteSchedule(_ xcontext.Context, scheduleID types.ScheduleID) error {
+	r.lockTx()
+	defer r.unlockTx()
+
+	result, err := r.exec(safesql.New(deleteScheduleStmt), scheduleID)
+	if err != nil {
+		return fmt.Errorf("could not delete schedule %d: %w", scheduleID, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete schedule %d: %w", scheduleID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("could not find schedule with id %v", scheduleID)
+	}
+	return nil
+}
+
+func (r *RDBMS) selectSchedules(query safesql.TrustedSQLString, args ...interface{}) ([]*job.Schedule, error) {
+	rows, err := r.query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*job.Schedule
+	for rows.Next() {
+		var (
+			schedule      job.Schedule
+			overlapPolicy string
+			lastFireTime  sql.NullTime
+		)
+		err := rows.Scan(
+			&schedule.ID,
+			&schedule.Requestor,
+			&schedule.ServerID,
+			&schedule.CreateTime,
+			&schedule.CronExpr,
+			&overlapPolicy,
+			&schedule.Paused,
+			&schedule.JobDescriptor,
+			&lastFireTime,
+			&schedule.LastJobID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not read schedule: %w", err)
+		}
+		schedule.OverlapPolicy = job.OverlapPolicy(overlapPolicy)
+		if lastFireTime.Valid {
+			schedule.LastFireTime = lastFireTime.Time
+		}
+		schedules = append(schedules, &schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not list schedules: %w", err)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	sqlitedriver "modernc.org/sqlite"
+)
+
+func init() {
+	sql.Register(DriverName, &utcDriver{})
+}
+
+// sqliteConn is the set of interfaces implemented by the connections of the
+// SQLite driver.
+type sqliteConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+}
+
+// utcDriver wraps the SQLite driver so that times are always stored in UTC.
+// SQLite has no time type, times are stored as text, and they are compared as
+// such by the queries filtering on timestamps. This only works if all of them
+// use the same time zone.
+type utcDriver struct {
+	sqlitedriver.Driver
+}
+
+// Open opens a new connection to the database.
+func (d *utcDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	sc, ok := c.(sqliteConn)
+	if !ok {
+		_ = c.Close()
+		return nil, fmt.Errorf("unexpected SQLite connection type %T", c)
+	}
+	return utcConn{sc}, nil
+}
+
+type utcConn struct {
+	sqliteConn
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, it converts times to
+// UTC and leaves the other values to the default converter.
+func (utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case time.Time:
+		nv.Value = v.UTC()
+		return nil
+	case sql.NullTime:
+		if v.Valid {
+			nv.Value = v.Time.UTC()
+		} else {
+			nv.Value = nil
+		}
+		return nil
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,221 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	sqlitedb "github.com/linuxboot/contest/db/sqlite"
+)
+
+// The version table and its layout are the ones used by goose for SQLite, so
+// that tools/migration can operate on databases created by this package.
+const (
+	createVersionTableStmt = `CREATE TABLE goose_db_version (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		version_id INTEGER NOT NULL,
+		is_applied INTEGER NOT NULL,
+		tstamp TIMESTAMP DEFAULT (datetime('now'))
+	)`
+	insertVersionStmt = "INSERT INTO goose_db_version (version_id, is_applied) VALUES (?, ?)"
+	selectVersionStmt = "SELECT version_id, is_applied FROM goose_db_version ORDER BY id DESC"
+	tableExistsStmt   = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+)
+
+// migration is a goose SQL migration.
+type migration struct {
+	version    uint64
+	name       string
+	statements []string
+}
+
+// Migrate creates the ConTest schema in the SQLite database of a data source
+// name, see DSN, and applies the migrations the database is missing. All the
+// changes are applied in a single transaction.
+//
+// Only SQL migrations exist for SQLite. The golang migrations of db/rdbms
+// backfill data written by older versions of ConTest, which never used SQLite.
+func Migrate(dsn string) error {
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return fmt.Errorf("could not open database: %w", err)
+	}
+	defer db.Close()
+	return migrate(db)
+}
+
+func migrate(db *sql.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("unable to start database transaction: %w", err)
+	}
+	defer func() {
+		// this always fails if tx.Commit() was called before, ignore error
+		_ = tx.Rollback()
+	}()
+
+	hasSchema, err := tableExists(tx, "jobs")
+	if err != nil {
+		return err
+	}
+	if !hasSchema {
+		schema, err := fs.ReadFile(sqlitedb.Schema, "schema/v0/create_contest_db.sql")
+		if err != nil {
+			return fmt.Errorf("could not read schema: %w", err)
+		}
+		for _, stmt := range splitStatements(string(schema)) {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("could not create schema: %w", err)
+			}
+		}
+	}
+
+	hasVersionTable, err := tableExists(tx, "goose_db_version")
+	if err != nil {
+		return err
+	}
+	if !hasVersionTable {
+		if _, err := tx.Exec(createVersionTableStmt); err != nil {
+			return fmt.Errorf("could not create version table: %w", err)
+		}
+		if _, err := tx.Exec(insertVersionStmt, 0, true); err != nil {
+			return fmt.Errorf("could not initialize version table: %w", err)
+		}
+	}
+
+	current, err := dbVersion(tx)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		for _, stmt := range m.statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("migration %s failed: %w", m.name, err)
+			}
+		}
+		if _, err := tx.Exec(insertVersionStmt, m.version, true); err != nil {
+			return fmt.Errorf("could not record migration %s: %w", m.name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func tableExists(tx *sql.Tx, name string) (bool, error) {
+	var count int
+	if err := tx.QueryRow(tableExistsStmt, name).Scan(&count); err != nil {
+		return false, fmt.Errorf("could not look up table %s: %w", name, err)
+	}
+	return count > 0, nil
+}
+
+// dbVersion returns the current version of the database schema, following the
+// same logic as migrationlib.DBVersion: the latest version whose last
+// migration was an up migration.
+func dbVersion(tx *sql.Tx) (uint64, error) {
+	rows, err := tx.Query(selectVersionStmt)
+	if err != nil {
+		return 0, fmt.Errorf("could not retrieve db version: %w", err)
+	}
+	defer rows.Close()
+
+	rolledBack := make(map[uint64]bool)
+	for rows.Next() {
+		var (
+			versionID uint64
+			isApplied bool
+		)
+		if err := rows.Scan(&versionID, &isApplied); err != nil {
+			return 0, fmt.Errorf("could not scan row: %w", err)
+		}
+		if rolledBack[versionID] {
+			continue
+		}
+		if isApplied {
+			return versionID, nil
+		}
+		rolledBack[versionID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("could not retrieve db version: %w", err)
+	}
+	return 0, nil
+}
+
+// loadMigrations returns the embedded migrations, ordered by version.
+func loadMigrations() ([]migration, error) {
+	const dir = "migration"
+	// fs.ReadDir returns the entries sorted by file name, file names start with
+	// the zero-padded version.
+	entries, err := fs.ReadDir(sqlitedb.Migrations, dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list migrations: %w", err)
+	}
+	var migrations []migration
+	for _, entry := range entries {
+		name := entry.Name()
+		versionStr, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version prefix: %w", name, err)
+		}
+		script, err := fs.ReadFile(sqlitedb.Migrations, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("could not read migration %s: %w", name, err)
+		}
+		migrations = append(migrations, migration{
+			version:    version,
+			name:       name,
+			statements: splitStatements(upSection(string(script))),
+		})
+	}
+	return migrations, nil
+}
+
+// upSection returns the part of a goose SQL migration between the
+// "-- +goose Up" and "-- +goose Down" annotations.
+func upSection(script string) string {
+	_, up, found := strings.Cut(script, "-- +goose Up")
+	if !found {
+		return ""
+	}
+	up, _, _ = strings.Cut(up, "-- +goose Down")
+	return up
+}
+
+// splitStatements splits a SQL script into statements. Statements must end
+// with a semicolon at the end of a line, and comments must be on their own
+// lines.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    []string
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, line)
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	return statements
+}
@@ -0,0 +1,156 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package sqlite implements a storage engine which stores ConTest information
+// in a SQLite database file. It is the rdbms storage engine on top of an
+// embedded SQLite driver, meant for single-node deployments which need to
+// keep the job history across restarts without running a database server.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/linuxboot/contest/pkg/storage"
+	"github.com/linuxboot/contest/plugins/storage/rdbms"
+)
+
+const (
+	// DriverName is the database/sql driver which opens the data source names
+	// returned by DSN.
+	DriverName = "contest_sqlite"
+	// URIScheme is the scheme of the database URIs handled by this package,
+	// e.g. sqlite:///var/lib/contest/contest.db for an absolute path or
+	// sqlite://contest.db for a path relative to the working directory.
+	URIScheme = "sqlite://"
+)
+
+// default pragmas, see DSN
+var defaultPragmas = []struct {
+	name, pragma string
+}{
+	// wait for other connections to release their locks instead of failing
+	{"busy_timeout", "busy_timeout(10000)"},
+	// readers don't block writers and vice-versa
+	{"journal_mode", "journal_mode(WAL)"},
+}
+
+// IsURI returns whether a database URI refers to a SQLite database.
+func IsURI(dbURI string) bool {
+	return strings.HasPrefix(dbURI, URIScheme)
+}
+
+// DSN converts a sqlite:// database URI to a data source name for DriverName.
+// Query parameters of the URI are passed to the driver as they are, e.g.
+// sqlite://contest.db?_pragma=synchronous(NORMAL). Defaults are added for the
+// busy timeout and the journal mode pragmas. Transactions always take the
+// database write lock when they begin, to avoid deadlocks between concurrent
+// transactions upgrading their read lock.
+func DSN(dbURI string) (string, error) {
+	if !IsURI(dbURI) {
+		return "", fmt.Errorf("database URI %q does not start with %s", dbURI, URIScheme)
+	}
+	path, query, _ := strings.Cut(strings.TrimPrefix(dbURI, URIScheme), "?")
+	if path == "" {
+		return "", fmt.Errorf("database URI %q has no file path", dbURI)
+	}
+	if path == ":memory:" {
+		// every connection of the pool would get its own database
+		return "", fmt.Errorf("in-memory SQLite databases are not supported, use the memory storage instead")
+	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("invalid parameters in database URI %q: %w", dbURI, err)
+	}
+	for _, d := range defaultPragmas {
+		found := false
+		for _, p := range params["_pragma"] {
+			if strings.HasPrefix(strings.ToLower(p), d.name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			params.Add("_pragma", d.pragma)
+		}
+	}
+	params.Set("_txlock", "immediate")
+	// times are stored as text, this format sorts chronologically as long as
+	// all of them are in UTC, see utcDriver.
+	params.Set("_time_format", "sqlite")
+	return path + "?" + params.Encode(), nil
+}
+
+// SQLite is the rdbms storage engine for SQLite databases.
+type SQLite struct {
+	*rdbms.RDBMS
+
+	// db is used for the statements which are specific to SQLite
+	db *sql.DB
+}
+
+// Reset wipes entire database contents. Used in tests.
+func (s *SQLite) Reset() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// this always fails if tx.Commit() was called before, ignore error
+		_ = tx.Rollback()
+	}()
+	for _, t := range []string{
+		"jobs",
+		"job_tags",
+		"run_reports",
+		"final_reports",
+		"test_events",
+		"framework_events",
+		"schedules",
+		"inventory",
+		// like TRUNCATE TABLE on MySQL, reset the auto-increment counters
+		"sqlite_sequence",
+	} {
+		if _, err := tx.Exec("DELETE FROM " + t); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close flushes pending events and closes the database connections.
+func (s *SQLite) Close() error {
+	err := s.RDBMS.Close()
+	if dbErr := s.db.Close(); err == nil {
+		err = dbErr
+	}
+	return err
+}
+
+// New creates a SQLite storage engine from a sqlite:// database URI. The
+// database file is created if it does not exist, and the migrations it is
+// missing are applied.
+func New(dbURI string, opts ...rdbms.Opt) (storage.Storage, error) {
+	dsn, err := DSN(dbURI)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(DriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize database: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not migrate database: %w", err)
+	}
+	s, err := rdbms.New(dsn, append(opts, rdbms.DriverName(DriverName))...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLite{RDBMS: s.(*rdbms.RDBMS), db: db}, nil
+}
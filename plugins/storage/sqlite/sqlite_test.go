@@ -0,0 +1,82 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDSN(t *testing.T) {
+	dsn, err := DSN("sqlite:///var/lib/contest.db")
+	require.NoError(t, err)
+	require.Equal(t, "/var/lib/contest.db?_pragma=busy_timeout%2810000%29&_pragma=journal_mode%28WAL%29&_time_format=sqlite&_txlock=immediate", dsn)
+
+	dsn, err = DSN("sqlite://contest.db?_pragma=journal_mode(DELETE)")
+	require.NoError(t, err)
+	require.Equal(t, "contest.db?_pragma=journal_mode%28DELETE%29&_pragma=busy_timeout%2810000%29&_time_format=sqlite&_txlock=immediate", dsn)
+
+	for _, uri := range []string{
+		"contest:contest@tcp(localhost:3306)/contest",
+		"sqlite://",
+		"sqlite://:memory:",
+		"sqlite://contest.db?%zz",
+	} {
+		_, err := DSN(uri)
+		require.Error(t, err, uri)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dsn, err := DSN(URIScheme + filepath.Join(t.TempDir(), "contest.db"))
+	require.NoError(t, err)
+
+	// migrating twice must be a no-op the second time
+	require.NoError(t, Migrate(dsn))
+	require.NoError(t, Migrate(dsn))
+
+	db, err := sql.Open(DriverName, dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	version, err := dbVersion(tx)
+	require.NoError(t, err)
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.Equal(t, migrations[len(migrations)-1].version, version)
+
+	var applied int
+	require.NoError(t, tx.QueryRow("SELECT COUNT(*) FROM goose_db_version WHERE version_id > 0").Scan(&applied))
+	require.Equal(t, len(migrations), applied)
+}
+
+func TestUTCTimes(t *testing.T) {
+	s, err := New(URIScheme + filepath.Join(t.TempDir(), "contest.db"))
+	require.NoError(t, err)
+	defer s.Close()
+	db := s.(*SQLite).db
+
+	local := time.Date(2022, 1, 2, 3, 4, 5, 6, time.FixedZone("UTC+2", 2*60*60))
+	_, err = db.Exec("INSERT INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)", "t", 1, local, local, true)
+	require.NoError(t, err)
+
+	var stored string
+	require.NoError(t, db.QueryRow("SELECT CAST(created_at AS TEXT) FROM locks").Scan(&stored))
+	require.Equal(t, "2022-01-02 01:04:05.000000006+00:00", stored)
+
+	var expiresAt time.Time
+	require.NoError(t, db.QueryRow("SELECT expires_at FROM locks WHERE expires_at = ?", local).Scan(&expiresAt))
+	require.True(t, local.Equal(expiresAt))
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/benbjohnson/clock"
 	// this blank import registers the mysql driver
 	_ "github.com/go-sql-driver/mysql"
+	// this blank import registers the postgres driver
+	_ "github.com/lib/pq"
 
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/types"
@@ -25,6 +27,18 @@ var Name = "DBLocker"
 
 const DefaultMaxBatchSize = 100
 
+// Dialect is the SQL dialect spoken by the database.
+type Dialect int
+
+const (
+	// DialectMySQL is the dialect of MySQL and of its compatible variants.
+	DialectMySQL Dialect = iota
+	// DialectSQLite is the dialect of SQLite.
+	DialectSQLite
+	// DialectPostgres is the dialect of PostgreSQL.
+	DialectPostgres
+)
+
 // used for functions that can operate with and without transactions
 type db interface {
 	Query(query string, args ...interface{}) (*sql.Rows, error)
@@ -74,16 +88,45 @@ func listQueryString(length uint) string {
 }
 
 // DBLocker implements a simple target locker based on a relational database.
-// The current implementation only supports MySQL officially.
+// The current implementation supports MySQL, SQLite and PostgreSQL, see WithDialect.
 // All functions in DBLocker are safe for concurrent use by multiple goroutines.
 type DBLocker struct {
 	driverName   string
+	dialect      Dialect
 	db           *sql.DB
 	maxBatchSize int
 	// clock is used for measuring time
 	clock clock.Clock
 }
 
+// insertIgnoreStmt returns the statement which inserts locks and skips the
+// rows conflicting with existing ones, and the clause which must follow the
+// values.
+func (d *DBLocker) insertIgnoreStmt() (string, string) {
+	switch d.dialect {
+	case DialectSQLite:
+		return "INSERT OR IGNORE INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)", ""
+	case DialectPostgres:
+		return "INSERT INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)", " ON CONFLICT DO NOTHING"
+	default:
+		return "INSERT IGNORE INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)", ""
+	}
+}
+
+// rebind converts the ? placeholders of a query to the ones of the dialect.
+func (d *DBLocker) rebind(query string) string {
+	if d.dialect != DialectPostgres {
+		return query
+	}
+	parts := strings.Split(query, "?")
+	var b strings.Builder
+	b.WriteString(parts[0])
+	for i, part := range parts[1:] {
+		fmt.Fprintf(&b, "$%d%s", i+1, part)
+	}
+	return b.String()
+}
+
 // queryLocks returns a map of ID -> dblock for a given list of targets
 func (d *DBLocker) queryLocks(tx db, targets []string) (map[string]dblock, error) {
 	q := "SELECT target_id, job_id, created_at, expires_at FROM locks WHERE target_id IN " + listQueryString(uint(len(targets)))
@@ -93,7 +136,7 @@ func (d *DBLocker) queryLocks(tx db, targets []string) (map[string]dblock, error
 		queryList = append(queryList, targetID)
 	}
 
-	rows, err := tx.Query(q, queryList...)
+	rows, err := tx.Query(d.rebind(q), queryList...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read existing locks: %w", err)
 	}
@@ -167,10 +210,14 @@ func (d *DBLocker) handleLock(ctx xcontext.Context, jobID int64, targets []strin
 			break
 		}
 	}
-	if (len(conflicts) > 0 && !allowConflicts) || len(missing) > 0 {
+	if len(missing) > 0 {
 		return nil, fmt.Errorf("unable to lock targets %v for owner %d, have %d conflicting locks (%v), %d missing locks (%v)",
 			targets, jobID, len(conflicts), conflicts, len(missing), missing)
 	}
+	if len(conflicts) > 0 && !allowConflicts {
+		return nil, fmt.Errorf("unable to lock targets %v for owner %d, have %d conflicting locks (%v): %w",
+			targets, jobID, len(conflicts), conflicts, target.ErrTargetsBusy)
+	}
 
 	// First, drop all the locks that we intend to extend or take over.
 	// Use strict matching so that if another instance races ahead of us, row will not be deleted and subsequent insert will fail.
@@ -187,7 +234,7 @@ func (d *DBLocker) handleLock(ctx xcontext.Context, jobID int64, targets []strin
 			if len(stmt) < d.maxBatchSize && i < len(toDelete)-1 {
 				continue
 			}
-			if _, err := tx.Exec(strings.Join(stmt, ""), args...); err != nil {
+			if _, err := tx.Exec(d.rebind(strings.Join(stmt, "")), args...); err != nil {
 				return nil, fmt.Errorf("insert statement failed: %w", err)
 			}
 			stmt = nil
@@ -199,6 +246,7 @@ func (d *DBLocker) handleLock(ctx xcontext.Context, jobID int64, targets []strin
 	{
 		var stmt []string
 		var args []interface{}
+		var suffix string
 		for i, targetID := range toInsert {
 			createdAt := now
 			// If we are updating our own lock, carry over the creation timestamp.
@@ -208,7 +256,9 @@ func (d *DBLocker) handleLock(ctx xcontext.Context, jobID int64, targets []strin
 			if len(stmt) == 0 {
 				// this can race with other transactions, acceptable for TryLock
 				if allowConflicts {
-					stmt = append(stmt, "INSERT IGNORE INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)")
+					var insert string
+					insert, suffix = d.insertIgnoreStmt()
+					stmt = append(stmt, insert)
 				} else {
 					stmt = append(stmt, "INSERT INTO locks (target_id, job_id, created_at, expires_at, valid) VALUES (?, ?, ?, ?, ?)")
 				}
@@ -219,7 +269,7 @@ func (d *DBLocker) handleLock(ctx xcontext.Context, jobID int64, targets []strin
 			if len(stmt) < d.maxBatchSize && i < len(toInsert)-1 {
 				continue
 			}
-			if _, err := tx.Exec(strings.Join(stmt, ""), args...); err != nil {
+			if _, err := tx.Exec(d.rebind(strings.Join(stmt, "")+suffix), args...); err != nil {
 				return nil, fmt.Errorf("insert statement failed: %w", err)
 			}
 			stmt = nil
@@ -291,7 +341,7 @@ func (d *DBLocker) handleUnlock(ctx xcontext.Context, jobID int64, targets []str
 	for _, targetID := range targets {
 		queryList = append(queryList, targetID)
 	}
-	if _, err := tx.Exec(del, queryList...); err != nil {
+	if _, err := tx.Exec(d.rebind(del), queryList...); err != nil {
 		return fmt.Errorf("unable to unlock targets %v, owner %d: %w", targets, jobID, err)
 	}
 	return tx.Commit()
@@ -375,7 +425,12 @@ func (d *DBLocker) Close() error {
 // is why it is not exposed by target.Locker
 func (d *DBLocker) ResetAllLocks(ctx xcontext.Context) error {
 	ctx.Warnf("DELETING ALL LOCKS")
-	_, err := d.db.Exec("TRUNCATE TABLE locks")
+	stmt := "TRUNCATE TABLE locks"
+	if d.dialect == DialectSQLite {
+		// SQLite has no TRUNCATE, an unconditional DELETE is optimized into one
+		stmt = "DELETE FROM locks"
+	}
+	_, err := d.db.Exec(stmt)
 	return err
 }
 
@@ -390,6 +445,15 @@ func WithDriverName(name string) Opt {
 	}
 }
 
+// WithDialect option sets the SQL dialect of the database, DialectMySQL by
+// default. DialectPostgres uses the postgres driver by default, other dialects
+// are meant to be used together with WithDriverName.
+func WithDialect(value Dialect) Opt {
+	return func(d *DBLocker) {
+		d.dialect = value
+	}
+}
+
 // WithMaxBatchSize option sets maximum batch size for statements.
 func WithMaxBatchSize(value int) Opt {
 	return func(d *DBLocker) {
@@ -416,6 +480,9 @@ func New(dbURI string, opts ...Opt) (*DBLocker, error) {
 	}
 
 	driverName := "mysql"
+	if res.dialect == DialectPostgres {
+		driverName = "postgres"
+	}
 	if res.driverName != "" {
 		driverName = res.driverName
 	}
@@ -117,7 +117,7 @@ func broker(clk clock.Clock, lockRequests, unlockRequests <-chan *request, done
 						} else {
 							// already locked
 							if !req.allowConflicts {
-								lockErr = fmt.Errorf("target %q is already locked by %d", t, l.owner)
+								lockErr = fmt.Errorf("target %q is already locked by %d: %w", t, l.owner, target.ErrTargetsBusy)
 								break
 							}
 							continue
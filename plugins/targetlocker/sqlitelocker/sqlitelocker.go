@@ -0,0 +1,36 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package sqlitelocker implements a target locker which keeps the locks in a
+// SQLite database file, normally the one of the SQLite storage. It is the
+// DBLocker using the SQLite dialect.
+// WARNING: SQLite databases are local files, the locks are only shared by the
+// ConTest servers running on the same host.
+package sqlitelocker
+
+import (
+	"fmt"
+
+	"github.com/linuxboot/contest/plugins/storage/sqlite"
+	"github.com/linuxboot/contest/plugins/targetlocker/dblocker"
+)
+
+// Name is the plugin name.
+var Name = "SQLiteLocker"
+
+// New initializes and returns a new target locker from a sqlite:// database
+// URI. The database is created and migrated if needed, like the SQLite
+// storage does.
+func New(dbURI string, opts ...dblocker.Opt) (*dblocker.DBLocker, error) {
+	dsn, err := sqlite.DSN(dbURI)
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlite.Migrate(dsn); err != nil {
+		return nil, fmt.Errorf("could not migrate database: %w", err)
+	}
+	opts = append(opts, dblocker.WithDriverName(sqlite.DriverName), dblocker.WithDialect(dblocker.DialectSQLite))
+	return dblocker.New(dsn, opts...)
+}
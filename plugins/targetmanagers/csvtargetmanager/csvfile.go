@@ -198,7 +198,11 @@ func (tf *CSVFileTargetManager) Acquire(ctx xcontext.Context, jobID types.JobID,
 				return nil, fmt.Errorf("can't unlock targets")
 			}
 		}
-		return nil, fmt.Errorf("can't lock enough targets")
+		return nil, &target.BusyError{
+			TargetIDs: target.TargetIDs(hosts),
+			Needed:    int(acquireParameters.MinNumberDevices) - len(locked),
+			Err:       fmt.Errorf("can't lock enough targets"),
+		}
 	}
 
 	tf.hosts = locked
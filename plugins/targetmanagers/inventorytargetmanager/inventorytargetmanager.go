@@ -0,0 +1,197 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package inventorytargetmanager implements a target manager that acquires
+// targets from the inventory of the server by label selector. Use it as
+// follows in a job descriptor:
+//
+//	"TargetManagerName": "InventoryTargetManager",
+//	"TargetManagerAcquireParameters": {
+//	    "Selector": "board=X,bmc=openbmc",
+//	    "Count": 4,
+//	    "MinCount": 2
+//	}
+//
+// The targets matching the selector which are neither drained nor unhealthy
+// are locked through the target locker, up to Count of them. Acquire fails if
+// fewer than MinCount (Count by default) targets could be locked. See
+// inventory.ParseSelector for the syntax of the selectors.
+package inventorytargetmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/storage"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/types"
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// Name defined the name of the plugin
+var (
+	Name = "InventoryTargetManager"
+)
+
+var (
+	inventoryStorageMu sync.RWMutex
+	// inventoryStorage is where the targets are looked up.
+	inventoryStorage storage.InventoryStorage
+)
+
+// SetStorage sets the storage of the inventory the targets are acquired from.
+// It must be set before any job using this target manager runs.
+func SetStorage(s storage.InventoryStorage) {
+	inventoryStorageMu.Lock()
+	defer inventoryStorageMu.Unlock()
+	inventoryStorage = s
+}
+
+func getStorage() storage.InventoryStorage {
+	inventoryStorageMu.RLock()
+	defer inventoryStorageMu.RUnlock()
+	return inventoryStorage
+}
+
+// AcquireParameters contains the parameters necessary to acquire targets.
+type AcquireParameters struct {
+	// Selector selects the targets by label, e.g. "board=X,bmc=openbmc".
+	// The empty selector matches all the targets.
+	Selector string
+	// Count is the number of targets to acquire.
+	Count uint32
+	// MinCount is the minimum number of targets to acquire, Count if zero.
+	MinCount uint32
+	// Shuffle acquires random targets among the matching ones, rather than
+	// the first ones by ID.
+	Shuffle bool
+}
+
+// ReleaseParameters contains the parameters necessary to release targets.
+type ReleaseParameters struct {
+}
+
+// InventoryTargetManager implements the contest.TargetManager interface.
+type InventoryTargetManager struct {
+}
+
+// ValidateAcquireParameters performs sanity checks on the fields of the
+// parameters that will be passed to Acquire.
+func (t InventoryTargetManager) ValidateAcquireParameters(params []byte) (interface{}, error) {
+	var ap AcquireParameters
+	if err := json.Unmarshal(params, &ap); err != nil {
+		return nil, err
+	}
+	if _, err := inventory.ParseSelector(ap.Selector); err != nil {
+		return nil, err
+	}
+	if ap.Count == 0 {
+		return nil, errors.New("the number of targets to acquire must be positive")
+	}
+	if ap.MinCount > ap.Count {
+		return nil, fmt.Errorf("MinCount (%d) cannot be greater than Count (%d)", ap.MinCount, ap.Count)
+	}
+	if ap.MinCount == 0 {
+		ap.MinCount = ap.Count
+	}
+	return ap, nil
+}
+
+// ValidateReleaseParameters performs sanity checks on the fields of the
+// parameters that will be passed to Release.
+func (t InventoryTargetManager) ValidateReleaseParameters(params []byte) (interface{}, error) {
+	var rp ReleaseParameters
+	if err := json.Unmarshal(params, &rp); err != nil {
+		return nil, err
+	}
+	return rp, nil
+}
+
+// Acquire implements contest.TargetManager.Acquire, locking up to Count of the
+// available targets of the inventory matching the selector.
+func (t *InventoryTargetManager) Acquire(ctx xcontext.Context, jobID types.JobID, jobTargetManagerAcquireTimeout time.Duration, parameters interface{}, tl target.Locker) ([]*target.Target, error) {
+	acquireParameters, ok := parameters.(AcquireParameters)
+	if !ok {
+		return nil, fmt.Errorf("Acquire expects %T object, got %T", acquireParameters, parameters)
+	}
+	s := getStorage()
+	if s == nil {
+		return nil, errors.New("the inventory storage is not set")
+	}
+	sel, err := inventory.ParseSelector(acquireParameters.Selector)
+	if err != nil {
+		return nil, err
+	}
+	inventoryTargets, err := s.ListInventoryTargets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list inventory targets: %w", err)
+	}
+
+	var candidates []*target.Target
+	for _, it := range inventoryTargets {
+		if it.Available() && sel.Matches(it.Labels) {
+			candidates = append(candidates, it.Target())
+		}
+	}
+	minCount := int(acquireParameters.MinCount)
+	if minCount == 0 {
+		minCount = int(acquireParameters.Count)
+	}
+	if len(candidates) < minCount {
+		return nil, fmt.Errorf("not enough available targets matching %q in the inventory, want %d, got %d",
+			sel, minCount, len(candidates))
+	}
+	ctx.Debugf("Found %d available targets matching %q", len(candidates), sel)
+	if acquireParameters.Shuffle {
+		rand.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+	}
+
+	lockedIDs, err := tl.TryLock(ctx, jobID, jobTargetManagerAcquireTimeout, candidates, uint(acquireParameters.Count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock targets: %w", err)
+	}
+	locked, err := target.FilterTargets(lockedIDs, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("can not find locked targets in inventory: %w", err)
+	}
+	if len(locked) < minCount {
+		// not enough, unlock what we got and fail
+		if err := tl.Unlock(ctx, jobID, locked); err != nil {
+			return nil, fmt.Errorf("can't unlock targets: %w", err)
+		}
+		return nil, &target.BusyError{
+			TargetIDs: target.TargetIDs(candidates),
+			Needed:    minCount - len(locked),
+			Err:       fmt.Errorf("can't lock enough targets matching %q, want %d, got %d", sel, minCount, len(locked)),
+		}
+	}
+
+	ctx.Infof("Acquired %d targets matching %q", len(locked), sel)
+	return locked, nil
+}
+
+// Release releases the acquired resources.
+func (t *InventoryTargetManager) Release(ctx xcontext.Context, jobID types.JobID, targets []*target.Target, params interface{}) error {
+	ctx.Infof("Released %d targets", len(targets))
+	return nil
+}
+
+// New builds a new InventoryTargetManager object.
+func New() target.TargetManager {
+	return &InventoryTargetManager{}
+}
+
+// Load returns the name and factory which are needed to register the
+// TargetManager.
+func Load() (string, target.TargetManagerFactory) {
+	return Name, New
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package inventorytargetmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/inventory"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/storage/memory"
+	"github.com/linuxboot/contest/plugins/targetlocker/inmemory"
+)
+
+func targetIDs(targets []*target.Target) []string {
+	ids := make([]string, 0, len(targets))
+	for _, t := range targets {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := xcontext.Background()
+	s, err := memory.New()
+	require.NoError(t, err)
+	for _, it := range []*inventory.Target{
+		{ID: "t1", Labels: map[string]string{"board": "X"}, Health: inventory.HealthHealthy},
+		{ID: "t2", Labels: map[string]string{"board": "X"}, Health: inventory.HealthUnhealthy},
+		{ID: "t3", Labels: map[string]string{"board": "X"}, Health: inventory.HealthUnknown, Drained: true},
+		{ID: "t4", Labels: map[string]string{"board": "X"}, Health: inventory.HealthUnknown},
+		{ID: "t5", Labels: map[string]string{"board": "Y"}, Health: inventory.HealthHealthy},
+	} {
+		require.NoError(t, s.StoreInventoryTarget(ctx, it))
+	}
+	SetStorage(s)
+	defer SetStorage(nil)
+
+	tl := inmemory.New(clock.New())
+	defer tl.Close()
+	tm := New()
+
+	params, err := tm.ValidateAcquireParameters([]byte(`{"Selector": "board=X", "Count": 2}`))
+	require.NoError(t, err)
+	job1Targets, err := tm.Acquire(ctx, 1, time.Minute, params, tl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"t1", "t4"}, targetIDs(job1Targets))
+
+	// the targets of job 1 are locked, job 2 gets the remaining one
+	params, err = tm.ValidateAcquireParameters([]byte(`{"Selector": "board", "Count": 2, "MinCount": 1}`))
+	require.NoError(t, err)
+	targets, err := tm.Acquire(ctx, 2, time.Minute, params, tl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"t5"}, targetIDs(targets))
+
+	// nothing is left until job 1 releases its targets
+	params, err = tm.ValidateAcquireParameters([]byte(`{"Selector": "board=X", "Count": 1}`))
+	require.NoError(t, err)
+	_, err = tm.Acquire(ctx, 3, time.Minute, params, tl)
+	require.Error(t, err)
+	require.NoError(t, tl.Unlock(ctx, 1, job1Targets))
+	targets, err = tm.Acquire(ctx, 3, time.Minute, params, tl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"t1"}, targetIDs(targets))
+}
+
+func TestValidateAcquireParameters(t *testing.T) {
+	tm := New()
+	for _, params := range []string{
+		`{"Selector": "board=X"}`,
+		`{"Selector": "board=X=Y", "Count": 1}`,
+		`{"Selector": "board=X", "Count": 1, "MinCount": 2}`,
+	} {
+		_, err := tm.ValidateAcquireParameters([]byte(params))
+		require.Error(t, err, params)
+	}
+	ap, err := tm.ValidateAcquireParameters([]byte(`{"Selector": "board=X,!broken", "Count": 3}`))
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), ap.(AcquireParameters).MinCount)
+}
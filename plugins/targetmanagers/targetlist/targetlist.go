@@ -89,6 +89,9 @@ func (t *TargetList) Acquire(ctx xcontext.Context, jobID types.JobID, jobTargetM
 
 	if err := tl.Lock(ctx, jobID, jobTargetManagerAcquireTimeout, acquireParameters.Targets); err != nil {
 		ctx.Warnf("Failed to lock %d targets: %v", len(acquireParameters.Targets), err)
+		if errors.Is(err, target.ErrTargetsBusy) {
+			return nil, &target.BusyError{TargetIDs: target.TargetIDs(acquireParameters.Targets), Err: err}
+		}
 		return nil, err
 	}
 
@@ -7,7 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"syscall"
 
+	"github.com/google/goterm/term"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
@@ -83,6 +85,73 @@ func (lp *localProcess) String() string {
 	return lp.cmd.String()
 }
 
+func (lt *LocalTransport) NewTerminal(ctx xcontext.Context, bin string, args []string, workingDir string) (Terminal, error) {
+	return newLocalTerminal(ctx, bin, args, workingDir)
+}
+
+// localTerminal is a local process attached to the slave end of a pty
+type localTerminal struct {
+	cmd    *exec.Cmd
+	master *os.File
+}
+
+func newLocalTerminal(ctx xcontext.Context, bin string, args []string, workingDir string) (Terminal, error) {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBinary(path); err != nil {
+		return nil, err
+	}
+
+	pty, err := term.OpenPTY()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty: %w", err)
+	}
+	// the slave is only needed by the process
+	defer pty.Slave.Close()
+
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Dir = workingDir
+	cmd.Stdin = pty.Slave
+	cmd.Stdout = pty.Slave
+	cmd.Stderr = pty.Slave
+	// make the pty the controlling terminal of the process
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	ctx.Debugf("starting local binary in a terminal: %v", cmd)
+	if err := cmd.Start(); err != nil {
+		pty.Master.Close()
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	return &localTerminal{cmd: cmd, master: pty.Master}, nil
+}
+
+func (lt *localTerminal) Read(p []byte) (int, error) {
+	n, err := lt.master.Read(p)
+	// linux fails reads with EIO once the slave end is closed
+	if errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (lt *localTerminal) Write(p []byte) (int, error) {
+	return lt.master.Write(p)
+}
+
+func (lt *localTerminal) Close() error {
+	// the process may have exited already
+	_ = lt.cmd.Process.Kill()
+	_ = lt.cmd.Wait()
+	return lt.master.Close()
+}
+
+func (lt *localTerminal) String() string {
+	return lt.cmd.String()
+}
+
 // localCopy is just a thin layer over exec.Command
 type localCopy struct {
 	src       string
@@ -11,11 +11,15 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/insomniacslk/xjson"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/xcontext"
 	"github.com/pkg/sftp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -45,7 +49,8 @@ func NewSSHTransport(config SSHTransportConfig) Transport {
 	return &SSHTransport{config}
 }
 
-func (st *SSHTransport) NewProcess(ctx xcontext.Context, bin string, args []string, workingDir string) (Process, error) {
+// dial connects to the SSH server.
+func (st *SSHTransport) dial() (*ssh.Client, error) {
 	var signer ssh.Signer
 	if st.IdentityFile != "" {
 		key, err := ioutil.ReadFile(st.IdentityFile)
@@ -76,12 +81,20 @@ func (st *SSHTransport) NewProcess(ctx xcontext.Context, bin string, args []stri
 		Timeout:         time.Duration(st.Timeout),
 	}
 
+	client, err := ssh.Dial("tcp", addr, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to SSH server %s: %v", addr, err)
+	}
+	return client, nil
+}
+
+func (st *SSHTransport) NewProcess(ctx xcontext.Context, bin string, args []string, workingDir string) (Process, error) {
 	// stack mechanism similar to defer, but run after the exec process ends
 	stack := newDeferedStack()
 
-	client, err := ssh.Dial("tcp", addr, clientConfig)
+	client, err := st.dial()
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to SSH server %s: %v", addr, err)
+		return nil, err
 	}
 
 	// cleanup the ssh client after the operations have ended
@@ -101,6 +114,9 @@ type sshProcess struct {
 	keepAliveDone chan struct{}
 
 	stack *deferedStack
+
+	addr string
+	span trace.Span
 }
 
 func (st *SSHTransport) newSSHProcess(ctx xcontext.Context, client *ssh.Client,
@@ -119,12 +135,19 @@ func (st *SSHTransport) newSSHProcess(ctx xcontext.Context, client *ssh.Client,
 	cmd := strings.Join(append([]string{bin}, args...), " ")
 	keepAliveDone := make(chan struct{})
 
-	return &sshProcess{session, cmd, workingDir, keepAliveDone, stack}, nil
+	addr := net.JoinHostPort(st.Host, strconv.Itoa(st.Port))
+	return &sshProcess{session, cmd, workingDir, keepAliveDone, stack, addr, nil}, nil
 }
 
 func (sp *sshProcess) Start(ctx xcontext.Context) error {
 	ctx.Debugf("starting remote binary: %s", sp.cmd)
 
+	// the span lasts until the process is waited for
+	_, sp.span = tracing.Start(ctx, "ssh exec",
+		attribute.String("contest.ssh.addr", sp.addr),
+		attribute.String("contest.ssh.command", sp.cmd),
+	)
+
 	var cmd string
 	if sp.workingDir != "" {
 		cmd = fmt.Sprintf("cd %s && %s", sp.workingDir, sp.cmd)
@@ -133,7 +156,10 @@ func (sp *sshProcess) Start(ctx xcontext.Context) error {
 	}
 
 	if err := sp.session.Start(cmd); err != nil {
-		return fmt.Errorf("failed to start process: %v", err)
+		err = fmt.Errorf("failed to start process: %v", err)
+		tracing.End(sp.span, err)
+		sp.span = nil
+		return err
 	}
 
 	go func() {
@@ -167,13 +193,18 @@ func (sp *sshProcess) Start(ctx xcontext.Context) error {
 	return nil
 }
 
-func (sp *sshProcess) Wait(ctx xcontext.Context) error {
+func (sp *sshProcess) Wait(ctx xcontext.Context) (err error) {
 	// close these no matter what error we get from the wait
 	defer func() {
 		sp.stack.Done()
 		close(sp.keepAliveDone)
 	}()
 	defer sp.session.Close()
+	defer func() {
+		if sp.span != nil {
+			tracing.End(sp.span, err)
+		}
+	}()
 
 	errChan := make(chan error, 1)
 	go func() {
@@ -232,52 +263,134 @@ func (sp *sshProcess) String() string {
 	return sp.cmd
 }
 
-type sftpCopy struct {
-	client    *sftp.Client
-	src       string
-	dst       string
-	recursive bool
+// sshTerminal is a remote process running in a pty requested for its session
+type sshTerminal struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.Writer
+	stdout  io.Reader
+	cmd     string
 
-	stack *deferedStack
+	closeOnce sync.Once
+	done      chan struct{}
 }
 
-func (st *SSHTransport) NewCopy(ctx xcontext.Context, src, dst string, recursive bool) (Copy, error) {
-	var signer ssh.Signer
-	if st.IdentityFile != "" {
-		key, err := ioutil.ReadFile(st.IdentityFile)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read private key at %s: %v", st.IdentityFile, err)
-		}
-		signer, err = ssh.ParsePrivateKey(key)
+func (st *SSHTransport) NewTerminal(ctx xcontext.Context, bin string, args []string, workingDir string) (_ Terminal, err error) {
+	client, err := st.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("cannot create SSH session to server: %v", err)
+	}
+
+	// release the connection if the process cannot be started
+	defer func() {
 		if err != nil {
-			return nil, fmt.Errorf("cannot parse private key: %v", err)
+			session.Close()
+			client.Close()
 		}
+	}()
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 115200,
+		ssh.TTY_OP_OSPEED: 115200,
+	}
+	if err := session.RequestPty("xterm", 24, 80, modes); err != nil {
+		return nil, fmt.Errorf("failed to request pty: %v", err)
 	}
 
-	auth := []ssh.AuthMethod{}
-	if signer != nil {
-		auth = append(auth, ssh.PublicKeys(signer))
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %v", err)
 	}
-	if st.Password != "" {
-		auth = append(auth, ssh.Password(st.Password))
+	// stderr goes to the pty as well
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %v", err)
 	}
 
-	addr := net.JoinHostPort(st.Host, strconv.Itoa(st.Port))
-	clientConfig := &ssh.ClientConfig{
-		User: st.User,
-		Auth: auth,
-		// TODO expose this in the plugin arguments
-		//HostKeyCallback: ssh.FixedHostKey(hostKey),
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         time.Duration(st.Timeout),
+	quoted := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{bin}, args...) {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	cmd := strings.Join(quoted, " ")
+	if workingDir != "" {
+		cmd = fmt.Sprintf("cd %s && %s", shellQuote(workingDir), cmd)
+	}
+
+	ctx.Debugf("starting remote binary in a terminal: %s", cmd)
+	if err := session.Start(cmd); err != nil {
+		return nil, fmt.Errorf("failed to start process: %v", err)
 	}
 
+	t := &sshTerminal{
+		client:  client,
+		session: session,
+		stdin:   stdin,
+		stdout:  stdout,
+		cmd:     cmd,
+		done:    make(chan struct{}),
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			ctx.Debugf("closing ssh terminal because of cancellation...")
+			t.Close()
+		case <-t.done:
+		}
+	}()
+
+	return t, nil
+}
+
+func (t *sshTerminal) Read(p []byte) (int, error) {
+	return t.stdout.Read(p)
+}
+
+func (t *sshTerminal) Write(p []byte) (int, error) {
+	return t.stdin.Write(p)
+}
+
+func (t *sshTerminal) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.done)
+		// not all servers implement signals, closing the session hangs up
+		// the pty anyway
+		_ = t.session.Signal(ssh.SIGKILL)
+		t.session.Close()
+		err = t.client.Close()
+	})
+	return err
+}
+
+func (t *sshTerminal) String() string {
+	return t.cmd
+}
+
+type sftpCopy struct {
+	client    *sftp.Client
+	src       string
+	dst       string
+	recursive bool
+
+	stack *deferedStack
+
+	addr string
+}
+
+func (st *SSHTransport) NewCopy(ctx xcontext.Context, src, dst string, recursive bool) (Copy, error) {
 	// stack mechanism similar to defer, but run after the exec process ends
 	stack := newDeferedStack()
 
-	client, err := ssh.Dial("tcp", addr, clientConfig)
+	client, err := st.dial()
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to SSH server %s: %v", addr, err)
+		return nil, err
 	}
 
 	SFTPClient, err := sftp.NewClient(client)
@@ -292,10 +405,22 @@ func (st *SSHTransport) NewCopy(ctx xcontext.Context, src, dst string, recursive
 		}
 	})
 
-	return &sftpCopy{client: SFTPClient, src: src, dst: dst, recursive: recursive, stack: stack}, nil
+	addr := net.JoinHostPort(st.Host, strconv.Itoa(st.Port))
+	return &sftpCopy{client: SFTPClient, src: src, dst: dst, recursive: recursive, stack: stack, addr: addr}, nil
 }
 
 func (sc *sftpCopy) Copy(ctx xcontext.Context) error {
+	_, span := tracing.Start(ctx, "sftp copy",
+		attribute.String("contest.ssh.addr", sc.addr),
+		attribute.String("contest.copy.src", sc.src),
+		attribute.String("contest.copy.dst", sc.dst),
+	)
+	err := sc.copy()
+	tracing.End(span, err)
+	return err
+}
+
+func (sc *sftpCopy) copy() error {
 
 	if sc.recursive {
 		if err := filepath.Walk(sc.src, func(srcPath string, info os.FileInfo, err error) error {
@@ -409,3 +534,12 @@ func (sc *sftpCopy) String() string {
 		return fmt.Sprintf("scp %s %s", sc.src, sc.dst)
 	}
 }
+
+// shellQuote quotes s as a single word for the remote shell, unless it only
+// contains characters which the shell does not interpret.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
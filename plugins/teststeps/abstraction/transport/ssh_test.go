@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellQuote(t *testing.T) {
+	for s, quoted := range map[string]string{
+		"":                "''",
+		"/usr/bin/shell":  "/usr/bin/shell",
+		"--flag=a,b:c@d":  "--flag=a,b:c@d",
+		"/tmp/my dir":     "'/tmp/my dir'",
+		"$(reboot)":       "'$(reboot)'",
+		"a;b&&c|d":        "'a;b&&c|d'",
+		"it's":            `'it'\''s'`,
+		"*.log ~ \"x\"\n": "'*.log ~ \"x\"\n'",
+	} {
+		require.Equal(t, quoted, shellQuote(s), s)
+	}
+}
@@ -33,9 +33,22 @@ type Parameters struct {
 		Options json.RawMessage `json:"options,omitempty"`
 	} 
 
+// Terminal is a process running in a pseudo terminal, for the programs which
+// are driven interactively, e.g. shells. Reads return its output, writes are
+// its input, and reads return io.EOF once it exits. Closing it kills the
+// process if it is still running.
+type Terminal interface {
+	io.ReadWriteCloser
+
+	String() string
+}
+
 type Transport interface {
 	NewProcess(ctx xcontext.Context, bin string, args []string, workingDir string) (Process, error)
 	NewCopy(ctx xcontext.Context, source, destination string, recursive bool) (Copy, error)
+	// NewTerminal starts a process in a pseudo terminal. The arguments are
+	// passed as is, they are not interpreted by a shell.
+	NewTerminal(ctx xcontext.Context, bin string, args []string, workingDir string) (Terminal, error)
 }
 
 func NewTransport(proto string, supportedProtos []string, configSource json.RawMessage, expander *test.ParamExpander) (Transport, error) {
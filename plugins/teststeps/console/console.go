@@ -0,0 +1,274 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package console implements the Console test step, which sends input to the
+// console of targets and waits for its output to match regular expressions,
+// through the DUT backend configured for each target (see pkg/dut). It can
+// also match the console log recorded during the whole test instead, see
+// pkg/console.
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	recorder "github.com/linuxboot/contest/pkg/console"
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+)
+
+// Name is the name used to look this plugin up.
+const Name = "Console"
+
+const (
+	defaultTimeout    = time.Minute
+	parametersKeyword = "parameters"
+)
+
+// Parts of the recorded console log matched by the step.
+const (
+	// LogTest is the console log since the beginning of the test.
+	LogTest = "test"
+	// LogStep is the console log since the target entered the step.
+	LogStep = "step"
+)
+
+type parameters struct {
+	// Port is the index of the console, e.g. of the UART.
+	Port  int    `json:"port,omitempty"`
+	Input string `json:"input,omitempty"`
+	// Log, if set, matches the console log recorded by the test instead of
+	// opening the console.
+	Log string `json:"log,omitempty"`
+
+	Expect []struct {
+		Regex string `json:"regex,omitempty"`
+	} `json:"expect,omitempty"`
+}
+
+// TestStep implementation for this teststep plugin
+type TestStep struct {
+	parameters
+	options options.Parameters
+	regexes []*regexp.Regexp
+}
+
+// Name returns the plugin name.
+func (ts TestStep) Name() string {
+	return Name
+}
+
+// Run talks to the console of every target.
+func (ts *TestStep) Run(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters,
+	ev testevent.Emitter, resumeState json.RawMessage,
+) (json.RawMessage, error) {
+	if err := ts.validateAndPopulate(params); err != nil {
+		return nil, err
+	}
+	return teststeps.ForEachTarget(Name, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+		var outputBuf strings.Builder
+
+		ctx, cancel := options.NewOptions(ctx, defaultTimeout, ts.options.Timeout)
+		defer cancel()
+
+		if err := ts.console(ctx, target, &outputBuf); err != nil {
+			outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+			return events.EmitError(ctx, outputBuf.String(), target, ev, err)
+		}
+		return events.EmitLog(ctx, outputBuf.String(), target, ev)
+	})
+}
+
+func (ts *TestStep) console(ctx xcontext.Context, target *target.Target, output io.Writer) error {
+	if ts.Log != "" {
+		return ts.matchLog(ctx, target, output)
+	}
+
+	drv, err := dut.OpenTarget(target, output)
+	if err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	console, ok := drv.(dut.Console)
+	if !ok {
+		return dut.Unsupported(drv, "console access")
+	}
+	conn, err := console.OpenConsole(ctx, ts.Port)
+	if err != nil {
+		return fmt.Errorf("failed to open console %d: %w", ts.Port, err)
+	}
+	defer conn.Close()
+
+	return expect(ctx, conn, ts.Input, ts.regexes, output)
+}
+
+// matchLog waits for the recorded console log of the target to match all the
+// regexes.
+func (ts *TestStep) matchLog(ctx xcontext.Context, target *target.Target, output io.Writer) error {
+	rec := recorder.TargetRecorder(ctx, target.ID)
+	if rec == nil {
+		return fmt.Errorf("the consoles are not recorded, the test needs a ConsoleRecorder")
+	}
+	var from int64
+	if ts.Log == LogStep {
+		from = rec.Size()
+	}
+	log, err := rec.Match(ctx, from, ts.regexes)
+	writeResult(output, log, ts.regexes)
+	return err
+}
+
+// expect writes input to the console and reads it until its output matches
+// all the regexes, or the context is done. The output read and the matches
+// are written to output.
+func expect(ctx xcontext.Context, conn io.ReadWriter, input string, regexes []*regexp.Regexp, output io.Writer) error {
+	if input != "" {
+		if _, err := io.WriteString(conn, input); err != nil {
+			return fmt.Errorf("failed to write to console: %w", err)
+		}
+	}
+
+	type chunk struct {
+		data []byte
+		err  error
+	}
+	chunks := make(chan chunk)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			buf := make([]byte, 4096)
+			n, err := conn.Read(buf)
+			select {
+			case chunks <- chunk{data: buf[:n], err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var serial []byte
+	for !matchAll(serial, regexes) {
+		select {
+		case c := <-chunks:
+			serial = append(serial, c.data...)
+			if c.err == io.EOF && !matchAll(serial, regexes) {
+				writeResult(output, serial, regexes)
+				return fmt.Errorf("console closed before all regexes matched")
+			}
+			if c.err != nil && c.err != io.EOF {
+				writeResult(output, serial, regexes)
+				return fmt.Errorf("failed to read console: %w", c.err)
+			}
+		case <-ctx.Done():
+			writeResult(output, serial, regexes)
+			return fmt.Errorf("timed out waiting for the console output: %w", ctx.Err())
+		}
+	}
+	writeResult(output, serial, regexes)
+	return nil
+}
+
+func matchAll(serial []byte, regexes []*regexp.Regexp) bool {
+	for _, re := range regexes {
+		if !re.Match(serial) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeResult(output io.Writer, serial []byte, regexes []*regexp.Regexp) {
+	for _, re := range regexes {
+		matches := re.FindAll(serial, -1)
+		if len(matches) == 0 {
+			fmt.Fprintf(output, "Could not find the expected regex '%s' in the console output.\n", re)
+			continue
+		}
+		fmt.Fprintf(output, "Found the expected regex '%s' in the console output. All matches listed here:\n", re)
+		for i, match := range matches {
+			fmt.Fprintf(output, "Match %d: '%s'\n", i+1, match)
+		}
+	}
+	fmt.Fprintf(output, "\nConsole Output:\n%s\n", serial)
+}
+
+// Retrieve all the parameters defines through the jobDesc
+func (ts *TestStep) validateAndPopulate(stepParams test.TestStepParameters) error {
+	var parameters, optionsParams *test.Param
+
+	if parameters = stepParams.GetOne(parametersKeyword); parameters.IsEmpty() {
+		return fmt.Errorf("parameters cannot be empty")
+	}
+
+	if err := json.Unmarshal(parameters.JSON(), &ts.parameters); err != nil {
+		return fmt.Errorf("failed to deserialize parameters: %v", err)
+	}
+
+	optionsParams = stepParams.GetOne(options.Keyword)
+
+	if !optionsParams.IsEmpty() {
+		if err := json.Unmarshal(optionsParams.JSON(), &ts.options); err != nil {
+			return fmt.Errorf("failed to deserialize options: %v", err)
+		}
+	}
+
+	switch ts.Log {
+	case "":
+		if ts.Input == "" && len(ts.Expect) == 0 {
+			return fmt.Errorf("at least one of 'input' and 'expect' must be set")
+		}
+	case LogTest, LogStep:
+		if ts.Input != "" {
+			return fmt.Errorf("'input' cannot be sent to the recorded console log")
+		}
+		if len(ts.Expect) == 0 {
+			return fmt.Errorf("'expect' must be set to match the recorded console log")
+		}
+	default:
+		return fmt.Errorf("invalid log %q, possible values are '%s' and '%s'", ts.Log, LogTest, LogStep)
+	}
+
+	ts.regexes = ts.regexes[:0]
+	for _, expect := range ts.Expect {
+		re, err := regexp.Compile(expect.Regex)
+		if err != nil {
+			return fmt.Errorf("failed to parse the regex '%s': %v", expect.Regex, err)
+		}
+		ts.regexes = append(ts.regexes, re)
+	}
+
+	return nil
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *TestStep) ValidateParameters(_ xcontext.Context, params test.TestStepParameters) error {
+	return ts.validateAndPopulate(params)
+}
+
+// New initializes and returns a new Console test step.
+func New() test.TestStep {
+	return &TestStep{}
+}
+
+// Load returns the name, factory and events which are needed to register the step.
+func Load() (string, test.TestStepFactory, []event.Name) {
+	return Name, New, events.Events
+}
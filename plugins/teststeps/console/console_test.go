@@ -0,0 +1,149 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package console
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+// fakeConsole outputs the content of its reader and records its input.
+type fakeConsole struct {
+	io.Reader
+	input  strings.Builder
+	closed bool
+}
+
+func (c *fakeConsole) Write(p []byte) (int, error) {
+	return c.input.Write(p)
+}
+
+func (c *fakeConsole) Close() error {
+	c.closed = true
+	if closer, ok := c.Reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// fakeDriver has the single console 0, whose output is read from the output
+// parameter of the backend, or which blocks if the parameter is not set.
+type fakeDriver struct {
+	output string
+}
+
+var lastConsole *fakeConsole
+
+func (d *fakeDriver) Backend() string { return "fake" }
+func (d *fakeDriver) Close() error    { return nil }
+
+func (d *fakeDriver) OpenConsole(ctx xcontext.Context, port int) (io.ReadWriteCloser, error) {
+	if port != 0 {
+		return nil, fmt.Errorf("no console %d", port)
+	}
+	lastConsole = &fakeConsole{Reader: strings.NewReader(d.output)}
+	if d.output == "" {
+		lastConsole.Reader, _ = io.Pipe()
+	}
+	return lastConsole, nil
+}
+
+// bareDriver has no capability.
+type bareDriver struct{}
+
+func (bareDriver) Backend() string { return "bare" }
+func (bareDriver) Close() error    { return nil }
+
+func init() {
+	dut.Register("fake", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		return &fakeDriver{output: params["output"]}, nil
+	})
+	dut.Register("bare", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		return bareDriver{}, nil
+	})
+}
+
+func runConsole(t *testing.T, dutConfig, params string) (string, error) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	ts := New().(*TestStep)
+	require.NoError(t, ts.ValidateParameters(ctx, test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(params)},
+	}))
+
+	ctx, cancel := xcontext.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	var output strings.Builder
+	err := ts.console(ctx, &target.Target{
+		ID:                 "board1",
+		TargetManagerState: []byte(`{"DUT": ` + dutConfig + `}`),
+	}, &output)
+	return output.String(), err
+}
+
+func TestConsole(t *testing.T) {
+	output, err := runConsole(t,
+		`{"Backend": "fake", "Params": {"output": "Linux version 6.1\nbox login: "}}`,
+		`{"input": "\n", "expect": [{"regex": "version [0-9.]+"}, {"regex": "login:"}]}`)
+	require.NoError(t, err)
+	require.Equal(t, "\n", lastConsole.input.String())
+	require.True(t, lastConsole.closed)
+	require.Contains(t, output, "Match 1: 'version 6.1'")
+	require.Contains(t, output, "Match 1: 'login:'")
+}
+
+func TestConsoleClosed(t *testing.T) {
+	output, err := runConsole(t,
+		`{"Backend": "fake", "Params": {"output": "Kernel panic"}}`,
+		`{"expect": [{"regex": "login:"}]}`)
+	require.EqualError(t, err, "console closed before all regexes matched")
+	require.Contains(t, output, "Could not find the expected regex 'login:'")
+	require.Contains(t, output, "Kernel panic")
+}
+
+func TestConsoleTimeout(t *testing.T) {
+	_, err := runConsole(t, `{"Backend": "fake"}`, `{"expect": [{"regex": "login:"}]}`)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, xcontext.ErrDeadlineExceeded), err)
+}
+
+func TestConsoleUnsupported(t *testing.T) {
+	_, err := runConsole(t, `{"Backend": "bare"}`, `{"input": "reboot\n"}`)
+	require.True(t, errors.Is(err, dut.ErrUnsupported))
+	require.EqualError(t, err, "console access is not supported by the DUT backend bare")
+
+	_, err = runConsole(t, `{"Backend": "fake"}`, `{"port": 1, "input": "reboot\n"}`)
+	require.EqualError(t, err, "failed to open console 1: no console 1")
+}
+
+func TestValidateParameters(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	for _, params := range []string{
+		`{}`,
+		`{"expect": [{"regex": "("}]}`,
+		`{"log": "test", "input": "x", "expect": [{"regex": "login:"}]}`,
+		`{"log": "test"}`,
+		`{"log": "run", "expect": [{"regex": "login:"}]}`,
+	} {
+		err := New().ValidateParameters(ctx, test.TestStepParameters{
+			parametersKeyword: []test.Param{*test.NewParam(params)},
+		})
+		require.Error(t, err, params)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/blindspotsoftware/system-suite/pkg/cpu"
+	"github.com/linuxboot/contest/pkg/events"
 )
 
 const (
@@ -195,6 +196,26 @@ func (s *Stats) CheckIndividualOption(expect Individual, interval bool, outputBu
 	return nil
 }
 
+// Metrics returns the stats as metrics. The metrics of the individual CPUs
+// are tagged with the index of the CPU.
+func (s Stats) Metrics() []events.Metric {
+	metrics := []events.Metric{
+		{Name: "CPUsLogical", Value: float64(s.Data.CPUsLogical)},
+		{Name: "CPUsPhysical", Value: float64(s.Data.CPUsPhysical)},
+		{Name: "CurPowerConsumption", Value: s.Data.Power.CurPowerConsumption, Unit: "W"},
+		{Name: "PowerLimit1", Value: float64(s.Data.Power.PowerLimit1), Unit: "W"},
+		{Name: "PowerLimit2", Value: float64(s.Data.Power.PowerLimit2), Unit: "W"},
+	}
+	for i, c := range s.Data.CPUs {
+		tags := map[string]string{"cpu": strconv.Itoa(i)}
+		metrics = append(metrics,
+			events.Metric{Name: "AverageFrequency", Value: float64(c.Frequency.AverageFrequency), Unit: "kHz", Tags: tags},
+			events.Metric{Name: "BusyFrequency", Value: float64(c.Frequency.BusyFrequency), Unit: "kHz", Tags: tags},
+		)
+	}
+	return metrics
+}
+
 func (s Stats) GeneralOptions() string {
 	return fmt.Sprintf("%s, %s, %s, %s, %s, %s", "CPUsLogical", "CPUsPhysical", "Profile", "CurPowerConsumption",
 		"PowerLimit1", "PowerLimit2")
@@ -62,7 +62,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		}
 	}
 
-	if err := r.ts.runLoad(ctx, &outputBuf, transportProto); err != nil {
+	if err := r.ts.runLoad(ctx, &outputBuf, transportProto, target, r.ev); err != nil {
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
 	}
 
@@ -70,6 +70,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 }
 
 func (ts *TestStep) runLoad(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport,
+	target *target.Target, ev testevent.Emitter,
 ) error {
 	var args []string
 
@@ -115,7 +116,7 @@ func (ts *TestStep) runLoad(ctx xcontext.Context, outputBuf *strings.Builder, tr
 	}
 
 	if len(ts.Expect.Individual) > 0 || len(ts.Expect.General) > 0 {
-		if err := ts.parseStats(ctx, outputBuf, transport); err != nil {
+		if err := ts.parseStats(ctx, outputBuf, transport, target, ev); err != nil {
 			return fmt.Errorf("Failed to parse cpu stats: %v.", err)
 		}
 	}
@@ -133,7 +134,9 @@ func (ts *TestStep) runLoad(ctx xcontext.Context, outputBuf *strings.Builder, tr
 	return err
 }
 
-func (ts *TestStep) parseStats(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport) error {
+func (ts *TestStep) parseStats(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport,
+	target *target.Target, ev testevent.Emitter,
+) error {
 	duration, err := time.ParseDuration(ts.Duration)
 	if err != nil {
 		return fmt.Errorf("wrong interval statement, valid units are ns, us, ms, s, m and h")
@@ -191,7 +194,7 @@ func (ts *TestStep) parseStats(ctx xcontext.Context, outputBuf *strings.Builder,
 		return fmt.Errorf("Failed to get CPU stats: %v.\nStats Stderr:\n%s\n", outcome, string(stderr))
 	}
 
-	if err = ts.parseOutput(ctx, outputBuf, stdout); err != nil {
+	if err = ts.parseOutput(ctx, outputBuf, stdout, target, ev); err != nil {
 		return err
 	}
 
@@ -227,7 +230,7 @@ func readBuffer(r io.Reader) ([]byte, error) {
 }
 
 func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder,
-	stdout []byte,
+	stdout []byte, target *target.Target, ev testevent.Emitter,
 ) error {
 	var (
 		stats       cpu.Stats
@@ -239,6 +242,10 @@ func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder
 		if err := json.Unmarshal(stdout, &stats); err != nil {
 			return fmt.Errorf("failed to unmarshal stdout: %v", err)
 		}
+
+		if err := events.EmitMetrics(ctx, stats.Metrics(), target, ev); err != nil {
+			return err
+		}
 	}
 
 	for _, expect := range ts.Expect.General {
@@ -66,7 +66,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		}
 	}
 
-	if err = r.ts.runStats(ctx, &outputBuf, transportProto); err != nil {
+	if err = r.ts.runStats(ctx, &outputBuf, transportProto, target, r.ev); err != nil {
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
 	}
 
@@ -74,6 +74,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 }
 
 func (ts *TestStep) runStats(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport,
+	target *target.Target, ev testevent.Emitter,
 ) error {
 	var args []string
 
@@ -131,7 +132,7 @@ func (ts *TestStep) runStats(ctx xcontext.Context, outputBuf *strings.Builder, t
 		return fmt.Errorf("Failed to get CPU stats:\n%v\n", outcome)
 	}
 
-	if err = ts.parseOutput(ctx, outputBuf, stdout); err != nil {
+	if err = ts.parseOutput(ctx, outputBuf, stdout, target, ev); err != nil {
 		return err
 	}
 
@@ -166,7 +167,9 @@ func readBuffer(r io.Reader) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder, stdout []byte) error {
+func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder, stdout []byte,
+	target *target.Target, ev testevent.Emitter,
+) error {
 	var (
 		stats       cpu.Stats
 		interval    bool
@@ -182,6 +185,10 @@ func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder
 		if err := json.Unmarshal(stdout, &stats); err != nil {
 			return fmt.Errorf("failed to unmarshal stdout: %v", err)
 		}
+
+		if err := events.EmitMetrics(ctx, stats.Metrics(), target, ev); err != nil {
+			return err
+		}
 	}
 
 	for _, expect := range ts.Expect.General {
@@ -4,157 +4,83 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
-	"github.com/9elements/fti/pkg/dutctl"
-	"github.com/9elements/fti/pkg/remote_lab/client"
-	"github.com/9elements/fti/pkg/tools"
+	dutdriver "github.com/linuxboot/contest/pkg/dut/dutctl"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
-const (
-	tryTimeout = 15 * time.Second
-)
-
-func (r *TargetRunner) powerCmds(ctx xcontext.Context, stdoutMsg, stderrMsg *strings.Builder) error {
-	var (
-		err          error
-		dutInterface dutctl.DutCtl
-		stdout       tools.LogginFunc
-	)
-
-	for tries := 0; tries < 2; tries += 1 {
-
-		dutInterface, err = client.NewDutCtl("", false, r.ts.Host, false, "", 0, 2)
-		if err != nil {
-			// Try insecure on port 10000
-			if strings.Contains(r.ts.Host, ":10001") {
-				r.ts.Host = strings.Split(r.ts.Host, ":")[0] + ":10000"
-			}
+func (r *TargetRunner) powerCmds(ctx xcontext.Context, drv *dutdriver.Driver, stdoutMsg, stderrMsg *strings.Builder) error {
+	if len(r.ts.Args) == 0 {
+		return fmt.Errorf("Failed to execute the power command. Args is empty. Possible values are 'on', 'off', 'hardreset' and 'powercycle'.")
+	}
 
-			dutInterface, err = client.NewDutCtl("", false, r.ts.Host, false, "", 0, 2)
-			if err != nil {
-				stderrMsg.WriteString(fmt.Sprintf("Failed to connect to DUT: %v\n", err))
+	switch r.ts.Args[0] {
+	case "on":
+		if err := drv.PowerOn(ctx); err != nil {
+			return err
+		}
 
-				time.Sleep(tryTimeout)
-				continue
-			}
+		return r.expect(ctx, drv, stdoutMsg, stderrMsg)
+	case "off":
+		return drv.PowerOff(ctx)
+	case "hardreset":
+		if err := drv.HardReset(ctx); err != nil {
+			return err
 		}
 
-		defer func() {
-			if dutInterface != nil {
-				dutInterface.Close()
-			}
-		}()
+		return r.expect(ctx, drv, stdoutMsg, stderrMsg)
+	case "powercycle":
+		if len(r.ts.Args) != 2 {
+			return fmt.Errorf("You have to add only a second argument to specify how often you want to powercycle.")
+		}
 
-		err = dutInterface.InitPowerPlugins(stdout)
+		reboots, err := strconv.Atoi(r.ts.Args[1])
 		if err != nil {
-			stderrMsg.WriteString(fmt.Sprintf("Failed to init power plugins: %v\n", err))
-
-			time.Sleep(tryTimeout)
-			continue
+			return fmt.Errorf("powercycle amount could not be parsed: %v\n", err)
 		}
 
-		if len(r.ts.Args) == 0 {
-			return fmt.Errorf("Failed to execute the power command. Args is empty. Possible values are 'on', 'off' and 'powercycle'.")
+		regexList, err := r.getRegexList()
+		if err != nil {
+			return fmt.Errorf("Failed to parse regex list: %v\n", err)
 		}
 
-		switch r.ts.Args[0] {
-		case "on":
-			if err := dutInterface.PowerOn(); err != nil {
-				stderrMsg.WriteString(fmt.Sprintf("Failed to power on: %v\n", err))
-
-				time.Sleep(tryTimeout)
-				continue
-			}
-
-			stdoutMsg.WriteString("Successfully powered on DUT.\n")
-
-			if len(r.ts.Expect) != 0 {
-				regexList, err := r.getRegexList()
-				if err != nil {
-					return fmt.Errorf("Failed to parse regex list: %v\n", err)
-				}
-
-				if err := r.serial(ctx, stdoutMsg, stderrMsg, dutInterface, regexList); err != nil {
-					return fmt.Errorf("the expect '%s' was not found in the logs", r.ts.Expect)
-				}
-			}
-
-			return nil
-		case "off":
-			if err := dutInterface.PowerOff(); err != nil {
-				stderrMsg.WriteString(fmt.Sprintf("Failed to power off: %v\n", err))
-
-				time.Sleep(tryTimeout)
-				continue
-			}
-
-			stdoutMsg.WriteString("Successfully powered off DUT.\n")
-
-			return nil
-		case "hardreset":
-			if !dutInterface.HasHardReset() {
-				return fmt.Errorf("The DUT does not support hardreset.")
-			}
-			if err := dutInterface.HardReset(); err != nil {
-				stderrMsg.WriteString(fmt.Sprintf("Failed to hardreset: %v\n", err))
-			}
-
-			stdoutMsg.WriteString("Successfully reset(hard) the DUT.\n")
-
-			if len(r.ts.Expect) != 0 {
-				regexList, err := r.getRegexList()
-				if err != nil {
-					return fmt.Errorf("Failed to parse regex list: %v\n", err)
-				}
-
-				if err := r.serial(ctx, stdoutMsg, stderrMsg, dutInterface, regexList); err != nil {
-					return fmt.Errorf("the expect '%s' was not found in the logs", r.ts.Expect)
-				}
-			}
-
-			return nil
-
-		case "powercycle":
-
-			if len(r.ts.Args) != 2 {
-				return fmt.Errorf("You have to add only a second argument to specify how often you want to powercycle.")
+		for i := 1; i < reboots; i++ {
+			if err := drv.PowerOff(ctx); err != nil {
+				return err
 			}
 
-			reboots, err := strconv.Atoi(r.ts.Args[1])
-			if err != nil {
-				return fmt.Errorf("powercycle amount could not be parsed: %v\n", err)
+			if err := drv.PowerOn(ctx); err != nil {
+				return err
 			}
 
-			regexList, err := r.getRegexList()
-			if err != nil {
-				return fmt.Errorf("Failed to parse regex list: %v\n", err)
+			if err := r.serial(ctx, drv, stdoutMsg, stderrMsg, regexList); err != nil {
+				return fmt.Errorf("the expect '%v' was not found in the logs", r.ts.Expect)
 			}
+		}
 
-			for i := 1; i < reboots; i++ {
-				err = dutInterface.PowerOff()
-				if err != nil {
-					return fmt.Errorf("Failed to power off: %v\n", err)
-				}
+		stdoutMsg.WriteString(fmt.Sprintf("Successfully powercycled the DUT '%s'.\n", r.ts.Args[1]))
 
-				err = dutInterface.PowerOn()
-				if err != nil {
-					return fmt.Errorf("Failed to power on: %v\n", err)
-				}
+		return nil
+	default:
+		return fmt.Errorf("Failed to execute the power command. The argument '%s' is not valid. Possible values are 'on', 'off', 'hardreset' and 'powercycle'.", r.ts.Args)
+	}
+}
 
-				if err := r.serial(ctx, stdoutMsg, stderrMsg, dutInterface, regexList); err != nil {
-					return fmt.Errorf("the expect '%v' was not found in the logs", r.ts.Expect)
-				}
-			}
+// expect greps the serial output of the DUT for the expected regexes, if
+// any, after the DUT was powered on.
+func (r *TargetRunner) expect(ctx xcontext.Context, drv *dutdriver.Driver, stdoutMsg, stderrMsg *strings.Builder) error {
+	if len(r.ts.Expect) == 0 {
+		return nil
+	}
 
-			stdoutMsg.WriteString(fmt.Sprintf("Successfully powercycled the DUT '%s'.\n", r.ts.Args[1]))
+	regexList, err := r.getRegexList()
+	if err != nil {
+		return fmt.Errorf("Failed to parse regex list: %v\n", err)
+	}
 
-			return nil
-		default:
-			return fmt.Errorf("Failed to execute the power command. The argument '%s' is not valid. Possible values are 'on', 'off' and 'powercycle'.", r.ts.Args)
-		}
+	if err := r.serial(ctx, drv, stdoutMsg, stderrMsg, regexList); err != nil {
+		return fmt.Errorf("the expect '%s' was not found in the logs", r.ts.Expect)
 	}
 
-	return fmt.Errorf("Maximum number of retries for power command reached.")
+	return nil
 }
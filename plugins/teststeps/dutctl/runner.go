@@ -2,10 +2,9 @@ package dutctl
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
+	dutdriver "github.com/linuxboot/contest/pkg/dut/dutctl"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/events"
 	"github.com/linuxboot/contest/pkg/target"
@@ -33,45 +32,30 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 
 	r.ts.writeTestStep(&stdoutMsg, &stderrMsg)
 
-	if r.ts.Host != "" {
-		var certFile string
-
-		fp := "/" + filepath.Join("etc", "fti", "keys", "ca-cert.pem")
-		if _, err := os.Stat(fp); err == nil {
-			certFile = fp
-		}
-
-		if !strings.Contains(r.ts.Host, ":") {
-			// Add default port
-			if certFile == "" {
-				r.ts.Host += ":10000"
-			} else {
-				r.ts.Host += ":10001"
-			}
-		}
-	}
-
 	writeCommand(r.ts.Command, r.ts.Args, &stdoutMsg, &stderrMsg)
 
 	stdoutMsg.WriteString("Stdout:\n")
 	stderrMsg.WriteString("Stderr:\n")
 
+	drv := dutdriver.New(r.ts.Host, &stdoutMsg)
+	defer drv.Close()
+
 	switch r.ts.Command {
 	case "power":
-		if err := r.powerCmds(ctx, &stdoutMsg, &stderrMsg); err != nil {
+		if err := r.powerCmds(ctx, drv, &stdoutMsg, &stderrMsg); err != nil {
 			stderrMsg.WriteString(fmt.Sprintf("%v\n", err))
 
 			return events.EmitError(ctx, stderrMsg.String(), target, r.ev, err)
 		}
 	case "flash":
-		if err := r.flashCmds(ctx, &stdoutMsg, &stderrMsg); err != nil {
+		if err := r.flashCmds(ctx, drv, &stdoutMsg, &stderrMsg); err != nil {
 			stderrMsg.WriteString(fmt.Sprintf("%v\n", err))
 
 			return events.EmitError(ctx, stderrMsg.String(), target, r.ev, err)
 		}
 
 	case "serial":
-		if err := r.serialCmds(ctx, &stdoutMsg, &stderrMsg); err != nil {
+		if err := r.serialCmds(ctx, drv, &stdoutMsg, &stderrMsg); err != nil {
 			stderrMsg.WriteString(fmt.Sprintf("%v\n", err))
 
 			return events.EmitError(ctx, stderrMsg.String(), target, r.ev, err)
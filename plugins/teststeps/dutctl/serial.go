@@ -3,68 +3,62 @@ package dutctl
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/9elements/fti/pkg/dutctl"
-	"github.com/9elements/fti/pkg/remote_lab/client"
+	dutdriver "github.com/linuxboot/contest/pkg/dut/dutctl"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
-var timeout time.Time
-
-func (r *TargetRunner) serialCmds(ctx xcontext.Context, stdoutMsg, stderrMsg *strings.Builder) error {
-	var (
-		dutInterface dutctl.DutCtl
-		err          error
-	)
-
-	dutInterface, err = client.NewDutCtl("", false, r.ts.Host, false, "", 0, 2)
-	if err != nil {
-		// Try insecure on port 10000
-		if strings.Contains(r.ts.Host, ":10001") {
-			r.ts.Host = strings.Split(r.ts.Host, ":")[0] + ":10000"
-		}
-
-		dutInterface, err = client.NewDutCtl("", false, r.ts.Host, false, "", 0, 2)
-		if err != nil {
-			return err
-		}
-	}
-
-	defer func() {
-		if dutInterface != nil {
-			dutInterface.Close()
-		}
-	}()
-
+func (r *TargetRunner) serialCmds(ctx xcontext.Context, drv *dutdriver.Driver, stdoutMsg, stderrMsg *strings.Builder) error {
 	regexList, err := r.getRegexList()
 	if err != nil {
 		return err
 	}
 
-	if err := r.serial(ctx, stdoutMsg, stderrMsg, dutInterface, regexList); err != nil {
-		return err
-	}
+	return r.serial(ctx, drv, stdoutMsg, stderrMsg, regexList)
+}
 
-	return nil
+// serialBuffer collects the output of a UART while it is grepped.
+type serialBuffer struct {
+	mu   sync.Mutex
+	data []byte
+	errs []string
 }
 
-func (r *TargetRunner) serial(ctx xcontext.Context, stdoutMsg, stderrMsg *strings.Builder, dutInterface dutctl.DutCtl, regexList []*regexp.Regexp) error {
-	timeout = time.Now().Add(time.Duration(r.ts.options.Timeout))
+func (b *serialBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
 
-	err := dutInterface.InitSerialPlugins()
-	if err != nil {
-		return fmt.Errorf("Failed to init serial plugins: %v\n", err)
+func (b *serialBuffer) snapshot() ([]byte, []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.data...), append([]string(nil), b.errs...)
+}
+
+func (b *serialBuffer) addError(msg string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errs = append(b.errs, msg)
+}
+
+func (r *TargetRunner) serial(ctx xcontext.Context, drv *dutdriver.Driver, stdoutMsg, stderrMsg *strings.Builder, regexList []*regexp.Regexp) error {
+	timeout := time.Duration(r.ts.options.Timeout)
+	if timeout == 0 {
+		timeout = defaultTimeout
 	}
+	deadline := time.After(timeout)
 
-	iface, err := dutInterface.GetSerial(r.ts.UART)
+	iface, err := drv.OpenConsole(ctx, r.ts.UART)
 	if err != nil {
-		return fmt.Errorf("Failed to get serial: %v\n", err)
+		return err
 	}
+	defer iface.Close()
 
 	// Write in into serial
 	if r.ts.Input != "" {
@@ -75,83 +69,77 @@ func (r *TargetRunner) serial(ctx xcontext.Context, stdoutMsg, stderrMsg *string
 		stdoutMsg.WriteString(fmt.Sprintf("Wrote '%s' to the DUT.\n", r.ts.Input))
 	}
 
-	if len(r.ts.Expect) > 0 {
-		dst, err := os.Create("/tmp/dutctlserial")
-		if err != nil {
-			return fmt.Errorf("Creating serial dst file failed: %v", err)
-		}
-		defer dst.Close()
+	if len(r.ts.Expect) == 0 {
+		return nil
+	}
 
-		go func(ctx xcontext.Context) {
-			defer func() {
-				iface.Close()
-			}()
+	var buf serialBuffer
+	done := make(chan struct{})
+	defer close(done)
 
-			retryCount := 0
+	go func() {
+		retryCount := 0
 
-			for {
-				select {
-				case <-ctx.Done():
-					stdoutMsg.WriteString("\n")
-					return
-				default:
-					_, err = io.Copy(dst, iface)
-					if err != nil {
-						retryCount++
-						stderrMsg.WriteString(fmt.Sprintf("Failed to copy data from serial to output: %v.\n", err))
-
-						if retryCount >= 5 {
-							stderrMsg.WriteString("Terminating after 5 failed retries.\n")
-							return
-						}
-					} else {
-						retryCount = 0
-					}
-				}
+		for {
+			select {
+			case <-done:
+				return
+			default:
 			}
-		}(ctx)
 
-		stdoutMsg.WriteString("Greping serial from the DUT with the help of the provided regexpressions.\n")
+			if _, err := io.Copy(&buf, iface); err != nil {
+				retryCount++
+				buf.addError(fmt.Sprintf("Failed to copy data from serial to output: %v.\n", err))
 
-		foundAll := false
-
-		for {
-			serial, err := ioutil.ReadFile("/tmp/dutctlserial")
-			if err != nil {
-				return fmt.Errorf("Failed to read serial file: %v", err)
+				if retryCount >= 5 {
+					buf.addError("Terminating after 5 failed retries.\n")
+					return
+				}
+			} else {
+				retryCount = 0
 			}
+		}
+	}()
 
-			if time.Now().After(timeout) {
-				ctx.Done()
-				r.writeMatches(stdoutMsg, stderrMsg, serial, regexList)
-				r.writeSerial(stdoutMsg, stderrMsg, serial)
+	stdoutMsg.WriteString("Greping serial from the DUT with the help of the provided regexpressions.\n")
 
-				return fmt.Errorf("Timed out after %s.", r.ts.options.Timeout.String())
-			}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
 
-			foundAll = true
+	for {
+		serial, errs := buf.snapshot()
 
-			for _, re := range regexList {
-				matches := re.FindAll(serial, -1)
-				if len(matches) == 0 {
-					foundAll = false
-				}
+		foundAll := true
+		for _, re := range regexList {
+			if !re.Match(serial) {
+				foundAll = false
 			}
+		}
 
-			if foundAll {
-				r.writeMatches(stdoutMsg, stderrMsg, serial, regexList)
-				r.writeSerial(stdoutMsg, stderrMsg, serial)
+		if foundAll {
+			r.writeMatches(stdoutMsg, stderrMsg, serial, regexList)
+			r.writeSerial(stdoutMsg, stderrMsg, serial)
 
-				ctx.Done()
+			return nil
+		}
 
-				return nil
-			}
+		select {
+		case <-ticker.C:
+			continue
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-deadline:
+			err = fmt.Errorf("Timed out after %s.", timeout)
+		}
 
-			time.Sleep(time.Second)
+		for _, msg := range errs {
+			stderrMsg.WriteString(msg)
 		}
-	}
+		r.writeMatches(stdoutMsg, stderrMsg, serial, regexList)
+		r.writeSerial(stdoutMsg, stderrMsg, serial)
 
-	return nil
+		return err
+	}
 }
 
 func (r *TargetRunner) writeMatches(stdoutMsg, stderrMsg *strings.Builder, serial []byte, regexList []*regexp.Regexp) {
@@ -0,0 +1,149 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package flash implements the Flash test step, which writes or reads the
+// firmware of targets through the DUT backend configured for each target
+// (see pkg/dut).
+package flash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+)
+
+// Name is the name used to look this plugin up.
+const Name = "Flash"
+
+const (
+	defaultTimeout    = 10 * time.Minute
+	parametersKeyword = "parameters"
+)
+
+// Flash actions.
+const (
+	ActionWrite = "write"
+	ActionRead  = "read"
+)
+
+type parameters struct {
+	Action string `json:"action"`
+	// File is the image written to the flash, or the file the flash is read
+	// into.
+	File string `json:"file"`
+}
+
+// TestStep implementation for this teststep plugin
+type TestStep struct {
+	parameters
+	options options.Parameters
+}
+
+// Name returns the plugin name.
+func (ts TestStep) Name() string {
+	return Name
+}
+
+// Run executes the flash action on every target.
+func (ts *TestStep) Run(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters,
+	ev testevent.Emitter, resumeState json.RawMessage,
+) (json.RawMessage, error) {
+	if err := ts.validateAndPopulate(params); err != nil {
+		return nil, err
+	}
+	return teststeps.ForEachTarget(Name, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+		var outputBuf strings.Builder
+
+		ctx, cancel := options.NewOptions(ctx, defaultTimeout, ts.options.Timeout)
+		defer cancel()
+
+		if err := ts.flash(ctx, target, &outputBuf); err != nil {
+			outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+			return events.EmitError(ctx, outputBuf.String(), target, ev, err)
+		}
+		return events.EmitLog(ctx, outputBuf.String(), target, ev)
+	})
+}
+
+func (ts *TestStep) flash(ctx xcontext.Context, target *target.Target, output io.Writer) error {
+	drv, err := dut.OpenTarget(target, output)
+	if err != nil {
+		return err
+	}
+	defer drv.Close()
+
+	flash, ok := drv.(dut.Flash)
+	if !ok {
+		return dut.Unsupported(drv, "flashing")
+	}
+	switch ts.Action {
+	case ActionWrite:
+		fmt.Fprintf(output, "Writing '%s' to the flash of %s target %s.\n", ts.File, drv.Backend(), target.ID)
+		return flash.FlashWrite(ctx, ts.File)
+	default:
+		fmt.Fprintf(output, "Reading the flash of %s target %s into '%s'.\n", drv.Backend(), target.ID, ts.File)
+		return flash.FlashRead(ctx, ts.File)
+	}
+}
+
+// Retrieve all the parameters defines through the jobDesc
+func (ts *TestStep) validateAndPopulate(stepParams test.TestStepParameters) error {
+	var parameters, optionsParams *test.Param
+
+	if parameters = stepParams.GetOne(parametersKeyword); parameters.IsEmpty() {
+		return fmt.Errorf("parameters cannot be empty")
+	}
+
+	if err := json.Unmarshal(parameters.JSON(), &ts.parameters); err != nil {
+		return fmt.Errorf("failed to deserialize parameters: %v", err)
+	}
+
+	optionsParams = stepParams.GetOne(options.Keyword)
+
+	if !optionsParams.IsEmpty() {
+		if err := json.Unmarshal(optionsParams.JSON(), &ts.options); err != nil {
+			return fmt.Errorf("failed to deserialize options: %v", err)
+		}
+	}
+
+	switch ts.Action {
+	case ActionWrite, ActionRead:
+	default:
+		return fmt.Errorf("invalid action %q, possible values are '%s' and '%s'", ts.Action, ActionWrite, ActionRead)
+	}
+
+	if ts.File == "" {
+		return fmt.Errorf("missing or empty 'file' parameter")
+	}
+
+	return nil
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *TestStep) ValidateParameters(_ xcontext.Context, params test.TestStepParameters) error {
+	return ts.validateAndPopulate(params)
+}
+
+// New initializes and returns a new Flash test step.
+func New() test.TestStep {
+	return &TestStep{}
+}
+
+// Load returns the name, factory and events which are needed to register the step.
+func Load() (string, test.TestStepFactory, []event.Name) {
+	return Name, New, events.Events
+}
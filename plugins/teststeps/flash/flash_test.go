@@ -0,0 +1,103 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package flash
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+// fakeDriver records the operations run by the step, its programmer cannot
+// read the flash.
+type fakeDriver struct {
+	ops []string
+}
+
+func (d *fakeDriver) Backend() string { return "fake" }
+func (d *fakeDriver) Close() error    { return nil }
+
+func (d *fakeDriver) FlashWrite(ctx xcontext.Context, imagePath string) error {
+	d.ops = append(d.ops, "write "+imagePath)
+	return nil
+}
+
+func (d *fakeDriver) FlashRead(ctx xcontext.Context, imagePath string) error {
+	return dut.Unsupported(d, "flash read")
+}
+
+// bareDriver has no capability.
+type bareDriver struct{}
+
+func (bareDriver) Backend() string { return "bare" }
+func (bareDriver) Close() error    { return nil }
+
+var lastDriver *fakeDriver
+
+func init() {
+	dut.Register("fake", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		lastDriver = &fakeDriver{}
+		return lastDriver, nil
+	})
+	dut.Register("bare", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		return bareDriver{}, nil
+	})
+}
+
+func runFlash(t *testing.T, backend, params string) (string, error) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	ts := New().(*TestStep)
+	require.NoError(t, ts.ValidateParameters(ctx, test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(params)},
+	}))
+
+	var output strings.Builder
+	err := ts.flash(ctx, &target.Target{
+		ID:                 "board1",
+		TargetManagerState: []byte(`{"DUT": {"Backend": "` + backend + `"}}`),
+	}, &output)
+	return output.String(), err
+}
+
+func TestFlash(t *testing.T) {
+	output, err := runFlash(t, "fake", `{"action": "write", "file": "/tmp/bios.bin"}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"write /tmp/bios.bin"}, lastDriver.ops)
+	require.Contains(t, output, "Writing '/tmp/bios.bin' to the flash of fake target board1.")
+
+	_, err = runFlash(t, "fake", `{"action": "read", "file": "/tmp/bios.bin"}`)
+	require.True(t, errors.Is(err, dut.ErrUnsupported))
+	require.EqualError(t, err, "flash read is not supported by the DUT backend fake")
+}
+
+func TestFlashUnsupported(t *testing.T) {
+	_, err := runFlash(t, "bare", `{"action": "write", "file": "/tmp/bios.bin"}`)
+	require.True(t, errors.Is(err, dut.ErrUnsupported))
+	require.EqualError(t, err, "flashing is not supported by the DUT backend bare")
+}
+
+func TestValidateParameters(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	for _, params := range []string{
+		`{"action": "erase", "file": "/tmp/bios.bin"}`,
+		`{"action": "write"}`,
+	} {
+		err := New().ValidateParameters(ctx, test.TestStepParameters{
+			parametersKeyword: []test.Param{*test.NewParam(params)},
+		})
+		require.Error(t, err, params)
+	}
+}
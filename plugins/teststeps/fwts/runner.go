@@ -60,7 +60,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
 	}
 
-	if err := r.ts.runFWTS(ctx, &outputBuf, transportProto); err != nil {
+	if err := r.ts.runFWTS(ctx, &outputBuf, transportProto, target, r.ev); err != nil {
 		outputBuf.WriteString(fmt.Sprintf("%v", err))
 
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
@@ -70,6 +70,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 }
 
 func (ts *TestStep) runFWTS(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport,
+	target *target.Target, ev testevent.Emitter,
 ) error {
 	args := []string{
 		cmd,
@@ -110,7 +111,7 @@ func (ts *TestStep) runFWTS(ctx xcontext.Context, outputBuf *strings.Builder, tr
 		outputBuf.WriteString(fmt.Sprintf("Stderr:\n%s\n", string(stderr)))
 	}
 
-	if err = ts.parseOutput(ctx, outputBuf, transport, outputPath); err != nil {
+	if err = ts.parseOutput(ctx, outputBuf, transport, outputPath, target, ev); err != nil {
 		return err
 	}
 
@@ -146,7 +147,7 @@ func readBuffer(r io.Reader) ([]byte, error) {
 }
 
 func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder,
-	transport transport.Transport, path string,
+	transport transport.Transport, path string, target *target.Target, ev testevent.Emitter,
 ) error {
 	proc, err := transport.NewProcess(ctx, "cat", []string{path}, "")
 	if err != nil {
@@ -191,6 +192,10 @@ func (ts *TestStep) parseOutput(ctx xcontext.Context, outputBuf *strings.Builder
 				return err
 			}
 
+			if err := events.EmitMetrics(ctx, data.metrics(), target, ev); err != nil {
+				return err
+			}
+
 			if ts.ReportOnly {
 				outputBuf.WriteString(fmt.Sprintf("Test result:\n%s", printData(data)))
 				return nil
@@ -216,6 +221,18 @@ type Data struct {
 	Info     int
 }
 
+// metrics returns the test counts of the result as metrics.
+func (d Data) metrics() []events.Metric {
+	return []events.Metric{
+		{Name: "Passed", Value: float64(d.Passed)},
+		{Name: "Failed", Value: float64(d.Failed)},
+		{Name: "Aborted", Value: float64(d.Aborted)},
+		{Name: "Warnings", Value: float64(d.Warnings)},
+		{Name: "Skipped", Value: float64(d.Skipped)},
+		{Name: "Info", Value: float64(d.Info)},
+	}
+}
+
 func parseLine(line string) (Data, error) {
 	// Remove leading and trailing spaces
 	line = strings.TrimSpace(line)
@@ -1,11 +1,9 @@
 package hwaas
 
 import (
-	"bytes"
 	"fmt"
-	"net/http"
-	"strings"
 
+	hwaasdut "github.com/linuxboot/contest/pkg/dut/hwaas"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
@@ -13,17 +11,13 @@ const (
 	clearCRC = "clearcrc"
 )
 
-// flashCmds is a helper function to call into the different flash commands
-func (ts *TestStep) keyboardCmds(ctx xcontext.Context, outputBuf *strings.Builder) error {
+// keyboardCmds is a helper function to call into the different keyboard commands
+func (ts *TestStep) keyboardCmds(ctx xcontext.Context, d *hwaasdut.Driver) error {
 	if len(ts.Args) >= 1 {
 		switch ts.Args[0] {
 
 		case clearCRC:
-			if err := ts.clearCRC(ctx, outputBuf); err != nil {
-				return err
-			}
-
-			return nil
+			return d.ClearCRC(ctx)
 
 		default:
 			return fmt.Errorf("failed to execute the keyboard command. The argument '%s' is not valid. Possible values are 'clearcrc'.", ts.Args)
@@ -32,23 +26,3 @@ func (ts *TestStep) keyboardCmds(ctx xcontext.Context, outputBuf *strings.Builde
 		return fmt.Errorf("failed to execute the keyboard command. Possible values are 'clearcrc'.")
 	}
 }
-
-// clearCRC calls out /clearcrc endpoint and clears the 'bad crc' error.
-func (ts *TestStep) clearCRC(ctx xcontext.Context, outputBuf *strings.Builder) error {
-	endpoint := fmt.Sprintf("%s%s/contexts/%s/machines/%s/auxiliaries/%s/api/clearcrc",
-		ts.Host, ts.Version, ts.ContextID, ts.MachineID, ts.DeviceID)
-
-	resp, err := HTTPRequest(ctx, http.MethodPost, endpoint, bytes.NewBuffer(nil))
-	if err != nil {
-		return fmt.Errorf("failed to do HTTP request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to clear bad crc error. Statuscode: %d", resp.StatusCode)
-	}
-
-	outputBuf.WriteString("Successfully cleared 'Bad CRC' Bios error.\n")
-
-	return nil
-}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	hwaasdut "github.com/linuxboot/contest/pkg/dut/hwaas"
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/events"
@@ -17,22 +18,13 @@ import (
 // We need a default timeout to avoid endless running tests.
 const (
 	defaultTimeout    time.Duration = 5 * time.Minute
-	defaultContextID  string        = "0fb4acd8-e429-11ed-b5ea-0242ac120002"
-	defaultMachineID  string        = "ws"
-	defaultDeviceID   string        = "flasher"
-	defaultHost       string        = "http://9e-hwaas-aux1.lab.9e.network"
 	parametersKeyword               = "parameters"
 )
 
 type parameters struct {
-	Command   string   `json:"command,omitempty"`
-	Args      []string `json:"args,omitempty"`
-	Host      string   `json:"host,omitempty"`
-	Version   string   `json:"version,omitempty"`
-	ContextID string   `json:"context_id,omitempty"`
-	MachineID string   `json:"machine_id,omitempty"`
-	DeviceID  string   `json:"device_id,omitempty"`
-	Image     string   `json:"image,omitempty"`
+	Command string   `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	hwaasdut.Params
 }
 
 // Name is the name used to look this plugin up.
@@ -69,21 +61,7 @@ func (ts *TestStep) validateAndPopulate(stepParams test.TestStepParameters) erro
 		}
 	}
 
-	if ts.Host == "" {
-		ts.Host = defaultHost
-	}
-
-	if ts.ContextID == "" {
-		ts.ContextID = defaultContextID
-	}
-
-	if ts.MachineID == "" {
-		ts.MachineID = defaultMachineID
-	}
-
-	if ts.DeviceID == "" {
-		ts.DeviceID = defaultDeviceID
-	}
+	ts.Params.SetDefaults()
 
 	if ts.Command == "" {
 		return fmt.Errorf("missing or empty 'command' parameter")
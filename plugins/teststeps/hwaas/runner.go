@@ -2,11 +2,10 @@ package hwaas
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
+	hwaasdut "github.com/linuxboot/contest/pkg/dut/hwaas"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/events"
 	"github.com/linuxboot/contest/pkg/target"
@@ -42,6 +41,8 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 
 	writeCommand(r.ts.Command, r.ts.Args, &outputBuf)
 
+	d := hwaasdut.New(r.ts.Params, &outputBuf)
+
 	switch r.ts.Command {
 	case power:
 		var err error
@@ -49,7 +50,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		try := 1
 
 		for ; try <= 3; try++ {
-			if err = r.ts.powerCmds(ctx, &outputBuf); err != nil {
+			if err = r.ts.powerCmds(ctx, d, &outputBuf); err != nil {
 				outputBuf.WriteString(fmt.Sprintf("%v failed on try %d\n", err, try))
 
 				time.Sleep(5 * time.Second)
@@ -64,14 +65,14 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		}
 
 	case flash:
-		if err := r.ts.flashCmds(ctx, &outputBuf); err != nil {
+		if err := r.ts.flashCmds(ctx, d); err != nil {
 			outputBuf.WriteString(fmt.Sprintf("%v\n", err))
 
 			return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
 		}
 
 	case keyboard:
-		if err := r.ts.keyboardCmds(ctx, &outputBuf); err != nil {
+		if err := r.ts.keyboardCmds(ctx, d); err != nil {
 			outputBuf.WriteString(fmt.Sprintf("%v\n", err))
 
 			return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
@@ -86,25 +87,3 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 
 	return events.EmitLog(ctx, outputBuf.String(), target, r.ev)
 }
-
-// HTTPRequest triggerers a http request and returns the response. The parameter that can be set are:
-// method: can be every http method
-// endpoint: api endpoint that shall be requested
-// body: the body of the request
-func HTTPRequest(ctx xcontext.Context, method string, endpoint string, body io.Reader) (*http.Response, error) {
-	client := &http.Client{}
-
-	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp, nil
-}
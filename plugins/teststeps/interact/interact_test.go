@@ -0,0 +1,202 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package interact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/transport"
+)
+
+// emitter records the events emitted by the step.
+type emitter struct {
+	mu     sync.Mutex
+	events []testevent.Data
+}
+
+func (e *emitter) Emit(ctx xcontext.Context, data testevent.Data) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, data)
+	return nil
+}
+
+func (e *emitter) lines(t *testing.T) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var lines []string
+	for _, ev := range e.events {
+		if ev.EventName == EventLine {
+			var p LinePayload
+			require.NoError(t, json.Unmarshal(*ev.Payload, &p))
+			lines = append(lines, p.Line)
+		}
+	}
+	return lines
+}
+
+func (e *emitter) message(t *testing.T) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ev := e.events[len(e.events)-1]
+	var p struct{ Msg string }
+	require.NoError(t, json.Unmarshal(*ev.Payload, &p))
+	return p.Msg
+}
+
+// runStep runs the step with the given parameters on a local terminal.
+func runStep(t *testing.T, params string) (*emitter, error) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	stepParams := test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(params)},
+		transport.Keyword: []test.Param{*test.NewParam(`{"proto": "local"}`)},
+	}
+	ts := New().(*TestStep)
+	require.NoError(t, ts.ValidateParameters(ctx, stepParams))
+
+	ev := &emitter{}
+	return ev, NewTargetRunner(ts, ev).Run(ctx, &target.Target{ID: "dut1"})
+}
+
+func TestInteractCapture(t *testing.T) {
+	ev, err := runStep(t, `{
+		"executable": "sh",
+		"args": ["-c", "echo 'IP address: 10.0.0.7'; read name; echo \"hello $name\"; read x"],
+		"vars": {"user": "{{ .ID }}"},
+		"script": [
+			{"expect": "IP address: (?P<ip>[0-9.]+)"},
+			{"send": "${user} from ${ip}\n"},
+			{"expect": "hello dut1 from ${ip}", "timeout": "5s"}
+		]
+	}`)
+	require.NoError(t, err)
+	require.Contains(t, ev.message(t), `Captured ip="10.0.0.7"`)
+	require.Contains(t, ev.lines(t), "IP address: 10.0.0.7")
+	require.Contains(t, ev.lines(t), "hello dut1 from 10.0.0.7")
+}
+
+func TestInteractLoop(t *testing.T) {
+	const menu = `{
+		"executable": "sh",
+		"args": ["-c", "i=0; while true; do echo \"entry $i\"; read key; i=$((i+1)); done"],
+		"script": [
+			{"expect": "entry 0"},
+			{"loop": [{"send": "\n"}], "until": "entry %s", "max": 5, "timeout": "200ms"}
+		]
+	}`
+
+	ev, err := runStep(t, fmt.Sprintf(menu, "3"))
+	require.NoError(t, err)
+	require.Contains(t, ev.message(t), "Loop ended after 3 iterations")
+
+	_, err = runStep(t, fmt.Sprintf(menu, "9"))
+	require.EqualError(t, err, "'entry 9' did not match after 5 iterations")
+}
+
+func TestInteractFail(t *testing.T) {
+	_, err := runStep(t, `{
+		"executable": "sh",
+		"args": ["-c", "echo booting; echo 'Kernel panic - not syncing'; sleep 5"],
+		"fail": ["Kernel panic"],
+		"script": [{"expect": "login:"}]
+	}`)
+	require.EqualError(t, err, `failed to match 'login:': the output line "Kernel panic - not syncing" matched the fail regex 'Kernel panic'`)
+}
+
+func TestInteractTimeout(t *testing.T) {
+	ev, err := runStep(t, `{
+		"executable": "sh",
+		"args": ["-c", "echo booting; sleep 5"],
+		"script": [{"expect": "login:", "timeout": "100ms"}]
+	}`)
+	require.EqualError(t, err, "failed to match 'login:': timed out after 100ms")
+	require.Equal(t, []string{"booting"}, ev.lines(t))
+}
+
+func TestInteractClosed(t *testing.T) {
+	_, err := runStep(t, `{
+		"executable": "sh",
+		"args": ["-c", "echo booting"],
+		"script": [{"expect": "login:"}]
+	}`)
+	require.EqualError(t, err, "failed to match 'login:': the program closed its output")
+}
+
+// The output kept for the expectations and the lines are bounded.
+func TestSessionBufferBound(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	output := strings.Repeat("x", 3*maxBufSize) + "\nlogin: "
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(output), io.Discard}
+	var lines []int
+	s := newSession(conn, map[string]string{}, nil, func(line string) error {
+		lines = append(lines, len(line))
+		return nil
+	}, io.Discard)
+	defer s.close()
+
+	require.EqualError(t, s.expect(ctx, regexp.MustCompile("^x+login"), 0), "the program closed its output")
+	require.Equal(t, maxBufSize, len(s.buf))
+	require.True(t, strings.HasSuffix(string(s.buf), "x\nlogin: "))
+	for _, n := range lines {
+		require.LessOrEqual(t, n, maxBufSize+4096)
+	}
+	require.Equal(t, 3*maxBufSize, sum(lines))
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestInteractValidation(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	local := `{"proto": "local"}`
+	for _, tc := range []struct {
+		params    string
+		transport string
+		err       string
+	}{
+		{`{"script": [{"send": "\n"}]}`, local, "one of 'console' and 'executable' must be set"},
+		{`{"executable": "sh", "console": 0, "script": [{"send": "\n"}]}`, local, "only one of 'console' and 'executable' can be set"},
+		{`{"console": 0, "script": [{"send": "\n"}]}`, local, "transport cannot be set with 'console'"},
+		{`{"executable": "sh", "script": [{"send": "\n"}]}`, "", "transport cannot be empty"},
+		{`{"executable": "sh"}`, local, "script cannot be empty"},
+		{`{"executable": "sh", "script": [{"send": "\n", "expect": "#"}]}`, local, "action 1 must have exactly one of 'send', 'expect', 'sleep' and 'loop'"},
+		{`{"executable": "sh", "script": [{"expect": "("}]}`, local, "action 1: failed to parse the regex '(': error parsing regexp: missing closing ): `(`"},
+		{`{"executable": "sh", "script": [{"send": "\n", "max": 2}]}`, local, "action 1: 'until' and 'max' can only be set with 'loop'"},
+		{`{"executable": "sh", "script": [{"loop": [{"send": "\n"}]}]}`, local, "action 1: a loop needs 'until' or 'max'"},
+		{`{"executable": "sh", "script": [{"loop": [{"sleep": "1s", "until": "#"}], "max": 2}]}`, local, "loop of action 1: action 1: 'until' and 'max' can only be set with 'loop'"},
+		{`{"executable": "sh", "fail": ["["], "script": [{"send": "\n"}]}`, local, "failed to parse the fail regex '[': error parsing regexp: missing closing ]: `[`"},
+	} {
+		stepParams := test.TestStepParameters{
+			parametersKeyword: []test.Param{*test.NewParam(tc.params)},
+		}
+		if tc.transport != "" {
+			stepParams[transport.Keyword] = []test.Param{*test.NewParam(tc.transport)}
+		}
+		require.EqualError(t, New().ValidateParameters(ctx, stepParams), tc.err, tc.params)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package interact implements the Interact test step, which drives an
+// interactive program, e.g. a shell, a UEFI shell or a bootloader menu, with
+// an expect script. The program runs in a terminal through a transport, or is
+// the console of the DUT of the target (see pkg/dut).
+package interact
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/insomniacslk/xjson"
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/transport"
+)
+
+// Name is the name used to look this plugin up.
+const Name = "Interact"
+
+const (
+	parametersKeyword = "parameters"
+)
+
+const (
+	defaultTimeout = 10 * time.Minute
+	// defaultUntilTimeout is the time a loop waits for its until regex after
+	// each iteration.
+	defaultUntilTimeout = time.Second
+)
+
+// EventLine is emitted for every line of output of the program.
+const EventLine = event.Name("InteractLine")
+
+// LinePayload is the payload of EventLine events.
+type LinePayload struct {
+	Line string
+}
+
+// action is an instruction of the script. Exactly one of Send, Expect, Sleep
+// and Loop is set.
+type action struct {
+	// Send is written to the program, e.g. "root\n".
+	Send string `json:"send,omitempty"`
+	// Expect waits for the output to match a regex. The named groups of the
+	// regex are captured into variables.
+	Expect string `json:"expect,omitempty"`
+	// Sleep pauses the script.
+	Sleep xjson.Duration `json:"sleep,omitempty"`
+
+	// Loop repeats actions until the output matches the Until regex, at most
+	// Max times.
+	Loop  []action `json:"loop,omitempty"`
+	Until string   `json:"until,omitempty"`
+	Max   int      `json:"max,omitempty"`
+
+	// Timeout is the time to wait for Expect, or for Until after each
+	// iteration of Loop.
+	Timeout xjson.Duration `json:"timeout,omitempty"`
+}
+
+type parameters struct {
+	// Executable runs in a terminal through the transport.
+	Executable string   `json:"executable,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+	// Console, if set, is the index of the console of the DUT to interact
+	// with instead of running an executable.
+	Console *int `json:"console,omitempty"`
+
+	// Vars are the initial variables of the script, referenced as ${name}.
+	Vars map[string]string `json:"vars,omitempty"`
+	// Fail are regexes which fail the step when an output line matches,
+	// e.g. a kernel panic.
+	Fail   []string `json:"fail,omitempty"`
+	Script []action `json:"script"`
+}
+
+// TestStep implementation for this teststep plugin
+type TestStep struct {
+	parameters
+	transport transport.Parameters
+	options   options.Parameters
+}
+
+// Run executes the step.
+func (ts *TestStep) Run(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters, ev testevent.Emitter, resumeState json.RawMessage) (json.RawMessage, error) {
+	if err := ts.populateParams(params); err != nil {
+		return nil, err
+	}
+
+	tr := NewTargetRunner(ts, ev)
+	return teststeps.ForEachTarget(Name, ctx, ch, tr.Run)
+}
+
+func (ts *TestStep) populateParams(stepParams test.TestStepParameters) error {
+	var parameters, transportParams, optionsParams *test.Param
+
+	if parameters = stepParams.GetOne(parametersKeyword); parameters.IsEmpty() {
+		return fmt.Errorf("parameters cannot be empty")
+	}
+
+	if err := json.Unmarshal(parameters.JSON(), &ts.parameters); err != nil {
+		return fmt.Errorf("failed to deserialize parameters: %v", err)
+	}
+
+	transportParams = stepParams.GetOne(transport.Keyword)
+
+	switch {
+	case ts.Console != nil && ts.Executable != "":
+		return fmt.Errorf("only one of 'console' and 'executable' can be set")
+	case ts.Console == nil && ts.Executable == "":
+		return fmt.Errorf("one of 'console' and 'executable' must be set")
+	case ts.Console != nil && !transportParams.IsEmpty():
+		return fmt.Errorf("transport cannot be set with 'console'")
+	case ts.Executable != "":
+		if transportParams.IsEmpty() {
+			return fmt.Errorf("transport cannot be empty")
+		}
+		if err := json.Unmarshal(transportParams.JSON(), &ts.transport); err != nil {
+			return fmt.Errorf("failed to deserialize transport: %v", err)
+		}
+	}
+
+	optionsParams = stepParams.GetOne(options.Keyword)
+
+	if !optionsParams.IsEmpty() {
+		if err := json.Unmarshal(optionsParams.JSON(), &ts.options); err != nil {
+			return fmt.Errorf("failed to deserialize options: %v", err)
+		}
+	}
+
+	for _, fail := range ts.Fail {
+		if _, err := regexp.Compile(fail); err != nil {
+			return fmt.Errorf("failed to parse the fail regex '%s': %v", fail, err)
+		}
+	}
+
+	if len(ts.Script) == 0 {
+		return fmt.Errorf("script cannot be empty")
+	}
+
+	return validateScript(ts.Script)
+}
+
+// validateScript checks that every action does one thing and that the
+// regexes compile.
+func validateScript(script []action) error {
+	for i, a := range script {
+		var set int
+		for _, isSet := range []bool{a.Send != "", a.Expect != "", a.Sleep != 0, len(a.Loop) != 0} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("action %d must have exactly one of 'send', 'expect', 'sleep' and 'loop'", i+1)
+		}
+
+		for _, regex := range []string{a.Expect, a.Until} {
+			if _, err := compile(regex, nil); err != nil {
+				return fmt.Errorf("action %d: %w", i+1, err)
+			}
+		}
+
+		if len(a.Loop) == 0 {
+			if a.Until != "" || a.Max != 0 {
+				return fmt.Errorf("action %d: 'until' and 'max' can only be set with 'loop'", i+1)
+			}
+			continue
+		}
+		if a.Max < 0 {
+			return fmt.Errorf("action %d: 'max' cannot be negative", i+1)
+		}
+		if a.Until == "" && a.Max == 0 {
+			return fmt.Errorf("action %d: a loop needs 'until' or 'max'", i+1)
+		}
+		if err := validateScript(a.Loop); err != nil {
+			return fmt.Errorf("loop of action %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *TestStep) ValidateParameters(ctx xcontext.Context, params test.TestStepParameters) error {
+	return ts.populateParams(params)
+}
+
+// New initializes and returns a new Interact test step.
+func New() test.TestStep {
+	return &TestStep{}
+}
+
+// Load returns the name, factory and events which are needed to register the
+// step.
+func Load() (string, test.TestStepFactory, []event.Name) {
+	return Name, New, append([]event.Name{EventLine}, events.Events...)
+}
+
+// Name returns the name of the Step
+func (ts TestStep) Name() string {
+	return Name
+}
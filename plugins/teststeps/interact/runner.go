@@ -0,0 +1,173 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package interact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/transport"
+)
+
+const (
+	ssh   = "ssh"
+	local = "local"
+)
+
+type TargetRunner struct {
+	ts *TestStep
+	ev testevent.Emitter
+}
+
+func NewTargetRunner(ts *TestStep, ev testevent.Emitter) *TargetRunner {
+	return &TargetRunner{
+		ts: ts,
+		ev: ev,
+	}
+}
+
+func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
+	var outputBuf strings.Builder
+
+	ctx, cancel := options.NewOptions(ctx, defaultTimeout, r.ts.options.Timeout)
+	defer cancel()
+
+	// The parameters are expanded for each target into a copy, as targets
+	// run concurrently.
+	pe := test.NewParamExpander(target)
+	var params parameters
+	if err := pe.ExpandObject(r.ts.parameters, &params); err != nil {
+		err = fmt.Errorf("failed to expand parameters: %w", err)
+		outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+
+		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
+	}
+
+	if err := r.interact(ctx, &outputBuf, target, &params, pe); err != nil {
+		outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+
+		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
+	}
+
+	return events.EmitLog(ctx, outputBuf.String(), target, r.ev)
+}
+
+func (r *TargetRunner) interact(ctx xcontext.Context, outputBuf *strings.Builder, target *target.Target,
+	params *parameters, pe *test.ParamExpander,
+) error {
+	// maps are not expanded with the other parameters
+	vars := make(map[string]string, len(params.Vars))
+	for name, value := range params.Vars {
+		expanded, err := pe.Expand(value)
+		if err != nil {
+			return fmt.Errorf("failed to expand variable '%s': %w", name, err)
+		}
+		vars[name] = expanded
+	}
+
+	var fail []*regexp.Regexp
+	for _, regex := range params.Fail {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return fmt.Errorf("failed to parse the fail regex '%s': %v", regex, err)
+		}
+		fail = append(fail, re)
+	}
+
+	conn, err := r.open(ctx, outputBuf, target, params, pe)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	onLine := func(line string) error {
+		return emitLine(ctx, line, target, r.ev)
+	}
+	s := newSession(conn, vars, fail, onLine, outputBuf)
+	defer s.close()
+
+	if err := s.run(ctx, params.Script); err != nil {
+		outputBuf.WriteString(fmt.Sprintf("Unmatched output:\n%s\n", s.buf))
+		return err
+	}
+
+	return nil
+}
+
+// open starts the program, or opens the console of the DUT.
+func (r *TargetRunner) open(ctx xcontext.Context, outputBuf *strings.Builder, target *target.Target,
+	params *parameters, pe *test.ParamExpander,
+) (io.ReadWriteCloser, error) {
+	if params.Console != nil {
+		drv, err := dut.OpenTarget(target, outputBuf)
+		if err != nil {
+			return nil, err
+		}
+		console, ok := drv.(dut.Console)
+		if !ok {
+			drv.Close()
+			return nil, dut.Unsupported(drv, "console access")
+		}
+		conn, err := console.OpenConsole(ctx, *params.Console)
+		if err != nil {
+			drv.Close()
+			return nil, fmt.Errorf("failed to open console %d: %w", *params.Console, err)
+		}
+		outputBuf.WriteString(fmt.Sprintf("Opened console %d of the DUT.\n", *params.Console))
+		return &consoleConn{ReadWriteCloser: conn, drv: drv}, nil
+	}
+
+	transportProto, err := transport.NewTransport(r.ts.transport.Proto, []string{ssh, local}, r.ts.transport.Options, pe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	term, err := transportProto.NewTerminal(ctx, params.Executable, params.Args, params.WorkingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start terminal: %w", err)
+	}
+	outputBuf.WriteString(fmt.Sprintf("Started '%s' in a terminal.\n", term))
+
+	return term, nil
+}
+
+// consoleConn is a console which releases its DUT driver when closed.
+type consoleConn struct {
+	io.ReadWriteCloser
+	drv dut.Driver
+}
+
+func (c *consoleConn) Close() error {
+	err := c.ReadWriteCloser.Close()
+	if drvErr := c.drv.Close(); err == nil {
+		err = drvErr
+	}
+	return err
+}
+
+func emitLine(ctx xcontext.Context, line string, tgt *target.Target, ev testevent.Emitter) error {
+	payload, err := json.Marshal(LinePayload{Line: line})
+	if err != nil {
+		return fmt.Errorf("cannot marshal payload for event '%s': %w", EventLine, err)
+	}
+
+	msg := json.RawMessage(payload)
+	return ev.Emit(ctx, testevent.Data{
+		EventName: EventLine,
+		Target:    tgt,
+		Payload:   &msg,
+	})
+}
@@ -0,0 +1,320 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package interact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+// variableRegex matches the references to variables, e.g. ${ip}.
+var variableRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+
+var errTimeout = errors.New("timed out")
+
+// maxBufSize bounds the output kept for the expectations and the length of
+// the lines, the oldest output is dropped beyond it.
+const maxBufSize = 64 << 10
+
+// expand replaces the references to variables in s. Values are quoted when
+// s is a regex. Without vars, references are replaced by placeholders, to
+// validate s.
+func expand(s string, vars map[string]string, quote bool) (string, error) {
+	var err error
+	expanded := variableRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variableRegex.FindStringSubmatch(ref)[1]
+		if vars == nil {
+			return name
+		}
+		value, ok := vars[name]
+		if !ok && err == nil {
+			err = fmt.Errorf("undefined variable '%s'", name)
+		}
+		if quote {
+			return regexp.QuoteMeta(value)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// compile compiles a regex after expanding its variables. It returns nil for
+// an empty regex.
+func compile(regex string, vars map[string]string) (*regexp.Regexp, error) {
+	if regex == "" {
+		return nil, nil
+	}
+	expanded, err := expand(regex, vars, true)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the regex '%s': %v", regex, err)
+	}
+	return re, nil
+}
+
+type chunk struct {
+	data []byte
+	err  error
+}
+
+// session runs a script against the output of a program.
+type session struct {
+	conn   io.Writer
+	chunks chan chunk
+	done   chan struct{}
+
+	vars   map[string]string
+	fail   []*regexp.Regexp
+	onLine func(line string) error
+	log    io.Writer
+
+	buf     []byte // Output not matched by an expectation yet, up to maxBufSize.
+	line    []byte // Last line of the output, until it is complete.
+	readErr error  // Set once the program cannot be read anymore.
+	failErr error  // Set once an output line matches a fail regex.
+}
+
+// newSession starts reading the output of conn. The actions and the captured
+// variables are written to log, and every line of output is passed to onLine.
+func newSession(conn io.ReadWriter, vars map[string]string, fail []*regexp.Regexp, onLine func(line string) error, log io.Writer) *session {
+	s := &session{
+		conn:   conn,
+		chunks: make(chan chunk),
+		done:   make(chan struct{}),
+		vars:   vars,
+		fail:   fail,
+		onLine: onLine,
+		log:    log,
+	}
+	go func() {
+		for {
+			buf := make([]byte, 4096)
+			n, err := conn.Read(buf)
+			select {
+			case s.chunks <- chunk{data: buf[:n], err: err}:
+			case <-s.done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// close stops reading the output and reports its last incomplete line.
+func (s *session) close() {
+	close(s.done)
+	if len(s.line) > 0 {
+		s.processLine(s.line)
+		s.line = nil
+	}
+}
+
+func (s *session) run(ctx xcontext.Context, script []action) error {
+	for _, a := range script {
+		if err := s.do(ctx, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *session) do(ctx xcontext.Context, a action) error {
+	switch {
+	case a.Send != "":
+		send, err := expand(a.Send, s.vars, false)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(s.conn, send); err != nil {
+			return fmt.Errorf("failed to send %q: %w", send, err)
+		}
+		fmt.Fprintf(s.log, "Sent %q\n", send)
+
+	case a.Expect != "":
+		re, err := compile(a.Expect, s.vars)
+		if err != nil {
+			return err
+		}
+		if err := s.expect(ctx, re, time.Duration(a.Timeout)); err != nil {
+			return fmt.Errorf("failed to match '%s': %w", re, err)
+		}
+
+	case a.Sleep != 0:
+		return s.sleep(ctx, time.Duration(a.Sleep))
+
+	case len(a.Loop) != 0:
+		return s.loop(ctx, a)
+	}
+	return nil
+}
+
+// loop runs the actions of a loop until the output matches its until regex,
+// or for its maximum number of iterations.
+func (s *session) loop(ctx xcontext.Context, a action) error {
+	timeout := time.Duration(a.Timeout)
+	if timeout == 0 {
+		timeout = defaultUntilTimeout
+	}
+
+	for i := 1; a.Max == 0 || i <= a.Max; i++ {
+		if err := s.run(ctx, a.Loop); err != nil {
+			return fmt.Errorf("iteration %d: %w", i, err)
+		}
+		if a.Until == "" {
+			continue
+		}
+		until, err := compile(a.Until, s.vars)
+		if err != nil {
+			return err
+		}
+		err = s.expect(ctx, until, timeout)
+		if err == nil {
+			fmt.Fprintf(s.log, "Loop ended after %d iterations\n", i)
+			return nil
+		}
+		if !errors.Is(err, errTimeout) {
+			return fmt.Errorf("failed to match '%s': %w", until, err)
+		}
+	}
+
+	if a.Until != "" {
+		return fmt.Errorf("'%s' did not match after %d iterations", a.Until, a.Max)
+	}
+	return nil
+}
+
+// expect reads the output until it matches re, captures the named groups of
+// re and consumes the output up to the end of the match. A zero timeout
+// waits until ctx is done.
+func (s *session) expect(ctx xcontext.Context, re *regexp.Regexp, timeout time.Duration) error {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		if s.failErr != nil {
+			return s.failErr
+		}
+		if loc := re.FindSubmatchIndex(s.buf); loc != nil {
+			fmt.Fprintf(s.log, "Matched '%s'\n", re)
+			for i, name := range re.SubexpNames() {
+				if name != "" && loc[2*i] >= 0 {
+					s.vars[name] = string(s.buf[loc[2*i]:loc[2*i+1]])
+					fmt.Fprintf(s.log, "Captured %s=%q\n", name, s.vars[name])
+				}
+			}
+			s.buf = s.buf[loc[1]:]
+			return nil
+		}
+		if s.readErr != nil {
+			return s.readErr
+		}
+		if err := s.read(ctx, deadline); err != nil {
+			if errors.Is(err, errTimeout) {
+				return fmt.Errorf("%w after %v", err, timeout)
+			}
+			return err
+		}
+	}
+}
+
+// sleep keeps reading the output for the given time.
+func (s *session) sleep(ctx xcontext.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for s.readErr == nil && s.failErr == nil {
+		if err := s.read(ctx, timer.C); err != nil {
+			if errors.Is(err, errTimeout) {
+				return nil
+			}
+			return err
+		}
+	}
+	if s.failErr != nil {
+		return s.failErr
+	}
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// read waits for the next output of the program, until the deadline or ctx
+// is done. Errors reading the output are recorded in readErr.
+func (s *session) read(ctx xcontext.Context, deadline <-chan time.Time) error {
+	select {
+	case c := <-s.chunks:
+		s.write(c.data)
+		if c.err == io.EOF {
+			s.readErr = errors.New("the program closed its output")
+		} else if c.err != nil {
+			s.readErr = fmt.Errorf("failed to read the output: %w", c.err)
+		}
+		return nil
+	case <-deadline:
+		return errTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// write records output and processes its complete lines. Lines longer than
+// maxBufSize are processed in parts.
+func (s *session) write(data []byte) {
+	s.buf = append(s.buf, data...)
+	if drop := len(s.buf) - maxBufSize; drop > 0 {
+		s.buf = s.buf[drop:]
+	}
+	s.line = append(s.line, data...)
+	for {
+		i := bytes.IndexByte(s.line, '\n')
+		if i < 0 {
+			break
+		}
+		s.processLine(s.line[:i])
+		s.line = s.line[i+1:]
+	}
+	if len(s.line) > maxBufSize {
+		s.processLine(s.line)
+		s.line = nil
+	}
+}
+
+func (s *session) processLine(line []byte) {
+	text := strings.TrimRight(string(line), "\r")
+	if err := s.onLine(text); err != nil {
+		fmt.Fprintf(s.log, "Failed to report the line %q: %v\n", text, err)
+	}
+	if s.failErr != nil {
+		return
+	}
+	for _, re := range s.fail {
+		if re.MatchString(text) {
+			s.failErr = fmt.Errorf("the output line %q matched the fail regex '%s'", text, re)
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	pikvmdut "github.com/linuxboot/contest/pkg/dut/pikvm"
 	"github.com/linuxboot/contest/pkg/event"
 	"github.com/linuxboot/contest/pkg/event/testevent"
 	"github.com/linuxboot/contest/pkg/events"
@@ -26,10 +27,8 @@ const (
 )
 
 type parameters struct {
-	Host     string `json:"host"`
-	Image    string `json:"image"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	pikvmdut.Params
+	Image string `json:"image"`
 }
 
 // TestStep implementation for this teststep plugin
@@ -0,0 +1,155 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package power implements the Power test step, which powers targets on or
+// off through the DUT backend configured for each target (see pkg/dut), so
+// that the same descriptor works whatever controls the targets.
+package power
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+)
+
+// Name is the name used to look this plugin up.
+const Name = "Power"
+
+const (
+	defaultTimeout    = 5 * time.Minute
+	parametersKeyword = "parameters"
+)
+
+// Power actions.
+const (
+	ActionOn    = "on"
+	ActionOff   = "off"
+	ActionCycle = "cycle"
+)
+
+type parameters struct {
+	Action string `json:"action"`
+	// Image, if set, is mounted on the target before powering it on.
+	Image string `json:"image,omitempty"`
+}
+
+// TestStep implementation for this teststep plugin
+type TestStep struct {
+	parameters
+	options options.Parameters
+}
+
+// Name returns the plugin name.
+func (ts TestStep) Name() string {
+	return Name
+}
+
+// Run executes the power action on every target.
+func (ts *TestStep) Run(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters,
+	ev testevent.Emitter, resumeState json.RawMessage,
+) (json.RawMessage, error) {
+	if err := ts.validateAndPopulate(params); err != nil {
+		return nil, err
+	}
+	return teststeps.ForEachTarget(Name, ctx, ch, func(ctx xcontext.Context, target *target.Target) error {
+		var outputBuf strings.Builder
+
+		ctx, cancel := options.NewOptions(ctx, defaultTimeout, ts.options.Timeout)
+		defer cancel()
+
+		if err := ts.power(ctx, target, &outputBuf); err != nil {
+			outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+			return events.EmitError(ctx, outputBuf.String(), target, ev, err)
+		}
+		return events.EmitLog(ctx, outputBuf.String(), target, ev)
+	})
+}
+
+func (ts *TestStep) power(ctx xcontext.Context, target *target.Target, output io.Writer) error {
+	drv, err := dut.OpenTarget(target, output)
+	if err != nil {
+		return err
+	}
+	defer drv.Close()
+	fmt.Fprintf(output, "Powering %s target %s %s.\n", drv.Backend(), target.ID, ts.Action)
+
+	power, ok := drv.(dut.Power)
+	if !ok {
+		return dut.Unsupported(drv, "power control")
+	}
+	if ts.Image != "" && ts.Action != ActionOff {
+		media, ok := drv.(dut.Media)
+		if !ok {
+			return dut.Unsupported(drv, "mounting images")
+		}
+		if err := media.MountImage(ctx, ts.Image); err != nil {
+			return fmt.Errorf("failed to mount image %s: %w", ts.Image, err)
+		}
+	}
+	switch ts.Action {
+	case ActionOn:
+		return power.PowerOn(ctx)
+	case ActionOff:
+		return power.PowerOff(ctx)
+	default:
+		return power.PowerCycle(ctx)
+	}
+}
+
+// Retrieve all the parameters defines through the jobDesc
+func (ts *TestStep) validateAndPopulate(stepParams test.TestStepParameters) error {
+	var parameters, optionsParams *test.Param
+
+	if parameters = stepParams.GetOne(parametersKeyword); parameters.IsEmpty() {
+		return fmt.Errorf("parameters cannot be empty")
+	}
+
+	if err := json.Unmarshal(parameters.JSON(), &ts.parameters); err != nil {
+		return fmt.Errorf("failed to deserialize parameters: %v", err)
+	}
+
+	optionsParams = stepParams.GetOne(options.Keyword)
+
+	if !optionsParams.IsEmpty() {
+		if err := json.Unmarshal(optionsParams.JSON(), &ts.options); err != nil {
+			return fmt.Errorf("failed to deserialize options: %v", err)
+		}
+	}
+
+	switch ts.Action {
+	case ActionOn, ActionOff, ActionCycle:
+	default:
+		return fmt.Errorf("invalid action %q, possible values are '%s', '%s' and '%s'", ts.Action, ActionOn, ActionOff, ActionCycle)
+	}
+
+	return nil
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *TestStep) ValidateParameters(_ xcontext.Context, params test.TestStepParameters) error {
+	return ts.validateAndPopulate(params)
+}
+
+// New initializes and returns a new Power test step.
+func New() test.TestStep {
+	return &TestStep{}
+}
+
+// Load returns the name, factory and events which are needed to register the step.
+func Load() (string, test.TestStepFactory, []event.Name) {
+	return Name, New, events.Events
+}
@@ -0,0 +1,137 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package power
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/dut"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+// fakeDriver records the operations run by the step.
+type fakeDriver struct {
+	ops    []string
+	closed bool
+}
+
+func (d *fakeDriver) Backend() string { return "fake" }
+func (d *fakeDriver) Close() error    { d.closed = true; return nil }
+
+func (d *fakeDriver) PowerOn(ctx xcontext.Context) error {
+	d.ops = append(d.ops, "on")
+	return nil
+}
+
+func (d *fakeDriver) PowerOff(ctx xcontext.Context) error {
+	d.ops = append(d.ops, "off")
+	return errors.New("stuck")
+}
+
+func (d *fakeDriver) PowerCycle(ctx xcontext.Context) error {
+	d.ops = append(d.ops, "cycle")
+	return nil
+}
+
+func (d *fakeDriver) MountImage(ctx xcontext.Context, imagePath string) error {
+	d.ops = append(d.ops, "mount "+imagePath)
+	return nil
+}
+
+// bareDriver has no capability.
+type bareDriver struct{}
+
+func (bareDriver) Backend() string { return "bare" }
+func (bareDriver) Close() error    { return nil }
+
+// powerOnly cannot mount images.
+type powerOnly struct{ fakeDriver }
+
+func (d *powerOnly) Backend() string { return "poweronly" }
+
+var lastDriver *fakeDriver
+
+func init() {
+	dut.Register("fake", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		lastDriver = &fakeDriver{}
+		return lastDriver, nil
+	})
+	dut.Register("poweronly", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		drv := &powerOnly{}
+		lastDriver = &drv.fakeDriver
+		return struct {
+			dut.Driver
+			dut.Power
+		}{drv, drv}, nil
+	})
+	dut.Register("bare", func(params map[string]string, output io.Writer) (dut.Driver, error) {
+		return bareDriver{}, nil
+	})
+}
+
+func runPower(t *testing.T, backend, params string) (string, error) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	ts := New().(*TestStep)
+	require.NoError(t, ts.ValidateParameters(ctx, test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(params)},
+	}))
+
+	var output strings.Builder
+	err := ts.power(ctx, &target.Target{
+		ID:                 "board1",
+		TargetManagerState: []byte(`{"DUT": {"Backend": "` + backend + `"}}`),
+	}, &output)
+	return output.String(), err
+}
+
+func TestPower(t *testing.T) {
+	output, err := runPower(t, "fake", `{"action": "on", "image": "/tmp/os.iso"}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mount /tmp/os.iso", "on"}, lastDriver.ops)
+	require.True(t, lastDriver.closed)
+	require.Contains(t, output, "Powering fake target board1 on.")
+
+	_, err = runPower(t, "fake", `{"action": "cycle"}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{"cycle"}, lastDriver.ops)
+
+	// The image is not mounted to power off.
+	_, err = runPower(t, "fake", `{"action": "off", "image": "/tmp/os.iso"}`)
+	require.EqualError(t, err, "stuck")
+	require.Equal(t, []string{"off"}, lastDriver.ops)
+}
+
+func TestPowerUnsupported(t *testing.T) {
+	_, err := runPower(t, "bare", `{"action": "on"}`)
+	require.True(t, errors.Is(err, dut.ErrUnsupported))
+	require.EqualError(t, err, "power control is not supported by the DUT backend bare")
+
+	_, err = runPower(t, "poweronly", `{"action": "on", "image": "/tmp/os.iso"}`)
+	require.True(t, errors.Is(err, dut.ErrUnsupported))
+	require.EqualError(t, err, "mounting images is not supported by the DUT backend poweronly")
+	require.Empty(t, lastDriver.ops)
+
+	_, err = runPower(t, "unknown", `{"action": "on"}`)
+	require.Error(t, err)
+}
+
+func TestValidateParameters(t *testing.T) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	err := New().ValidateParameters(ctx, test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(`{"action": "reboot"}`)},
+	})
+	require.Error(t, err)
+	require.Error(t, New().ValidateParameters(ctx, test.TestStepParameters{}))
+}
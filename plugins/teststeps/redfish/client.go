@@ -0,0 +1,474 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package redfish
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/xcontext"
+)
+
+const serviceRoot = "/redfish/v1"
+
+// client talks to the Redfish service of a BMC with basic authentication.
+type client struct {
+	base     string
+	username string
+	password string
+	http     *http.Client
+}
+
+// The transports are shared by the clients of all the targets, so that the
+// idle connections to the BMCs are reused and eventually closed.
+var (
+	secureTransport   = newTransport(false)
+	insecureTransport = newTransport(true)
+)
+
+func newTransport(insecure bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
+	return transport
+}
+
+func newClient(host, username, password string, insecure bool) *client {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	transport := secureTransport
+	if insecure {
+		transport = insecureTransport
+	}
+	return &client{
+		base:     strings.TrimSuffix(host, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Transport: transport},
+	}
+}
+
+// odataID is a link to a resource.
+type odataID struct {
+	ID string `json:"@odata.id"`
+}
+
+type collection struct {
+	Members  []odataID `json:"Members"`
+	NextLink string    `json:"Members@odata.nextLink"`
+}
+
+type actionTarget struct {
+	Target string `json:"target"`
+}
+
+// redfishError is the error response of a Redfish service.
+type redfishError struct {
+	Error struct {
+		Code         string `json:"code"`
+		Message      string `json:"message"`
+		ExtendedInfo []struct {
+			Message string `json:"Message"`
+		} `json:"@Message.ExtendedInfo"`
+	} `json:"error"`
+}
+
+// do sends a request to the path of the service and returns the response if
+// its status is successful.
+func (c *client) do(ctx xcontext.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg := resp.Status
+		var rerr redfishError
+		if data, err := io.ReadAll(resp.Body); err == nil && json.Unmarshal(data, &rerr) == nil && rerr.Error.Message != "" {
+			msg += ": " + rerr.Error.Message
+			for _, info := range rerr.Error.ExtendedInfo {
+				msg += " " + info.Message
+			}
+		}
+		return nil, fmt.Errorf("%s %s failed: %s", method, path, msg)
+	}
+	return resp, nil
+}
+
+// get decodes the resource at path into out.
+func (c *client) get(ctx xcontext.Context, path string, out interface{}) error {
+	resp, err := c.do(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// send sends in as the JSON body of a request to path.
+func (c *client) send(ctx xcontext.Context, method, path string, in interface{}) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, method, path, bytes.NewReader(body), "application/json")
+}
+
+// members returns the links of all the members of the collection at path,
+// following the pagination of the collection.
+func (c *client) members(ctx xcontext.Context, path string) ([]string, error) {
+	var links []string
+	for path != "" {
+		var coll collection
+		if err := c.get(ctx, path, &coll); err != nil {
+			return nil, err
+		}
+		for _, m := range coll.Members {
+			links = append(links, m.ID)
+		}
+		path = coll.NextLink
+	}
+	return links, nil
+}
+
+// system returns the path of the computer system with the given Id, or of
+// the first system if id is empty.
+func (c *client) system(ctx xcontext.Context, id string) (string, error) {
+	if id != "" {
+		return serviceRoot + "/Systems/" + id, nil
+	}
+	systems, err := c.members(ctx, serviceRoot+"/Systems")
+	if err != nil {
+		return "", err
+	}
+	if len(systems) == 0 {
+		return "", fmt.Errorf("the BMC has no computer system")
+	}
+	return systems[0], nil
+}
+
+// reset runs the Reset action of a computer system.
+func (c *client) reset(ctx xcontext.Context, system, resetType string) error {
+	var sys struct {
+		Actions struct {
+			Reset actionTarget `json:"#ComputerSystem.Reset"`
+		} `json:"Actions"`
+	}
+	if err := c.get(ctx, system, &sys); err != nil {
+		return err
+	}
+	target := sys.Actions.Reset.Target
+	if target == "" {
+		target = system + "/Actions/ComputerSystem.Reset"
+	}
+	resp, err := c.send(ctx, http.MethodPost, target, map[string]string{"ResetType": resetType})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// setBootOverride sets the boot source of the next boot of a computer system.
+func (c *client) setBootOverride(ctx xcontext.Context, system, bootTarget, bootMode string) error {
+	boot := map[string]string{
+		"BootSourceOverrideEnabled": "Once",
+		"BootSourceOverrideTarget":  bootTarget,
+	}
+	if bootMode != "" {
+		boot["BootSourceOverrideMode"] = bootMode
+	}
+	resp, err := c.send(ctx, http.MethodPatch, system, map[string]interface{}{"Boot": boot})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type updateService struct {
+	MultipartHTTPPushURI string `json:"MultipartHttpPushUri"`
+	Actions              struct {
+		SimpleUpdate actionTarget `json:"#UpdateService.SimpleUpdate"`
+	} `json:"Actions"`
+}
+
+// simpleUpdate has the BMC fetch and apply the image at imageURI. It returns
+// the path of the task monitoring the update, or an empty path if the update
+// is already done.
+func (c *client) simpleUpdate(ctx xcontext.Context, imageURI, protocol string, targets []string) (string, error) {
+	var us updateService
+	if err := c.get(ctx, serviceRoot+"/UpdateService", &us); err != nil {
+		return "", err
+	}
+	target := us.Actions.SimpleUpdate.Target
+	if target == "" {
+		target = serviceRoot + "/UpdateService/Actions/UpdateService.SimpleUpdate"
+	}
+	req := map[string]interface{}{"ImageURI": imageURI}
+	if protocol != "" {
+		req["TransferProtocol"] = protocol
+	}
+	if len(targets) > 0 {
+		req["Targets"] = targets
+	}
+	resp, err := c.send(ctx, http.MethodPost, target, req)
+	if err != nil {
+		return "", err
+	}
+	return taskLocation(resp)
+}
+
+// multipartUpdate pushes the image at path to the BMC and has it applied. It
+// returns the path of the task monitoring the update, or an empty path if
+// the update is already done.
+func (c *client) multipartUpdate(ctx xcontext.Context, path string, targets []string) (string, error) {
+	var us updateService
+	if err := c.get(ctx, serviceRoot+"/UpdateService", &us); err != nil {
+		return "", err
+	}
+	if us.MultipartHTTPPushURI == "" {
+		return "", fmt.Errorf("the BMC does not support multipart HTTP push updates")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	// The image is streamed to the BMC rather than buffered in memory.
+	body, bodyWriter := io.Pipe()
+	defer body.Close()
+	writer := multipart.NewWriter(bodyWriter)
+	go func() {
+		bodyWriter.CloseWithError(writeUpdateForm(writer, file, targets))
+	}()
+
+	resp, err := c.do(ctx, http.MethodPost, us.MultipartHTTPPushURI, body, writer.FormDataContentType())
+	if err != nil {
+		return "", err
+	}
+	return taskLocation(resp)
+}
+
+// writeUpdateForm writes the update parameters and the image of a multipart
+// HTTP push update.
+func writeUpdateForm(writer *multipart.Writer, file *os.File, targets []string) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="UpdateParameters"`)
+	header.Set("Content-Type", "application/json")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if targets == nil {
+		targets = []string{}
+	}
+	if err := json.NewEncoder(part).Encode(map[string]interface{}{"Targets": targets}); err != nil {
+		return err
+	}
+	part, err = writer.CreateFormFile("UpdateFile", filepath.Base(file.Name()))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	return writer.Close()
+}
+
+// taskLocation returns the path of the task created by an asynchronous
+// operation, from the Location header or the body of the response.
+func taskLocation(resp *http.Response) (string, error) {
+	defer resp.Body.Close()
+	if loc := resp.Header.Get("Location"); loc != "" {
+		// The location may be an absolute URL.
+		u, err := url.Parse(loc)
+		if err != nil {
+			return "", fmt.Errorf("invalid task location %q: %w", loc, err)
+		}
+		return u.RequestURI(), nil
+	}
+	var task odataID
+	if data, err := io.ReadAll(resp.Body); err == nil && len(data) > 0 {
+		_ = json.Unmarshal(data, &task)
+	}
+	if strings.Contains(task.ID, "/Task") {
+		return task.ID, nil
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		return "", fmt.Errorf("the BMC accepted the update without returning its task")
+	}
+	return "", nil
+}
+
+type task struct {
+	ID              string `json:"Id"`
+	TaskState       string `json:"TaskState"`
+	TaskStatus      string `json:"TaskStatus"`
+	PercentComplete *int   `json:"PercentComplete"`
+	Messages        []struct {
+		Message string `json:"Message"`
+	} `json:"Messages"`
+}
+
+// waitTask polls the task at path until it finishes, and returns an error if
+// it did not complete successfully. Progress is written to output.
+func (c *client) waitTask(ctx xcontext.Context, path string, interval time.Duration, output io.Writer) error {
+	lastState := ""
+	for {
+		var t task
+		if err := c.get(ctx, path, &t); err != nil {
+			return err
+		}
+		state := t.TaskState
+		if t.PercentComplete != nil {
+			state += fmt.Sprintf(" (%d%%)", *t.PercentComplete)
+		}
+		if state != lastState {
+			fmt.Fprintf(output, "Task %s: %s\n", path, state)
+			lastState = state
+		}
+
+		switch t.TaskState {
+		case "Completed":
+			if t.TaskStatus == "Critical" {
+				return fmt.Errorf("task %s failed: %s", path, t.messages())
+			}
+			return nil
+		case "Exception", "Killed", "Cancelled":
+			return fmt.Errorf("task %s ended in state %s: %s", path, t.TaskState, t.messages())
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for task %s: %w", path, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (t *task) messages() string {
+	msgs := make([]string, 0, len(t.Messages))
+	for _, m := range t.Messages {
+		msgs = append(msgs, m.Message)
+	}
+	return strings.Join(msgs, " ")
+}
+
+// logService is a log service and its entries.
+type logService struct {
+	// Path is the path of the service relative to the service root, e.g.
+	// Systems/1/LogServices/SEL.
+	Path    string
+	ID      string
+	Entries []json.RawMessage
+}
+
+// logServices returns the log services of a computer system and of the
+// managers, with their entries. If ids is not empty, only the services with
+// one of the given Ids are returned.
+func (c *client) logServices(ctx xcontext.Context, system string, ids []string) ([]logService, error) {
+	parents := []string{system}
+	managers, err := c.members(ctx, serviceRoot+"/Managers")
+	if err != nil {
+		return nil, err
+	}
+	parents = append(parents, managers...)
+
+	var services []logService
+	for _, parent := range parents {
+		var res struct {
+			LogServices *odataID `json:"LogServices"`
+		}
+		if err := c.get(ctx, parent, &res); err != nil {
+			return nil, err
+		}
+		if res.LogServices == nil {
+			continue
+		}
+		links, err := c.members(ctx, res.LogServices.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			var svc struct {
+				ID      string   `json:"Id"`
+				Entries *odataID `json:"Entries"`
+			}
+			if err := c.get(ctx, link, &svc); err != nil {
+				return nil, err
+			}
+			if !selected(svc.ID, ids) || svc.Entries == nil {
+				continue
+			}
+			entries, err := c.entries(ctx, svc.Entries.ID)
+			if err != nil {
+				return nil, err
+			}
+			services = append(services, logService{
+				Path:    strings.TrimPrefix(link, serviceRoot+"/"),
+				ID:      svc.ID,
+				Entries: entries,
+			})
+		}
+	}
+	return services, nil
+}
+
+// entries returns all the members of the log entry collection at path.
+func (c *client) entries(ctx xcontext.Context, path string) ([]json.RawMessage, error) {
+	entries := []json.RawMessage{}
+	for path != "" {
+		var coll struct {
+			Members  []json.RawMessage `json:"Members"`
+			NextLink string            `json:"Members@odata.nextLink"`
+		}
+		if err := c.get(ctx, path, &coll); err != nil {
+			return nil, err
+		}
+		entries = append(entries, coll.Members...)
+		path = coll.NextLink
+	}
+	return entries, nil
+}
+
+func selected(id string, ids []string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, i := range ids {
+		if strings.EqualFold(i, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package redfish implements the Redfish test step, which controls the BMC of
+// targets through the standard Redfish API: power actions, one-time boot
+// source override, firmware update and collection of the log entries.
+package redfish
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/insomniacslk/xjson"
+
+	"github.com/linuxboot/contest/pkg/event"
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+)
+
+// Name is the name used to look this plugin up.
+const Name = "Redfish"
+
+const (
+	defaultTimeout      = 10 * time.Minute
+	defaultPollInterval = 5 * time.Second
+	parametersKeyword   = "parameters"
+)
+
+// Commands of the step.
+const (
+	CommandPower  = "power"
+	CommandBoot   = "boot"
+	CommandUpdate = "update"
+	CommandLogs   = "logs"
+)
+
+// resetTypes are the values of the Redfish ResetType enum.
+var resetTypes = []string{
+	"On", "ForceOff", "GracefulShutdown", "GracefulRestart", "ForceRestart",
+	"Nmi", "ForceOn", "PushPowerButton", "PowerCycle",
+}
+
+// parameters of the step, all string parameters are expanded as templates
+// for each target, e.g. "https://{{ .FQDN }}-bmc".
+type parameters struct {
+	// Host is the URL of the BMC, https is assumed if it has no scheme.
+	Host     string `json:"host"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Insecure skips the verification of the certificate of the BMC.
+	Insecure bool `json:"insecure,omitempty"`
+	// SystemID selects the computer system of the BMC, by default the first
+	// member of the Systems collection.
+	SystemID string `json:"system_id,omitempty"`
+	Command  string `json:"command"`
+
+	// ResetType is the power action of the power command, e.g. On,
+	// ForceOff or GracefulRestart.
+	ResetType string `json:"reset_type,omitempty"`
+
+	// BootTarget is the boot source of the next boot for the boot command,
+	// e.g. Pxe, Hdd, Cd, Usb or BiosSetup, and BootMode optionally sets the
+	// mode of that boot, UEFI or Legacy.
+	BootTarget string `json:"boot_target,omitempty"`
+	BootMode   string `json:"boot_mode,omitempty"`
+
+	// The update command either has the BMC fetch the image at ImageURI with
+	// the SimpleUpdate action, or pushes the local File to the BMC with a
+	// multipart HTTP push update. Targets optionally restricts the update to
+	// the given resources.
+	ImageURI         string   `json:"image_uri,omitempty"`
+	TransferProtocol string   `json:"transfer_protocol,omitempty"`
+	File             string   `json:"file,omitempty"`
+	Targets          []string `json:"targets,omitempty"`
+	// PollInterval is the interval between the polls of the update task.
+	PollInterval xjson.Duration `json:"poll_interval,omitempty"`
+
+	// LogServices restricts the logs command to the log services with the
+	// given Ids, e.g. SEL. By default the entries of all the log services of
+	// the system and of the managers are collected.
+	LogServices []string `json:"log_services,omitempty"`
+}
+
+// TestStep implementation for this teststep plugin
+type TestStep struct {
+	parameters
+	options options.Parameters
+}
+
+// Name returns the plugin name.
+func (ts TestStep) Name() string {
+	return Name
+}
+
+// Run executes the Redfish command on every target.
+func (ts *TestStep) Run(ctx xcontext.Context, ch test.TestStepChannels, params test.TestStepParameters,
+	ev testevent.Emitter, resumeState json.RawMessage,
+) (json.RawMessage, error) {
+	if err := ts.validateAndPopulate(params); err != nil {
+		return nil, err
+	}
+
+	tr := NewTargetRunner(ts, ev)
+	return teststeps.ForEachTarget(Name, ctx, ch, tr.Run)
+}
+
+// Retrieve all the parameters defines through the jobDesc
+func (ts *TestStep) validateAndPopulate(stepParams test.TestStepParameters) error {
+	var parameters, optionsParams *test.Param
+
+	if parameters = stepParams.GetOne(parametersKeyword); parameters.IsEmpty() {
+		return fmt.Errorf("parameters cannot be empty")
+	}
+
+	if err := json.Unmarshal(parameters.JSON(), &ts.parameters); err != nil {
+		return fmt.Errorf("failed to deserialize parameters: %v", err)
+	}
+
+	optionsParams = stepParams.GetOne(options.Keyword)
+
+	if !optionsParams.IsEmpty() {
+		if err := json.Unmarshal(optionsParams.JSON(), &ts.options); err != nil {
+			return fmt.Errorf("failed to deserialize options: %v", err)
+		}
+	}
+
+	if ts.Host == "" {
+		return fmt.Errorf("host must not be empty")
+	}
+
+	switch ts.Command {
+	case CommandPower:
+		if !validResetType(ts.ResetType) {
+			return fmt.Errorf("invalid reset_type %q, possible values are %v", ts.ResetType, resetTypes)
+		}
+	case CommandBoot:
+		if ts.BootTarget == "" {
+			return fmt.Errorf("boot_target must not be empty")
+		}
+	case CommandUpdate:
+		if (ts.ImageURI == "") == (ts.File == "") {
+			return fmt.Errorf("exactly one of image_uri and file must be set")
+		}
+	case CommandLogs:
+	default:
+		return fmt.Errorf("invalid command %q, possible values are '%s', '%s', '%s' and '%s'",
+			ts.Command, CommandPower, CommandBoot, CommandUpdate, CommandLogs)
+	}
+
+	if ts.PollInterval <= 0 {
+		ts.PollInterval = xjson.Duration(defaultPollInterval)
+	}
+
+	return nil
+}
+
+func validResetType(resetType string) bool {
+	for _, t := range resetTypes {
+		if t == resetType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateParameters validates the parameters associated to the TestStep
+func (ts *TestStep) ValidateParameters(_ xcontext.Context, params test.TestStepParameters) error {
+	return ts.validateAndPopulate(params)
+}
+
+// New initializes and returns a new Redfish test step.
+func New() test.TestStep {
+	return &TestStep{}
+}
+
+// Load returns the name, factory and events which are needed to register the step.
+func Load() (string, test.TestStepFactory, []event.Name) {
+	return Name, New, events.Events
+}
@@ -0,0 +1,292 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package redfish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
+	"github.com/linuxboot/contest/pkg/xcontext/logger"
+)
+
+// mockBMC is a minimal Redfish service with one system and one manager.
+type mockBMC struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]string
+	polls    int
+	taskFail bool
+}
+
+func newMockBMC(t *testing.T) *httptest.Server {
+	bmc := &mockBMC{bodies: map[string]string{}}
+	srv := httptest.NewServer(bmc)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (m *mockBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, pass, ok := r.BasicAuth()
+	if !ok || user != "admin" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"code": "Base.1.0.GeneralError", "message": "Unauthorized"}}`)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, r.Method+" "+r.URL.Path)
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		m.bodies[r.Method+" "+r.URL.Path] = string(body)
+	}
+
+	reply := func(v string) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, v)
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /redfish/v1/Systems":
+		reply(`{"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`)
+	case "GET /redfish/v1/Systems/1":
+		reply(`{"Id": "1",
+			"Actions": {"#ComputerSystem.Reset": {"target": "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset"}},
+			"LogServices": {"@odata.id": "/redfish/v1/Systems/1/LogServices"}}`)
+	case "POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset":
+		var req struct{ ResetType string }
+		if json.Unmarshal(body, &req) != nil || req.ResetType == "Nmi" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"code": "Base.1.0.ActionNotSupported", "message": "The action is not supported.",
+				"@Message.ExtendedInfo": [{"Message": "Nmi is not allowed."}]}}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "PATCH /redfish/v1/Systems/1":
+		w.WriteHeader(http.StatusNoContent)
+	case "GET /redfish/v1/Systems/1/LogServices":
+		reply(`{"Members": [{"@odata.id": "/redfish/v1/Systems/1/LogServices/SEL"}, {"@odata.id": "/redfish/v1/Systems/1/LogServices/Debug"}]}`)
+	case "GET /redfish/v1/Systems/1/LogServices/SEL":
+		reply(`{"Id": "SEL", "Entries": {"@odata.id": "/redfish/v1/Systems/1/LogServices/SEL/Entries"}}`)
+	case "GET /redfish/v1/Systems/1/LogServices/SEL/Entries":
+		if r.URL.Query().Get("$skip") == "" {
+			reply(`{"Members": [{"Id": "1", "Message": "Power on"}],
+				"Members@odata.nextLink": "/redfish/v1/Systems/1/LogServices/SEL/Entries?$skip=1"}`)
+			return
+		}
+		reply(`{"Members": [{"Id": "2", "Message": "Watchdog timeout"}]}`)
+	case "GET /redfish/v1/Systems/1/LogServices/Debug":
+		reply(`{"Id": "Debug", "Entries": {"@odata.id": "/redfish/v1/Systems/1/LogServices/Debug/Entries"}}`)
+	case "GET /redfish/v1/Systems/1/LogServices/Debug/Entries":
+		reply(`{"Members": []}`)
+	case "GET /redfish/v1/Managers":
+		reply(`{"Members": [{"@odata.id": "/redfish/v1/Managers/bmc"}]}`)
+	case "GET /redfish/v1/Managers/bmc":
+		reply(`{"Id": "bmc", "LogServices": {"@odata.id": "/redfish/v1/Managers/bmc/LogServices"}}`)
+	case "GET /redfish/v1/Managers/bmc/LogServices":
+		reply(`{"Members": [{"@odata.id": "/redfish/v1/Managers/bmc/LogServices/Journal"}]}`)
+	case "GET /redfish/v1/Managers/bmc/LogServices/Journal":
+		reply(`{"Id": "Journal", "Entries": {"@odata.id": "/redfish/v1/Managers/bmc/LogServices/Journal/Entries"}}`)
+	case "GET /redfish/v1/Managers/bmc/LogServices/Journal/Entries":
+		reply(`{"Members": [{"Id": "1", "Message": "BMC started"}]}`)
+	case "GET /redfish/v1/UpdateService":
+		reply(`{"MultipartHttpPushUri": "/redfish/v1/UpdateService/update",
+			"Actions": {"#UpdateService.SimpleUpdate": {"target": "/redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate"}}}`)
+	case "POST /redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate":
+		w.Header().Set("Location", "http://"+r.Host+"/redfish/v1/TaskService/Tasks/1")
+		w.WriteHeader(http.StatusAccepted)
+	case "POST /redfish/v1/UpdateService/update":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err == nil {
+			reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+			// Keep the parts instead of the raw body, which has random
+			// boundaries.
+			var parts []string
+			for part, err := reader.NextPart(); err == nil; part, err = reader.NextPart() {
+				data, _ := io.ReadAll(part)
+				parts = append(parts, part.FormName()+"="+strings.TrimSpace(string(data)))
+			}
+			m.bodies[r.Method+" "+r.URL.Path] = strings.Join(parts, "&")
+		}
+		w.WriteHeader(http.StatusAccepted)
+		reply(`{"@odata.id": "/redfish/v1/TaskService/Tasks/1", "TaskState": "New"}`)
+	case "GET /redfish/v1/TaskService/Tasks/1":
+		m.polls++
+		switch {
+		case m.polls < 3:
+			reply(fmt.Sprintf(`{"Id": "1", "TaskState": "Running", "PercentComplete": %d}`, m.polls*40))
+		case m.taskFail:
+			reply(`{"Id": "1", "TaskState": "Exception", "TaskStatus": "Critical", "Messages": [{"Message": "Image is corrupt."}]}`)
+		default:
+			reply(`{"Id": "1", "TaskState": "Completed", "TaskStatus": "OK", "PercentComplete": 100}`)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (m *mockBMC) body(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bodies[key]
+}
+
+// emitter records the events emitted by the step.
+type emitter struct {
+	mu     sync.Mutex
+	events []testevent.Data
+}
+
+func (e *emitter) Emit(ctx xcontext.Context, data testevent.Data) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, data)
+	return nil
+}
+
+func (e *emitter) outputs(t *testing.T) map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	outputs := map[string]string{}
+	for _, ev := range e.events {
+		if ev.EventName == events.EventOutput {
+			var c events.Component
+			require.NoError(t, json.Unmarshal(*ev.Payload, &c))
+			outputs[c.Name] = string(c.Data)
+		}
+	}
+	return outputs
+}
+
+func (e *emitter) message(t *testing.T) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ev := e.events[len(e.events)-1]
+	var p struct{ Msg string }
+	require.NoError(t, json.Unmarshal(*ev.Payload, &p))
+	return p.Msg
+}
+
+// runStep runs the step with the given parameters on a target whose FQDN is
+// the address of the mock BMC.
+func runStep(t *testing.T, srv *httptest.Server, params string) (*emitter, error) {
+	ctx := logrusctx.NewContext(logger.LevelDebug)
+	stepParams := test.TestStepParameters{
+		parametersKeyword: []test.Param{*test.NewParam(params)},
+	}
+	ts := New().(*TestStep)
+	if err := ts.ValidateParameters(ctx, stepParams); err != nil {
+		return nil, err
+	}
+	ts.PollInterval = 1
+
+	ev := &emitter{}
+	tgt := &target.Target{ID: "dut1", FQDN: strings.TrimPrefix(srv.URL, "http://")}
+	return ev, NewTargetRunner(ts, ev).Run(ctx, tgt)
+}
+
+const auth = `"host": "http://{{ .FQDN }}", "username": "admin", "password": "secret"`
+
+func TestRedfishPower(t *testing.T) {
+	srv := newMockBMC(t)
+	bmc := srv.Config.Handler.(*mockBMC)
+
+	_, err := runStep(t, srv, `{`+auth+`, "command": "power", "reset_type": "GracefulRestart"}`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ResetType": "GracefulRestart"}`, bmc.body("POST /redfish/v1/Systems/1/Actions/ComputerSystem.Reset"))
+
+	ev, err := runStep(t, srv, `{`+auth+`, "command": "power", "reset_type": "Nmi"}`)
+	require.Error(t, err)
+	require.Contains(t, ev.message(t), "The action is not supported. Nmi is not allowed.")
+
+	_, err = runStep(t, srv, `{"host": "http://{{ .FQDN }}", "command": "power", "reset_type": "On"}`)
+	require.ErrorContains(t, err, "401 Unauthorized: Unauthorized")
+
+	_, err = runStep(t, srv, `{`+auth+`, "command": "power", "reset_type": "Off"}`)
+	require.ErrorContains(t, err, "invalid reset_type")
+}
+
+func TestRedfishBoot(t *testing.T) {
+	srv := newMockBMC(t)
+	bmc := srv.Config.Handler.(*mockBMC)
+
+	_, err := runStep(t, srv, `{`+auth+`, "system_id": "1", "command": "boot", "boot_target": "Pxe", "boot_mode": "UEFI"}`)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"Boot": {"BootSourceOverrideEnabled": "Once", "BootSourceOverrideTarget": "Pxe", "BootSourceOverrideMode": "UEFI"}}`,
+		bmc.body("PATCH /redfish/v1/Systems/1"))
+	require.NotContains(t, bmc.requests, "GET /redfish/v1/Systems")
+}
+
+func TestRedfishSimpleUpdate(t *testing.T) {
+	srv := newMockBMC(t)
+	bmc := srv.Config.Handler.(*mockBMC)
+
+	ev, err := runStep(t, srv, `{`+auth+`, "command": "update", "image_uri": "http://files/bios-{{ .ID }}.bin", "transfer_protocol": "HTTP"}`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"ImageURI": "http://files/bios-dut1.bin", "TransferProtocol": "HTTP"}`,
+		bmc.body("POST /redfish/v1/UpdateService/Actions/UpdateService.SimpleUpdate"))
+	require.Equal(t, 3, bmc.polls)
+	require.Contains(t, ev.message(t), "Running (80%)")
+	require.Contains(t, ev.message(t), "Successfully updated the firmware.")
+}
+
+func TestRedfishMultipartUpdate(t *testing.T) {
+	srv := newMockBMC(t)
+	bmc := srv.Config.Handler.(*mockBMC)
+	bmc.taskFail = true
+
+	file := filepath.Join(t.TempDir(), "bios.bin")
+	require.NoError(t, os.WriteFile(file, []byte("firmware"), 0o600))
+
+	_, err := runStep(t, srv, `{`+auth+`, "command": "update", "file": "`+file+`", "targets": ["/redfish/v1/UpdateService/FirmwareInventory/BIOS"]}`)
+	require.ErrorContains(t, err, "ended in state Exception: Image is corrupt.")
+	require.Equal(t,
+		`UpdateParameters={"Targets":["/redfish/v1/UpdateService/FirmwareInventory/BIOS"]}&UpdateFile=firmware`,
+		bmc.body("POST /redfish/v1/UpdateService/update"))
+
+	_, err = runStep(t, srv, `{`+auth+`, "command": "update", "file": "`+file+`", "image_uri": "http://files/bios.bin"}`)
+	require.ErrorContains(t, err, "exactly one of image_uri and file must be set")
+}
+
+func TestRedfishLogs(t *testing.T) {
+	srv := newMockBMC(t)
+
+	ev, err := runStep(t, srv, `{`+auth+`, "command": "logs"}`)
+	require.NoError(t, err)
+	outputs := ev.outputs(t)
+	require.Len(t, outputs, 3)
+	require.JSONEq(t, `[{"Id": "1", "Message": "Power on"}, {"Id": "2", "Message": "Watchdog timeout"}]`, outputs["Systems/1/LogServices/SEL"])
+	require.JSONEq(t, `[]`, outputs["Systems/1/LogServices/Debug"])
+	require.JSONEq(t, `[{"Id": "1", "Message": "BMC started"}]`, outputs["Managers/bmc/LogServices/Journal"])
+
+	ev, err = runStep(t, srv, `{`+auth+`, "command": "logs", "log_services": ["sel"]}`)
+	require.NoError(t, err)
+	outputs = ev.outputs(t)
+	require.Len(t, outputs, 1)
+	require.Contains(t, outputs, "Systems/1/LogServices/SEL")
+
+	_, err = runStep(t, srv, `{`+auth+`, "command": "logs", "log_services": ["Audit"]}`)
+	require.ErrorContains(t, err, "no log service found")
+}
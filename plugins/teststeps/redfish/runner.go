@@ -0,0 +1,121 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package redfish
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/linuxboot/contest/pkg/event/testevent"
+	"github.com/linuxboot/contest/pkg/events"
+	"github.com/linuxboot/contest/pkg/target"
+	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/xcontext"
+	"github.com/linuxboot/contest/plugins/teststeps/abstraction/options"
+)
+
+type TargetRunner struct {
+	ts *TestStep
+	ev testevent.Emitter
+}
+
+func NewTargetRunner(ts *TestStep, ev testevent.Emitter) *TargetRunner {
+	return &TargetRunner{
+		ts: ts,
+		ev: ev,
+	}
+}
+
+func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
+	var outputBuf strings.Builder
+
+	ctx, cancel := options.NewOptions(ctx, defaultTimeout, r.ts.options.Timeout)
+	defer cancel()
+
+	// The parameters are expanded for each target into a copy, as targets
+	// run concurrently.
+	var params parameters
+	pe := test.NewParamExpander(target)
+	if err := pe.ExpandObject(r.ts.parameters, &params); err != nil {
+		err = fmt.Errorf("failed to expand parameters: %w", err)
+		outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+
+		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
+	}
+
+	if err := params.run(ctx, &outputBuf, target, r.ev); err != nil {
+		outputBuf.WriteString(fmt.Sprintf("%v\n", err))
+
+		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
+	}
+
+	return events.EmitLog(ctx, outputBuf.String(), target, r.ev)
+}
+
+func (p *parameters) run(ctx xcontext.Context, outputBuf *strings.Builder, target *target.Target, ev testevent.Emitter) error {
+	c := newClient(p.Host, p.Username, p.Password, p.Insecure)
+
+	system, err := c.system(ctx, p.SystemID)
+	if err != nil {
+		return err
+	}
+
+	switch p.Command {
+	case CommandPower:
+		if err := c.reset(ctx, system, p.ResetType); err != nil {
+			return fmt.Errorf("failed to reset %s: %w", system, err)
+		}
+		outputBuf.WriteString(fmt.Sprintf("Successfully sent %s to %s.\n", p.ResetType, system))
+
+	case CommandBoot:
+		if err := c.setBootOverride(ctx, system, p.BootTarget, p.BootMode); err != nil {
+			return fmt.Errorf("failed to set the boot source override of %s: %w", system, err)
+		}
+		outputBuf.WriteString(fmt.Sprintf("Successfully set the next boot of %s to %s.\n", system, p.BootTarget))
+
+	case CommandUpdate:
+		var task string
+		if p.ImageURI != "" {
+			outputBuf.WriteString(fmt.Sprintf("Updating the firmware from '%s'.\n", p.ImageURI))
+			task, err = c.simpleUpdate(ctx, p.ImageURI, p.TransferProtocol, p.Targets)
+		} else {
+			outputBuf.WriteString(fmt.Sprintf("Updating the firmware with '%s'.\n", p.File))
+			task, err = c.multipartUpdate(ctx, p.File, p.Targets)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to start the firmware update: %w", err)
+		}
+		if task != "" {
+			if err := c.waitTask(ctx, task, time.Duration(p.PollInterval), outputBuf); err != nil {
+				return fmt.Errorf("firmware update failed: %w", err)
+			}
+		}
+		outputBuf.WriteString("Successfully updated the firmware.\n")
+
+	case CommandLogs:
+		services, err := c.logServices(ctx, system, p.LogServices)
+		if err != nil {
+			return fmt.Errorf("failed to collect the logs: %w", err)
+		}
+		if len(services) == 0 {
+			return fmt.Errorf("no log service found")
+		}
+		for _, svc := range services {
+			data, err := json.Marshal(svc.Entries)
+			if err != nil {
+				return err
+			}
+			if err := events.EmitOutput(ctx, svc.Path, data, target, ev); err != nil {
+				return fmt.Errorf("failed to emit output: %w", err)
+			}
+			outputBuf.WriteString(fmt.Sprintf("Collected %d entries of %s.\n", len(svc.Entries), svc.Path))
+		}
+	}
+
+	return nil
+}
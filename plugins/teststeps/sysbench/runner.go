@@ -64,7 +64,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
 	}
 
-	if err := r.ts.runPerformance(ctx, &outputBuf, transportProto); err != nil {
+	if err := r.ts.runPerformance(ctx, &outputBuf, transportProto, target, r.ev); err != nil {
 		outputBuf.WriteString(fmt.Sprintf("%v\n", err))
 
 		return events.EmitError(ctx, outputBuf.String(), target, r.ev, err)
@@ -74,6 +74,7 @@ func (r *TargetRunner) Run(ctx xcontext.Context, target *target.Target) error {
 }
 
 func (ts *TestStep) runPerformance(ctx xcontext.Context, outputBuf *strings.Builder, transport transport.Transport,
+	target *target.Target, ev testevent.Emitter,
 ) error {
 	proc, err := transport.NewProcess(ctx, sysbench, ts.Args, "")
 	if err != nil {
@@ -112,11 +113,16 @@ func (ts *TestStep) runPerformance(ctx xcontext.Context, outputBuf *strings.Buil
 		return fmt.Errorf("Failed to get CPU performance data: %v.", outcome)
 	}
 
-	if err := ts.parseOutput(outputBuf, stdout); err != nil {
+	output, err := parseOutput(stdout)
+	if err != nil {
 		return fmt.Errorf("failed to parse sysbench output: %v", err)
 	}
 
-	return nil
+	if err := events.EmitMetrics(ctx, output.metrics(), target, ev); err != nil {
+		return err
+	}
+
+	return ts.checkExpect(outputBuf, output)
 }
 
 // getOutputFromReader reads data from the provided io.Reader instances
@@ -169,9 +175,18 @@ type SysbenchOutput struct {
 		AverageEventsPerThread        float64 `json:"average_events_per_thread"`
 		AverageExecutionTimePerThread float64 `json:"average_execution_time_per_thread"`
 	} `json:"threads_fairness"`
+
+	// parsed holds the results found in the output, in order.
+	parsed []events.Metric
 }
 
-func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
+// metrics returns the sysbench results which were found in the output as
+// metrics.
+func (o SysbenchOutput) metrics() []events.Metric {
+	return o.parsed
+}
+
+func parseOutput(data []byte) (SysbenchOutput, error) {
 	output := SysbenchOutput{}
 
 	// Parse CPU Speed
@@ -180,10 +195,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(cpuSpeedMatches) > 1 {
 		cpuSpeed, err := strconv.ParseFloat(string(cpuSpeedMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.CpuSpeed.EventsPerSecond = cpuSpeed
+		output.parsed = append(output.parsed, events.Metric{Name: "EventsPerSecond", Value: cpuSpeed})
 	}
 
 	// Parse General Statistics
@@ -192,10 +208,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(totalTimeMatches) > 1 {
 		totalTime, err := strconv.ParseFloat(string(totalTimeMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.GeneralStatistics.TotalTime = totalTime
+		output.parsed = append(output.parsed, events.Metric{Name: "TotalTime", Value: totalTime, Unit: "s"})
 	}
 
 	reTotalEvents := regexp.MustCompile(`total number of events:\s+(\d+)`)
@@ -203,10 +220,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(totalEventsMatches) > 1 {
 		totalEvents, err := strconv.Atoi(string(totalEventsMatches[1]))
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.GeneralStatistics.TotalEvents = totalEvents
+		output.parsed = append(output.parsed, events.Metric{Name: "TotalEvents", Value: float64(totalEvents)})
 	}
 
 	// Parse Latency
@@ -215,10 +233,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(latencyMinMatches) > 1 {
 		latencyMin, err := strconv.ParseFloat(string(latencyMinMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.Latency.Min = latencyMin
+		output.parsed = append(output.parsed, events.Metric{Name: "LatencyMin", Value: latencyMin, Unit: "ms"})
 	}
 
 	reLatencyAvg := regexp.MustCompile(`avg:\s+([\d.]+)`)
@@ -226,10 +245,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(latencyAvgMatches) > 1 {
 		latencyAvg, err := strconv.ParseFloat(string(latencyAvgMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.Latency.Average = latencyAvg
+		output.parsed = append(output.parsed, events.Metric{Name: "LatencyAvg", Value: latencyAvg, Unit: "ms"})
 	}
 
 	reLatencyMax := regexp.MustCompile(`max:\s+([\d.]+)`)
@@ -237,10 +257,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(latencyMaxMatches) > 1 {
 		latencyMax, err := strconv.ParseFloat(string(latencyMaxMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.Latency.Max = latencyMax
+		output.parsed = append(output.parsed, events.Metric{Name: "LatencyMax", Value: latencyMax, Unit: "ms"})
 	}
 
 	reLatencyP95 := regexp.MustCompile(`95th percentile:\s+([\d.]+)`)
@@ -248,10 +269,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(latencyP95Matches) > 1 {
 		latencyP95, err := strconv.ParseFloat(string(latencyP95Matches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.Latency.Percentile95th = latencyP95
+		output.parsed = append(output.parsed, events.Metric{Name: "LatencyP95", Value: latencyP95, Unit: "ms"})
 	}
 
 	reLatencySum := regexp.MustCompile(`sum:\s+([\d.]+)`)
@@ -259,10 +281,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(latencySumMatches) > 1 {
 		latencySum, err := strconv.ParseFloat(string(latencySumMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.Latency.Sum = latencySum
+		output.parsed = append(output.parsed, events.Metric{Name: "LatencySum", Value: latencySum, Unit: "ms"})
 	}
 
 	// Parse Threads Fairness
@@ -271,10 +294,11 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(avgEventsPerThreadMatches) > 2 {
 		avgEventsPerThread, err := strconv.ParseFloat(string(avgEventsPerThreadMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.ThreadsFairness.AverageEventsPerThread = avgEventsPerThread
+		output.parsed = append(output.parsed, events.Metric{Name: "AverageEventsPerThread", Value: avgEventsPerThread})
 	}
 
 	reAvgExecutionTimePerThread := regexp.MustCompile(`execution time \(avg/stddev\):\s+([\d.]+)/([\d.]+)`)
@@ -282,12 +306,17 @@ func (ts *TestStep) parseOutput(outputBuf *strings.Builder, data []byte) error {
 	if len(avgExecutionTimePerThreadMatches) > 2 {
 		avgExecutionTimePerThread, err := strconv.ParseFloat(string(avgExecutionTimePerThreadMatches[1]), 64)
 		if err != nil {
-			return err
+			return output, err
 		}
 
 		output.ThreadsFairness.AverageExecutionTimePerThread = avgExecutionTimePerThread
+		output.parsed = append(output.parsed, events.Metric{Name: "AverageExecutionTimePerThread", Value: avgExecutionTimePerThread, Unit: "s"})
 	}
 
+	return output, nil
+}
+
+func (ts *TestStep) checkExpect(outputBuf *strings.Builder, output SysbenchOutput) error {
 	for _, option := range ts.Expect {
 		switch option.Option {
 		case "EventsPerSecond":
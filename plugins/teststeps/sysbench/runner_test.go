@@ -0,0 +1,28 @@
+package sysbench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/linuxboot/contest/pkg/events"
+)
+
+func TestParseOutputMetrics(t *testing.T) {
+	// The output of a sysbench run which was interrupted after the general
+	// statistics.
+	output, err := parseOutput([]byte(`
+CPU speed:
+    events per second:  1234.56
+
+General statistics:
+    total time:                          10.0004s
+    total number of events:              12346
+`))
+	require.NoError(t, err)
+	require.Equal(t, []events.Metric{
+		{Name: "EventsPerSecond", Value: 1234.56},
+		{Name: "TotalTime", Value: 10.0004, Unit: "s"},
+		{Name: "TotalEvents", Value: 12346},
+	}, output.metrics())
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/linuxboot/contest/pkg/target"
 	"github.com/linuxboot/contest/pkg/test"
+	"github.com/linuxboot/contest/pkg/tracing"
 	"github.com/linuxboot/contest/pkg/xcontext"
 )
 
@@ -57,7 +58,7 @@ func ForEachTarget(pluginName string, ctx xcontext.Context, ch test.TestStepChan
 				go func() {
 					defer wg.Done()
 
-					err := f(ctx, tgt)
+					err := f(tracing.WithTargetSpan(ctx, tgt.ID), tgt)
 					reportTarget(tgt, err)
 				}()
 			case <-ctx.Done():
@@ -140,7 +141,7 @@ func ForEachTargetWithResume(ctx xcontext.Context, ch test.TestStepChannels, res
 	handleTarget := func(tgt2 *TargetWithData) {
 		defer wg.Done()
 
-		err := f(ctx, tgt2)
+		err := f(tracing.WithTargetSpan(ctx, tgt2.Target.ID), tgt2)
 		switch err {
 		case xcontext.ErrCanceled:
 			// nothing to do for failed
@@ -3,7 +3,7 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-// +build integration integration_storage
+// +build integration integration_storage integration_postgres
 
 package test
 
@@ -154,3 +154,24 @@ func (suite *FrameworkEventsSuite) TestRetrieveSingleFrameworkEventByEmitTime()
 	require.NoError(suite.T(), err)
 	assert.Equal(suite.T(), 4, len(results))
 }
+
+func (suite *FrameworkEventsSuite) TestRetrieveFrameworkEventsBySequenceID() {
+
+	emitTime := time.Now().Truncate(2 * time.Second)
+	err := populateFrameworkEvents(suite.txStorage, emitTime)
+	require.NoError(suite.T(), err)
+
+	eventQuery := mustBuildQuery(suite.T(), frameworkevent.QueryJobID(1))
+	results, err := suite.txStorage.GetFrameworkEvent(ctx, eventQuery)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, len(results))
+
+	eventQuery = mustBuildQuery(suite.T(),
+		frameworkevent.QueryJobID(1),
+		frameworkevent.QuerySequenceIDStart(results[1].SequenceID),
+	)
+	results, err = suite.txStorage.GetFrameworkEvent(ctx, eventQuery)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(results))
+	assert.Equal(suite.T(), event.Name("BFrameworkEvent"), results[0].EventName)
+}
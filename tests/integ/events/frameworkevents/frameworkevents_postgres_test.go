@@ -0,0 +1,35 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build integration_postgres
+
+package test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/linuxboot/contest/plugins/storage/rdbms"
+	"github.com/linuxboot/contest/tests/integ/common"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFrameworkEventsSuitePostgresStorage(t *testing.T) {
+
+	testSuite := FrameworkEventsSuite{}
+
+	opts := []rdbms.Opt{
+		rdbms.FrameworkEventsFlushSize(0),
+		rdbms.FrameworkEventsFlushInterval(10 * time.Second),
+	}
+	storageLayer, err := common.NewPostgresStorage(opts...)
+	if err != nil {
+		panic(fmt.Sprintf("could not initialize postgres storage layer: %v", err))
+	}
+
+	testSuite.storage = storageLayer
+	suite.Run(t, &testSuite)
+}
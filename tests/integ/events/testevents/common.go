@@ -3,8 +3,8 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-//go:build integration || integration_storage
-// +build integration integration_storage
+//go:build integration || integration_storage || integration_postgres
+// +build integration integration_storage integration_postgres
 
 package test
 
@@ -212,6 +212,37 @@ func (suite *TestEventsSuite) TestRetrieveSingleTestEventsByNameAndJobID() {
 	assertTestEvents(suite.T(), results, emitTime)
 }
 
+func (suite *TestEventsSuite) TestRetrieveTestEventsBySequenceID() {
+
+	emitTime := time.Now().Truncate(2 * time.Second)
+	err := populateTestEvents(suite.txStorage, emitTime)
+	require.NoError(suite.T(), err)
+
+	testEventQuery := mustBuildQuery(suite.T(), testevent.QueryJobID(1))
+	results, err := suite.txStorage.GetTestEvents(ctx, testEventQuery)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(results))
+	sequenceID := results[0].SequenceID
+
+	testEventQuery = mustBuildQuery(suite.T(),
+		testevent.QueryTestStepLabel("TestStepLabel"),
+		testevent.QuerySequenceIDStart(sequenceID),
+	)
+	results, err = suite.txStorage.GetTestEvents(ctx, testEventQuery)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, len(results))
+	assertTestEvents(suite.T(), results, emitTime)
+
+	testEventQuery = mustBuildQuery(suite.T(),
+		testevent.QueryTestStepLabel("TestStepLabel"),
+		testevent.QuerySequenceIDStart(sequenceID+1),
+	)
+	results, err = suite.txStorage.GetTestEvents(ctx, testEventQuery)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(results))
+	assert.Equal(suite.T(), types.JobID(2), results[0].Header.JobID)
+}
+
 func (suite TestEventsSuite) GetStorageEngineVault() storage.EngineVault {
 	return suite.storageEngineVault
 }
@@ -3,11 +3,12 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-// +build integration integration_storage
+// +build integration integration_storage integration_postgres
 
 package test
 
 import (
+	"net"
 	"time"
 
 	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/linuxboot/contest/pkg/event/frameworkevent"
+	"github.com/linuxboot/contest/pkg/inventory"
 	"github.com/linuxboot/contest/pkg/job"
 	"github.com/linuxboot/contest/pkg/storage"
 	"github.com/linuxboot/contest/pkg/types"
@@ -227,3 +229,139 @@ func (suite *JobSuite) TestListJobs() {
 	require.NoError(t, err)
 	require.Equal(t, []types.JobID{jobIDa}, res)
 }
+
+func (suite *JobSuite) TestSchedules() {
+	t := suite.T()
+
+	createTime := time.Now().Truncate(time.Second)
+	scheduleFirst := job.Schedule{
+		Requestor:     "AIntegrationTest",
+		ServerID:      "server-a",
+		CreateTime:    createTime,
+		CronExpr:      "*/5 * * * *",
+		OverlapPolicy: job.OverlapSkip,
+		JobDescriptor: jobDescriptorFirst,
+	}
+	scheduleIDa, err := suite.txStorage.StoreSchedule(ctx, &scheduleFirst)
+	require.NoError(t, err)
+
+	scheduleSecond := job.Schedule{
+		Requestor:     "BIntegrationTest",
+		ServerID:      "server-b",
+		CreateTime:    createTime,
+		CronExpr:      "@daily",
+		OverlapPolicy: job.OverlapQueue,
+		JobDescriptor: jobDescriptorSecond,
+	}
+	scheduleIDb, err := suite.txStorage.StoreSchedule(ctx, &scheduleSecond)
+	require.NoError(t, err)
+
+	schedule, err := suite.txStorage.GetSchedule(ctx, scheduleIDa)
+	require.NoError(t, err)
+	require.Equal(t, scheduleIDa, schedule.ID)
+	require.Equal(t, "AIntegrationTest", schedule.Requestor)
+	require.Equal(t, "*/5 * * * *", schedule.CronExpr)
+	require.Equal(t, job.OverlapSkip, schedule.OverlapPolicy)
+	require.Equal(t, jobDescriptorFirst, schedule.JobDescriptor)
+	require.False(t, schedule.Paused)
+	require.True(t, schedule.LastFireTime.IsZero())
+
+	// Listing - everything or per server.
+	schedules, err := suite.txStorage.ListSchedules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	require.Equal(t, scheduleIDa, schedules[0].ID)
+	require.Equal(t, scheduleIDb, schedules[1].ID)
+	schedules, err = suite.txStorage.ListSchedules(ctx, "server-b")
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, scheduleIDb, schedules[0].ID)
+
+	// Update the state of a schedule.
+	fireTime := createTime.Add(5 * time.Minute)
+	schedule.Paused = true
+	schedule.LastFireTime = fireTime
+	schedule.LastJobID = 42
+	require.NoError(t, suite.txStorage.UpdateSchedule(ctx, schedule))
+	schedule, err = suite.txStorage.GetSchedule(ctx, scheduleIDa)
+	require.NoError(t, err)
+	require.True(t, schedule.Paused)
+	require.True(t, fireTime.Equal(schedule.LastFireTime))
+	require.Equal(t, types.JobID(42), schedule.LastJobID)
+	require.NoError(t, suite.txStorage.UpdateSchedule(ctx, schedule))
+
+	// Deletion.
+	require.NoError(t, suite.txStorage.DeleteSchedule(ctx, scheduleIDa))
+	_, err = suite.txStorage.GetSchedule(ctx, scheduleIDa)
+	require.Error(t, err)
+	require.Error(t, suite.txStorage.DeleteSchedule(ctx, scheduleIDa))
+	require.Error(t, suite.txStorage.UpdateSchedule(ctx, schedule))
+	schedules, err = suite.txStorage.ListSchedules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+}
+
+func (suite *JobSuite) TestInventory() {
+	t := suite.T()
+
+	updateTime := time.Now().Truncate(time.Second)
+	targetFirst := inventory.Target{
+		ID:          "T1",
+		FQDN:        "t1.example.com",
+		PrimaryIPv4: net.ParseIP("10.0.0.1"),
+		Labels:      map[string]string{"board": "X", "bmc": "openbmc"},
+		Health:      inventory.HealthHealthy,
+		UpdateTime:  updateTime,
+	}
+	require.NoError(t, suite.txStorage.StoreInventoryTarget(ctx, &targetFirst))
+	targetSecond := inventory.Target{
+		ID:          "T0",
+		PrimaryIPv6: net.ParseIP("2001:db8::1"),
+		Health:      inventory.HealthUnknown,
+		UpdateTime:  updateTime,
+	}
+	require.NoError(t, suite.txStorage.StoreInventoryTarget(ctx, &targetSecond))
+	// IDs are unique.
+	require.Error(t, suite.txStorage.StoreInventoryTarget(ctx, &targetSecond))
+
+	tgt, err := suite.txStorage.GetInventoryTarget(ctx, "T1")
+	require.NoError(t, err)
+	require.Equal(t, "t1.example.com", tgt.FQDN)
+	require.True(t, net.ParseIP("10.0.0.1").Equal(tgt.PrimaryIPv4))
+	require.Nil(t, tgt.PrimaryIPv6)
+	require.Equal(t, map[string]string{"board": "X", "bmc": "openbmc"}, tgt.Labels)
+	require.Equal(t, inventory.HealthHealthy, tgt.Health)
+	require.False(t, tgt.Drained)
+	require.True(t, updateTime.Equal(tgt.UpdateTime))
+
+	// Listing is sorted by ID.
+	targets, err := suite.txStorage.ListInventoryTargets(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, "T0", targets[0].ID)
+	require.Empty(t, targets[0].Labels)
+	require.Equal(t, "T1", targets[1].ID)
+
+	// Update the labels and the state of a target.
+	tgt.Labels["rev"] = "2"
+	delete(tgt.Labels, "bmc")
+	tgt.Drained = true
+	tgt.Health = inventory.HealthUnhealthy
+	require.NoError(t, suite.txStorage.UpdateInventoryTarget(ctx, tgt))
+	tgt, err = suite.txStorage.GetInventoryTarget(ctx, "T1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"board": "X", "rev": "2"}, tgt.Labels)
+	require.True(t, tgt.Drained)
+	require.Equal(t, inventory.HealthUnhealthy, tgt.Health)
+	require.NoError(t, suite.txStorage.UpdateInventoryTarget(ctx, tgt))
+
+	// Deletion.
+	require.NoError(t, suite.txStorage.DeleteInventoryTarget(ctx, "T1"))
+	_, err = suite.txStorage.GetInventoryTarget(ctx, "T1")
+	require.Error(t, err)
+	require.Error(t, suite.txStorage.DeleteInventoryTarget(ctx, "T1"))
+	require.Error(t, suite.txStorage.UpdateInventoryTarget(ctx, tgt))
+	targets, err = suite.txStorage.ListInventoryTargets(ctx)
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+}
@@ -3,8 +3,8 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-//go:build integration || integration_storage
-// +build integration integration_storage
+//go:build integration || integration_storage || integration_postgres
+// +build integration integration_storage integration_postgres
 
 package test
 
@@ -61,15 +61,33 @@ const (
 	StartJob CommandType = "start"
 	StopJob  CommandType = "stop"
 	Status   CommandType = "status"
+	Retry    CommandType = "retry"
 	List     CommandType = "list"
+	Pause    CommandType = "pause"
+	Resume   CommandType = "resume"
+	Events   CommandType = "events"
+
+	AddSchedule    CommandType = "add_schedule"
+	ListSchedules  CommandType = "list_schedules"
+	PauseSchedule  CommandType = "pause_schedule"
+	DeleteSchedule CommandType = "delete_schedule"
 )
 
 type command struct {
 	commandType   CommandType
 	jobID         types.JobID
 	jobDescriptor string
+	// Retry arguments
+	failedTargetsOnly bool
+	// Pause arguments
+	releaseTargets bool
 	// List arguments
 	jobQuery *storage.JobQuery
+	// Schedule arguments
+	cronExpr      string
+	overlapPolicy job.OverlapPolicy
+	scheduleID    types.ScheduleID
+	paused        bool
 }
 
 const fakeJobID types.JobID = 1234567
@@ -118,12 +136,60 @@ func (tl *TestListener) Serve(ctx xcontext.Context, contestApi *api.API) error {
 					tl.errorCh <- err
 				}
 				tl.responseCh <- resp
+			case Retry:
+				resp, err := contestApi.Retry(ctx, "IntegrationTest", command.jobID, command.failedTargetsOnly)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case Pause:
+				resp, err := contestApi.Pause(ctx, "IntegrationTest", command.jobID, command.releaseTargets)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case Resume:
+				resp, err := contestApi.Resume(ctx, "IntegrationTest", command.jobID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case Events:
+				resp, err := contestApi.JobEvents(ctx, "IntegrationTest", command.jobID, 0, 0)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
 			case List:
 				resp, err := contestApi.List(ctx, "IntegrationTest", command.jobQuery)
 				if err != nil {
 					tl.errorCh <- err
 				}
 				tl.responseCh <- resp
+			case AddSchedule:
+				resp, err := contestApi.AddSchedule(ctx, "IntegrationTest", command.cronExpr, command.overlapPolicy, command.jobDescriptor)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case ListSchedules:
+				resp, err := contestApi.ListSchedules(ctx, "IntegrationTest")
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case PauseSchedule:
+				resp, err := contestApi.PauseSchedule(ctx, "IntegrationTest", command.scheduleID, command.paused)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
+			case DeleteSchedule:
+				resp, err := contestApi.DeleteSchedule(ctx, "IntegrationTest", command.scheduleID)
+				if err != nil {
+					tl.errorCh <- err
+				}
+				tl.responseCh <- resp
 			default:
 				return nil
 			}
@@ -271,6 +337,55 @@ func (suite *TestJobManagerSuite) jobStatus(jobID types.JobID) (*job.Status, err
 	return resp.Data.(api.ResponseDataStatus).Status, nil
 }
 
+func (suite *TestJobManagerSuite) jobEvents(jobID types.JobID) (*api.ResponseDataEvents, error) {
+	suite.listener.commandCh <- command{commandType: Events, jobID: jobID}
+	var resp api.Response
+	select {
+	case resp = <-suite.listener.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	data := resp.Data.(api.ResponseDataEvents)
+	return &data, nil
+}
+
+func (suite *TestJobManagerSuite) retryJob(jobID types.JobID, failedTargetsOnly bool) (types.JobID, error) {
+	suite.listener.commandCh <- command{commandType: Retry, jobID: jobID, failedTargetsOnly: failedTargetsOnly}
+	var resp api.Response
+	select {
+	case resp = <-suite.listener.responseCh:
+		if resp.Err != nil {
+			return types.JobID(0), resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return types.JobID(0), fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data.(api.ResponseDataRetry).NewJobID, nil
+}
+
+func (suite *TestJobManagerSuite) pauseJob(jobID types.JobID, releaseTargets bool) error {
+	suite.listener.commandCh <- command{commandType: Pause, jobID: jobID, releaseTargets: releaseTargets}
+	select {
+	case resp := <-suite.listener.responseCh:
+		return resp.Err
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("Listener response should come within the timeout")
+	}
+}
+
+func (suite *TestJobManagerSuite) resumeJob(jobID types.JobID) error {
+	suite.listener.commandCh <- command{commandType: Resume, jobID: jobID}
+	select {
+	case resp := <-suite.listener.responseCh:
+		return resp.Err
+	case <-time.After(2 * time.Second):
+		return fmt.Errorf("Listener response should come within the timeout")
+	}
+}
+
 func (suite *TestJobManagerSuite) listJobs(states []job.State, tags []string, serverID string) ([]types.JobID, error) {
 	var fields []storage.JobQueryField
 	if len(states) > 0 {
@@ -299,6 +414,30 @@ func (suite *TestJobManagerSuite) listJobs(states []job.State, tags []string, se
 	return resp.Data.(api.ResponseDataList).JobIDs, nil
 }
 
+// scheduleCommand sends a schedule management command to the listener and
+// returns the response data.
+func (suite *TestJobManagerSuite) scheduleCommand(cmd command) (api.ResponseData, error) {
+	suite.listener.commandCh <- cmd
+	var resp api.Response
+	select {
+	case resp = <-suite.listener.responseCh:
+		if resp.Err != nil {
+			return nil, resp.Err
+		}
+	case <-time.After(2 * time.Second):
+		return nil, fmt.Errorf("Listener response should come within the timeout")
+	}
+	return resp.Data, nil
+}
+
+func (suite *TestJobManagerSuite) listSchedules() ([]*job.Schedule, error) {
+	data, err := suite.scheduleCommand(command{commandType: ListSchedules})
+	if err != nil {
+		return nil, err
+	}
+	return data.(api.ResponseDataListSchedules).Schedules, nil
+}
+
 func (suite *TestJobManagerSuite) SetupTest() {
 
 	suite.storageEngineVault = storage.NewSimpleEngineVault()
@@ -634,6 +773,34 @@ func (suite *TestJobManagerSuite) TestJobManagerJobStartSingle() {
 	require.Equal(suite.T(), 1, len(ev))
 }
 
+// The events of a job which did not start yet can be fetched, the job has no
+// state until it starts.
+func (suite *TestJobManagerSuite) TestJobManagerJobEventsBeforeStart() {
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+	data, err := suite.jobEvents(jobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), string(job.EventJobCompleted), data.JobState)
+
+	// Store the request of a job which is not run, as if it was just submitted.
+	req, err := suite.jsm.GetJobRequest(suite.jmCtx, jobID)
+	require.NoError(suite.T(), err)
+	req.JobID = 0
+	pendingJobID, err := suite.jsm.StoreJobRequest(suite.jmCtx, req)
+	require.NoError(suite.T(), err)
+	data, err = suite.jobEvents(pendingJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "", data.JobState)
+	require.Empty(suite.T(), data.TestEvents)
+
+	_, err = suite.jobEvents(fakeJobID)
+	require.Error(suite.T(), err)
+}
+
 func (suite *TestJobManagerSuite) TestJobManagerTargetManagerMetadataAvailable() {
 	suite.startJobManager(false /* resumeJobs */)
 
@@ -773,6 +940,83 @@ func (suite *TestJobManagerSuite) TestJobManagerJobCrash() {
 	require.Equal(suite.T(), &job.JobReport{JobID: jobID}, jobReport)
 }
 
+func (suite *TestJobManagerSuite) TestJobManagerJobRetry() {
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID, err := suite.startJob(jobDescriptorNoop)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+
+	newJobID, err := suite.retryJob(jobID, false)
+	require.NoError(suite.T(), err)
+	require.NotEqual(suite.T(), jobID, newJobID)
+
+	ev, err := pollForEvent(suite.eventManager, job.EventJobCompleted, newJobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(ev))
+
+	status, err := suite.jobStatus(newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), jobID, status.RetryOf)
+
+	origRequest, err := suite.jsm.GetJobRequest(suite.jmCtx, jobID)
+	require.NoError(suite.T(), err)
+	newRequest, err := suite.jsm.GetJobRequest(suite.jmCtx, newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), origRequest.ExtendedDescriptor, newRequest.ExtendedDescriptor)
+
+	// A job without failed targets cannot be retried on its failed targets only.
+	_, err = suite.retryJob(jobID, true)
+	require.Error(suite.T(), err)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobRetryFailedTargetsOnly() {
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID, err := suite.startJob(jobDescriptorFailure)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+
+	newJobID, err := suite.retryJob(jobID, true)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, newJobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+
+	origRequest, err := suite.jsm.GetJobRequest(suite.jmCtx, jobID)
+	require.NoError(suite.T(), err)
+	newRequest, err := suite.jsm.GetJobRequest(suite.jmCtx, newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, len(newRequest.ExtendedDescriptor.TestDescriptors))
+	// The targets are acquired by the original target manager, and the test
+	// only runs on the failed ones.
+	origTD, newTD := origRequest.ExtendedDescriptor.TestDescriptors[0], newRequest.ExtendedDescriptor.TestDescriptors[0]
+	require.Equal(suite.T(), origTD.TargetManagerName, newTD.TargetManagerName)
+	require.Equal(suite.T(), origTD.TargetManagerAcquireParameters, newTD.TargetManagerAcquireParameters)
+	require.ElementsMatch(suite.T(), []string{"id1", "id2"}, newTD.TargetIDs)
+
+	status, err := suite.jobStatus(newJobID)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), jobID, status.RetryOf)
+}
+
+func (suite *TestJobManagerSuite) TestJobManagerJobRetryRunning() {
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID, err := suite.startJob(jobDescriptorSlowEcho)
+	require.NoError(suite.T(), err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobStarted, jobID, 1*time.Second)
+	require.NoError(suite.T(), err)
+
+	// A job which is still running cannot be retried
+	_, err = suite.retryJob(jobID, false)
+	require.Error(suite.T(), err)
+
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID, 2*time.Second)
+	require.NoError(suite.T(), err)
+}
+
 func (suite *TestJobManagerSuite) TestTestStepNoLabel() {
 	suite.startJobManager(false /* resumeJobs */)
 
@@ -937,3 +1181,157 @@ func (suite *TestJobManagerSuite) TestJobListing() {
 	require.NoError(suite.T(), err)
 	require.Equal(suite.T(), []types.JobID{jobID1, jobID2}, jobIDs)
 }
+
+func (suite *TestJobManagerSuite) TestJobQueuedOnBusyTargets() {
+	t := suite.T()
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID1, err := suite.startJob(jobDescriptorSlowEcho)
+	require.NoError(t, err)
+	_, err = pollForTestEvent(suite.testEventManager, target.EventTargetAcquired, jobID1, 1*time.Second)
+	require.NoError(t, err)
+
+	// The second job needs the targets of the first one, it waits in the queue.
+	jobID2, err := suite.startJob(jobDescriptorSlowEcho)
+	require.NoError(t, err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobQueued, jobID2, 1*time.Second)
+	require.NoError(t, err)
+	status, err := suite.jobStatus(jobID2)
+	require.NoError(t, err)
+	require.Equal(t, string(job.EventJobQueued), status.State)
+	require.Equal(t, 1, status.QueuePosition)
+	jobIDs, err := suite.listJobs([]job.State{job.JobStateQueued}, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, []types.JobID{jobID2}, jobIDs)
+
+	// It runs once the first job releases the targets.
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID1, 5*time.Second)
+	require.NoError(t, err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID2, 5*time.Second)
+	require.NoError(t, err)
+	status, err = suite.jobStatus(jobID2)
+	require.NoError(t, err)
+	require.Equal(t, 0, status.QueuePosition)
+	require.Equal(t, 1, len(status.RunStatuses))
+}
+
+func (suite *TestJobManagerSuite) TestJobPreemptedByHigherPriority() {
+	t := suite.T()
+	suite.startJobManager(false /* resumeJobs */)
+
+	jobID1, err := suite.startJob(jobDescriptorSlowEcho)
+	require.NoError(t, err)
+	_, err = pollForTestEvent(suite.testEventManager, target.EventTargetAcquired, jobID1, 1*time.Second)
+	require.NoError(t, err)
+
+	// The job of higher priority takes the targets of the first one, which is
+	// paused and resumed to wait for them.
+	jobID2, err := suite.startJob(jobDescriptorSlowEchoHighPriority)
+	require.NoError(t, err)
+	ev, err := pollForEvent(suite.eventManager, job.EventJobPaused, jobID1, 5*time.Second)
+	require.NoError(t, err)
+	require.Len(t, ev, 1)
+	var pauseState job.PauseEventPayload
+	require.NoError(t, json.Unmarshal(*ev[0].Payload, &pauseState))
+	require.True(t, pauseState.Preempted)
+	require.Empty(t, pauseState.Targets)
+
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID2, 5*time.Second)
+	require.NoError(t, err)
+	_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID1, 5*time.Second)
+	require.NoError(t, err)
+	status, err := suite.jobStatus(jobID1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(status.RunStatuses))
+}
+
+func (suite *TestJobManagerSuite) TestPauseAndResumeJob() {
+	t := suite.T()
+	suite.startJobManager(false /* resumeJobs */)
+
+	for _, releaseTargets := range []bool{false, true} {
+		jobID, err := suite.startJob(jobDescriptorSlowEcho)
+		require.NoError(t, err)
+		_, err = pollForTestEvent(suite.testEventManager, target.EventTargetAcquired, jobID, 1*time.Second)
+		require.NoError(t, err)
+		// Only paused jobs can be resumed.
+		require.Error(t, suite.resumeJob(jobID))
+
+		require.NoError(t, suite.pauseJob(jobID, releaseTargets))
+		ev, err := pollForEvent(suite.eventManager, job.EventJobPaused, jobID, 5*time.Second)
+		require.NoError(t, err)
+		require.Len(t, ev, 1)
+		var pauseState job.PauseEventPayload
+		require.NoError(t, json.Unmarshal(*ev[0].Payload, &pauseState))
+		require.True(t, pauseState.ResumeOnRequest)
+		if releaseTargets {
+			require.Empty(t, pauseState.Targets)
+			_, err = pollForTestEvent(suite.testEventManager, target.EventTargetReleased, jobID, 1*time.Second)
+			require.NoError(t, err)
+		} else {
+			require.Len(t, pauseState.Targets, 2)
+		}
+		// Only running jobs can be paused.
+		require.Eventually(t, func() bool {
+			return suite.pauseJob(jobID, releaseTargets) != nil
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, suite.resumeJob(jobID))
+		_, err = pollForEvent(suite.eventManager, job.EventJobCompleted, jobID, 5*time.Second)
+		require.NoError(t, err)
+		status, err := suite.jobStatus(jobID)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(status.RunStatuses))
+	}
+}
+
+func (suite *TestJobManagerSuite) TestSchedules() {
+	t := suite.T()
+	suite.startJobManager(false /* resumeJobs */)
+
+	// Invalid schedules are rejected.
+	_, err := suite.scheduleCommand(command{commandType: AddSchedule, cronExpr: "* * *", jobDescriptor: jobDescriptorNoop})
+	require.Error(t, err)
+	_, err = suite.scheduleCommand(command{commandType: AddSchedule, cronExpr: "@daily", overlapPolicy: "sometimes", jobDescriptor: jobDescriptorNoop})
+	require.Error(t, err)
+	_, err = suite.scheduleCommand(command{commandType: AddSchedule, cronExpr: "@daily", jobDescriptor: jobDescriptorBadTag})
+	require.Error(t, err)
+
+	data, err := suite.scheduleCommand(command{commandType: AddSchedule, cronExpr: "0 3 * * mon-fri", overlapPolicy: job.OverlapQueue, jobDescriptor: jobDescriptorNoop})
+	require.NoError(t, err)
+	scheduleID := data.(api.ResponseDataAddSchedule).ScheduleID
+	require.NotZero(t, scheduleID)
+	data, err = suite.scheduleCommand(command{commandType: AddSchedule, cronExpr: "@hourly", jobDescriptor: jobDescriptorNoop})
+	require.NoError(t, err)
+	otherScheduleID := data.(api.ResponseDataAddSchedule).ScheduleID
+
+	schedules, err := suite.listSchedules()
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	require.Equal(t, scheduleID, schedules[0].ID)
+	require.Equal(t, "0 3 * * mon-fri", schedules[0].CronExpr)
+	require.Equal(t, job.OverlapQueue, schedules[0].OverlapPolicy)
+	require.Equal(t, "IntegrationTest", schedules[0].Requestor)
+	require.False(t, schedules[0].Paused)
+	// The overlap policy defaults to skip.
+	require.Equal(t, job.OverlapSkip, schedules[1].OverlapPolicy)
+
+	_, err = suite.scheduleCommand(command{commandType: PauseSchedule, scheduleID: scheduleID, paused: true})
+	require.NoError(t, err)
+	schedules, err = suite.listSchedules()
+	require.NoError(t, err)
+	require.True(t, schedules[0].Paused)
+	require.False(t, schedules[1].Paused)
+
+	_, err = suite.scheduleCommand(command{commandType: DeleteSchedule, scheduleID: otherScheduleID})
+	require.NoError(t, err)
+	schedules, err = suite.listSchedules()
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	require.Equal(t, scheduleID, schedules[0].ID)
+
+	_, err = suite.scheduleCommand(command{commandType: DeleteSchedule, scheduleID: otherScheduleID})
+	require.Error(t, err)
+	_, err = suite.scheduleCommand(command{commandType: PauseSchedule, scheduleID: otherScheduleID})
+	require.Error(t, err)
+}
@@ -3,7 +3,7 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-// +build integration integration_storage
+// +build integration integration_storage integration_postgres
 // +build longtest
 
 package test
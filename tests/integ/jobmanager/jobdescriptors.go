@@ -3,8 +3,8 @@
 // This source code is licensed under the MIT license found in the
 // LICENSE file in the root directory of this source tree.
 
-//go:build integration || integration_storage
-// +build integration integration_storage
+//go:build integration || integration_storage || integration_postgres
+// +build integration integration_storage integration_postgres
 
 package test
 
@@ -19,7 +19,8 @@ var jobDescriptorVersion = job.CurrentDescriptorVersion()
 var jobDescriptorTemplate = template.Must(template.New("jobDescriptor").Parse(`
 {
     "JobName": "test job",
-    "Version": "{{ .Version }}",
+    "Version": "{{ .Version }}",{{ if .Priority }}
+    "Priority": {{ .Priority }},{{ end }}
     "ReporterName": "TargetSuccess",
     "ReporterParameters": {
         "SuccessExpression": ">10%"
@@ -68,6 +69,7 @@ type templateData struct {
 	Runs        int
 	RunInterval string
 	ExtraTags   string
+	Priority    int
 	Def         string
 }
 
@@ -116,6 +118,23 @@ var jobDescriptorSlowEcho = descriptorMust(jobDescriptorTemplate, `
        "TestName": "IntegrationTest: slow echo"
    }`)
 
+var jobDescriptorSlowEchoHighPriority = descriptorMust2(
+	jobDescriptorTemplate,
+	&templateData{Version: jobDescriptorVersion, Runs: 1, RunInterval: "1s", Priority: 10, Def: `
+   "TestFetcherFetchParameters": {
+       "Steps": [
+           {
+               "name": "slowecho",
+               "label": "slowecho_label",
+               "parameters": {
+                 "sleep": ["0.5"],
+                 "text": ["Hello world"]
+               }
+           }
+       ],
+       "TestName": "IntegrationTest: slow echo"
+   }`})
+
 var jobDescriptorFailure = descriptorMust(jobDescriptorTemplate, `
    "TestFetcherFetchParameters": {
        "Steps": [
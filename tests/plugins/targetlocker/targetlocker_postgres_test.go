@@ -0,0 +1,49 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+// +build integration_postgres
+
+package targetlocker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/linuxboot/contest/plugins/targetlocker/dblocker"
+	"github.com/linuxboot/contest/tests/integ/common"
+)
+
+type PostgresLockerTestSuite struct {
+	TargetLockerTestSuite
+}
+
+func (ts *PostgresLockerTestSuite) SetupTest() {
+	ts.clock = clock.NewMock()
+	require.NotNil(ts.T(), ts.clock)
+	ts.clock.Add(1 * time.Hour) // avoid zero time, start at 1:00
+	tl, err := dblocker.New(
+		common.GetPostgresDatabaseURI(),
+		dblocker.WithDialect(dblocker.DialectPostgres),
+		dblocker.WithClock(ts.clock),
+		dblocker.WithMaxBatchSize(3),
+	)
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), tl)
+	tl.ResetAllLocks(ctx)
+	ts.tl = tl
+}
+
+func (ts *PostgresLockerTestSuite) TearDownTest() {
+	ts.tl.Close()
+	ts.tl = nil
+}
+
+func TestPostgresLocker(t *testing.T) {
+	suite.Run(t, &PostgresLockerTestSuite{})
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Facebook, Inc. and its affiliates.
+//
+// This source code is licensed under the MIT license found in the
+// LICENSE file in the root directory of this source tree.
+
+package targetlocker
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/linuxboot/contest/plugins/storage/sqlite"
+	"github.com/linuxboot/contest/plugins/targetlocker/dblocker"
+	"github.com/linuxboot/contest/plugins/targetlocker/sqlitelocker"
+)
+
+type SQLiteLockerTestSuite struct {
+	TargetLockerTestSuite
+}
+
+func (ts *SQLiteLockerTestSuite) SetupTest() {
+	ts.clock = clock.NewMock()
+	require.NotNil(ts.T(), ts.clock)
+	ts.clock.Add(1 * time.Hour) // avoid zero time, start at 1:00
+	tl, err := sqlitelocker.New(
+		sqlite.URIScheme+filepath.Join(ts.T().TempDir(), "contest.db"),
+		dblocker.WithClock(ts.clock),
+		dblocker.WithMaxBatchSize(3),
+	)
+	require.NoError(ts.T(), err)
+	require.NotNil(ts.T(), tl)
+	ts.tl = tl
+}
+
+func (ts *SQLiteLockerTestSuite) TearDownTest() {
+	ts.tl.Close()
+	ts.tl = nil
+}
+
+func TestSQLiteLocker(t *testing.T) {
+	suite.Run(t, &SQLiteLockerTestSuite{})
+}
@@ -7,6 +7,7 @@ package main
 
 import (
 	"bytes"
+	"database/sql"
 	"flag"
 	"fmt"
 	"os"
@@ -16,10 +17,12 @@ import (
 	_ "github.com/linuxboot/contest/db/rdbms/migration"
 	"github.com/linuxboot/contest/pkg/xcontext/bundles/logrusctx"
 	"github.com/linuxboot/contest/pkg/xcontext/logger"
+	"github.com/linuxboot/contest/plugins/storage/sqlite"
 
 	"github.com/linuxboot/contest/tools/migration/rdbms/migrate"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 
 	"github.com/pressly/goose"
 	"github.com/sirupsen/logrus"
@@ -27,7 +30,7 @@ import (
 
 var (
 	flags        = flag.NewFlagSet("migrate", flag.ExitOnError)
-	flagDBDriver = flags.String("dbDriver", "mysql", "DB Driver")
+	flagDBDriver = flags.String("dbDriver", "mysql", "DB Driver, mysql, postgres or sqlite")
 	flagDBURI    = flags.String("dbURI", "contest:contest@tcp(localhost:3306)/contest?parseTime=true", "Database URI")
 	flagDir      = flags.String("dir", "", "Directory containing migration scripts")
 	flagDebug    = flags.Bool("debug", false, "Enabled debug logging")
@@ -85,13 +88,23 @@ func main() {
 		ctx.Logger().Fatalf("migration directory was not specified")
 	}
 
-	for _, m := range migrate.Migrations {
-		migration := m.Factory(ctx.WithField("migration", filepath.Base(m.Name)))
-		goose.AddNamedMigration(m.Name, migration.Up, migration.Down)
-	}
-
 	command := os.Args[len(os.Args)-1]
-	db, err := goose.OpenDBWithDriver(*flagDBDriver, *flagDBURI)
+	var db *sql.DB
+	switch *flagDBDriver {
+	case "sqlite":
+		// SQLite databases only have the SQL migrations of db/sqlite/migration,
+		// the golang migrations backfill data of MySQL databases.
+		db, err = openSQLite(*flagDBURI)
+	case "postgres":
+		// same as SQLite, the migrations of db/postgres/migration are SQL only.
+		db, err = goose.OpenDBWithDriver(*flagDBDriver, *flagDBURI)
+	default:
+		for _, m := range migrate.Migrations {
+			migration := m.Factory(ctx.WithField("migration", filepath.Base(m.Name)))
+			goose.AddNamedMigration(m.Name, migration.Up, migration.Down)
+		}
+		db, err = goose.OpenDBWithDriver(*flagDBDriver, *flagDBURI)
+	}
 	if err != nil {
 		ctx.Logger().Fatalf("failed to open DB: %v", err)
 	}
@@ -109,3 +122,16 @@ func main() {
 		ctx.Logger().Fatalf("could not run command %v for migration: %v", command, err)
 	}
 }
+
+// openSQLite opens a SQLite database from a sqlite:// database URI, the same
+// URIs accepted by the ConTest server.
+func openSQLite(dbURI string) (*sql.DB, error) {
+	dsn, err := sqlite.DSN(dbURI)
+	if err != nil {
+		return nil, err
+	}
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		return nil, err
+	}
+	return sql.Open(sqlite.DriverName, dsn)
+}
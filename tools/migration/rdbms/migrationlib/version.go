@@ -10,6 +10,7 @@ import (
 	"github.com/google/go-safeweb/safesql"
 
 	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
 	"github.com/pressly/goose"
 )
 
@@ -35,6 +36,12 @@ func DBVersion(db safesql.DB) (uint64, error) {
 				return 0, nil
 			}
 		}
+		if pqErr, ok := err.(*pq.Error); ok {
+			if pqErr.Code == "42P01" {
+				// undefined_table, same as above
+				return 0, nil
+			}
+		}
 		return 0, fmt.Errorf("could not retrieve db version, error %+v, %T: %w", err, err, err)
 	}
 	defer rows.Close()